@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+type AlertsHandler struct {
+	AlertController alert.Controller
+}
+
+func (h *AlertsHandler) GetAll(ctx context.Context, w http.ResponseWriter, _ *http.Request,
+	_ httprouter.Params,
+) error {
+	if err := web.Respond(ctx, w, h.AlertController.GetAll(), http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+func (h *AlertsHandler) Acknowledge(ctx context.Context, w http.ResponseWriter, _ *http.Request,
+	p httprouter.Params,
+) error {
+	id := p.ByName("id")
+
+	a, err := h.AlertController.Acknowledge(id)
+	if err != nil {
+		if errors.Is(err, alert.ErrNotFound) {
+			return web.NewRequestError(fmt.Sprintf("alert '%s' not found", id), http.StatusNotFound)
+		}
+
+		return errors.Wrapf(err, "could not acknowledge alert %s", id)
+	}
+
+	if err := web.Respond(ctx, w, a, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAllAlerts(t *testing.T) {
+	t.Parallel()
+
+	alerts := []*alert.Alert{
+		{ID: alertID, ChamberID: chamberID, Rule: alert.ThresholdRuleType, Status: alert.FiringStatus},
+	}
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	alertMock := &mocks.AlertController{}
+	alertMock.On("GetAll").Return(alerts)
+
+	handler := &handlers.AlertsHandler{AlertController: alertMock}
+	err := handler.GetAll(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+
+	var result []*alert.Alert
+
+	err = json.NewDecoder(w.Body).Decode(&result)
+	assert.NoError(t, err)
+	assert.Equal(t, alerts, result)
+}
+
+func TestAcknowledgeAlert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		returnErr error
+		errMsg    string
+		status    int
+	}{
+		{name: "acknowledge"},
+		{
+			name: "notFound", returnErr: alert.ErrNotFound,
+			errMsg: fmt.Sprintf(notFoundErrorMsg, "alert", alertID), status: http.StatusNotFound,
+		},
+		{
+			name: "otherError", returnErr: errSomeError,
+			errMsg: fmt.Sprintf("could not acknowledge alert %s", alertID),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, r, ctx := setupHandlerTest("", nil)
+
+			var a *alert.Alert
+			if tc.returnErr == nil {
+				a = &alert.Alert{ID: alertID}
+			}
+
+			alertMock := &mocks.AlertController{}
+			alertMock.On("Acknowledge", alertID).Return(a, tc.returnErr)
+
+			handler := &handlers.AlertsHandler{AlertController: alertMock}
+			err := handler.Acknowledge(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: alertID}})
+			assertHandlerError(t, err, tc.errMsg, tc.status)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+const defaultAuditPeriod = 7 * 24 * time.Hour
+
+type AuditHandler struct {
+	AuditRepo audit.Repo
+}
+
+// GetAll returns the entries of the audit log. The from and to query parameters are RFC 3339 times and default to the
+// last week. The username and action query parameters only return the entries of the given user or action.
+func (h *AuditHandler) GetAll(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
+	from, to, err := parseTimeRange(r, defaultAuditPeriod)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.AuditRepo.GetAll(from, to)
+	if err != nil {
+		return errors.Wrap(err, "could not get audit log from repository")
+	}
+
+	username := r.URL.Query().Get("username")
+	action := audit.Action(r.URL.Query().Get("action"))
+
+	filtered := []*audit.Entry{}
+
+	for _, e := range entries {
+		if (username == "" || e.Username == username) && (action == "" || e.Action == action) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	if err := web.Respond(ctx, w, filtered, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// newAuditEntry returns an audit log entry for an action taken by the user of the request on target.
+func newAuditEntry(ctx context.Context, r *http.Request, action audit.Action, target string) *audit.Entry {
+	e := &audit.Entry{
+		Action:   action,
+		SourceIP: web.ClientIP(r),
+		Target:   target,
+	}
+
+	if claims, ok := auth.FromContext(ctx); ok {
+		e.Username = claims.Username
+	}
+
+	return e
+}
@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/julienschmidt/httprouter"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createAuditMock() *mocks.AuditRepo {
+	auditMock := &mocks.AuditRepo{}
+	auditMock.On("Save", mock.Anything).Return(nil)
+
+	return auditMock
+}
+
+func TestGetAllAuditEntries(t *testing.T) {
+	t.Parallel()
+
+	entries := []*audit.Entry{
+		{Action: audit.LoginAction, Username: "admin", SourceIP: "10.0.0.1"},
+		{Action: audit.ChamberSavedAction, Username: "admin", Target: chamberID},
+		{Action: audit.LoginAction, Username: "brewer", SourceIP: "10.0.0.2"},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []*audit.Entry
+		errMsg   string
+		status   int
+	}{
+		{name: "all", expected: entries},
+		{name: "byUsername", query: "username=admin", expected: entries[:2]},
+		{name: "byAction", query: "action=login", expected: []*audit.Entry{entries[0], entries[2]}},
+		{name: "byUsernameAndAction", query: "username=brewer&action=login", expected: entries[2:]},
+		{name: "invalidFrom", query: "from=yesterday", errMsg: "from is invalid", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, r, ctx := setupHandlerTest(tc.query, nil)
+
+			auditMock := &mocks.AuditRepo{}
+			auditMock.On("GetAll", mock.Anything, mock.Anything).Return(entries, nil)
+
+			handler := &handlers.AuditHandler{AuditRepo: auditMock}
+			err := handler.GetAll(ctx, w, r, httprouter.Params{})
+			assertHandlerError(t, err, tc.errMsg, tc.status)
+
+			if tc.errMsg != "" {
+				return
+			}
+
+			var result []*audit.Entry
+
+			err = json.NewDecoder(w.Body).Decode(&result)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+
+			from := auditMock.Calls[0].Arguments.Get(0).(time.Time)
+			to := auditMock.Calls[0].Arguments.Get(1).(time.Time)
+			assert.Equal(t, 7*24*time.Hour, to.Sub(from))
+		})
+	}
+}
+
+func TestChamberActionsRecordAudit(t *testing.T) {
+	t.Parallel()
+
+	var entries []*audit.Entry
+
+	auditMock := &mocks.AuditRepo{}
+	auditMock.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		entries = append(entries, args.Get(0).(*audit.Entry))
+	}).Return(nil)
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("StartFermentation", chamberID, "A").Return(nil)
+	controllerMock.On("StopFermentation", chamberID).Return(nil)
+	controllerMock.On("SetBatch", chamberID, mock.Anything).Return(nil)
+
+	l, _ := logtest.NewNullLogger()
+
+	handler := &handlers.ChambersHandler{
+		ChamberController: controllerMock,
+		AuditRecorder:     audit.NewRecorder(auditMock, l),
+		Logger:            l,
+	}
+
+	p := httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}}
+
+	w, r, ctx := setupHandlerTest("step=A", nil)
+	ctx = auth.NewContext(ctx, &auth.Claims{Username: "brewer", Role: auth.BrewerRole})
+	err := handler.Start(ctx, w, r, p)
+	assert.NoError(t, err)
+
+	w, r, ctx = setupHandlerTest("", nil)
+	ctx = auth.NewContext(ctx, &auth.Claims{Username: "brewer", Role: auth.BrewerRole})
+	err = handler.Stop(ctx, w, r, p)
+	assert.NoError(t, err)
+
+	w, r, ctx = setupImportTest(t, importedRecipe)
+	ctx = auth.NewContext(ctx, &auth.Claims{Username: "brewer", Role: auth.BrewerRole})
+	err = handler.Import(ctx, w, r, p)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*audit.Entry{
+		{
+			Time: entries[0].Time, Action: audit.FermentationStartedAction, Username: "brewer", SourceIP: "192.0.2.1",
+			Target: chamberID, Detail: "step A",
+		},
+		{
+			Time: entries[1].Time, Action: audit.FermentationStoppedAction, Username: "brewer", SourceIP: "192.0.2.1",
+			Target: chamberID,
+		},
+		{
+			Time: entries[2].Time, Action: audit.BatchImportedAction, Username: "brewer", SourceIP: "192.0.2.1",
+			Target: chamberID, Detail: "recipe Pale Ale",
+		},
+	}, entries)
+}
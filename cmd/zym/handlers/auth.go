@@ -3,94 +3,329 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
 	"github.com/benjaminbartels/zymurgauge/internal/auth"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
 	"github.com/benjaminbartels/zymurgauge/internal/settings"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	expiresIn = 7 * 24 * time.Hour
+	accessExpiresIn  = 15 * time.Minute
+	refreshExpiresIn = 7 * 24 * time.Hour
 )
 
 type AuthHandler struct {
-	SettingsRepo settings.Repo
-	Logger       *logrus.Logger
+	UserRepo      auth.UserRepo
+	SessionRepo   auth.SessionRepo
+	SettingsRepo  settings.Repo
+	LoginThrottle *auth.LoginThrottle
+	AuditRecorder *audit.Recorder
+	Logger        *logrus.Logger
 }
 
+// LoginSuccess holds a short-lived access token and the refresh token to get a new one with.
+type LoginSuccess struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	Role         auth.Role `json:"role"`
+}
+
+// RefreshRequest is the request body to refresh an access token.
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Login returns tokens for a new session if the credentials are correct. The response does not reveal whether the
+// username or the password was wrong, and logins are refused for a while after too many failures for the same username
+// or from the same source IP.
 func (h *AuthHandler) Login(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
 	creds, err := parseCredentials(r)
 	if err != nil {
 		return errors.Wrap(err, "could not parse credentials")
 	}
 
-	s, err := h.SettingsRepo.Get()
+	ip := web.ClientIP(r)
+
+	if wait := h.LoginThrottle.Check(creds.Username, ip); wait > 0 {
+		h.AuditRecorder.Record(&audit.Entry{Action: audit.LoginThrottledAction, Username: creds.Username, SourceIP: ip})
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+		return web.NewRequestError("too many failed logins, try again later", http.StatusTooManyRequests)
+	}
+
+	u, err := h.UserRepo.GetByUsername(creds.Username)
 	if err != nil {
-		return errors.Wrap(err, "could not get settings")
+		return errors.Wrap(err, "could not get user by username from repository")
 	}
 
-	if creds.Username != s.Username {
-		return web.NewRequestError("incorrect username", http.StatusUnauthorized)
+	if !u.CheckPassword(creds.Password) {
+		h.LoginThrottle.Fail(creds.Username, ip)
+		h.AuditRecorder.Record(&audit.Entry{Action: audit.LoginFailedAction, Username: creds.Username, SourceIP: ip})
+
+		return web.NewRequestError("incorrect username or password", http.StatusUnauthorized)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(s.Password), []byte(creds.Password)); err != nil {
-		return web.NewRequestError("incorrect password", http.StatusUnauthorized)
+	h.LoginThrottle.Reset(creds.Username)
+	h.AuditRecorder.Record(&audit.Entry{Action: audit.LoginAction, Username: u.Username, SourceIP: ip})
+
+	return h.respondWithTokens(ctx, w, u, auth.NewSession(u.ID))
+}
+
+// Refresh returns a new access token for a refresh token. The refresh token is replaced by a new one, and using it
+// again ends the session since it may have been stolen.
+func (h *AuthHandler) Refresh(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return errors.Wrap(err, "could not decode refresh token from request body")
 	}
 
-	token, err := auth.CreateToken(s.AuthSecret, creds.Username, expiresIn)
+	id, err := auth.ParseSessionID(req.RefreshToken)
 	if err != nil {
-		return errors.Wrap(err, "could not create token")
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
 	}
 
-	response := struct {
-		Token string `json:"token"`
-	}{
-		Token: token,
+	session, err := h.SessionRepo.Get(id)
+	if err != nil {
+		return errors.Wrap(err, "could not get session from repository")
 	}
 
-	if err := web.Respond(ctx, w, response, http.StatusOK); err != nil {
+	if session == nil {
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	if !session.Check(req.RefreshToken) {
+		h.Logger.Warnf("Refresh token of session %s was reused, ending session.", session.ID)
+
+		if err := h.endSession(session); err != nil {
+			return err
+		}
+
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	if session.IsExpired() {
+		if err := h.SessionRepo.Delete(session.ID); err != nil {
+			return errors.Wrap(err, "could not delete session from repository")
+		}
+
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	u, err := h.UserRepo.Get(session.UserID)
+	if err != nil {
+		return errors.Wrap(err, "could not get user from repository")
+	}
+
+	if u == nil {
+		if err := h.SessionRepo.Delete(session.ID); err != nil {
+			return errors.Wrap(err, "could not delete session from repository")
+		}
+
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	return h.respondWithTokens(ctx, w, u, session)
+}
+
+// Logout revokes the access token of the request and ends its session.
+func (h *AuthHandler) Logout(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	if claims.APITokenID != "" {
+		return web.NewRequestError("api tokens can not be logged out, delete them instead", http.StatusForbidden)
+	}
+
+	if err := h.SessionRepo.Revoke(claims.Id, claims.GetExpiresTime()); err != nil {
+		return errors.Wrap(err, "could not revoke token")
+	}
+
+	if claims.SessionID != "" {
+		if err := h.SessionRepo.Delete(claims.SessionID); err != nil {
+			return errors.Wrap(err, "could not delete session from repository")
+		}
+	}
+
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.LogoutAction, ""))
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// Rotate replaces the auth secret, which invalidates all access tokens, and ends all sessions.
+func (h *AuthHandler) Rotate(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
+	s, err := h.SettingsRepo.Get()
+	if err != nil {
+		return errors.Wrap(err, "could not get settings from repository")
+	}
+
+	if s == nil {
+		return web.NewRequestError("settings not found", http.StatusNotFound)
+	}
+
+	if s.AuthSecret, err = auth.NewSecret(); err != nil {
+		return errors.Wrap(err, "could not create auth secret")
+	}
+
+	if err := h.SettingsRepo.Save(s); err != nil {
+		return errors.Wrap(err, "could not save settings to repository")
+	}
+
+	if err := h.SessionRepo.DeleteAll(); err != nil {
+		return errors.Wrap(err, "could not delete all sessions from repository")
+	}
+
+	h.Logger.Info("Auth secret was rotated, all sessions have ended.")
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.SecretRotatedAction, ""))
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
 
 	return nil
 }
 
+// Save changes the username and password of the user that is logged in. All sessions of the user are ended and a new
+// one is started for the request.
 func (h *AuthHandler) Save(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
 	credentials, err := parseCredentials(r)
 	if err != nil {
 		return errors.Wrap(err, "could not parse settings")
 	}
 
+	if credentials.Password == "" {
+		return web.NewRequestError("password is required", http.StatusBadRequest)
+	}
+
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	if claims.APITokenID != "" {
+		return web.NewRequestError("credentials can not be updated with an api token", http.StatusForbidden)
+	}
+
+	// the user is looked up by ID as the username of the claims is out of date once the user has been renamed.
+	// Tokens issued before claims had user IDs must be refreshed first.
+	if claims.UserID == "" {
+		return web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	u, err := h.UserRepo.Get(claims.UserID)
+	if err != nil {
+		return errors.Wrap(err, "could not get user from repository")
+	}
+
+	if u == nil {
+		return web.NewRequestError(fmt.Sprintf("user '%s' not found", claims.UserID), http.StatusNotFound)
+	}
+
+	if credentials.Username != "" && credentials.Username != u.Username {
+		existing, err := h.UserRepo.GetByUsername(credentials.Username)
+		if err != nil {
+			return errors.Wrap(err, "could not get user by username from repository")
+		}
+
+		if existing != nil {
+			return web.NewRequestError(fmt.Sprintf("username '%s' is taken", credentials.Username),
+				http.StatusConflict)
+		}
+
+		u.Username = credentials.Username
+	}
+
+	if err := u.SetPassword(credentials.Password); err != nil {
+		return errors.Wrap(err, "could not set password")
+	}
+
+	if err := h.UserRepo.Save(u); err != nil {
+		return errors.Wrap(err, "could not save user to repository")
+	}
+
+	if err := h.SessionRepo.DeleteByUser(u.ID); err != nil {
+		return errors.Wrap(err, "could not delete sessions of user from repository")
+	}
+
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.CredentialsUpdatedAction, u.ID))
+
+	return h.respondWithTokens(ctx, w, u, auth.NewSession(u.ID))
+}
+
+// respondWithTokens issues a new access and refresh token for the session and saves it.
+func (h *AuthHandler) respondWithTokens(ctx context.Context, w http.ResponseWriter, u *auth.User,
+	session *auth.Session,
+) error {
 	s, err := h.SettingsRepo.Get()
 	if err != nil {
-		return errors.Wrap(err, "could not get settings from repository")
+		return errors.Wrap(err, "could not get settings")
+	}
+
+	if s == nil {
+		return web.NewRequestError("settings not found", http.StatusNotFound)
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(credentials.Password), bcrypt.DefaultCost)
+	refreshToken, err := session.Rotate(refreshExpiresIn)
 	if err != nil {
-		return errors.Wrap(err, "could not generate password hash")
+		return errors.Wrap(err, "could not create refresh token")
 	}
 
-	s.Credentials.Username = credentials.Username
-	s.Credentials.Password = string(hash)
+	claims := &auth.Claims{UserID: u.ID, Username: u.Username, Role: u.Role, SessionID: session.ID}
 
-	if err := h.SettingsRepo.Save(s); err != nil {
-		return errors.Wrap(err, "could not save settings to repository")
+	token, err := auth.CreateToken(s.AuthSecret, claims, accessExpiresIn)
+	if err != nil {
+		return errors.Wrap(err, "could not create token")
 	}
 
-	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+	session.SetAccessToken(claims)
+
+	if err := h.SessionRepo.Save(session); err != nil {
+		return errors.Wrap(err, "could not save session to repository")
+	}
+
+	response := &LoginSuccess{
+		Token:        token,
+		RefreshToken: refreshToken,
+		Role:         u.Role,
+	}
+
+	if err := web.Respond(ctx, w, response, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
 
 	return nil
 }
 
+// endSession deletes a session and revokes the access token that was last issued for it.
+func (h *AuthHandler) endSession(session *auth.Session) error {
+	if session.AccessTokenID != "" {
+		if err := h.SessionRepo.Revoke(session.AccessTokenID, session.AccessExpiresTime); err != nil {
+			return errors.Wrap(err, "could not revoke token")
+		}
+	}
+
+	if err := h.SessionRepo.Delete(session.ID); err != nil {
+		return errors.Wrap(err, "could not delete session from repository")
+	}
+
+	return nil
+}
+
 func parseCredentials(r *http.Request) (auth.Credentials, error) {
 	var user auth.Credentials
 	err := json.NewDecoder(r.Body).Decode(&user)
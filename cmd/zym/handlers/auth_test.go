@@ -0,0 +1,474 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/benjaminbartels/zymurgauge/internal/settings"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/julienschmidt/httprouter"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	authSecret       = "my-auth-secret"
+	maxLoginAttempts = 3
+)
+
+func createTestUser(t *testing.T, username, password string, role auth.Role) *auth.User {
+	t.Helper()
+
+	u := &auth.User{ID: userID, Username: username, Role: role}
+	assert.NoError(t, u.SetPassword(password))
+
+	return u
+}
+
+func createAuthHandler(userMock *mocks.UserRepo, sessionMock *mocks.SessionRepo) *handlers.AuthHandler {
+	l, _ := logtest.NewNullLogger()
+
+	settingsMock := &mocks.SettingsRepo{}
+	settingsMock.On("Get").Return(getTestSettingsWithSecret(), nil)
+
+	return &handlers.AuthHandler{
+		UserRepo:      userMock,
+		SessionRepo:   sessionMock,
+		SettingsRepo:  settingsMock,
+		LoginThrottle: auth.NewLoginThrottle(maxLoginAttempts, maxLoginAttempts, time.Minute),
+		AuditRecorder: audit.NewRecorder(createAuditMock(), l),
+		Logger:        l,
+	}
+}
+
+func createSessionMock() *mocks.SessionRepo {
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("Save", mock.Anything).Return(nil)
+
+	return sessionMock
+}
+
+func decodeLoginSuccess(t *testing.T, w *httptest.ResponseRecorder) *handlers.LoginSuccess {
+	t.Helper()
+
+	resp := &handlers.LoginSuccess{}
+	assert.NoError(t, json.NewDecoder(w.Result().Body).Decode(resp))
+
+	return resp
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestLogin(t *testing.T) {
+	t.Parallel()
+	t.Run("loginWithRole", loginWithRole)
+	t.Run("loginIncorrectPassword", loginIncorrectPassword)
+	t.Run("loginUnknownUser", loginUnknownUser)
+	t.Run("loginThrottled", loginThrottled)
+	t.Run("loginRecordsAudit", loginRecordsAudit)
+}
+
+func login(t *testing.T, userMock *mocks.UserRepo, sessionMock *mocks.SessionRepo,
+	creds auth.Credentials,
+) (*handlers.LoginSuccess, error) {
+	t.Helper()
+
+	w, err := loginWithHandler(t, createAuthHandler(userMock, sessionMock), creds)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeLoginSuccess(t, w), nil
+}
+
+func loginWithHandler(t *testing.T, handler *handlers.AuthHandler,
+	creds auth.Credentials,
+) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+
+	jsonBytes, err := json.Marshal(creds)
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	return w, handler.Login(ctx, w, r, httprouter.Params{})
+}
+
+func loginWithRole(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetByUsername", "brewer").Return(createTestUser(t, "brewer", "password", auth.BrewerRole), nil)
+
+	var session *auth.Session
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		session = args.Get(0).(*auth.Session)
+	}).Return(nil)
+
+	resp, err := login(t, userMock, sessionMock, auth.Credentials{Username: "brewer", Password: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, auth.BrewerRole, resp.Role)
+
+	claims, err := auth.ParseToken(authSecret, resp.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "brewer", claims.Username)
+	assert.Equal(t, auth.BrewerRole, claims.GetRole())
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), claims.GetExpiresTime(), time.Second)
+
+	assert.Equal(t, userID, session.UserID)
+	assert.Equal(t, session.ID, claims.SessionID)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, claims.Id, session.AccessTokenID)
+	assert.True(t, session.Check(resp.RefreshToken))
+}
+
+func loginIncorrectPassword(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetByUsername", "brewer").Return(createTestUser(t, "brewer", "password", auth.BrewerRole), nil)
+
+	_, err := login(t, userMock, createSessionMock(), auth.Credentials{Username: "brewer", Password: "wrong"})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnauthorized, reqErr.Status)
+	assert.Equal(t, incorrectCredentialsErrorMsg, reqErr.Error())
+}
+
+func loginUnknownUser(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetByUsername", "someone").Return(nil, nil)
+
+	_, err := login(t, userMock, createSessionMock(), auth.Credentials{Username: "someone", Password: "password"})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnauthorized, reqErr.Status)
+	assert.Equal(t, incorrectCredentialsErrorMsg, reqErr.Error())
+}
+
+func loginThrottled(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetByUsername", "brewer").Return(createTestUser(t, "brewer", "password", auth.BrewerRole), nil)
+
+	handler := createAuthHandler(userMock, createSessionMock())
+	creds := auth.Credentials{Username: "brewer", Password: "wrong"}
+
+	for i := 0; i < maxLoginAttempts; i++ {
+		_, err := loginWithHandler(t, handler, creds)
+		assert.Error(t, err)
+	}
+
+	// the correct password is refused too while the user is locked out
+	w, err := loginWithHandler(t, handler, auth.Credentials{Username: "brewer", Password: "password"})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusTooManyRequests, reqErr.Status)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	userMock.AssertNumberOfCalls(t, "GetByUsername", maxLoginAttempts)
+}
+
+func loginRecordsAudit(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetByUsername", "brewer").Return(createTestUser(t, "brewer", "password", auth.BrewerRole), nil)
+
+	var entries []*audit.Entry
+
+	auditMock := &mocks.AuditRepo{}
+	auditMock.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		entries = append(entries, args.Get(0).(*audit.Entry))
+	}).Return(nil)
+
+	l, _ := logtest.NewNullLogger()
+
+	handler := createAuthHandler(userMock, createSessionMock())
+	handler.AuditRecorder = audit.NewRecorder(auditMock, l)
+
+	_, err := loginWithHandler(t, handler, auth.Credentials{Username: "brewer", Password: "wrong"})
+	assert.Error(t, err)
+
+	_, err = loginWithHandler(t, handler, auth.Credentials{Username: "brewer", Password: "password"})
+	assert.NoError(t, err)
+
+	assert.Len(t, entries, 2)
+	assert.Equal(t, audit.LoginFailedAction, entries[0].Action)
+	assert.Equal(t, audit.LoginAction, entries[1].Action)
+
+	for _, e := range entries {
+		assert.Equal(t, "brewer", e.Username)
+		assert.Equal(t, "192.0.2.1", e.SourceIP)
+	}
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSaveCredentials(t *testing.T) {
+	t.Parallel()
+	t.Run("saveOwnCredentials", saveOwnCredentials)
+	t.Run("saveCredentialsWithoutClaims", saveCredentialsWithoutClaims)
+	t.Run("saveCredentialsWithoutUserID", saveCredentialsWithoutUserID)
+}
+
+func saveOwnCredentials(t *testing.T) {
+	t.Parallel()
+
+	jsonBytes, err := json.Marshal(auth.Credentials{Username: "head-brewer", Password: "new-password"})
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+	// the username of the claims is out of date as the user was renamed by an admin after the token was issued
+	ctx = auth.NewContext(ctx, &auth.Claims{UserID: userID, Username: "apprentice", Role: auth.BrewerRole})
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", userID).Return(createTestUser(t, "brewer", "password", auth.BrewerRole), nil)
+	userMock.On("GetByUsername", "head-brewer").Return(nil, nil)
+	userMock.On("Save", mock.MatchedBy(func(u *auth.User) bool {
+		return u.Username == "head-brewer" && u.Role == auth.BrewerRole && u.CheckPassword("new-password")
+	})).Return(nil)
+
+	sessionMock := createSessionMock()
+	sessionMock.On("DeleteByUser", userID).Return(nil)
+
+	err = createAuthHandler(userMock, sessionMock).Save(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+	userMock.AssertExpectations(t)
+	sessionMock.AssertExpectations(t)
+
+	resp := decodeLoginSuccess(t, w)
+
+	claims, err := auth.ParseToken(authSecret, resp.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "head-brewer", claims.Username)
+	assert.Equal(t, userID, claims.UserID)
+}
+
+func saveCredentialsWithoutClaims(t *testing.T) {
+	t.Parallel()
+
+	jsonBytes, err := json.Marshal(auth.Credentials{Username: "brewer", Password: "new-password"})
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	err = createAuthHandler(&mocks.UserRepo{}, &mocks.SessionRepo{}).Save(ctx, w, r, httprouter.Params{})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnauthorized, reqErr.Status)
+}
+
+func saveCredentialsWithoutUserID(t *testing.T) {
+	t.Parallel()
+
+	jsonBytes, err := json.Marshal(auth.Credentials{Username: "brewer", Password: "new-password"})
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+	ctx = auth.NewContext(ctx, &auth.Claims{Username: "brewer", Role: auth.BrewerRole})
+
+	err = createAuthHandler(&mocks.UserRepo{}, &mocks.SessionRepo{}).Save(ctx, w, r, httprouter.Params{})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnauthorized, reqErr.Status)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+	t.Run("refreshRotatesToken", refreshRotatesToken)
+	t.Run("refreshReusedToken", refreshReusedToken)
+	t.Run("refreshExpiredSession", refreshExpiredSession)
+	t.Run("refreshUnknownSession", refreshUnknownSession)
+}
+
+func createTestSession(t *testing.T, expiresIn time.Duration) (*auth.Session, string) {
+	t.Helper()
+
+	session := auth.NewSession(userID)
+	session.AccessTokenID = "access"
+	session.AccessExpiresTime = time.Now().Add(time.Minute)
+
+	refreshToken, err := session.Rotate(expiresIn)
+	assert.NoError(t, err)
+
+	return session, refreshToken
+}
+
+func refresh(t *testing.T, userMock *mocks.UserRepo, sessionMock *mocks.SessionRepo,
+	refreshToken string,
+) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+
+	jsonBytes, err := json.Marshal(handlers.RefreshRequest{RefreshToken: refreshToken})
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	return w, createAuthHandler(userMock, sessionMock).Refresh(ctx, w, r, httprouter.Params{})
+}
+
+func refreshRotatesToken(t *testing.T) {
+	t.Parallel()
+
+	session, refreshToken := createTestSession(t, time.Hour)
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", userID).Return(createTestUser(t, "brewer", "password", auth.BrewerRole), nil)
+
+	sessionMock := createSessionMock()
+	sessionMock.On("Get", session.ID).Return(session, nil)
+
+	w, err := refresh(t, userMock, sessionMock, refreshToken)
+	assert.NoError(t, err)
+
+	resp := decodeLoginSuccess(t, w)
+	assert.NotEqual(t, refreshToken, resp.RefreshToken)
+	assert.False(t, session.Check(refreshToken))
+	assert.True(t, session.Check(resp.RefreshToken))
+
+	claims, err := auth.ParseToken(authSecret, resp.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, claims.SessionID)
+	assert.Equal(t, claims.Id, session.AccessTokenID)
+	sessionMock.AssertCalled(t, "Save", session)
+}
+
+func refreshReusedToken(t *testing.T) {
+	t.Parallel()
+
+	session, refreshToken := createTestSession(t, time.Hour)
+
+	_, err := session.Rotate(time.Hour)
+	assert.NoError(t, err)
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("Get", session.ID).Return(session, nil)
+	sessionMock.On("Revoke", "access", session.AccessExpiresTime).Return(nil)
+	sessionMock.On("Delete", session.ID).Return(nil)
+
+	_, err = refresh(t, &mocks.UserRepo{}, sessionMock, refreshToken)
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnauthorized, reqErr.Status)
+	sessionMock.AssertExpectations(t)
+}
+
+func refreshExpiredSession(t *testing.T) {
+	t.Parallel()
+
+	session, refreshToken := createTestSession(t, -time.Minute)
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("Get", session.ID).Return(session, nil)
+	sessionMock.On("Delete", session.ID).Return(nil)
+
+	_, err := refresh(t, &mocks.UserRepo{}, sessionMock, refreshToken)
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnauthorized, reqErr.Status)
+	sessionMock.AssertExpectations(t)
+}
+
+func refreshUnknownSession(t *testing.T) {
+	t.Parallel()
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("Get", "session").Return(nil, nil)
+
+	_, err := refresh(t, &mocks.UserRepo{}, sessionMock, "session.secret")
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnauthorized, reqErr.Status)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestLogout(t *testing.T) {
+	t.Parallel()
+	t.Run("logoutEndsSession", logoutEndsSession)
+	t.Run("logoutWithAPIToken", logoutWithAPIToken)
+}
+
+func logoutEndsSession(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	claims := &auth.Claims{Username: "brewer", Role: auth.BrewerRole, SessionID: "session"}
+	claims.Id = "access"
+	claims.ExpiresAt = time.Now().Add(time.Minute).Unix()
+	ctx = auth.NewContext(ctx, claims)
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("Revoke", "access", claims.GetExpiresTime()).Return(nil)
+	sessionMock.On("Delete", "session").Return(nil)
+
+	err := createAuthHandler(&mocks.UserRepo{}, sessionMock).Logout(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+	sessionMock.AssertExpectations(t)
+}
+
+func logoutWithAPIToken(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+	ctx = auth.NewContext(ctx, &auth.Claims{Username: "admin", Role: auth.AdminRole, APITokenID: tokenID})
+
+	err := createAuthHandler(&mocks.UserRepo{}, &mocks.SessionRepo{}).Logout(ctx, w, r, httprouter.Params{})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusForbidden, reqErr.Status)
+}
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+	l, _ := logtest.NewNullLogger()
+
+	settingsMock := &mocks.SettingsRepo{}
+	settingsMock.On("Get").Return(getTestSettingsWithSecret(), nil)
+	settingsMock.On("Save", mock.MatchedBy(func(s *settings.Settings) bool {
+		return s.AuthSecret != authSecret && len(s.AuthSecret) == 64
+	})).Return(nil)
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("DeleteAll").Return(nil)
+
+	handler := &handlers.AuthHandler{SessionRepo: sessionMock, SettingsRepo: settingsMock, Logger: l}
+
+	err := handler.Rotate(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+	settingsMock.AssertExpectations(t)
+	sessionMock.AssertExpectations(t)
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
@@ -13,18 +15,21 @@ import (
 )
 
 type BatchesHandler struct {
-	Service brewfather.Service
+	BatchController batch.Controller
 }
 
-func (h *BatchesHandler) GetAll(ctx context.Context, w http.ResponseWriter, _ *http.Request,
+func (h *BatchesHandler) GetAll(ctx context.Context, w http.ResponseWriter, r *http.Request,
 	_ httprouter.Params,
 ) error {
-	batchSummaries, err := h.Service.GetAllBatchSummaries(ctx)
+	ctx, err := withRefresh(ctx, r)
 	if err != nil {
-		return errors.Wrap(err, "could not get all batches from repository")
+		return err
 	}
 
-	summaries := batch.ConvertSummaries(batchSummaries)
+	summaries, err := h.BatchController.GetAll(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not get all batches from controller")
+	}
 
 	if err = web.Respond(ctx, w, summaries, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
@@ -33,27 +38,98 @@ func (h *BatchesHandler) GetAll(ctx context.Context, w http.ResponseWriter, _ *h
 	return nil
 }
 
-func (h *BatchesHandler) Get(ctx context.Context, w http.ResponseWriter, _ *http.Request, p httprouter.Params) error {
+func (h *BatchesHandler) Get(ctx context.Context, w http.ResponseWriter, r *http.Request, p httprouter.Params) error {
 	id := p.ByName("id")
 
-	batchDetail, err := h.Service.GetBatchDetail(ctx, id)
+	ctx, err := withRefresh(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	detail, err := h.BatchController.Get(ctx, id)
 	if err != nil {
-		if errors.Is(err, brewfather.ErrNotFound) {
+		if errors.Is(err, batch.ErrNotFound) {
 			return web.NewRequestError(fmt.Sprintf("batch '%s' not found", id), http.StatusNotFound)
 		}
 
-		return errors.Wrap(err, "could not get batch from repository")
+		return errors.Wrap(err, "could not get batch from controller")
 	}
 
-	if batchDetail == nil {
-		return web.NewRequestError(fmt.Sprintf("batch '%s' not found", id), http.StatusNotFound)
+	if err = web.Respond(ctx, w, detail, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
 	}
 
-	detail := batch.ConvertDetail(batchDetail)
+	return nil
+}
 
-	if err = web.Respond(ctx, w, detail, http.StatusOK); err != nil {
+func (h *BatchesHandler) Save(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
+	d, err := parseBatch(r)
+	if err != nil {
+		return errors.Wrap(err, "could not parse batch")
+	}
+
+	if err := h.BatchController.Save(&d); err != nil {
+		var batchError *batch.InvalidBatchError
+
+		switch {
+		case errors.As(err, &batchError):
+			return web.NewRequestError(formatProblems(batchError.Error(), batchError.Problems()), http.StatusBadRequest)
+		case errors.Is(err, batch.ErrNotFound):
+			return web.NewRequestError(fmt.Sprintf("batch '%s' not found", d.ID), http.StatusNotFound)
+		default:
+			return errors.Wrap(err, "could not save batch to controller")
+		}
+	}
+
+	if err := web.Respond(ctx, w, d, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
 
 	return nil
 }
+
+func (h *BatchesHandler) Delete(ctx context.Context, w http.ResponseWriter, _ *http.Request,
+	p httprouter.Params,
+) error {
+	id := p.ByName("id")
+
+	if err := h.BatchController.Delete(id); err != nil {
+		if errors.Is(err, batch.ErrNotFound) {
+			return web.NewRequestError(fmt.Sprintf("batch '%s' not found", id), http.StatusNotFound)
+		}
+
+		return errors.Wrapf(err, "could not delete batch %s from controller", id)
+	}
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+func parseBatch(r *http.Request) (batch.Detail, error) {
+	var d batch.Detail
+	err := json.NewDecoder(r.Body).Decode(&d)
+
+	return d, errors.Wrap(err, "could not decode batch from request body")
+}
+
+// withRefresh returns a context that bypasses cached Brewfather batches if the refresh query parameter is true.
+func withRefresh(ctx context.Context, r *http.Request) (context.Context, error) {
+	v := r.URL.Query().Get("refresh")
+	if v == "" {
+		return ctx, nil
+	}
+
+	refresh, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, web.NewRequestError("refresh is invalid", http.StatusBadRequest)
+	}
+
+	if refresh {
+		ctx = brewfather.WithRefresh(ctx)
+	}
+
+	return ctx, nil
+}
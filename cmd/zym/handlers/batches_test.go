@@ -1,6 +1,7 @@
 package handlers_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -14,7 +15,9 @@ import (
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
 	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
 	"github.com/julienschmidt/httprouter"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 //nolint:paralleltest // False positives with r.Run not in a loop
@@ -22,7 +25,9 @@ func TestGetAllBatches(t *testing.T) {
 	t.Parallel()
 	t.Run("getAllBatches", getAllBatches)
 	t.Run("getAllBatchesEmpty", getAllBatchesEmpty)
-	t.Run("getAllServiceError", getAllServiceError)
+	t.Run("getAllBatchesRefresh", getAllBatchesRefresh)
+	t.Run("getAllBatchesRefreshInvalid", getAllBatchesRefreshInvalid)
+	t.Run("getAllControllerError", getAllControllerError)
 	t.Run("getAllBatchesRespondError", getAllBatchesRespondError)
 }
 
@@ -32,16 +37,13 @@ func getAllBatches(t *testing.T) {
 	w, r, ctx := setupHandlerTest("", nil)
 
 	expected := []batch.Summary{
-		{ID: batchID},
-		{ID: "f4ce0e05-1ada-42b8-8fc4-fb3482525d0d"},
+		{ID: batchID, Source: batch.BrewfatherSource},
+		{ID: "f4ce0e05-1ada-42b8-8fc4-fb3482525d0d", Source: batch.LocalSource},
 	}
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetAllBatchSummaries", ctx).Return([]brewfather.BatchSummary{
-		{ID: batchID},
-		{ID: "f4ce0e05-1ada-42b8-8fc4-fb3482525d0d"},
-	}, nil)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("GetAll", ctx).Return(expected, nil)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
 	err := handler.GetAll(ctx, w, r, httprouter.Params{})
 	assert.NoError(t, err)
 
@@ -61,10 +63,10 @@ func getAllBatchesEmpty(t *testing.T) {
 	w, r, ctx := setupHandlerTest("", nil)
 
 	expected := []batch.Summary{}
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetAllBatchSummaries", ctx).Return([]brewfather.BatchSummary{}, nil)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("GetAll", ctx).Return([]batch.Summary{}, nil)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
 	err := handler.GetAll(ctx, w, r, httprouter.Params{})
 	assert.NoError(t, err)
 
@@ -78,18 +80,42 @@ func getAllBatchesEmpty(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
-func getAllServiceError(t *testing.T) {
+func getAllBatchesRefresh(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("refresh=true", nil)
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("GetAll", mock.MatchedBy(brewfather.IsRefresh)).Return([]batch.Summary{}, nil)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err := handler.GetAll(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+	controllerMock.AssertExpectations(t)
+}
+
+func getAllBatchesRefreshInvalid(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("refresh=maybe", nil)
+
+	handler := &handlers.BatchesHandler{BatchController: &mocks.BatchController{}}
+	err := handler.GetAll(ctx, w, r, httprouter.Params{})
+	assertHandlerError(t, err, "refresh is invalid", http.StatusBadRequest)
+}
+
+func getAllControllerError(t *testing.T) {
 	t.Parallel()
 
 	w, r, ctx := setupHandlerTest("", nil)
 
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetAllBatchSummaries", ctx).Return([]brewfather.BatchSummary{}, errSomeError)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("GetAll", ctx).Return(nil, errSomeError)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
 	err := handler.GetAll(ctx, w, r, httprouter.Params{})
 	// TODO: Waiting on PR for ErrorContains(): https://github.com/stretchr/testify/pull/1022
-	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "get all batches from"))
+	assert.Contains(t, err.Error(), fmt.Sprintf(controllerErrMsg, "get all batches from"))
 }
 
 func getAllBatchesRespondError(t *testing.T) {
@@ -98,10 +124,10 @@ func getAllBatchesRespondError(t *testing.T) {
 	w, r, _ := setupHandlerTest("", nil)
 	ctx := context.Background()
 
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetAllBatchSummaries", ctx).Return([]brewfather.BatchSummary{}, nil)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("GetAll", ctx).Return([]batch.Summary{}, nil)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
 	// use new ctx to force error
 	err := handler.GetAll(ctx, w, r, httprouter.Params{})
 	assert.Contains(t, err.Error(), respondErrMsg)
@@ -111,9 +137,9 @@ func getAllBatchesRespondError(t *testing.T) {
 func TestGetBatch(t *testing.T) {
 	t.Parallel()
 	t.Run("getBatchFound", getBatchFound)
+	t.Run("getBatchRefresh", getBatchRefresh)
 	t.Run("getBatchNotFoundError", getBatchNotFoundError)
-	t.Run("getBatchIsNil", getBatchIsNil)
-	t.Run("getServiceError", getServiceError)
+	t.Run("getControllerError", getControllerError)
 	t.Run("getBatchRespondError", getBatchRespondError)
 }
 
@@ -129,11 +155,12 @@ func getBatchFound(t *testing.T) {
 				Steps: []batch.FermentationStep{},
 			},
 		},
+		Source: batch.BrewfatherSource,
 	}
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetBatchDetail", ctx, batchID).Return(&brewfather.BatchDetail{ID: batchID}, nil)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Get", ctx, batchID).Return(&expected, nil)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
 	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
 	assert.NoError(t, err)
 
@@ -147,15 +174,29 @@ func getBatchFound(t *testing.T) {
 	assert.Equal(t, expected, batch)
 }
 
+func getBatchRefresh(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("refresh=1", nil)
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Get", mock.MatchedBy(brewfather.IsRefresh), batchID).Return(&batch.Detail{ID: batchID}, nil)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
+	assert.NoError(t, err)
+	controllerMock.AssertExpectations(t)
+}
+
 func getBatchNotFoundError(t *testing.T) {
 	t.Parallel()
 
 	w, r, ctx := setupHandlerTest("", nil)
 
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetBatchDetail", ctx, batchID).Return(nil, brewfather.ErrNotFound)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Get", ctx, batchID).Return(nil, batch.ErrNotFound)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
 	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
 	assert.Contains(t, err.Error(), fmt.Sprintf(notFoundErrorMsg, "batch", batchID))
 
@@ -165,16 +206,118 @@ func getBatchNotFoundError(t *testing.T) {
 	assert.Equal(t, reqErr.Status, http.StatusNotFound)
 }
 
-func getBatchIsNil(t *testing.T) {
+func getControllerError(t *testing.T) {
 	t.Parallel()
 
 	w, r, ctx := setupHandlerTest("", nil)
 
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetBatchDetail", ctx, batchID).Return(nil, nil)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Get", ctx, batchID).Return(nil, errSomeError)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
+	assert.Contains(t, err.Error(), fmt.Sprintf(controllerErrMsg, "get batch from"))
+}
+
+func getBatchRespondError(t *testing.T) {
+	t.Parallel()
+
+	w, r, _ := setupHandlerTest("", nil)
+	ctx := context.Background()
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Get", ctx, batchID).Return(&batch.Detail{ID: batchID}, nil)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	// use new ctx to force error
 	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
+	assert.Contains(t, err.Error(), respondErrMsg)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSaveBatch(t *testing.T) {
+	t.Parallel()
+	t.Run("saveBatch", saveBatch)
+	t.Run("saveBatchParseError", saveBatchParseError)
+	t.Run("saveBatchInvalidError", saveBatchInvalidError)
+	t.Run("saveBatchNotFoundError", saveBatchNotFoundError)
+	t.Run("saveBatchControllerError", saveBatchControllerError)
+	t.Run("saveBatchRespondError", saveBatchRespondError)
+}
+
+func saveBatch(t *testing.T) {
+	t.Parallel()
+
+	d := &batch.Detail{ID: batchID, Recipe: batch.Recipe{Name: "My Recipe"}}
+	jsonBytes, err := json.Marshal(d)
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Save", d).Return(nil)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err = handler.Save(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+
+	resp := w.Result()
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	result := &batch.Detail{}
+	err = json.Unmarshal(bodyBytes, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, d, result)
+}
+
+func saveBatchParseError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	handler := &handlers.BatchesHandler{BatchController: &mocks.BatchController{}}
+	err := handler.Save(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(), parseErrorMsg)
+}
+
+// This test uses a real batch Library and not a mock due to needing to set the problems field in InvalidBatchError.
+func saveBatchInvalidError(t *testing.T) {
+	t.Parallel()
+
+	jsonBytes, err := json.Marshal(&batch.Detail{})
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+	l, _ := logtest.NewNullLogger()
+
+	library := batch.NewLibrary(&mocks.BatchRepo{}, &mocks.Service{}, l)
+
+	handler := &handlers.BatchesHandler{BatchController: library}
+	err = handler.Save(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(),
+		"batch is invalid: recipe name is required, at least one fermentation step is required")
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, reqErr.Status, http.StatusBadRequest)
+}
+
+func saveBatchNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	d := &batch.Detail{ID: batchID}
+	jsonBytes, err := json.Marshal(d)
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Save", d).Return(batch.ErrNotFound)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err = handler.Save(ctx, w, r, httprouter.Params{})
 	assert.Contains(t, err.Error(), fmt.Sprintf(notFoundErrorMsg, "batch", batchID))
 
 	var reqErr *web.RequestError
@@ -183,30 +326,107 @@ func getBatchIsNil(t *testing.T) {
 	assert.Equal(t, reqErr.Status, http.StatusNotFound)
 }
 
-func getServiceError(t *testing.T) {
+func saveBatchControllerError(t *testing.T) {
+	t.Parallel()
+
+	d := &batch.Detail{ID: batchID}
+	jsonBytes, err := json.Marshal(d)
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Save", d).Return(errSomeError)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err = handler.Save(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(), fmt.Sprintf(controllerErrMsg, "save batch to"))
+}
+
+func saveBatchRespondError(t *testing.T) {
+	t.Parallel()
+
+	d := &batch.Detail{ID: batchID}
+	jsonBytes, err := json.Marshal(d)
+	assert.NoError(t, err)
+
+	w, r, _ := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+	ctx := context.Background()
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Save", d).Return(nil)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	// use new ctx to force error
+	err = handler.Save(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(), respondErrMsg)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestDeleteBatch(t *testing.T) {
+	t.Parallel()
+	t.Run("deleteBatch", deleteBatch)
+	t.Run("deleteBatchNotFoundError", deleteBatchNotFoundError)
+	t.Run("deleteBatchControllerError", deleteBatchControllerError)
+	t.Run("deleteBatchRespondError", deleteBatchRespondError)
+}
+
+func deleteBatch(t *testing.T) {
 	t.Parallel()
 
 	w, r, ctx := setupHandlerTest("", nil)
 
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetBatchDetail", ctx, batchID).Return(&brewfather.BatchDetail{}, errSomeError)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Delete", batchID).Return(nil)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
-	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
-	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "get batch from"))
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
+	assert.NoError(t, err)
+	controllerMock.AssertExpectations(t)
 }
 
-func getBatchRespondError(t *testing.T) {
+func deleteBatchNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Delete", batchID).Return(batch.ErrNotFound)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
+	assert.Contains(t, err.Error(), fmt.Sprintf(notFoundErrorMsg, "batch", batchID))
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, reqErr.Status, http.StatusNotFound)
+}
+
+func deleteBatchControllerError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Delete", batchID).Return(errSomeError)
+
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
+	assert.Contains(t, err.Error(), fmt.Sprintf(controllerErrMsg, "delete batch "+batchID+" from"))
+}
+
+func deleteBatchRespondError(t *testing.T) {
 	t.Parallel()
 
 	w, r, _ := setupHandlerTest("", nil)
 	ctx := context.Background()
 
-	serviceMock := &mocks.Service{}
-	serviceMock.On("GetBatchDetail", ctx, batchID).Return(&brewfather.BatchDetail{ID: batchID}, nil)
+	controllerMock := &mocks.BatchController{}
+	controllerMock.On("Delete", batchID).Return(nil)
 
-	handler := &handlers.BatchesHandler{Service: serviceMock}
+	handler := &handlers.BatchesHandler{BatchController: controllerMock}
 	// use new ctx to force error
-	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: batchID}})
 	assert.Contains(t, err.Error(), respondErrMsg)
 }
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+type BrewfatherHandler struct {
+	OutboxReporter brewfather.OutboxReporter
+}
+
+func (h *BrewfatherHandler) GetOutbox(ctx context.Context, w http.ResponseWriter, _ *http.Request,
+	_ httprouter.Params,
+) error {
+	if err := web.Respond(ctx, w, h.OutboxReporter.Status(), http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
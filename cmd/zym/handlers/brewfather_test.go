@@ -0,0 +1,66 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestGetOutbox(t *testing.T) {
+	t.Parallel()
+	t.Run("getOutbox", getOutbox)
+	t.Run("getOutboxRespondError", getOutboxRespondError)
+}
+
+func getOutbox(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	expected := brewfather.OutboxStatus{
+		Depth:    1,
+		Sent:     2,
+		Failures: 1,
+		Devices: []brewfather.OutboxDeviceStatus{
+			{DeviceName: "Chamber 1", PendingReadings: 3, Attempts: 1, LastError: "some error"},
+		},
+	}
+	outboxMock := &mocks.OutboxReporter{}
+	outboxMock.On("Status").Return(expected)
+
+	handler := &handlers.BrewfatherHandler{OutboxReporter: outboxMock}
+	err := handler.GetOutbox(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+
+	resp := w.Result()
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	result := brewfather.OutboxStatus{}
+	err = json.Unmarshal(bodyBytes, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func getOutboxRespondError(t *testing.T) {
+	t.Parallel()
+
+	w, r, _ := setupHandlerTest("", nil)
+	ctx := context.Background()
+
+	outboxMock := &mocks.OutboxReporter{}
+	outboxMock.On("Status").Return(brewfather.OutboxStatus{})
+
+	handler := &handlers.BrewfatherHandler{OutboxReporter: outboxMock}
+	// use new ctx to force error
+	err := handler.GetOutbox(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(), respondErrMsg)
+}
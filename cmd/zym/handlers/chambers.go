@@ -4,18 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/export"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	defaultReadingsPeriod = 24 * time.Hour
+	maxImportSize         = 1 << 20
+)
+
 type ChambersHandler struct {
 	ChamberController chamber.Controller
+	AuditRecorder     *audit.Recorder
 	Logger            *logrus.Logger
 }
 
@@ -23,11 +36,6 @@ func (h *ChambersHandler) GetAll(ctx context.Context, w http.ResponseWriter, _ *
 	_ httprouter.Params,
 ) error {
 	chambers, err := h.ChamberController.GetAll()
-
-	for _, c := range chambers {
-		c.RefreshReadings()
-	}
-
 	if err != nil {
 		return errors.Wrap(err, "could not get all chambers from controller")
 	}
@@ -51,8 +59,6 @@ func (h *ChambersHandler) Get(ctx context.Context, w http.ResponseWriter, _ *htt
 		return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
 	}
 
-	c.RefreshReadings()
-
 	if err := web.Respond(ctx, w, c, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
@@ -71,23 +77,18 @@ func (h *ChambersHandler) Save(ctx context.Context, w http.ResponseWriter, r *ht
 
 		switch {
 		case errors.As(err, &cfgError):
-			var b strings.Builder
-
-			b.WriteString(fmt.Sprintf("%s: ", cfgError.Error()))
-
-			for _, problem := range cfgError.Problems() {
-				b.WriteString(fmt.Sprintf("%s, ", problem))
-			}
-
-			return web.NewRequestError(strings.TrimSuffix(b.String(), ", "), http.StatusBadRequest)
-
+			return web.NewRequestError(formatProblems(cfgError.Error(), cfgError.Problems()), http.StatusBadRequest)
 		case errors.Is(err, chamber.ErrFermenting):
 			return web.NewRequestError("fermentation is in progress", http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrAutoTuning):
+			return web.NewRequestError("auto-tune is in progress", http.StatusConflict)
 		default:
 			return errors.Wrap(err, "could not save chamber to controller")
 		}
 	}
 
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.ChamberSavedAction, c.ID))
+
 	if err := web.Respond(ctx, w, c, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
@@ -95,7 +96,7 @@ func (h *ChambersHandler) Save(ctx context.Context, w http.ResponseWriter, r *ht
 	return nil
 }
 
-func (h *ChambersHandler) Delete(ctx context.Context, w http.ResponseWriter, _ *http.Request,
+func (h *ChambersHandler) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request,
 	p httprouter.Params,
 ) error {
 	id := p.ByName("id")
@@ -106,11 +107,15 @@ func (h *ChambersHandler) Delete(ctx context.Context, w http.ResponseWriter, _ *
 			return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
 		case errors.Is(err, chamber.ErrFermenting):
 			return web.NewRequestError("fermentation is in progress", http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrAutoTuning):
+			return web.NewRequestError("auto-tune is in progress", http.StatusConflict)
 		default:
 			return errors.Wrapf(err, "could not delete chamber %s from controller", id)
 		}
 	}
 
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.ChamberDeletedAction, id))
+
 	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
@@ -123,6 +128,10 @@ func (h *ChambersHandler) Start(ctx context.Context, w http.ResponseWriter, r *h
 ) error {
 	id := p.ByName("id")
 
+	if r.URL.Query().Has("setPoint") {
+		return h.startManual(ctx, w, r, id)
+	}
+
 	step := r.URL.Query().Get("step")
 
 	if err := h.ChamberController.StartFermentation(id, step); err != nil {
@@ -133,11 +142,23 @@ func (h *ChambersHandler) Start(ctx context.Context, w http.ResponseWriter, r *h
 			return web.NewRequestError(fmt.Sprintf("step '%s' is invalid for chamber '%s'", step, id), http.StatusBadRequest)
 		case errors.Is(err, chamber.ErrNoCurrentBatch):
 			return web.NewRequestError(fmt.Sprintf("chamber '%s' does not have a current batch", id), http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrNoTemperatureController):
+			return web.NewRequestError(fmt.Sprintf("chamber '%s' does not have a temperature controller", id),
+				http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrAutoTuning):
+			return web.NewRequestError("auto-tune is in progress", http.StatusConflict)
 		default:
 			return errors.Wrapf(err, "could not start fermentation for chamber %s", id)
 		}
 	}
 
+	e := newAuditEntry(ctx, r, audit.FermentationStartedAction, id)
+	if step != "" {
+		e.Detail = fmt.Sprintf("step %s", step)
+	}
+
+	h.AuditRecorder.Record(e)
+
 	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
@@ -145,7 +166,7 @@ func (h *ChambersHandler) Start(ctx context.Context, w http.ResponseWriter, r *h
 	return nil
 }
 
-func (h *ChambersHandler) Stop(ctx context.Context, w http.ResponseWriter, _ *http.Request, p httprouter.Params) error {
+func (h *ChambersHandler) Stop(ctx context.Context, w http.ResponseWriter, r *http.Request, p httprouter.Params) error {
 	id := p.ByName("id")
 
 	if err := h.ChamberController.StopFermentation(id); err != nil {
@@ -159,6 +180,126 @@ func (h *ChambersHandler) Stop(ctx context.Context, w http.ResponseWriter, _ *ht
 		}
 	}
 
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.FermentationStoppedAction, id))
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// startManual holds the chamber at the setPoint query parameter. The optional duration query parameter, such as 72h,
+// limits how long the set point is held, after which the chamber stops or, if revert is true, returns to the
+// fermentation step that was running.
+func (h *ChambersHandler) startManual(ctx context.Context, w http.ResponseWriter, r *http.Request, id string) error {
+	setPoint, err := strconv.ParseFloat(r.URL.Query().Get("setPoint"), 64)
+	if err != nil {
+		return web.NewRequestError("setPoint is invalid", http.StatusBadRequest)
+	}
+
+	var duration time.Duration
+
+	if v := r.URL.Query().Get("duration"); v != "" {
+		if duration, err = time.ParseDuration(v); err != nil || duration <= 0 {
+			return web.NewRequestError("duration is invalid", http.StatusBadRequest)
+		}
+	}
+
+	var revert bool
+
+	if v := r.URL.Query().Get("revert"); v != "" {
+		if revert, err = strconv.ParseBool(v); err != nil {
+			return web.NewRequestError("revert is invalid", http.StatusBadRequest)
+		}
+	}
+
+	if err := h.ChamberController.StartManual(id, setPoint, duration, revert); err != nil {
+		switch {
+		case errors.Is(err, chamber.ErrNotFound):
+			return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
+		case errors.Is(err, chamber.ErrNoTemperatureController):
+			return web.NewRequestError(fmt.Sprintf("chamber '%s' does not have a temperature controller", id),
+				http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrAutoTuning):
+			return web.NewRequestError("auto-tune is in progress", http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "could not start manual set point for chamber %s", id)
+		}
+	}
+
+	e := newAuditEntry(ctx, r, audit.FermentationStartedAction, id)
+	e.Detail = fmt.Sprintf("set point %g", setPoint)
+
+	h.AuditRecorder.Record(e)
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+func (h *ChambersHandler) StartAutoTune(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	p httprouter.Params,
+) error {
+	id := p.ByName("id")
+
+	setPoint, err := strconv.ParseFloat(r.URL.Query().Get("setPoint"), 64)
+	if err != nil {
+		return web.NewRequestError("setPoint is invalid", http.StatusBadRequest)
+	}
+
+	rule := pid.TuningRule(r.URL.Query().Get("rule"))
+	if rule == "" {
+		rule = pid.TyreusLuyben
+	}
+
+	if err := h.ChamberController.StartAutoTune(id, setPoint, rule); err != nil {
+		switch {
+		case errors.Is(err, chamber.ErrNotFound):
+			return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
+		case errors.Is(err, pid.ErrInvalidTuningRule):
+			return web.NewRequestError(fmt.Sprintf("rule '%s' is invalid", rule), http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrFermenting):
+			return web.NewRequestError("fermentation is in progress", http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrAutoTuning):
+			return web.NewRequestError("auto-tune is in progress", http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "could not start auto-tune for chamber %s", id)
+		}
+	}
+
+	e := newAuditEntry(ctx, r, audit.AutoTuneStartedAction, id)
+	e.Detail = fmt.Sprintf("set point %g", setPoint)
+
+	h.AuditRecorder.Record(e)
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+func (h *ChambersHandler) StopAutoTune(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	p httprouter.Params,
+) error {
+	id := p.ByName("id")
+
+	if err := h.ChamberController.StopAutoTune(id); err != nil {
+		switch {
+		case errors.Is(err, chamber.ErrNotFound):
+			return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
+		case errors.Is(err, chamber.ErrNotAutoTuning):
+			return web.NewRequestError(fmt.Sprintf("chamber '%s' is not auto-tuning", id), http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "could not stop auto-tune for chamber %s", id)
+		}
+	}
+
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.AutoTuneStoppedAction, id))
+
 	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
@@ -166,9 +307,223 @@ func (h *ChambersHandler) Stop(ctx context.Context, w http.ResponseWriter, _ *ht
 	return nil
 }
 
+// GetReadings returns the history of a chamber's readings. The from and to query parameters are RFC 3339 times and
+// default to the last day. The resolution query parameter is a duration, such as 15m, to downsample the readings to.
+func (h *ChambersHandler) GetReadings(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	p httprouter.Params,
+) error {
+	id := p.ByName("id")
+
+	from, to, resolution, err := parseReadingsQuery(r)
+	if err != nil {
+		return err
+	}
+
+	c, err := h.ChamberController.Get(id)
+	if err != nil {
+		return errors.Wrapf(err, "could not get chamber %s from controller", id)
+	}
+
+	if c == nil {
+		return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
+	}
+
+	readings, err := c.GetReadings(from, to, resolution)
+	if err != nil {
+		return errors.Wrapf(err, "could not get readings for chamber %s", id)
+	}
+
+	if err := web.Respond(ctx, w, readings, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// Export writes the fermentation log of a chamber's batch as a file. The format query parameter is csv, the default,
+// or ndjson. The batchId query parameter defaults to the chamber's current batch and the from and to query parameters
+// default to the whole history.
+func (h *ChambersHandler) Export(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	p httprouter.Params,
+) error {
+	id := p.ByName("id")
+
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return web.NewRequestError(fmt.Sprintf("format '%s' is invalid", r.URL.Query().Get("format")),
+			http.StatusBadRequest)
+	}
+
+	from, to, err := parseTimeRange(r, 0)
+	if err != nil {
+		return err
+	}
+
+	c, err := h.ChamberController.Get(id)
+	if err != nil {
+		return errors.Wrapf(err, "could not get chamber %s from controller", id)
+	}
+
+	if c == nil {
+		return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
+	}
+
+	readings, err := c.GetReadings(from, to, 0)
+	if err != nil {
+		return errors.Wrapf(err, "could not get readings for chamber %s", id)
+	}
+
+	b := export.GetBatch(c, r.URL.Query().Get("batchId"))
+	events := export.Events(readings, b)
+
+	fileName := c.ID
+	if b != nil {
+		fileName = b.ID
+	}
+
+	if err := web.SetStatusCode(ctx, http.StatusOK); err != nil {
+		return errors.Wrap(err, "could not set status code in context")
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+"."+string(format)))
+	w.WriteHeader(http.StatusOK)
+
+	if err := export.Write(w, format, events); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// Import sets the current batch of a chamber to the recipe in the BeerXML or BeerJSON file uploaded in the "file" field
+// of a multipart form.
+func (h *ChambersHandler) Import(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	p httprouter.Params,
+) error {
+	id := p.ByName("id")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return web.NewRequestError("file is missing or too large", http.StatusBadRequest)
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return web.NewRequestError("file could not be read", http.StatusBadRequest)
+	}
+
+	b, err := batch.Import(data)
+	if err != nil {
+		var (
+			importErr *batch.ImportError
+			batchErr  *batch.InvalidBatchError
+		)
+
+		switch {
+		case errors.As(err, &importErr), errors.Is(err, batch.ErrUnknownFormat):
+			return web.NewRequestError(err.Error(), http.StatusBadRequest)
+		case errors.As(err, &batchErr):
+			return web.NewRequestError(formatProblems(batchErr.Error(), batchErr.Problems()), http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "could not import recipe")
+		}
+	}
+
+	if err := h.ChamberController.SetBatch(id, b); err != nil {
+		switch {
+		case errors.Is(err, chamber.ErrNotFound):
+			return web.NewRequestError(fmt.Sprintf("chamber '%s' not found", id), http.StatusNotFound)
+		case errors.Is(err, chamber.ErrFermenting):
+			return web.NewRequestError("fermentation is in progress", http.StatusBadRequest)
+		case errors.Is(err, chamber.ErrAutoTuning):
+			return web.NewRequestError("auto-tune is in progress", http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "could not set batch of chamber %s", id)
+		}
+	}
+
+	e := newAuditEntry(ctx, r, audit.BatchImportedAction, id)
+	e.Detail = fmt.Sprintf("recipe %s", b.Recipe.Name)
+
+	h.AuditRecorder.Record(e)
+
+	if err := web.Respond(ctx, w, b, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+func parseReadingsQuery(r *http.Request) (time.Time, time.Time, time.Duration, error) {
+	from, to, err := parseTimeRange(r, defaultReadingsPeriod)
+	if err != nil {
+		return from, to, 0, err
+	}
+
+	var resolution time.Duration
+
+	if v := r.URL.Query().Get("resolution"); v != "" {
+		if resolution, err = time.ParseDuration(v); err != nil || resolution < 0 {
+			return from, to, resolution, web.NewRequestError("resolution is invalid", http.StatusBadRequest)
+		}
+	}
+
+	return from, to, resolution, nil
+}
+
+// parseTimeRange returns the from and to query parameters. To defaults to now and from defaults to defaultPeriod
+// before to, or the Unix epoch if defaultPeriod is zero.
+func parseTimeRange(r *http.Request, defaultPeriod time.Duration) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	to := time.Now()
+	from := time.Unix(0, 0)
+
+	var err error
+
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, web.NewRequestError("to is invalid", http.StatusBadRequest)
+		}
+	}
+
+	if defaultPeriod > 0 {
+		from = to.Add(-defaultPeriod)
+	}
+
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, web.NewRequestError("from is invalid", http.StatusBadRequest)
+		}
+	}
+
+	if !from.Before(to) {
+		return from, to, web.NewRequestError("from must be before to", http.StatusBadRequest)
+	}
+
+	return from, to, nil
+}
+
 func parseChamber(r *http.Request) (chamber.Chamber, error) {
 	var chamber chamber.Chamber
 	err := json.NewDecoder(r.Body).Decode(&chamber)
 
 	return chamber, errors.Wrap(err, "could not decode chamber from request body")
 }
+
+// formatProblems joins the given problems into a single message that starts with msg.
+func formatProblems(msg string, problems []error) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("%s: ", msg))
+
+	for _, problem := range problems {
+		b.WriteString(fmt.Sprintf("%s, ", problem))
+	}
+
+	return strings.TrimSuffix(b.String(), ", ")
+}
@@ -6,14 +6,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
 	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/database"
+	"github.com/benjaminbartels/zymurgauge/internal/export"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
 	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
 	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
 	"github.com/julienschmidt/httprouter"
@@ -21,6 +28,7 @@ import (
 	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.etcd.io/bbolt"
 )
 
 const (
@@ -37,6 +45,7 @@ func getTestChamber() chamber.Chamber {
 			BeerThermometerID:   "1",
 		},
 		CurrentBatch: &batch.Detail{
+			Source: batch.LocalSource,
 			Recipe: batch.Recipe{
 				Fermentation: batch.Fermentation{
 					Steps: []batch.FermentationStep{
@@ -244,6 +253,7 @@ func TestSaveChamber(t *testing.T) {
 	t.Run("saveChamberParseError", saveChamberParseError)
 	t.Run("saveChamberInvalidConfigError", saveChamberInvalidConfigError)
 	t.Run("saveChamberFermentingError", saveChamberFermentingError)
+	t.Run("saveChamberAutoTuningError", saveChamberAutoTuningError)
 	t.Run("saveChamberOtherError", saveChamberOtherError)
 	t.Run("saveChamberRespondError", saveChamberRespondError)
 }
@@ -311,6 +321,11 @@ func saveChamberInvalidConfigError(t *testing.T) {
 	repoMock := &mocks.ChamberRepo{}
 	repoMock.On("GetAll").Return(chambers, nil)
 
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
 	configuratorMock := &mocks.Configurator{}
 	configuratorMock.On("CreateDs18b20", mock.Anything).Return(nil, errSomeError)
 	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(nil, nil)
@@ -318,7 +333,8 @@ func saveChamberInvalidConfigError(t *testing.T) {
 	serviceMock := &mocks.Service{}
 	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
 
-	controller, err := chamber.NewManager(ctx, repoMock, configuratorMock, serviceMock, l, m, readingUpdateInterval)
+	controller, err := chamber.NewManager(ctx, repoMock, stateRepoMock, configuratorMock, serviceMock, l, m,
+		readingUpdateInterval)
 	assert.NoError(t, err)
 
 	handler := &handlers.ChambersHandler{ChamberController: controller, Logger: l}
@@ -358,6 +374,25 @@ func saveChamberFermentingError(t *testing.T) {
 	assert.Equal(t, reqErr.Status, http.StatusBadRequest)
 }
 
+func saveChamberAutoTuningError(t *testing.T) {
+	t.Parallel()
+
+	c := &chamber.Chamber{ID: chamberID}
+	jsonBytes, err := json.Marshal(c)
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+	l, _ := logtest.NewNullLogger()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("Save", c).Return(chamber.ErrAutoTuning)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+
+	err = handler.Save(ctx, w, r, httprouter.Params{})
+	assertHandlerError(t, err, autoTuningMsg, http.StatusConflict)
+}
+
 func saveChamberOtherError(t *testing.T) {
 	t.Parallel()
 
@@ -403,6 +438,7 @@ func TestDeleteChamber(t *testing.T) {
 	t.Run("deleteChamber", deleteChamber)
 	t.Run("deleteChamberNotFoundError", deleteChamberNotFoundError)
 	t.Run("deleteChamberFermentingError", deleteChamberFermentingError)
+	t.Run("deleteChamberAutoTuningError", deleteChamberAutoTuningError)
 	t.Run("deleteChamberOtherError", deleteChamberOtherError)
 	t.Run("deleteChamberRespondError", deleteChamberRespondError)
 }
@@ -480,6 +516,20 @@ func deleteChamberFermentingError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, reqErr.Status)
 }
 
+func deleteChamberAutoTuningError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+	l, _ := logtest.NewNullLogger()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("Delete", chamberID).Return(chamber.ErrAutoTuning)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, autoTuningMsg, http.StatusConflict)
+}
+
 func deleteChamberOtherError(t *testing.T) {
 	t.Parallel()
 
@@ -530,6 +580,8 @@ func TestStartFermentation(t *testing.T) {
 	t.Run("startFermentationInvalidStepError", startFermentationInvalidStepError)
 	t.Run("startFermentationNotFoundError", startFermentationNotFoundError)
 	t.Run("startFermentationNoBatchError", startFermentationNoBatchError)
+	t.Run("startFermentationNoTemperatureControllerError", startFermentationNoTemperatureControllerError)
+	t.Run("startFermentationAutoTuningError", startFermentationAutoTuningError)
 	t.Run("startFermentationOtherError", startFermentationOtherError)
 	t.Run("startFermentationRespondError", startFermentationRespondError)
 }
@@ -638,6 +690,32 @@ func startFermentationNoBatchError(t *testing.T) {
 	assert.Equal(t, reqErr.Status, http.StatusBadRequest)
 }
 
+func startFermentationNoTemperatureControllerError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("step="+primaryStep, nil)
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("StartFermentation", chamberID, primaryStep).Return(chamber.ErrNoTemperatureController)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+	err := handler.Start(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, fmt.Sprintf(noTemperatureControllerMsg, chamberID), http.StatusBadRequest)
+}
+
+func startFermentationAutoTuningError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("step="+primaryStep, nil)
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("StartFermentation", chamberID, primaryStep).Return(chamber.ErrAutoTuning)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+	err := handler.Start(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, autoTuningMsg, http.StatusConflict)
+}
+
 func startFermentationOtherError(t *testing.T) {
 	t.Parallel()
 
@@ -837,3 +915,518 @@ func assertChambersAreEqual(t *testing.T, c1, c2 *chamber.Chamber) {
 	assert.Equal(t, c1.HeatingDifferential, c2.HeatingDifferential)
 	assert.Equal(t, c1.ModTime, c2.ModTime)
 }
+
+func TestStartAutoTune(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		query     string
+		rule      pid.TuningRule
+		returnErr error
+		errMsg    string
+		status    int
+	}{
+		{name: "defaultRule", query: "setPoint=18", rule: pid.TyreusLuyben},
+		{name: "zieglerNichols", query: "setPoint=18&rule=ziegler-nichols", rule: pid.ZieglerNichols},
+		{name: "invalidSetPoint", query: "setPoint=cold", errMsg: "setPoint is invalid", status: http.StatusBadRequest},
+		{
+			name: "invalidRule", query: "setPoint=18&rule=guess", rule: "guess", returnErr: pid.ErrInvalidTuningRule,
+			errMsg: "rule 'guess' is invalid", status: http.StatusBadRequest,
+		},
+		{
+			name: "notFound", query: "setPoint=18", rule: pid.TyreusLuyben, returnErr: chamber.ErrNotFound,
+			errMsg: fmt.Sprintf(notFoundErrorMsg, "chamber", chamberID), status: http.StatusNotFound,
+		},
+		{
+			name: "fermenting", query: "setPoint=18", rule: pid.TyreusLuyben, returnErr: chamber.ErrFermenting,
+			errMsg: "fermentation is in progress", status: http.StatusBadRequest,
+		},
+		{
+			name: "autoTuning", query: "setPoint=18", rule: pid.TyreusLuyben, returnErr: chamber.ErrAutoTuning,
+			errMsg: "auto-tune is in progress", status: http.StatusBadRequest,
+		},
+		{
+			name: "otherError", query: "setPoint=18", rule: pid.TyreusLuyben, returnErr: errSomeError,
+			errMsg: fmt.Sprintf("could not start auto-tune for chamber %s", chamberID),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, r, ctx := setupHandlerTest(tc.query, nil)
+
+			controllerMock := &mocks.Controller{}
+			controllerMock.On("StartAutoTune", chamberID, 18.0, tc.rule).Return(tc.returnErr)
+
+			handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+			err := handler.StartAutoTune(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+			assertHandlerError(t, err, tc.errMsg, tc.status)
+		})
+	}
+}
+
+func TestStartManual(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		query     string
+		duration  time.Duration
+		revert    bool
+		returnErr error
+		errMsg    string
+		status    int
+	}{
+		{name: "hold", query: "setPoint=2"},
+		{name: "duration", query: "setPoint=2&duration=72h", duration: 72 * time.Hour},
+		{name: "revert", query: "setPoint=2&duration=1h30m&revert=true", duration: 90 * time.Minute, revert: true},
+		{name: "invalidSetPoint", query: "setPoint=cold", errMsg: "setPoint is invalid", status: http.StatusBadRequest},
+		{
+			name: "invalidDuration", query: "setPoint=2&duration=3", errMsg: "duration is invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "negativeDuration", query: "setPoint=2&duration=-1h", errMsg: "duration is invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "invalidRevert", query: "setPoint=2&revert=maybe", errMsg: "revert is invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "notFound", query: "setPoint=2", returnErr: chamber.ErrNotFound,
+			errMsg: fmt.Sprintf(notFoundErrorMsg, "chamber", chamberID), status: http.StatusNotFound,
+		},
+		{
+			name: "noTemperatureController", query: "setPoint=2", returnErr: chamber.ErrNoTemperatureController,
+			errMsg: fmt.Sprintf(noTemperatureControllerMsg, chamberID), status: http.StatusBadRequest,
+		},
+		{
+			name: "autoTuning", query: "setPoint=2", returnErr: chamber.ErrAutoTuning,
+			errMsg: autoTuningMsg, status: http.StatusConflict,
+		},
+		{
+			name: "otherError", query: "setPoint=2", returnErr: errSomeError,
+			errMsg: fmt.Sprintf("could not start manual set point for chamber %s", chamberID),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, r, ctx := setupHandlerTest(tc.query, nil)
+
+			controllerMock := &mocks.Controller{}
+			controllerMock.On("StartManual", chamberID, 2.0, tc.duration, tc.revert).Return(tc.returnErr)
+
+			handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+			err := handler.Start(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+			assertHandlerError(t, err, tc.errMsg, tc.status)
+		})
+	}
+}
+
+func TestStopAutoTune(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		returnErr error
+		errMsg    string
+		status    int
+	}{
+		{name: "stopAutoTune"},
+		{
+			name: "notFound", returnErr: chamber.ErrNotFound,
+			errMsg: fmt.Sprintf(notFoundErrorMsg, "chamber", chamberID), status: http.StatusNotFound,
+		},
+		{
+			name: "notAutoTuning", returnErr: chamber.ErrNotAutoTuning,
+			errMsg: fmt.Sprintf("chamber '%s' is not auto-tuning", chamberID), status: http.StatusBadRequest,
+		},
+		{
+			name: "otherError", returnErr: errSomeError,
+			errMsg: fmt.Sprintf("could not stop auto-tune for chamber %s", chamberID),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, r, ctx := setupHandlerTest("", nil)
+
+			controllerMock := &mocks.Controller{}
+			controllerMock.On("StopAutoTune", chamberID).Return(tc.returnErr)
+
+			handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+			err := handler.StopAutoTune(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+			assertHandlerError(t, err, tc.errMsg, tc.status)
+		})
+	}
+}
+
+func assertHandlerError(t *testing.T, err error, errMsg string, status int) {
+	t.Helper()
+
+	if errMsg == "" {
+		assert.NoError(t, err)
+
+		return
+	}
+
+	assert.Contains(t, err.Error(), errMsg)
+
+	if status != 0 {
+		var reqErr *web.RequestError
+
+		assert.ErrorAs(t, err, &reqErr)
+		assert.Equal(t, status, reqErr.Status)
+	}
+}
+
+func TestGetReadings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		query     string
+		chamber   *chamber.Chamber
+		returnErr error
+		errMsg    string
+		status    int
+	}{
+		{name: "defaultPeriod", chamber: &chamber.Chamber{ID: chamberID}},
+		{
+			name: "period", query: "from=2023-05-01T00:00:00Z&to=2023-05-02T00:00:00Z&resolution=15m",
+			chamber: &chamber.Chamber{ID: chamberID},
+		},
+		{name: "invalidFrom", query: "from=yesterday", errMsg: "from is invalid", status: http.StatusBadRequest},
+		{name: "invalidTo", query: "to=today", errMsg: "to is invalid", status: http.StatusBadRequest},
+		{
+			name: "fromAfterTo", query: "from=2023-05-02T00:00:00Z&to=2023-05-01T00:00:00Z",
+			errMsg: "from must be before to", status: http.StatusBadRequest,
+		},
+		{name: "invalidResolution", query: "resolution=1x", errMsg: "resolution is invalid", status: http.StatusBadRequest},
+		{
+			name: "negativeResolution", query: "resolution=-1m", errMsg: "resolution is invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "notFound", errMsg: fmt.Sprintf(notFoundErrorMsg, "chamber", chamberID), status: http.StatusNotFound,
+		},
+		{
+			name: "otherError", returnErr: errSomeError,
+			errMsg: fmt.Sprintf("could not get chamber %s from controller", chamberID),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, r, ctx := setupHandlerTest(tc.query, nil)
+
+			controllerMock := &mocks.Controller{}
+			controllerMock.On("Get", chamberID).Return(tc.chamber, tc.returnErr)
+
+			handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+			err := handler.GetReadings(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+			assertHandlerError(t, err, tc.errMsg, tc.status)
+
+			if tc.errMsg == "" {
+				body, _ := io.ReadAll(w.Body)
+				assert.JSONEq(t, "[]", string(body))
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		query       string
+		chamber     *chamber.Chamber
+		returnErr   error
+		contentType string
+		fileName    string
+		errMsg      string
+		status      int
+	}{
+		{
+			name: "csv", chamber: &chamber.Chamber{ID: chamberID}, contentType: "text/csv",
+			fileName: chamberID + ".csv",
+		},
+		{
+			name: "ndjson", query: "format=ndjson&batchId=" + batchID, chamber: &chamber.Chamber{ID: chamberID},
+			contentType: "application/x-ndjson", fileName: batchID + ".ndjson",
+		},
+		{name: "invalidFormat", query: "format=xml", errMsg: "format 'xml' is invalid", status: http.StatusBadRequest},
+		{name: "invalidFrom", query: "from=yesterday", errMsg: "from is invalid", status: http.StatusBadRequest},
+		{
+			name: "notFound", errMsg: fmt.Sprintf(notFoundErrorMsg, "chamber", chamberID), status: http.StatusNotFound,
+		},
+		{
+			name: "otherError", returnErr: errSomeError,
+			errMsg: fmt.Sprintf("could not get chamber %s from controller", chamberID),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, r, ctx := setupHandlerTest(tc.query, nil)
+
+			controllerMock := &mocks.Controller{}
+			controllerMock.On("Get", chamberID).Return(tc.chamber, tc.returnErr)
+
+			handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+			err := handler.Export(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+			assertHandlerError(t, err, tc.errMsg, tc.status)
+
+			if tc.errMsg == "" {
+				assert.Equal(t, tc.contentType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Header().Get("Content-Disposition"), tc.fileName)
+			}
+		})
+	}
+}
+
+func TestExportWithoutFrom(t *testing.T) {
+	t.Parallel()
+
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "zymurgaugedb"), 0o600, &bbolt.Options{Timeout: time.Second})
+	assert.NoError(t, err)
+
+	defer db.Close()
+
+	readingsRepo, err := database.NewReadingsRepo(db, 0)
+	assert.NoError(t, err)
+
+	temperature := 19.5
+	err = readingsRepo.SaveReading(chamberID, &chamber.Reading{
+		Time: time.Now().Add(-time.Hour), BeerTemperature: &temperature,
+	})
+	assert.NoError(t, err)
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	c := &chamber.Chamber{ID: chamberID}
+	err = c.Configure(configuratorMock, &mocks.Service{}, l, m, readingUpdateInterval,
+		chamber.SetReadingsRepo(readingsRepo))
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("format=ndjson", nil)
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("Get", chamberID).Return(c, nil)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock}
+	err = handler.Export(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assert.NoError(t, err)
+
+	var event export.Event
+
+	err = json.NewDecoder(w.Body).Decode(&event)
+	assert.NoError(t, err)
+	assert.Equal(t, export.ReadingEvent, event.Type)
+	assert.Equal(t, &temperature, event.BeerTemperature)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestImport(t *testing.T) {
+	t.Parallel()
+	t.Run("importRecipe", importRecipe)
+	t.Run("importMissingFile", importMissingFile)
+	t.Run("importInvalidRecipe", importInvalidRecipe)
+	t.Run("importUnknownFormat", importUnknownFormat)
+	t.Run("importNotFoundError", importNotFoundError)
+	t.Run("importFermentingError", importFermentingError)
+	t.Run("importAutoTuningError", importAutoTuningError)
+	t.Run("importOtherError", importOtherError)
+	t.Run("importRespondError", importRespondError)
+}
+
+const importedRecipe = "<RECIPES><RECIPE><NAME>Pale Ale</NAME><PRIMARY_AGE>7</PRIMARY_AGE>" +
+	"<PRIMARY_TEMP>19</PRIMARY_TEMP></RECIPE></RECIPES>"
+
+func importRecipe(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupImportTest(t, importedRecipe)
+	l, _ := logtest.NewNullLogger()
+
+	expected := &batch.Detail{
+		Recipe: batch.Recipe{
+			Name: "Pale Ale",
+			Fermentation: batch.Fermentation{
+				Steps: []batch.FermentationStep{{Name: primaryStep, Temperature: 19, Duration: 7}},
+			},
+		},
+		Source: batch.LocalSource,
+	}
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("SetBatch", chamberID, expected).Return(nil)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assert.NoError(t, err)
+
+	resp := w.Result()
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	result := &batch.Detail{}
+	err = json.Unmarshal(bodyBytes, result)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func importMissingFile(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+	l, _ := logtest.NewNullLogger()
+
+	handler := &handlers.ChambersHandler{ChamberController: &mocks.Controller{}, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, "file is missing or too large", http.StatusBadRequest)
+}
+
+func importInvalidRecipe(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupImportTest(t, "<RECIPES><RECIPE><PRIMARY_TEMP>19</PRIMARY_TEMP></RECIPE></RECIPES>")
+	l, _ := logtest.NewNullLogger()
+
+	handler := &handlers.ChambersHandler{ChamberController: &mocks.Controller{}, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, "RECIPES/RECIPE[1]/NAME: is required", http.StatusBadRequest)
+}
+
+func importUnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupImportTest(t, "Pale Ale")
+	l, _ := logtest.NewNullLogger()
+
+	handler := &handlers.ChambersHandler{ChamberController: &mocks.Controller{}, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, batch.ErrUnknownFormat.Error(), http.StatusBadRequest)
+}
+
+func importNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupImportTest(t, importedRecipe)
+	l, _ := logtest.NewNullLogger()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("SetBatch", chamberID, mock.Anything).Return(chamber.ErrNotFound)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, fmt.Sprintf(notFoundErrorMsg, "chamber", chamberID), http.StatusNotFound)
+}
+
+func importFermentingError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupImportTest(t, importedRecipe)
+	l, _ := logtest.NewNullLogger()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("SetBatch", chamberID, mock.Anything).Return(chamber.ErrFermenting)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, fermentationInProgressMsg, http.StatusBadRequest)
+}
+
+func importAutoTuningError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupImportTest(t, importedRecipe)
+	l, _ := logtest.NewNullLogger()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("SetBatch", chamberID, mock.Anything).Return(chamber.ErrAutoTuning)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assertHandlerError(t, err, autoTuningMsg, http.StatusConflict)
+}
+
+func importOtherError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupImportTest(t, importedRecipe)
+	l, _ := logtest.NewNullLogger()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("SetBatch", chamberID, mock.Anything).Return(errSomeError)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assert.Contains(t, err.Error(), fmt.Sprintf("could not set batch of chamber %s", chamberID))
+}
+
+func importRespondError(t *testing.T) {
+	t.Parallel()
+
+	w, r, _ := setupImportTest(t, importedRecipe)
+	ctx := context.Background()
+	l, _ := logtest.NewNullLogger()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("SetBatch", chamberID, mock.Anything).Return(nil)
+
+	handler := &handlers.ChambersHandler{ChamberController: controllerMock, Logger: l}
+	// use new ctx to force error
+	err := handler.Import(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: chamberID}})
+	assert.Contains(t, err.Error(), respondErrMsg)
+}
+
+func setupImportTest(t *testing.T, recipe string) (*httptest.ResponseRecorder, *http.Request, context.Context) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+
+	fw, err := mw.CreateFormFile("file", "recipe.xml")
+	assert.NoError(t, err)
+
+	_, err = fw.Write([]byte(recipe))
+	assert.NoError(t, err)
+
+	err = mw.Close()
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return w, r, ctx
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+type EventsHandler struct {
+	Subscriber event.Subscriber
+}
+
+// Stream sends events to the client as server-sent events until the client disconnects. Events can be limited to a
+// single chamber with the chamberId query parameter.
+func (h *EventsHandler) Stream(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	_ httprouter.Params,
+) error {
+	rc := http.NewResponseController(w)
+
+	// the stream is long-lived so it must not be cut off by the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return errors.Wrap(err, "could not clear write deadline")
+	}
+
+	chamberID := r.URL.Query().Get("chamberId")
+
+	events, unsubscribe := h.Subscriber.Subscribe()
+	defer unsubscribe()
+
+	if err := web.SetStatusCode(ctx, http.StatusOK); err != nil {
+		return errors.Wrap(err, "could not set status code in context")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// errors while writing mean that the client has gone away, which ends the stream
+	if err := rc.Flush(); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if chamberID != "" && e.ChamberID != chamberID {
+				continue
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				return errors.Wrap(err, "could not marshal event")
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
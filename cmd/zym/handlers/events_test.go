@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSubscriber struct {
+	events       chan event.Event
+	unsubscribed bool
+}
+
+func (s *testSubscriber) Subscribe() (<-chan event.Event, func()) {
+	return s.events, func() { s.unsubscribed = true }
+}
+
+func TestStreamEvents(t *testing.T) {
+	t.Parallel()
+
+	eventTime := time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:  "allChambers",
+			query: "",
+			expected: "event: step\ndata: {\"type\":\"step\",\"chamberId\":\"" + chamberID +
+				"\",\"time\":\"2023-05-10T12:00:00Z\",\"data\":{\"step\":\"A\"}}\n\n" +
+				"event: alert\ndata: {\"type\":\"alert\",\"chamberId\":\"other\"," +
+				"\"time\":\"2023-05-10T12:00:00Z\",\"data\":null}\n\n",
+		},
+		{
+			name:  "singleChamber",
+			query: "chamberId=" + chamberID,
+			expected: "event: step\ndata: {\"type\":\"step\",\"chamberId\":\"" + chamberID +
+				"\",\"time\":\"2023-05-10T12:00:00Z\",\"data\":{\"step\":\"A\"}}\n\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			subscriber := &testSubscriber{events: make(chan event.Event, 2)}
+			subscriber.events <- event.Event{
+				Type: event.StepType, ChamberID: chamberID, Time: eventTime, Data: map[string]string{"step": "A"},
+			}
+			subscriber.events <- event.Event{Type: event.AlertType, ChamberID: "other", Time: eventTime}
+			close(subscriber.events) // ends the stream once the events have been sent
+
+			w, r, ctx := setupHandlerTest(tc.query, nil)
+
+			handler := &handlers.EventsHandler{Subscriber: subscriber}
+			err := handler.Stream(ctx, w, r, httprouter.Params{})
+			assert.NoError(t, err)
+			assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expected, w.Body.String())
+			assert.True(t, subscriber.unsubscribed)
+		})
+	}
+}
+
+func TestStreamEventsCanceled(t *testing.T) {
+	t.Parallel()
+
+	subscriber := &testSubscriber{events: make(chan event.Event)}
+
+	w, r, ctx := setupHandlerTest("", nil)
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	handler := &handlers.EventsHandler{Subscriber: subscriber}
+	err := handler.Stream(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+	assert.Empty(t, w.Body.String())
+	assert.True(t, subscriber.unsubscribed)
+}
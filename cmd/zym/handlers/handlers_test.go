@@ -12,21 +12,27 @@ import (
 )
 
 const (
-	chamberID                 = "96f58a65-03c0-49f3-83ca-ab751bbf3768"
-	batchID                   = "KBTM3F9soO5TtbAx0A5mBZTAUsNZyg"
-	repoErrMsg                = "could not %s repository"
-	controllerErrMsg          = "could not %s controller"
-	respondErrMsg             = "problem responding to client"
-	notFoundErrorMsg          = "%s '%s' not found"
-	parseErrorMsg             = "could not parse"
-	fermentationInProgressMsg = "fermentation is in progress"
-	invalidStepErrorMsg       = "step '%s' is invalid for chamber '%s'"
-	noCurrentBatchErrorMsg    = "chamber '%s' does not have a current batch"
-	notFermentingErrorMsg     = "chamber '%s' is not fermenting"
-	startFermentationErrorMsg = "could not start fermentation for chamber %s"
-	stopFermentationErrorMsg  = "could not stop fermentation for chamber %s"
-	invalidConfigErrorMsg     = "configuration is invalid: %s: some error"
-	settingsNotFound          = "settings not found"
+	chamberID                    = "96f58a65-03c0-49f3-83ca-ab751bbf3768"
+	alertID                      = "a0c4b1e8-4a4c-4a39-8e55-cb3d7d1c1f5a"
+	batchID                      = "KBTM3F9soO5TtbAx0A5mBZTAUsNZyg"
+	userID                       = "0f9e7a0c-8d44-4b2e-a8b8-6f0c1a3e5d21"
+	tokenID                      = "3c1d5e2a-7b9f-4c6e-8a0d-2f4b6e8c0a1d"
+	repoErrMsg                   = "could not %s repository"
+	controllerErrMsg             = "could not %s controller"
+	respondErrMsg                = "problem responding to client"
+	notFoundErrorMsg             = "%s '%s' not found"
+	parseErrorMsg                = "could not parse"
+	fermentationInProgressMsg    = "fermentation is in progress"
+	invalidStepErrorMsg          = "step '%s' is invalid for chamber '%s'"
+	noCurrentBatchErrorMsg       = "chamber '%s' does not have a current batch"
+	notFermentingErrorMsg        = "chamber '%s' is not fermenting"
+	noTemperatureControllerMsg   = "chamber '%s' does not have a temperature controller"
+	autoTuningMsg                = "auto-tune is in progress"
+	startFermentationErrorMsg    = "could not start fermentation for chamber %s"
+	stopFermentationErrorMsg     = "could not stop fermentation for chamber %s"
+	invalidConfigErrorMsg        = "configuration is invalid: %s: some error"
+	settingsNotFound             = "settings not found"
+	incorrectCredentialsErrorMsg = "incorrect username or password"
 )
 
 var errSomeError = errors.New("some error")
@@ -5,12 +5,17 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
 	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
 	"github.com/benjaminbartels/zymurgauge/internal/middleware"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
 	"github.com/benjaminbartels/zymurgauge/internal/settings"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,6 +25,12 @@ const (
 	thermometersPath = "/thermometers"
 	batchesPath      = "/batches"
 	settingsPath     = "/settings"
+	alertsPath       = "/alerts"
+	eventsPath       = "/events"
+	brewfatherPath   = "/brewfather"
+	usersPath        = "/users"
+	tokensPath       = "/tokens"
+	auditPath        = "/audit"
 	version          = "v1"
 )
 
@@ -27,61 +38,136 @@ type Status struct {
 	Message string `json:"message"`
 }
 
-func NewApp(chamberManager chamber.Controller, devicePath string, service brewfather.Service,
-	settingsRepo settings.Repo, updateChan chan settings.Settings, uiFileReader web.FileReader, shutdown chan os.Signal,
-	logger *logrus.Logger,
+func NewApp(chamberManager chamber.Controller, devicePath string, batchController batch.Controller,
+	alertController alert.Controller, eventSubscriber event.Subscriber, outboxReporter brewfather.OutboxReporter,
+	settingsRepo settings.Repo, userRepo auth.UserRepo, apiTokenRepo auth.APITokenRepo, sessionRepo auth.SessionRepo,
+	auditRepo audit.Repo, loginThrottle *auth.LoginThrottle, updateChan chan settings.Settings,
+	uiFileReader web.FileReader, shutdown chan os.Signal, logger *logrus.Logger, metrics metrics.Metrics,
 ) (*web.App, error) {
+	var metricsMw web.Middleware
+	if metrics != nil {
+		metricsMw = middleware.Metrics(metrics)
+	}
+
 	api := web.NewAPI(shutdown,
 		middleware.RequestLogger(logger),
+		metricsMw,
 		middleware.Errors(logger))
 
-	s, err := settingsRepo.Get()
-	if err != nil {
-		return nil, errors.Wrap(err, "could not get settings")
-	}
+	viewerMw := middleware.Authorize(settingsRepo, apiTokenRepo, sessionRepo, auth.ViewerRole)
+	brewerMw := middleware.Authorize(settingsRepo, apiTokenRepo, sessionRepo, auth.BrewerRole)
+	adminMw := middleware.Authorize(settingsRepo, apiTokenRepo, sessionRepo, auth.AdminRole)
 
-	authMw := middleware.Authorize(s.AuthSecret)
+	auditRecorder := audit.NewRecorder(auditRepo, logger)
 
 	AuthHandler := &AuthHandler{
-		SettingsRepo: settingsRepo,
-		Logger:       logger,
+		UserRepo:      userRepo,
+		SessionRepo:   sessionRepo,
+		SettingsRepo:  settingsRepo,
+		LoginThrottle: loginThrottle,
+		AuditRecorder: auditRecorder,
+		Logger:        logger,
 	}
 
 	api.Register(http.MethodPost, version, fmt.Sprintf("%s/login", authPath), AuthHandler.Login)
-	api.Register(http.MethodPost, version, fmt.Sprintf("%s/update", authPath), AuthHandler.Save, authMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/refresh", authPath), AuthHandler.Refresh)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/logout", authPath), AuthHandler.Logout, viewerMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/update", authPath), AuthHandler.Save, viewerMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/rotate", authPath), AuthHandler.Rotate, adminMw)
 
 	chambersHandler := &ChambersHandler{
 		ChamberController: chamberManager,
+		AuditRecorder:     auditRecorder,
 		Logger:            logger,
 	}
 
-	api.Register(http.MethodGet, version, chambersPath, chambersHandler.GetAll, authMw)
-	api.Register(http.MethodGet, version, fmt.Sprintf("%s/:id", chambersPath), chambersHandler.Get, authMw)
-	api.Register(http.MethodPost, version, chambersPath, chambersHandler.Save, authMw)
-	api.Register(http.MethodDelete, version, fmt.Sprintf("%s/:id", chambersPath), chambersHandler.Delete, authMw)
-	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/start", chambersPath), chambersHandler.Start, authMw)
-	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/stop", chambersPath), chambersHandler.Stop, authMw)
+	api.Register(http.MethodGet, version, chambersPath, chambersHandler.GetAll, viewerMw)
+	api.Register(http.MethodGet, version, fmt.Sprintf("%s/:id", chambersPath), chambersHandler.Get, viewerMw)
+	api.Register(http.MethodPost, version, chambersPath, chambersHandler.Save, adminMw)
+	api.Register(http.MethodDelete, version, fmt.Sprintf("%s/:id", chambersPath), chambersHandler.Delete, adminMw)
+	api.Register(http.MethodGet, version, fmt.Sprintf("%s/:id/readings", chambersPath), chambersHandler.GetReadings,
+		viewerMw)
+	api.Register(http.MethodGet, version, fmt.Sprintf("%s/:id/export", chambersPath), chambersHandler.Export, viewerMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/import", chambersPath), chambersHandler.Import, brewerMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/start", chambersPath), chambersHandler.Start, brewerMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/stop", chambersPath), chambersHandler.Stop, brewerMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/autotune/start", chambersPath),
+		chambersHandler.StartAutoTune, adminMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/autotune/stop", chambersPath),
+		chambersHandler.StopAutoTune, adminMw)
 
 	batchesHandler := &BatchesHandler{
-		Service: service,
+		BatchController: batchController,
 	}
 
-	api.Register(http.MethodGet, version, batchesPath, batchesHandler.GetAll, authMw)
-	api.Register(http.MethodGet, version, fmt.Sprintf("%s/:id", batchesPath), batchesHandler.Get, authMw)
+	api.Register(http.MethodGet, version, batchesPath, batchesHandler.GetAll, viewerMw)
+	api.Register(http.MethodGet, version, fmt.Sprintf("%s/:id", batchesPath), batchesHandler.Get, viewerMw)
+	api.Register(http.MethodPost, version, batchesPath, batchesHandler.Save, brewerMw)
+	api.Register(http.MethodDelete, version, fmt.Sprintf("%s/:id", batchesPath), batchesHandler.Delete, brewerMw)
 
 	thermometersHandler := &ThermometersHandler{
 		DevicePath: devicePath,
 	}
 
-	api.Register(http.MethodGet, version, thermometersPath, thermometersHandler.GetAll, authMw)
+	api.Register(http.MethodGet, version, thermometersPath, thermometersHandler.GetAll, viewerMw)
 
 	settingsHandler := &SettingsHandler{
-		SettingsRepo: settingsRepo,
-		UpdateChan:   updateChan,
+		SettingsRepo:  settingsRepo,
+		UpdateChan:    updateChan,
+		AuditRecorder: auditRecorder,
+	}
+
+	api.Register(http.MethodGet, version, settingsPath, settingsHandler.Get, viewerMw)
+	api.Register(http.MethodPost, version, settingsPath, settingsHandler.Save, adminMw)
+
+	alertsHandler := &AlertsHandler{
+		AlertController: alertController,
+	}
+
+	api.Register(http.MethodGet, version, alertsPath, alertsHandler.GetAll, viewerMw)
+	api.Register(http.MethodPost, version, fmt.Sprintf("%s/:id/acknowledge", alertsPath), alertsHandler.Acknowledge,
+		brewerMw)
+
+	eventsHandler := &EventsHandler{
+		Subscriber: eventSubscriber,
+	}
+
+	// browser EventSources can not set the Authorization header
+	eventsMw := middleware.Authorize(settingsRepo, apiTokenRepo, sessionRepo, auth.ViewerRole,
+		middleware.AllowQueryToken())
+
+	api.Register(http.MethodGet, version, eventsPath, eventsHandler.Stream, eventsMw)
+
+	brewfatherHandler := &BrewfatherHandler{
+		OutboxReporter: outboxReporter,
+	}
+
+	api.Register(http.MethodGet, version, fmt.Sprintf("%s/outbox", brewfatherPath), brewfatherHandler.GetOutbox,
+		viewerMw)
+
+	usersHandler := &UsersHandler{
+		UserRepo:    userRepo,
+		SessionRepo: sessionRepo,
+	}
+
+	api.Register(http.MethodGet, version, usersPath, usersHandler.GetAll, adminMw)
+	api.Register(http.MethodGet, version, fmt.Sprintf("%s/:id", usersPath), usersHandler.Get, adminMw)
+	api.Register(http.MethodPost, version, usersPath, usersHandler.Save, adminMw)
+	api.Register(http.MethodDelete, version, fmt.Sprintf("%s/:id", usersPath), usersHandler.Delete, adminMw)
+
+	tokensHandler := &TokensHandler{
+		APITokenRepo: apiTokenRepo,
+	}
+
+	api.Register(http.MethodGet, version, tokensPath, tokensHandler.GetAll, adminMw)
+	api.Register(http.MethodPost, version, tokensPath, tokensHandler.Create, adminMw)
+	api.Register(http.MethodDelete, version, fmt.Sprintf("%s/:id", tokensPath), tokensHandler.Delete, adminMw)
+
+	auditHandler := &AuditHandler{
+		AuditRepo: auditRepo,
 	}
 
-	api.Register(http.MethodGet, version, settingsPath, settingsHandler.Get, authMw)
-	api.Register(http.MethodPost, version, settingsPath, settingsHandler.Save, authMw)
+	api.Register(http.MethodGet, version, auditPath, auditHandler.GetAll, adminMw)
 
 	app := web.NewApp(api, uiFileReader, logger)
 
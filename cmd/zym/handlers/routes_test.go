@@ -7,17 +7,23 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
 	"github.com/benjaminbartels/zymurgauge/internal/auth"
 	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
 	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/debug"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
 	"github.com/benjaminbartels/zymurgauge/internal/settings"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
 	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
 	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
 	logtest "github.com/sirupsen/logrus/hooks/test"
@@ -38,6 +44,16 @@ func TestRoutes(t *testing.T) {
 		method string
 		body   interface{}
 		code   int
+		// stream is set for routes that stream until the request is canceled
+		stream bool
+		// role is the role of the token, admin if it is not set
+		role auth.Role
+		// scope is set to use an api token with the scope instead of a JWT
+		scope auth.Scope
+		// revoked is set to use a JWT that has been revoked
+		revoked bool
+		// queryToken is set to pass the token in the access_token query parameter instead of the header
+		queryToken bool
 	}
 
 	testCases := []test{
@@ -45,11 +61,98 @@ func TestRoutes(t *testing.T) {
 		{path: "/api/v1/chambers/" + chamberID, method: http.MethodGet, body: nil, code: http.StatusOK},
 		{path: "/api/v1/chambers", method: http.MethodPost, body: &chamber.Chamber{ID: chamberID}, code: http.StatusOK},
 		{path: "/api/v1/chambers/" + chamberID, method: http.MethodDelete, body: nil, code: http.StatusOK},
+		{
+			path: "/api/v1/chambers/" + chamberID + "/import", method: http.MethodPost, body: nil,
+			code: http.StatusBadRequest,
+		},
 		{path: "/api/v1/chambers/" + chamberID + "/start?step=A", method: http.MethodPost, body: nil, code: http.StatusOK},
+		{
+			path: "/api/v1/chambers/" + chamberID + "/start?setPoint=2&duration=1h", method: http.MethodPost, body: nil,
+			code: http.StatusOK,
+		},
 		{path: "/api/v1/chambers/" + chamberID + "/stop", method: http.MethodPost, body: nil, code: http.StatusOK},
+		{
+			path: "/api/v1/chambers/" + chamberID + "/autotune/start?setPoint=18", method: http.MethodPost, body: nil,
+			code: http.StatusOK,
+		},
+		{path: "/api/v1/chambers/" + chamberID + "/autotune/stop", method: http.MethodPost, body: nil, code: http.StatusOK},
+		{path: "/api/v1/chambers/" + chamberID + "/readings", method: http.MethodGet, body: nil, code: http.StatusOK},
+		{path: "/api/v1/chambers/" + chamberID + "/export", method: http.MethodGet, body: nil, code: http.StatusOK},
 		{path: "/api/v1/thermometers", method: http.MethodGet, body: nil, code: http.StatusOK},
+		{path: "/api/v1/alerts", method: http.MethodGet, body: nil, code: http.StatusOK},
+		{path: "/api/v1/alerts/" + alertID + "/acknowledge", method: http.MethodPost, body: nil, code: http.StatusOK},
+		{path: "/api/v1/events", method: http.MethodGet, body: nil, code: http.StatusOK, stream: true},
+		{
+			path: "/api/v1/events", method: http.MethodGet, body: nil, code: http.StatusOK, stream: true,
+			queryToken: true,
+		},
+		{path: "/api/v1/chambers", method: http.MethodGet, body: nil, code: http.StatusBadRequest, queryToken: true},
+		{path: "/api/v1/brewfather/outbox", method: http.MethodGet, body: nil, code: http.StatusOK},
 		{path: "/api/v1/batches", method: http.MethodGet, body: nil, code: http.StatusOK},
 		{path: "/api/v1/batches/" + batchID, method: http.MethodGet, body: nil, code: http.StatusOK},
+		{path: "/api/v1/batches", method: http.MethodPost, body: &batch.Detail{ID: batchID}, code: http.StatusOK},
+		{path: "/api/v1/batches/" + batchID, method: http.MethodDelete, body: nil, code: http.StatusOK},
+		{path: "/api/v1/users", method: http.MethodGet, body: nil, code: http.StatusOK},
+		{path: "/api/v1/users/" + userID, method: http.MethodGet, body: nil, code: http.StatusOK},
+		{
+			path: "/api/v1/users", method: http.MethodPost,
+			body: &auth.User{Username: "viewer", Password: "password", Role: auth.ViewerRole}, code: http.StatusOK,
+		},
+		{path: "/api/v1/users/" + userID, method: http.MethodDelete, body: nil, code: http.StatusOK},
+		{path: "/api/v1/users", method: http.MethodGet, body: nil, code: http.StatusForbidden, role: auth.BrewerRole},
+		{
+			path: "/api/v1/chambers", method: http.MethodPost, body: &chamber.Chamber{ID: chamberID},
+			code: http.StatusForbidden, role: auth.BrewerRole,
+		},
+		{
+			path: "/api/v1/chambers/" + chamberID + "/start?step=A", method: http.MethodPost, body: nil,
+			code: http.StatusForbidden, role: auth.ViewerRole,
+		},
+		{
+			path: "/api/v1/chambers/" + chamberID + "/stop", method: http.MethodPost, body: nil, code: http.StatusOK,
+			role: auth.BrewerRole,
+		},
+		{path: "/api/v1/chambers", method: http.MethodGet, body: nil, code: http.StatusOK, role: auth.ViewerRole},
+		{path: "/api/v1/tokens", method: http.MethodGet, body: nil, code: http.StatusOK},
+		{
+			path: "/api/v1/tokens", method: http.MethodPost,
+			body: &handlers.NewAPIToken{Name: "grafana", Scope: auth.ReadOnlyScope}, code: http.StatusOK,
+		},
+		{path: "/api/v1/tokens/" + tokenID, method: http.MethodDelete, body: nil, code: http.StatusOK},
+		{
+			path: "/api/v1/tokens", method: http.MethodGet, body: nil, code: http.StatusForbidden,
+			role: auth.BrewerRole,
+		},
+		{
+			path: "/api/v1/chambers", method: http.MethodGet, body: nil, code: http.StatusOK,
+			scope: auth.ReadOnlyScope,
+		},
+		{
+			path: "/api/v1/chambers/" + chamberID + "/stop", method: http.MethodPost, body: nil, code: http.StatusOK,
+			scope: auth.ControlScope,
+		},
+		{
+			path: "/api/v1/chambers/" + chamberID + "/start?step=A", method: http.MethodPost, body: nil,
+			code: http.StatusForbidden, scope: auth.ReadOnlyScope,
+		},
+		{
+			path: "/api/v1/auth/update", method: http.MethodPost,
+			body: &auth.Credentials{Username: "username", Password: "password"}, code: http.StatusForbidden,
+			scope: auth.AdminScope,
+		},
+		{path: "/api/v1/auth/logout", method: http.MethodPost, body: nil, code: http.StatusOK, role: auth.ViewerRole},
+		{
+			path: "/api/v1/auth/refresh", method: http.MethodPost,
+			body: &handlers.RefreshRequest{RefreshToken: "session.secret"}, code: http.StatusUnauthorized,
+		},
+		{path: "/api/v1/auth/rotate", method: http.MethodPost, body: nil, code: http.StatusOK},
+		{
+			path: "/api/v1/auth/rotate", method: http.MethodPost, body: nil, code: http.StatusForbidden,
+			role: auth.BrewerRole,
+		},
+		{path: "/api/v1/chambers", method: http.MethodGet, body: nil, code: http.StatusUnauthorized, revoked: true},
+		{path: "/api/v1/audit", method: http.MethodGet, body: nil, code: http.StatusOK},
+		{path: "/api/v1/audit", method: http.MethodGet, body: nil, code: http.StatusForbidden, role: auth.BrewerRole},
 		{path: "/api/v1/bad_path/" + batchID, method: http.MethodGet, body: nil, code: http.StatusNotFound},
 		{path: "/index.html", method: http.MethodGet, body: nil, code: http.StatusOK},
 	}
@@ -112,6 +215,9 @@ func TestRoutes(t *testing.T) {
 		controllerMock.On("Delete", mock.Anything).Return(nil)
 		controllerMock.On("StartFermentation", chamberID, "A").Return(nil)
 		controllerMock.On("StopFermentation", chamberID).Return(nil)
+		controllerMock.On("StartManual", chamberID, 2.0, time.Hour, false).Return(nil)
+		controllerMock.On("StartAutoTune", chamberID, 18.0, pid.TyreusLuyben).Return(nil)
+		controllerMock.On("StopAutoTune", chamberID).Return(nil)
 
 		s := &settings.Settings{
 			AppSettings: settings.AppSettings{AuthSecret: "my-auth-secret"},
@@ -119,6 +225,7 @@ func TestRoutes(t *testing.T) {
 
 		settingsMock := &mocks.SettingsRepo{}
 		settingsMock.On("Get").Return(s, nil)
+		settingsMock.On("Save", mock.Anything).Return(nil)
 
 		shutdown := make(chan os.Signal, 1)
 		logger, _ := logtest.NewNullLogger()
@@ -126,10 +233,65 @@ func TestRoutes(t *testing.T) {
 		fsMock := &mocks.FileReader{}
 		fsMock.On("ReadFile", "build/index.html").Return([]byte(""), nil)
 
-		app, _ := handlers.NewApp(controllerMock, devicePath, serviceMock, settingsMock, nil, fsMock,
-			shutdown, logger)
+		batchMock := &mocks.BatchController{}
+		batchMock.On("GetAll", mock.Anything).Return([]batch.Summary{}, nil)
+		batchMock.On("Get", mock.Anything, batchID).Return(&batch.Detail{ID: batchID}, nil)
+		batchMock.On("Save", mock.Anything).Return(nil)
+		batchMock.On("Delete", batchID).Return(nil)
 
-		t.Run(tc.path, func(t *testing.T) {
+		alertMock := &mocks.AlertController{}
+		alertMock.On("GetAll").Return([]*alert.Alert{})
+		alertMock.On("Acknowledge", alertID).Return(&alert.Alert{ID: alertID}, nil)
+
+		outboxMock := &mocks.OutboxReporter{}
+		outboxMock.On("Status").Return(brewfather.OutboxStatus{})
+
+		users := []*auth.User{
+			{ID: userID, Username: "username", Role: auth.BrewerRole},
+			{ID: "b6f5b5a4-5a0e-4b7a-9f0e-3c7f7d2f9c1e", Username: "admin", Role: auth.AdminRole},
+		}
+
+		userMock := &mocks.UserRepo{}
+		userMock.On("GetAll").Return(users, nil)
+		userMock.On("Get", userID).Return(users[0], nil)
+		userMock.On("GetByUsername", mock.Anything).Return(nil, nil)
+		userMock.On("Save", mock.Anything).Return(nil)
+		userMock.On("Delete", userID).Return(nil)
+
+		apiToken, apiTokenString, err := auth.NewAPIToken("script", tc.scope, "username")
+		assert.NoError(t, err)
+
+		apiTokenMock := &mocks.APITokenRepo{}
+		apiTokenMock.On("GetAll").Return([]*auth.APIToken{apiToken}, nil)
+		apiTokenMock.On("Get", apiToken.ID).Return(apiToken, nil)
+		apiTokenMock.On("Get", tokenID).Return(&auth.APIToken{ID: tokenID}, nil)
+		apiTokenMock.On("Save", mock.Anything).Return(nil)
+		apiTokenMock.On("Delete", tokenID).Return(nil)
+		apiTokenMock.On("SetLastUsedTime", apiToken.ID, mock.Anything).Return(nil)
+
+		sessionMock := &mocks.SessionRepo{}
+		sessionMock.On("IsRevoked", "revoked").Return(true, nil)
+		sessionMock.On("IsRevoked", mock.Anything).Return(false, nil)
+		sessionMock.On("Get", mock.Anything).Return(nil, nil)
+		sessionMock.On("Revoke", mock.Anything, mock.Anything).Return(nil)
+		sessionMock.On("Delete", mock.Anything).Return(nil)
+		sessionMock.On("DeleteByUser", mock.Anything).Return(nil)
+		sessionMock.On("DeleteAll").Return(nil)
+
+		auditMock := &mocks.AuditRepo{}
+		auditMock.On("GetAll", mock.Anything, mock.Anything).Return([]*audit.Entry{}, nil)
+		auditMock.On("Save", mock.Anything).Return(nil)
+
+		loginThrottle := auth.NewLoginThrottle(5, 20, time.Minute)
+
+		app, _ := handlers.NewApp(controllerMock, devicePath, batchMock, alertMock, event.NewBus(), outboxMock,
+			settingsMock, userMock, apiTokenMock, sessionMock, auditMock, loginThrottle, nil, fsMock, shutdown, logger,
+			nil)
+
+		name := fmt.Sprintf("%s %s %s%s revoked=%t query=%t", tc.method, tc.path, tc.role, tc.scope, tc.revoked,
+			tc.queryToken)
+
+		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
 			if tc.path == "/v1/chambers/"+chamberID+"/stop" {
@@ -143,9 +305,34 @@ func TestRoutes(t *testing.T) {
 			w := httptest.NewRecorder()
 			jsonBytes, _ := json.Marshal(tc.body)
 			r := httptest.NewRequest(tc.method, tc.path, bytes.NewBuffer(jsonBytes))
-			token, _ := auth.CreateToken("my-auth-secret", "username", 1*time.Minute)
+			role := tc.role
+			if role == "" {
+				role = auth.AdminRole
+			}
+
+			claims := &auth.Claims{Username: "username", Role: role, SessionID: "session"}
+			if tc.revoked {
+				claims.Id = "revoked"
+			}
+
+			token, _ := auth.CreateToken("my-auth-secret", claims, 1*time.Minute)
+			if tc.scope != "" {
+				token = apiTokenString
+			}
+
+			if tc.queryToken {
+				r.URL.RawQuery = url.Values{"access_token": []string{token}}.Encode()
+			} else {
+				r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+			}
+
+			if tc.stream {
+				ctx, cancel := context.WithCancel(r.Context())
+				cancel()
+
+				r = r.WithContext(ctx)
+			}
 
-			r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
 			app.ServeHTTP(w, r)
 			assert.Equal(t, tc.code, w.Code)
 		})
@@ -155,7 +342,8 @@ func TestRoutes(t *testing.T) {
 func TestDebugMux(t *testing.T) {
 	t.Parallel()
 
-	mux := debug.Mux()
+	logger, _ := logtest.NewNullLogger()
+	mux := debug.Mux(metrics.NewPrometheus(logger).Handler())
 
 	type test struct {
 		path   string
@@ -170,6 +358,7 @@ func TestDebugMux(t *testing.T) {
 		{path: "/debug/pprof/symbol", method: http.MethodGet, code: http.StatusOK},
 		{path: "/debug/pprof/trace", method: http.MethodGet, code: http.StatusOK},
 		{path: "/debug/vars", method: http.MethodGet, code: http.StatusOK},
+		{path: "/metrics", method: http.MethodGet, code: http.StatusOK},
 		{path: "/debug/pprof/bad_path", method: http.MethodGet, code: http.StatusNotFound},
 	}
 
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
 	"github.com/benjaminbartels/zymurgauge/internal/settings"
 	"github.com/julienschmidt/httprouter"
@@ -12,10 +14,13 @@ import (
 )
 
 type SettingsHandler struct {
-	SettingsRepo settings.Repo
-	UpdateChan   chan settings.Settings
+	SettingsRepo  settings.Repo
+	UpdateChan    chan settings.Settings
+	AuditRecorder *audit.Recorder
 }
 
+// Get returns the settings. The auth secret is always left out and the other secrets are left out for users that are not
+// admins.
 func (h *SettingsHandler) Get(ctx context.Context, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) error {
 	s, err := h.SettingsRepo.Get()
 	if err != nil {
@@ -26,13 +31,21 @@ func (h *SettingsHandler) Get(ctx context.Context, w http.ResponseWriter, _ *htt
 		return web.NewRequestError("settings not found", http.StatusNotFound)
 	}
 
-	if err := web.Respond(ctx, w, s.AppSettings, http.StatusOK); err != nil {
+	appSettings := s.AppSettings
+	appSettings.AuthSecret = ""
+
+	if claims, ok := auth.FromContext(ctx); ok && !claims.GetRole().Allows(auth.AdminRole) {
+		appSettings = redactSettings(appSettings)
+	}
+
+	if err := web.Respond(ctx, w, appSettings, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
 
 	return nil
 }
 
+// Save saves the settings. The auth secret can only be changed by rotating it.
 func (h *SettingsHandler) Save(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
 	appSettings, err := parseAppSettings(r)
 	if err != nil {
@@ -44,13 +57,19 @@ func (h *SettingsHandler) Save(ctx context.Context, w http.ResponseWriter, r *ht
 		return errors.Wrap(err, "could not get settings from repository")
 	}
 
+	appSettings.AuthSecret = s.AuthSecret
 	s.AppSettings = appSettings
 
 	if err := h.SettingsRepo.Save(s); err != nil {
 		return errors.Wrap(err, "could not save settings to repository")
 	}
 
-	if err := web.Respond(ctx, w, s, http.StatusOK); err != nil {
+	h.AuditRecorder.Record(newAuditEntry(ctx, r, audit.SettingsSavedAction, ""))
+
+	response := *s
+	response.AuthSecret = ""
+
+	if err := web.Respond(ctx, w, response, http.StatusOK); err != nil {
 		return errors.Wrap(err, "problem responding to client")
 	}
 
@@ -61,6 +80,22 @@ func (h *SettingsHandler) Save(ctx context.Context, w http.ResponseWriter, r *ht
 	return nil
 }
 
+// redactSettings returns a copy of s without the secrets that would allow a user to act as an admin or on behalf of
+// zymurgauge. The InfluxDB read token is kept since the UI needs it to graph readings.
+func redactSettings(s settings.AppSettings) settings.AppSettings {
+	s.AuthSecret = ""
+	s.BrewfatherAPIUserID = ""
+	s.BrewfatherAPIKey = ""
+	s.BrewfatherLogURL = ""
+	s.InfluxDBWriteToken = ""
+	s.Alerts.WebhookURL = ""
+	s.Alerts.NtfyToken = ""
+	s.Alerts.SMTPPassword = ""
+	s.MQTT.Password = ""
+
+	return s
+}
+
 func parseAppSettings(r *http.Request) (settings.AppSettings, error) {
 	var settings settings.AppSettings
 	err := json.NewDecoder(r.Body).Decode(&settings)
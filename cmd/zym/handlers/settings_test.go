@@ -10,12 +10,14 @@ import (
 	"testing"
 
 	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
 	"github.com/benjaminbartels/zymurgauge/internal/settings"
 	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func getTestSettings() *settings.Settings {
@@ -29,10 +31,21 @@ func getTestSettings() *settings.Settings {
 	}
 }
 
+func getTestSettingsWithSecret() *settings.Settings {
+	s := getTestSettings()
+	s.AuthSecret = authSecret
+	s.InfluxDBURL = "http://influxdb:8086"
+	s.InfluxDBReadToken = "readToken"
+	s.InfluxDBWriteToken = "writeToken"
+
+	return s
+}
+
 //nolint:paralleltest // False positives with r.Run not in a loop
 func TestGetSettings(t *testing.T) {
 	t.Parallel()
 	t.Run("getSettingsFound", getSettingsFound)
+	t.Run("getSettingsRedacted", getSettingsRedacted)
 	t.Run("getSettingsNotFoundError", getSettingsNotFoundError)
 	t.Run("getSettingsOtherError", getSettingsOtherError)
 	t.Run("getSettingsRespondError", getSettingsRespondError)
@@ -65,6 +78,30 @@ func getSettingsFound(t *testing.T) {
 	assert.Equal(t, s, result)
 }
 
+func getSettingsRedacted(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+	ctx = auth.NewContext(ctx, &auth.Claims{Username: "viewer", Role: auth.ViewerRole})
+
+	settingsMock := &mocks.SettingsRepo{}
+	settingsMock.On("Get").Return(getTestSettingsWithSecret(), nil)
+
+	handler := &handlers.SettingsHandler{SettingsRepo: settingsMock}
+
+	err := handler.Get(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+
+	result := &settings.AppSettings{}
+	err = json.NewDecoder(w.Result().Body).Decode(result)
+	assert.NoError(t, err)
+	assert.Empty(t, result.AuthSecret)
+	assert.Empty(t, result.BrewfatherAPIKey)
+	assert.Empty(t, result.InfluxDBWriteToken)
+	assert.Equal(t, "readToken", result.InfluxDBReadToken)
+	assert.Equal(t, "Celsius", result.TemperatureUnits)
+}
+
 func getSettingsNotFoundError(t *testing.T) {
 	t.Parallel()
 
@@ -131,6 +168,7 @@ func getSettingsRespondError(t *testing.T) {
 func TestSaveSettings(t *testing.T) {
 	t.Parallel()
 	t.Run("saveSettings", saveSettings)
+	t.Run("saveSettingsKeepsAuthSecret", saveSettingsKeepsAuthSecret)
 	t.Run("saveSettingsParseError", saveSettingsParseError)
 	t.Run("saveSettingsOtherError", saveSettingsOtherError)
 	t.Run("saveSettingsRespondError", saveSettingsRespondError)
@@ -169,6 +207,34 @@ func saveSettings(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func saveSettingsKeepsAuthSecret(t *testing.T) {
+	t.Parallel()
+
+	update := getTestSettings()
+	update.AuthSecret = "other-secret"
+	jsonBytes, err := json.Marshal(update.AppSettings)
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	settingsMock := &mocks.SettingsRepo{}
+	settingsMock.On("Get").Return(getTestSettingsWithSecret(), nil)
+	settingsMock.On("Save", mock.MatchedBy(func(s *settings.Settings) bool {
+		return s.AuthSecret == authSecret
+	})).Return(nil)
+
+	handler := &handlers.SettingsHandler{SettingsRepo: settingsMock}
+
+	err = handler.Save(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+	settingsMock.AssertExpectations(t)
+
+	result := &settings.Settings{}
+	err = json.NewDecoder(w.Result().Body).Decode(result)
+	assert.NoError(t, err)
+	assert.Empty(t, result.AuthSecret)
+}
+
 func saveSettingsParseError(t *testing.T) {
 	t.Parallel()
 
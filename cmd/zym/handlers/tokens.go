@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+type TokensHandler struct {
+	APITokenRepo auth.APITokenRepo
+}
+
+// NewAPIToken is the request body to create an API token.
+type NewAPIToken struct {
+	Name  string     `json:"name"`
+	Scope auth.Scope `json:"scope"`
+}
+
+// CreatedAPIToken is a newly created API token along with the token itself, which is not returned again.
+type CreatedAPIToken struct {
+	*auth.APIToken
+	Token string `json:"token"`
+}
+
+func (h *TokensHandler) GetAll(ctx context.Context, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) error {
+	tokens, err := h.APITokenRepo.GetAll()
+	if err != nil {
+		return errors.Wrap(err, "could not get all api tokens from repository")
+	}
+
+	for _, t := range tokens {
+		t.Hash = ""
+	}
+
+	if err = web.Respond(ctx, w, tokens, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// Create creates a new API token. The token itself is only returned in the response.
+func (h *TokensHandler) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
+	var n NewAPIToken
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		return errors.Wrap(err, "could not decode api token from request body")
+	}
+
+	if n.Name == "" {
+		return web.NewRequestError("name is required", http.StatusBadRequest)
+	}
+
+	if !n.Scope.IsValid() {
+		return web.NewRequestError(fmt.Sprintf("scope '%s' is invalid", n.Scope), http.StatusBadRequest)
+	}
+
+	var createdBy string
+	if claims, ok := auth.FromContext(ctx); ok {
+		createdBy = claims.Username
+	}
+
+	apiToken, token, err := auth.NewAPIToken(n.Name, n.Scope, createdBy)
+	if err != nil {
+		return errors.Wrap(err, "could not create api token")
+	}
+
+	if err := h.APITokenRepo.Save(apiToken); err != nil {
+		return errors.Wrap(err, "could not save api token to repository")
+	}
+
+	apiToken.Hash = ""
+
+	if err := web.Respond(ctx, w, &CreatedAPIToken{APIToken: apiToken, Token: token}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// Delete revokes an API token.
+func (h *TokensHandler) Delete(ctx context.Context, w http.ResponseWriter, _ *http.Request, p httprouter.Params) error {
+	id := p.ByName("id")
+
+	t, err := h.APITokenRepo.Get(id)
+	if err != nil {
+		return errors.Wrap(err, "could not get api token from repository")
+	}
+
+	if t == nil {
+		return web.NewRequestError(fmt.Sprintf("api token '%s' not found", id), http.StatusNotFound)
+	}
+
+	if err := h.APITokenRepo.Delete(id); err != nil {
+		return errors.Wrapf(err, "could not delete api token %s from repository", id)
+	}
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestGetAllTokens(t *testing.T) {
+	t.Parallel()
+	t.Run("getAllTokensWithoutHashes", getAllTokensWithoutHashes)
+	t.Run("getAllTokensRepoError", getAllTokensRepoError)
+}
+
+func getAllTokensWithoutHashes(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	apiTokenMock := &mocks.APITokenRepo{}
+	apiTokenMock.On("GetAll").Return([]*auth.APIToken{
+		{ID: tokenID, Name: "grafana", Scope: auth.ReadOnlyScope, Hash: "hash"},
+	}, nil)
+
+	handler := &handlers.TokensHandler{APITokenRepo: apiTokenMock}
+
+	err := handler.GetAll(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+
+	bodyBytes, _ := io.ReadAll(w.Result().Body)
+
+	var result []*auth.APIToken
+	err = json.Unmarshal(bodyBytes, &result)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "grafana", result[0].Name)
+	assert.NotContains(t, string(bodyBytes), "hash")
+}
+
+func getAllTokensRepoError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	apiTokenMock := &mocks.APITokenRepo{}
+	apiTokenMock.On("GetAll").Return(nil, errSomeError)
+
+	handler := &handlers.TokensHandler{APITokenRepo: apiTokenMock}
+
+	err := handler.GetAll(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "get all api tokens from"))
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestCreateToken(t *testing.T) {
+	t.Parallel()
+	t.Run("createToken", createToken)
+	t.Run("createTokenInvalid", createTokenInvalid)
+	t.Run("createTokenRepoError", createTokenRepoError)
+}
+
+func createToken(t *testing.T) {
+	t.Parallel()
+
+	jsonBytes, _ := json.Marshal(&handlers.NewAPIToken{Name: "grafana", Scope: auth.ReadOnlyScope})
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+	ctx = auth.NewContext(ctx, &auth.Claims{Username: "admin", Role: auth.AdminRole})
+
+	var saved *auth.APIToken
+
+	apiTokenMock := &mocks.APITokenRepo{}
+	apiTokenMock.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		apiToken := *args.Get(0).(*auth.APIToken)
+		saved = &apiToken
+	}).Return(nil)
+
+	handler := &handlers.TokensHandler{APITokenRepo: apiTokenMock}
+
+	err := handler.Create(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+
+	bodyBytes, _ := io.ReadAll(w.Result().Body)
+
+	result := struct {
+		auth.APIToken
+		Token string `json:"token"`
+	}{}
+	err = json.Unmarshal(bodyBytes, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "grafana", result.Name)
+	assert.Equal(t, auth.ReadOnlyScope, result.Scope)
+	assert.Equal(t, "admin", result.CreatedBy)
+	assert.Empty(t, result.Hash)
+	assert.True(t, auth.IsAPIToken(result.Token))
+
+	assert.Equal(t, result.ID, saved.ID)
+	assert.True(t, saved.Check(result.Token))
+}
+
+func createTokenInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []handlers.NewAPIToken{
+		{Scope: auth.ReadOnlyScope},
+		{Name: "grafana", Scope: "write"},
+	} {
+		jsonBytes, _ := json.Marshal(&n)
+		w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+		handler := &handlers.TokensHandler{APITokenRepo: &mocks.APITokenRepo{}}
+
+		err := handler.Create(ctx, w, r, httprouter.Params{})
+
+		var reqErr *web.RequestError
+
+		assert.ErrorAs(t, err, &reqErr)
+		assert.Equal(t, http.StatusBadRequest, reqErr.Status)
+	}
+}
+
+func createTokenRepoError(t *testing.T) {
+	t.Parallel()
+
+	jsonBytes, _ := json.Marshal(&handlers.NewAPIToken{Name: "grafana", Scope: auth.ReadOnlyScope})
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	apiTokenMock := &mocks.APITokenRepo{}
+	apiTokenMock.On("Save", mock.Anything).Return(errSomeError)
+
+	handler := &handlers.TokensHandler{APITokenRepo: apiTokenMock}
+
+	err := handler.Create(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "save api token to"))
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestDeleteToken(t *testing.T) {
+	t.Parallel()
+	t.Run("deleteTokenFound", deleteTokenFound)
+	t.Run("deleteTokenNotFound", deleteTokenNotFound)
+}
+
+func deleteTokenFound(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	apiTokenMock := &mocks.APITokenRepo{}
+	apiTokenMock.On("Get", tokenID).Return(&auth.APIToken{ID: tokenID}, nil)
+	apiTokenMock.On("Delete", tokenID).Return(nil)
+
+	handler := &handlers.TokensHandler{APITokenRepo: apiTokenMock}
+
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: tokenID}})
+	assert.NoError(t, err)
+	apiTokenMock.AssertCalled(t, "Delete", tokenID)
+}
+
+func deleteTokenNotFound(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	apiTokenMock := &mocks.APITokenRepo{}
+	apiTokenMock.On("Get", tokenID).Return(nil, nil)
+
+	handler := &handlers.TokensHandler{APITokenRepo: apiTokenMock}
+
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: tokenID}})
+	assert.Contains(t, err.Error(), fmt.Sprintf(notFoundErrorMsg, "api token", tokenID))
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusNotFound, reqErr.Status)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+type UsersHandler struct {
+	UserRepo    auth.UserRepo
+	SessionRepo auth.SessionRepo
+}
+
+func (h *UsersHandler) GetAll(ctx context.Context, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) error {
+	users, err := h.UserRepo.GetAll()
+	if err != nil {
+		return errors.Wrap(err, "could not get all users from repository")
+	}
+
+	for _, u := range users {
+		u.Password = ""
+	}
+
+	if err = web.Respond(ctx, w, users, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+func (h *UsersHandler) Get(ctx context.Context, w http.ResponseWriter, _ *http.Request, p httprouter.Params) error {
+	id := p.ByName("id")
+
+	u, err := h.UserRepo.Get(id)
+	if err != nil {
+		return errors.Wrap(err, "could not get user from repository")
+	}
+
+	if u == nil {
+		return web.NewRequestError(fmt.Sprintf("user '%s' not found", id), http.StatusNotFound)
+	}
+
+	u.Password = ""
+
+	if err = web.Respond(ctx, w, u, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// Save creates or updates a user. The password of an existing user is only changed if one is given. Changing the
+// password or role of a user ends all of its sessions.
+func (h *UsersHandler) Save(ctx context.Context, w http.ResponseWriter, r *http.Request, _ httprouter.Params) error {
+	update, err := parseUser(r)
+	if err != nil {
+		return errors.Wrap(err, "could not parse user")
+	}
+
+	if update.Username == "" {
+		return web.NewRequestError("username is required", http.StatusBadRequest)
+	}
+
+	if !update.Role.IsValid() {
+		return web.NewRequestError(fmt.Sprintf("role '%s' is invalid", update.Role), http.StatusBadRequest)
+	}
+
+	u := &auth.User{}
+
+	if update.ID != "" {
+		if u, err = h.UserRepo.Get(update.ID); err != nil {
+			return errors.Wrap(err, "could not get user from repository")
+		}
+
+		if u == nil {
+			return web.NewRequestError(fmt.Sprintf("user '%s' not found", update.ID), http.StatusNotFound)
+		}
+
+		if u.Role == auth.AdminRole && update.Role != auth.AdminRole {
+			if err := h.checkNotLastAdmin(u.ID); err != nil {
+				return err
+			}
+		}
+	} else if update.Password == "" {
+		return web.NewRequestError("password is required", http.StatusBadRequest)
+	}
+
+	existing, err := h.UserRepo.GetByUsername(update.Username)
+	if err != nil {
+		return errors.Wrap(err, "could not get user by username from repository")
+	}
+
+	if existing != nil && existing.ID != u.ID {
+		return web.NewRequestError(fmt.Sprintf("username '%s' is taken", update.Username), http.StatusConflict)
+	}
+
+	endSessions := u.ID != "" && (update.Password != "" || update.Role != u.Role)
+
+	u.Username = update.Username
+	u.Role = update.Role
+
+	if update.Password != "" {
+		if err := u.SetPassword(update.Password); err != nil {
+			return errors.Wrap(err, "could not set password")
+		}
+	}
+
+	if err := h.UserRepo.Save(u); err != nil {
+		return errors.Wrap(err, "could not save user to repository")
+	}
+
+	if endSessions {
+		if err := h.SessionRepo.DeleteByUser(u.ID); err != nil {
+			return errors.Wrap(err, "could not delete sessions of user from repository")
+		}
+	}
+
+	u.Password = ""
+
+	if err := web.Respond(ctx, w, u, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+func (h *UsersHandler) Delete(ctx context.Context, w http.ResponseWriter, _ *http.Request, p httprouter.Params) error {
+	id := p.ByName("id")
+
+	u, err := h.UserRepo.Get(id)
+	if err != nil {
+		return errors.Wrap(err, "could not get user from repository")
+	}
+
+	if u == nil {
+		return web.NewRequestError(fmt.Sprintf("user '%s' not found", id), http.StatusNotFound)
+	}
+
+	if u.Role == auth.AdminRole {
+		if err := h.checkNotLastAdmin(id); err != nil {
+			return err
+		}
+	}
+
+	if err := h.UserRepo.Delete(id); err != nil {
+		return errors.Wrapf(err, "could not delete user %s from repository", id)
+	}
+
+	if err := h.SessionRepo.DeleteByUser(id); err != nil {
+		return errors.Wrap(err, "could not delete sessions of user from repository")
+	}
+
+	if err := web.Respond(ctx, w, &Status{Message: "Success"}, http.StatusOK); err != nil {
+		return errors.Wrap(err, "problem responding to client")
+	}
+
+	return nil
+}
+
+// checkNotLastAdmin returns a request error if the user with the given id is the only admin, so that nobody is locked
+// out of the settings and users.
+func (h *UsersHandler) checkNotLastAdmin(id string) error {
+	users, err := h.UserRepo.GetAll()
+	if err != nil {
+		return errors.Wrap(err, "could not get all users from repository")
+	}
+
+	for _, u := range users {
+		if u.ID != id && u.Role == auth.AdminRole {
+			return nil
+		}
+	}
+
+	return web.NewRequestError("at least one admin is required", http.StatusBadRequest)
+}
+
+func parseUser(r *http.Request) (auth.User, error) {
+	var u auth.User
+	err := json.NewDecoder(r.Body).Decode(&u)
+
+	return u, errors.Wrap(err, "could not decode user from request body")
+}
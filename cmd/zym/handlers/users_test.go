@@ -0,0 +1,298 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const adminID = "b6f5b5a4-5a0e-4b7a-9f0e-3c7f7d2f9c1e"
+
+func getTestUsers() []*auth.User {
+	return []*auth.User{
+		{ID: adminID, Username: "admin", Password: "hash", Role: auth.AdminRole},
+		{ID: userID, Username: "brewer", Password: "hash", Role: auth.BrewerRole},
+	}
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestGetAllUsers(t *testing.T) {
+	t.Parallel()
+	t.Run("getAllUsersWithoutPasswords", getAllUsersWithoutPasswords)
+	t.Run("getAllUsersRepoError", getAllUsersRepoError)
+}
+
+func getAllUsersWithoutPasswords(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetAll").Return(getTestUsers(), nil)
+
+	handler := &handlers.UsersHandler{UserRepo: userMock}
+
+	err := handler.GetAll(ctx, w, r, httprouter.Params{})
+	assert.NoError(t, err)
+
+	bodyBytes, _ := io.ReadAll(w.Result().Body)
+
+	var result []*auth.User
+	err = json.Unmarshal(bodyBytes, &result)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Empty(t, result[0].Password)
+	assert.NotContains(t, string(bodyBytes), "hash")
+}
+
+func getAllUsersRepoError(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetAll").Return(nil, errSomeError)
+
+	handler := &handlers.UsersHandler{UserRepo: userMock}
+
+	err := handler.GetAll(ctx, w, r, httprouter.Params{})
+	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "get all users from"))
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestGetUser(t *testing.T) {
+	t.Parallel()
+	t.Run("getUserFound", getUserFound)
+	t.Run("getUserNotFound", getUserNotFound)
+}
+
+func getUserFound(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", userID).Return(getTestUsers()[1], nil)
+
+	handler := &handlers.UsersHandler{UserRepo: userMock}
+
+	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: userID}})
+	assert.NoError(t, err)
+
+	result := &auth.User{}
+	err = json.NewDecoder(w.Result().Body).Decode(result)
+	assert.NoError(t, err)
+	assert.Equal(t, &auth.User{ID: userID, Username: "brewer", Role: auth.BrewerRole}, result)
+}
+
+func getUserNotFound(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", userID).Return(nil, nil)
+
+	handler := &handlers.UsersHandler{UserRepo: userMock}
+
+	err := handler.Get(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: userID}})
+	assert.Contains(t, err.Error(), fmt.Sprintf(notFoundErrorMsg, "user", userID))
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusNotFound, reqErr.Status)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSaveUser(t *testing.T) {
+	t.Parallel()
+	t.Run("saveNewUser", saveNewUser)
+	t.Run("saveExistingUserKeepsPassword", saveExistingUserKeepsPassword)
+	t.Run("saveExistingUserKeepsSessions", saveExistingUserKeepsSessions)
+	t.Run("saveUserInvalid", saveUserInvalid)
+	t.Run("saveUserUsernameTaken", saveUserUsernameTaken)
+	t.Run("saveUserDemoteLastAdmin", saveUserDemoteLastAdmin)
+}
+
+func saveUser(t *testing.T, userMock *mocks.UserRepo, sessionMock *mocks.SessionRepo, u auth.User) error {
+	t.Helper()
+
+	jsonBytes, err := json.Marshal(u)
+	assert.NoError(t, err)
+
+	w, r, ctx := setupHandlerTest("", bytes.NewBuffer(jsonBytes))
+
+	handler := &handlers.UsersHandler{UserRepo: userMock, SessionRepo: sessionMock}
+
+	return handler.Save(ctx, w, r, httprouter.Params{})
+}
+
+func saveNewUser(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetByUsername", "viewer").Return(nil, nil)
+	userMock.On("Save", mock.MatchedBy(func(u *auth.User) bool {
+		return u.Username == "viewer" && u.Role == auth.ViewerRole && u.CheckPassword("password")
+	})).Return(nil)
+
+	sessionMock := &mocks.SessionRepo{}
+
+	err := saveUser(t, userMock, sessionMock,
+		auth.User{Username: "viewer", Password: "password", Role: auth.ViewerRole})
+	assert.NoError(t, err)
+	userMock.AssertExpectations(t)
+	sessionMock.AssertNotCalled(t, "DeleteByUser", mock.Anything)
+}
+
+func saveExistingUserKeepsPassword(t *testing.T) {
+	t.Parallel()
+
+	users := getTestUsers()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", userID).Return(users[1], nil)
+	userMock.On("GetByUsername", "brewer").Return(users[1], nil)
+	userMock.On("Save", mock.MatchedBy(func(u *auth.User) bool {
+		return u.ID == userID && u.Role == auth.ViewerRole && u.Password == "hash"
+	})).Return(nil)
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("DeleteByUser", userID).Return(nil)
+
+	err := saveUser(t, userMock, sessionMock, auth.User{ID: userID, Username: "brewer", Role: auth.ViewerRole})
+	assert.NoError(t, err)
+	userMock.AssertExpectations(t)
+	sessionMock.AssertExpectations(t)
+}
+
+func saveExistingUserKeepsSessions(t *testing.T) {
+	t.Parallel()
+
+	users := getTestUsers()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", userID).Return(users[1], nil)
+	userMock.On("GetByUsername", "head-brewer").Return(nil, nil)
+	userMock.On("Save", mock.Anything).Return(nil)
+
+	sessionMock := &mocks.SessionRepo{}
+
+	err := saveUser(t, userMock, sessionMock,
+		auth.User{ID: userID, Username: "head-brewer", Role: auth.BrewerRole})
+	assert.NoError(t, err)
+	sessionMock.AssertNotCalled(t, "DeleteByUser", mock.Anything)
+}
+
+func saveUserInvalid(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+
+	var reqErr *web.RequestError
+
+	err := saveUser(t, userMock, &mocks.SessionRepo{}, auth.User{Username: "someone", Password: "password", Role: "owner"})
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusBadRequest, reqErr.Status)
+
+	err = saveUser(t, userMock, &mocks.SessionRepo{}, auth.User{Password: "password", Role: auth.ViewerRole})
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusBadRequest, reqErr.Status)
+
+	err = saveUser(t, userMock, &mocks.SessionRepo{}, auth.User{Username: "someone", Role: auth.ViewerRole})
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusBadRequest, reqErr.Status)
+}
+
+func saveUserUsernameTaken(t *testing.T) {
+	t.Parallel()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("GetByUsername", "brewer").Return(getTestUsers()[1], nil)
+
+	err := saveUser(t, userMock, &mocks.SessionRepo{}, auth.User{Username: "brewer", Password: "password", Role: auth.ViewerRole})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusConflict, reqErr.Status)
+}
+
+func saveUserDemoteLastAdmin(t *testing.T) {
+	t.Parallel()
+
+	users := getTestUsers()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", adminID).Return(users[0], nil)
+	userMock.On("GetAll").Return(users, nil)
+
+	err := saveUser(t, userMock, &mocks.SessionRepo{}, auth.User{ID: adminID, Username: "admin", Role: auth.BrewerRole})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusBadRequest, reqErr.Status)
+	userMock.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestDeleteUser(t *testing.T) {
+	t.Parallel()
+	t.Run("deleteUserFound", deleteUserFound)
+	t.Run("deleteUserLastAdmin", deleteUserLastAdmin)
+}
+
+func deleteUserFound(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", userID).Return(getTestUsers()[1], nil)
+	userMock.On("Delete", userID).Return(nil)
+
+	sessionMock := &mocks.SessionRepo{}
+	sessionMock.On("DeleteByUser", userID).Return(nil)
+
+	handler := &handlers.UsersHandler{UserRepo: userMock, SessionRepo: sessionMock}
+
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: userID}})
+	assert.NoError(t, err)
+	userMock.AssertExpectations(t)
+	sessionMock.AssertExpectations(t)
+}
+
+func deleteUserLastAdmin(t *testing.T) {
+	t.Parallel()
+
+	w, r, ctx := setupHandlerTest("", nil)
+
+	users := getTestUsers()
+
+	userMock := &mocks.UserRepo{}
+	userMock.On("Get", adminID).Return(users[0], nil)
+	userMock.On("GetAll").Return(users, nil)
+
+	handler := &handlers.UsersHandler{UserRepo: userMock}
+
+	err := handler.Delete(ctx, w, r, httprouter.Params{httprouter.Param{Key: "id", Value: adminID}})
+
+	var reqErr *web.RequestError
+
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusBadRequest, reqErr.Status)
+	userMock.AssertNotCalled(t, "Delete", adminID)
+}
@@ -2,25 +2,34 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
 	"fmt"
-	"math/big"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/alexcesaro/statsd"
 	"github.com/benjaminbartels/zymurgauge/cmd/zym/handlers"
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
 	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
 	"github.com/benjaminbartels/zymurgauge/internal/chamber"
 	"github.com/benjaminbartels/zymurgauge/internal/database"
 	"github.com/benjaminbartels/zymurgauge/internal/device/onewire"
 	"github.com/benjaminbartels/zymurgauge/internal/device/tilt"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/export"
+	"github.com/benjaminbartels/zymurgauge/internal/mqtt"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/certs"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/debug"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
 	"github.com/benjaminbartels/zymurgauge/internal/settings"
 	"github.com/benjaminbartels/zymurgauge/ui"
 	"github.com/kelseyhightower/envconfig"
@@ -35,11 +44,14 @@ import (
 var version = "develop"
 
 const (
-	dbFilePermissions      = 0o600
-	bboltReadTimeout       = 1 * time.Second
-	statsDConnectTimeout   = 5 * time.Second
-	statsDRetryCount       = 5
-	debugReadHeaderTimeout = 3 * time.Second
+	dbFilePermissions         = 0o600
+	bboltReadTimeout          = 1 * time.Second
+	statsDConnectTimeout      = 5 * time.Second
+	statsDRetryCount          = 5
+	debugReadHeaderTimeout    = 3 * time.Second
+	redirectReadHeaderTimeout = 3 * time.Second
+	defaultInfluxDBOrg        = "zymurgauge"
+	defaultInfluxDBBucket     = "telegraf"
 )
 
 type config struct {
@@ -51,24 +63,70 @@ type config struct {
 	IdleTimeout            time.Duration `default:"120s"`
 	ShutdownTimeout        time.Duration `default:"20s"`
 	ReadingsUpdateInterval time.Duration `default:"1m"`
+	ReadingsRetention      time.Duration `default:"8760h"`
+	AuditRetention         time.Duration `default:"2160h"`
+	AuditMaxEntries        int           `default:"100000"`
+	BrewfatherCacheTTL     time.Duration `default:"5m"`
+	LoginMaxAttempts       int           `default:"5"`
+	LoginMaxAttemptsPerIP  int           `default:"20"`
+	LoginLockout           time.Duration `default:"15m"`
+	TrustProxyHeaders      bool          `default:"false"`
+	TLSMode                string        `default:"none"`
+	ACMEDirectoryURL       string        `default:"https://acme-v02.api.letsencrypt.org/directory"`
 	Debug                  bool          `default:"false"`
+	TLSCertFile            string
+	TLSKeyFile             string
+	TLSHosts               []string
+	ACMEEmail              string
+	ACMECAFile             string
+	HTTPRedirectHost       string
 }
 
 type initArgs struct {
-	Username         string `kong:"required,help:'Admin username.'"`
-	Password         string `kong:"required,help:'Admin password.'"`
-	BrewfatherUserID string `kong:"optional,help:'Brewfather API User ID.'"`
-	BrewfatherKey    string `kong:"optional,help:'Brewfather API Key.'"`
-	BrewfatherLogURL string `kong:"optional,help:'URL of the Brewfather logging endpoint.'"`
-	InfluxDBURL      string `kong:"optional,help:'URL of the InfluxDB server.'"`
-	InfluxDBToken    string `kong:"optional,help:'Read Access token for InfluxDB.'"`
-	StatsDAddress    string `kong:"optional,help:'Address of the telegraf metrics server. (hostname:port)'"`
+	Username           string `kong:"required,help:'Admin username.'"`
+	Password           string `kong:"required,help:'Admin password.'"`
+	BrewfatherUserID   string `kong:"optional,help:'Brewfather API User ID.'"`
+	BrewfatherKey      string `kong:"optional,help:'Brewfather API Key.'"`
+	BrewfatherLogURL   string `kong:"optional,help:'URL of the Brewfather logging endpoint.'"`
+	BrewfatherUpdate   bool   `kong:"optional,help:'Update status and gravities of Brewfather batches.'"`
+	InfluxDBURL        string `kong:"optional,help:'URL of the InfluxDB server.'"`
+	InfluxDBToken      string `kong:"optional,help:'Read Access token for InfluxDB.'"`
+	InfluxDBWriteToken string `kong:"optional,help:'Write Access token for InfluxDB.'"`
+	InfluxDBOrg        string `kong:"optional,default:'zymurgauge',help:'InfluxDB organization to write to.'"`
+	InfluxDBBucket     string `kong:"optional,default:'telegraf',help:'InfluxDB bucket to write to.'"`
+	StatsDAddress      string `kong:"optional,help:'Address of the telegraf metrics server. (hostname:port)'"`
+	MetricsSink        string `kong:"optional,help:'Where to write metrics. (statsd, influxdb or none)'"`
+}
+
+type exportArgs struct {
+	ChamberID string `kong:"required,help:'ID of the chamber to export.'"`
+	BatchID   string `kong:"optional,help:'ID of the batch to export. Defaults to the current batch of the chamber.'"`
+	Format    string `kong:"optional,default:'csv',enum:'csv,ndjson',help:'Format of the export. (csv or ndjson)'"`
+	Output    string `kong:"optional,short:'o',help:'Path of the file to write to. Defaults to stdout.'"`
+}
+
+type importArgs struct {
+	ChamberID string `kong:"required,help:'ID of the chamber to set the current batch of.'"`
+	File      string `kong:"arg,type:'existingfile',help:'BeerXML or BeerJSON file to import.'"`
+}
+
+type userCmd struct {
+	Add userAddArgs `kong:"cmd,help:'Add a user, or change the password and role of an existing one.'"`
+}
+
+type userAddArgs struct {
+	Username string `kong:"required,help:'Username.'"`
+	Password string `kong:"required,help:'Password.'"`
+	Role     string `kong:"optional,default:'viewer',enum:'viewer,brewer,admin',help:'Role. (viewer, brewer or admin)'"`
 }
 
 type cli struct {
-	Run     struct{} `kong:"cmd,help:'Run zymurgauge service.'"`
-	Init    initArgs `kong:"cmd,help:'Initialize admin credentials.'"`
-	Version struct{} `kong:"cmd,help:'Display Version.'"`
+	Run     struct{}   `kong:"cmd,help:'Run zymurgauge service.'"`
+	Init    initArgs   `kong:"cmd,help:'Initialize admin credentials.'"`
+	Export  exportArgs `kong:"cmd,help:'Export the fermentation log of a chamber. The service must not be running.'"`
+	Import  importArgs `kong:"cmd,help:'Import a recipe into a chamber. The service must not be running.'"`
+	User    userCmd    `kong:"cmd,help:'Manage users. The service must not be running.'"`
+	Version struct{}   `kong:"cmd,help:'Display Version.'"`
 }
 
 func main() {
@@ -98,13 +156,33 @@ func main() {
 			os.Exit(1)
 		}
 	case "init":
-		_, settingsRepo, err := createRepos(cfg.DBPath)
+		repos, err := createRepos(cfg)
 		if err != nil {
 			logger.Error(err)
 			os.Exit(1)
 		}
 
-		if err := checkAndInitSettings(cli.Init, settingsRepo, logger); err != nil {
+		if err := checkAndInitSettings(cli.Init, repos.settings, logger); err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+
+		if err := migrateCredentials(repos.settings, repos.user, logger); err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	case "user add":
+		if err := addUser(cli.User.Add, cfg, logger); err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	case "export":
+		if err := exportFermentationLog(cli.Export, cfg); err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	case "import <file>":
+		if err := importRecipe(cli.Import, cfg); err != nil {
 			logger.Error(err)
 			os.Exit(1)
 		}
@@ -125,12 +203,12 @@ func run(logger *logrus.Logger, cfg config) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
-	chamberRepo, settingsRepo, err := createRepos(cfg.DBPath)
+	repos, err := createRepos(cfg)
 	if err != nil {
 		return errors.Wrap(err, "could not create databases")
 	}
 
-	s, err := settingsRepo.Get()
+	s, err := repos.settings.Get()
 	if err != nil {
 		logger.WithError(err).Warn("could not get settings")
 	}
@@ -139,64 +217,222 @@ func run(logger *logrus.Logger, cfg config) error {
 		return errors.New("Settings are not initialized. Please run 'zym init'")
 	}
 
+	if err := migrateCredentials(repos.settings, repos.user, logger); err != nil {
+		return errors.Wrap(err, "could not migrate admin credentials")
+	}
+
 	errCh := make(chan error, 1)
 
-	monitor := createTiltMonitor(ctx, logger, errCh)
+	prom := metrics.NewPrometheus(logger)
+	sink := &metricsSink{ctx: ctx, logger: logger}
+	sink.configure(s.AppSettings)
+	appMetrics := metrics.Multi{prom, sink}
 
-	startDebugEndpoint(cfg.DebugHost, logger)
+	monitor := createTiltMonitor(ctx, logger, appMetrics, errCh)
+
+	startDebugEndpoint(cfg.DebugHost, prom.Handler(), logger)
 
 	configurator := &chamber.DefaultConfigurator{
 		TiltMonitor: monitor,
 	}
 
-	var statsdClient *statsd.Client
+	brewfatherClient := brewfather.New(s.BrewfatherAPIUserID, s.BrewfatherAPIKey, s.BrewfatherLogURL,
+		brewfather.EnableBatchUpdates(s.BrewfatherUpdates))
 
-	if s.StatsDAddress != "" {
-		statsdClient, err = createStatDClient(s.StatsDAddress, logger)
-		if err != nil {
-			logger.WithError(err).Error("could not create statsd client")
-		}
-	} else {
-		statsdClient = nil
+	brewfatherCache := brewfather.NewCache(brewfatherClient, repos.brewfatherCache, logger, appMetrics,
+		brewfather.CacheTTL(cfg.BrewfatherCacheTTL))
 
-		logger.Warn("StatsD Address not set.")
+	brewfatherOutbox, err := brewfather.NewOutbox(brewfatherCache, repos.brewfatherOutbox, logger, appMetrics)
+	if err != nil {
+		return errors.Wrap(err, "could not create brewfather outbox")
 	}
 
-	brewfatherClient := brewfather.New(s.BrewfatherAPIUserID, s.BrewfatherAPIKey, s.BrewfatherLogURL)
+	go brewfatherOutbox.Run(ctx)
+
+	eventBus := event.NewBus()
 
-	chamberManager, err := chamber.NewManager(ctx, chamberRepo, configurator, brewfatherClient, logger, statsdClient,
-		cfg.ReadingsUpdateInterval)
+	chamberManager, err := chamber.NewManager(ctx, repos.chamber, repos.fermentationState, configurator,
+		brewfatherOutbox, logger, appMetrics, cfg.ReadingsUpdateInterval, chamber.SetReadingsRepo(repos.readings),
+		chamber.SetPublisher(eventBus))
 	if err != nil {
 		logger.WithError(err).Warn("An error occurred while creating chamber manager")
 	}
 
+	go chamberManager.RefreshReadings(ctx)
+
+	alertEngine := alert.NewEngine(chamberManager, repos.readings, logger,
+		alert.AddNotifier(alert.NewEventNotifier(eventBus)))
+	alertEngine.Configure(s.Alerts)
+
+	go alertEngine.Run(ctx)
+
+	mqttBridge := mqtt.NewBridge(chamberManager, eventBus, logger,
+		mqtt.SetAuditRecorder(audit.NewRecorder(repos.audit, logger)))
+	mqttBridge.Configure(s.MQTT)
+
+	go mqttBridge.Run(ctx)
+
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
-	settingsCh := startUpdateSettingsChannel(brewfatherClient)
+	settingsCh := startUpdateSettingsChannel(brewfatherClient, brewfatherCache, s.BrewfatherAPIUserID, alertEngine,
+		mqttBridge, sink, logger)
+
+	batchLibrary := batch.NewLibrary(repos.batch, brewfatherCache, logger)
 
-	app, err := handlers.NewApp(chamberManager, onewire.DefaultDevicePath, brewfatherClient, settingsRepo, settingsCh,
-		ui.FS, shutdown, logger)
+	loginThrottle := auth.NewLoginThrottle(cfg.LoginMaxAttempts, cfg.LoginMaxAttemptsPerIP, cfg.LoginLockout)
+
+	app, err := handlers.NewApp(chamberManager, onewire.DefaultDevicePath, batchLibrary, alertEngine, eventBus,
+		brewfatherOutbox, repos.settings, repos.user, repos.apiToken, repos.session, repos.audit, loginThrottle,
+		settingsCh, ui.FS, shutdown, logger, appMetrics)
 	if err != nil {
 		return errors.Wrap(err, "could not create new app")
 	}
 
+	var handler http.Handler = app
+
+	// Behind a reverse proxy the address of the client is only known from the headers the proxy sets.
+	if cfg.TrustProxyHeaders {
+		handler = web.RealIP(app)
+	}
+
+	serverTLS, err := certs.New(certs.Config{
+		Mode:             certs.Mode(cfg.TLSMode),
+		CertFile:         cfg.TLSCertFile,
+		KeyFile:          cfg.TLSKeyFile,
+		Dir:              filepath.Dir(cfg.DBPath),
+		Hosts:            cfg.TLSHosts,
+		ACMEDirectoryURL: cfg.ACMEDirectoryURL,
+		ACMEEmail:        cfg.ACMEEmail,
+		ACMECAFile:       cfg.ACMECAFile,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not create tls configuration")
+	}
+
 	httpServer := &http.Server{
 		Addr:         cfg.Host,
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
-		Handler:      app,
+		Handler:      handler,
 	}
 
-	go func() {
-		logger.Infof("zymurgauge version %s started, listening at %s", version, cfg.Host)
-		errCh <- httpServer.ListenAndServe()
-	}()
+	if serverTLS == nil {
+		go func() {
+			logger.Infof("zymurgauge version %s started, listening at %s", version, cfg.Host)
+			errCh <- httpServer.ListenAndServe()
+		}()
+	} else {
+		httpServer.TLSConfig = serverTLS.Config
+
+		if cfg.HTTPRedirectHost != "" {
+			startHTTPRedirect(cfg.HTTPRedirectHost, cfg.Host, serverTLS, logger)
+		}
+
+		go func() {
+			logger.Infof("zymurgauge version %s started, listening at %s with %s tls", version, cfg.Host, cfg.TLSMode)
+			errCh <- httpServer.ListenAndServeTLS("", "")
+		}()
+	}
 
 	return wait(ctx, httpServer, errCh, cfg.ShutdownTimeout, logger)
 }
 
+// metricsSink sends metrics to the sink selected in the settings and replaces the sink when its settings change.
+type metricsSink struct {
+	metrics.Swappable
+	ctx      context.Context //nolint:containedctx // the sinks run until the app is stopped
+	logger   *logrus.Logger
+	settings *settings.AppSettings
+	cancel   context.CancelFunc
+}
+
+// configure replaces the sink if its settings changed. The replaced sink writes the metrics it holds before it is
+// closed.
+func (m *metricsSink) configure(s settings.AppSettings) {
+	if m.settings != nil && !metricsSinkChanged(*m.settings, s) {
+		return
+	}
+
+	if m.settings != nil {
+		m.logger.Infof("Metrics sink settings changed, writing metrics to %s.", s.GetMetricsSink())
+	}
+
+	m.settings = &s
+
+	ctx, cancel := context.WithCancel(m.ctx)
+
+	m.Swap(createMetricsSink(ctx, s, m.logger))
+
+	if m.cancel != nil {
+		m.cancel()
+	}
+
+	m.cancel = cancel
+}
+
+// metricsSinkChanged reports whether the settings of the metrics sink differ.
+func metricsSinkChanged(a, b settings.AppSettings) bool {
+	return a.GetMetricsSink() != b.GetMetricsSink() || a.StatsDAddress != b.StatsDAddress ||
+		a.InfluxDBURL != b.InfluxDBURL || a.InfluxDBWriteToken != b.InfluxDBWriteToken ||
+		a.InfluxDBOrg != b.InfluxDBOrg || a.InfluxDBBucket != b.InfluxDBBucket
+}
+
+// createMetricsSink returns the metrics sink selected in the settings, or nil if none is selected or it could not be
+// created. The sink is closed when the context is canceled.
+func createMetricsSink(ctx context.Context, s settings.AppSettings, logger *logrus.Logger) metrics.Metrics {
+	switch sink := s.GetMetricsSink(); sink {
+	case settings.StatsDMetricsSink:
+		if s.StatsDAddress == "" {
+			logger.Warn("StatsD Address not set.")
+
+			return nil
+		}
+
+		statsdClient, err := createStatDClient(s.StatsDAddress, logger)
+		if err != nil {
+			logger.WithError(err).Error("could not create statsd client")
+
+			return nil
+		}
+
+		go func() {
+			<-ctx.Done()
+			statsdClient.Close()
+		}()
+
+		return statsdClient
+	case settings.InfluxDBMetricsSink:
+		if s.InfluxDBURL == "" || s.InfluxDBWriteToken == "" {
+			logger.Warn("InfluxDB URL or write token not set.")
+
+			return nil
+		}
+
+		org, bucket := s.InfluxDBOrg, s.InfluxDBBucket
+		if org == "" {
+			org = defaultInfluxDBOrg
+		}
+
+		if bucket == "" {
+			bucket = defaultInfluxDBBucket
+		}
+
+		influxDB := metrics.NewInfluxDB(s.InfluxDBURL, org, bucket, s.InfluxDBWriteToken, logger)
+
+		go influxDB.Run(ctx)
+
+		return influxDB
+	case settings.NoMetricsSink:
+		return nil
+	default:
+		logger.Warnf("Unknown metrics sink %s.", sink)
+
+		return nil
+	}
+}
+
 func createStatDClient(addr string, logger *logrus.Logger) (*statsd.Client, error) {
 	var (
 		statsdClient *statsd.Client
@@ -223,8 +459,10 @@ func createStatDClient(addr string, logger *logrus.Logger) (*statsd.Client, erro
 	return statsdClient, nil
 }
 
-func createTiltMonitor(ctx context.Context, logger *logrus.Logger, errCh chan error) *tilt.Monitor {
-	monitor := tilt.NewMonitor(logger)
+func createTiltMonitor(ctx context.Context, logger *logrus.Logger, metrics metrics.Metrics,
+	errCh chan error,
+) *tilt.Monitor {
+	monitor := tilt.NewMonitor(logger, tilt.SetMetrics(metrics))
 
 	go func() {
 		errCh <- monitor.Run(ctx)
@@ -233,11 +471,11 @@ func createTiltMonitor(ctx context.Context, logger *logrus.Logger, errCh chan er
 	return monitor
 }
 
-func startDebugEndpoint(host string, logger *logrus.Logger) {
+func startDebugEndpoint(host string, metricsHandler http.Handler, logger *logrus.Logger) {
 	server := &http.Server{
 		Addr:              host,
 		ReadHeaderTimeout: debugReadHeaderTimeout,
-		Handler:           debug.Mux(),
+		Handler:           debug.Mux(metricsHandler),
 	}
 
 	go func() {
@@ -247,29 +485,270 @@ func startDebugEndpoint(host string, logger *logrus.Logger) {
 	}()
 }
 
-func createRepos(path string) (*database.ChamberRepo, *database.SettingsRepo, error) {
-	db, err := bbolt.Open(path, dbFilePermissions, &bbolt.Options{Timeout: bboltReadTimeout})
+// startHTTPRedirect serves plain HTTP at host, redirecting requests to the HTTPS server at httpsHost.
+func startHTTPRedirect(host, httpsHost string, serverTLS *certs.TLS, logger *logrus.Logger) {
+	_, httpsPort, err := net.SplitHostPort(httpsHost)
 	if err != nil {
-		err = errors.Wrap(err, "could not open database")
+		logger.WithError(err).Errorf("Could not get port of %s, not redirecting HTTP to HTTPS.", httpsHost)
 
-		return nil, nil, err
+		return
+	}
+
+	server := &http.Server{
+		Addr:              host,
+		ReadHeaderTimeout: redirectReadHeaderTimeout,
+		Handler:           serverTLS.HTTPHandler(httpsPort),
+	}
+
+	go func() {
+		logger.Infof("Redirecting HTTP at %s to HTTPS", host)
+
+		if err := server.ListenAndServe(); err != nil {
+			logger.WithError(err).Errorf("HTTP redirect endpoint %s closed.", host)
+		}
+	}()
+}
+
+type repos struct {
+	batch             *database.BatchRepo
+	brewfatherCache   *database.BrewfatherCacheRepo
+	brewfatherOutbox  *database.BrewfatherOutboxRepo
+	chamber           *database.ChamberRepo
+	fermentationState *database.FermentationStateRepo
+	readings          *database.ReadingsRepo
+	settings          *database.SettingsRepo
+	user              *database.UserRepo
+	apiToken          *database.APITokenRepo
+	session           *database.SessionRepo
+	audit             *database.AuditRepo
+}
+
+func createRepos(cfg config) (*repos, error) {
+	db, err := bbolt.Open(cfg.DBPath, dbFilePermissions, &bbolt.Options{Timeout: bboltReadTimeout})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open database")
+	}
+
+	batchRepo, err := database.NewBatchRepo(db)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create batch repo")
+	}
+
+	brewfatherCacheRepo, err := database.NewBrewfatherCacheRepo(db)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create brewfather cache repo")
+	}
+
+	brewfatherOutboxRepo, err := database.NewBrewfatherOutboxRepo(db)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create brewfather outbox repo")
 	}
 
 	chamberRepo, err := database.NewChamberRepo(db)
 	if err != nil {
-		err = errors.Wrap(err, "could not create chamber repo")
+		return nil, errors.Wrap(err, "could not create chamber repo")
+	}
+
+	fermentationStateRepo, err := database.NewFermentationStateRepo(db)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create fermentation state repo")
+	}
 
-		return nil, nil, err
+	readingsRepo, err := database.NewReadingsRepo(db, cfg.ReadingsRetention)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create readings repo")
 	}
 
 	settingsRepo, err := database.NewSettingsRepo(db)
 	if err != nil {
-		err = errors.Wrap(err, "could not create settings repo")
+		return nil, errors.Wrap(err, "could not create settings repo")
+	}
+
+	userRepo, err := database.NewUserRepo(db)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create user repo")
+	}
+
+	apiTokenRepo, err := database.NewAPITokenRepo(db)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create api token repo")
+	}
+
+	sessionRepo, err := database.NewSessionRepo(db)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create session repo")
+	}
+
+	auditRepo, err := database.NewAuditRepo(db, cfg.AuditRetention, cfg.AuditMaxEntries)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create audit repo")
+	}
+
+	return &repos{
+		batch:             batchRepo,
+		brewfatherCache:   brewfatherCacheRepo,
+		brewfatherOutbox:  brewfatherOutboxRepo,
+		chamber:           chamberRepo,
+		fermentationState: fermentationStateRepo,
+		readings:          readingsRepo,
+		settings:          settingsRepo,
+		user:              userRepo,
+		apiToken:          apiTokenRepo,
+		session:           sessionRepo,
+		audit:             auditRepo,
+	}, nil
+}
+
+func exportFermentationLog(args exportArgs, cfg config) error {
+	format, err := export.ParseFormat(args.Format)
+	if err != nil {
+		return errors.Wrapf(err, "could not parse format %s", args.Format)
+	}
+
+	repos, err := createRepos(cfg)
+	if err != nil {
+		return errors.Wrap(err, "could not create databases")
+	}
+
+	c, err := repos.chamber.Get(args.ChamberID)
+	if err != nil {
+		return errors.Wrapf(err, "could not get chamber %s", args.ChamberID)
+	}
+
+	if c == nil {
+		return errors.Errorf("chamber %s not found", args.ChamberID)
+	}
+
+	readings, err := repos.readings.GetReadings(c.ID, time.Unix(0, 0), time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "could not get readings for chamber %s", c.ID)
+	}
 
-		return nil, nil, err
+	events := export.Events(readings, export.GetBatch(c, args.BatchID))
+
+	if args.Output == "" {
+		return errors.Wrap(export.Write(os.Stdout, format, events), "could not write export")
 	}
 
-	return chamberRepo, settingsRepo, nil
+	f, err := os.Create(args.Output)
+	if err != nil {
+		return errors.Wrapf(err, "could not create file %s", args.Output)
+	}
+
+	if err := export.Write(f, format, events); err != nil {
+		f.Close()
+
+		return errors.Wrapf(err, "could not write export to %s", args.Output)
+	}
+
+	return errors.Wrapf(f.Close(), "could not close file %s", args.Output)
+}
+
+func importRecipe(args importArgs, cfg config) error {
+	data, err := os.ReadFile(args.File)
+	if err != nil {
+		return errors.Wrapf(err, "could not read file %s", args.File)
+	}
+
+	b, err := batch.Import(data)
+	if err != nil {
+		var batchErr *batch.InvalidBatchError
+		if errors.As(err, &batchErr) {
+			return errors.Errorf("%s: %v", batchErr.Error(), batchErr.Problems())
+		}
+
+		return errors.Wrapf(err, "could not import recipe from %s", args.File)
+	}
+
+	repos, err := createRepos(cfg)
+	if err != nil {
+		return errors.Wrap(err, "could not create databases")
+	}
+
+	c, err := repos.chamber.Get(args.ChamberID)
+	if err != nil {
+		return errors.Wrapf(err, "could not get chamber %s", args.ChamberID)
+	}
+
+	if c == nil {
+		return errors.Errorf("chamber %s not found", args.ChamberID)
+	}
+
+	state, err := repos.fermentationState.GetState(c.ID)
+	if err != nil {
+		return errors.Wrapf(err, "could not get fermentation state of chamber %s", c.ID)
+	}
+
+	if state != nil {
+		return errors.Errorf("chamber %s is fermenting", c.ID)
+	}
+
+	c.CurrentBatch = b
+
+	return errors.Wrapf(repos.chamber.Save(c), "could not save chamber %s", c.ID)
+}
+
+// migrateCredentials creates an admin user from the credentials in the settings if there are no users yet, since
+// the credentials in the settings were used to log in before there were users.
+func migrateCredentials(settingsRepo *database.SettingsRepo, userRepo *database.UserRepo, logger *logrus.Logger,
+) error {
+	s, err := settingsRepo.Get()
+	if err != nil {
+		return errors.Wrap(err, "could not get settings")
+	}
+
+	if s == nil || s.Username == "" || s.Password == "" {
+		return nil
+	}
+
+	users, err := userRepo.GetAll()
+	if err != nil {
+		return errors.Wrap(err, "could not get all users")
+	}
+
+	if len(users) > 0 {
+		return nil
+	}
+
+	u := &auth.User{Username: s.Username, Password: s.Password, Role: auth.AdminRole}
+
+	if err := userRepo.Save(u); err != nil {
+		return errors.Wrapf(err, "could not save user %s", u.Username)
+	}
+
+	logger.Infof("Created admin user %s from the settings.", u.Username)
+
+	return nil
+}
+
+func addUser(args userAddArgs, cfg config, logger *logrus.Logger) error {
+	repos, err := createRepos(cfg)
+	if err != nil {
+		return errors.Wrap(err, "could not create databases")
+	}
+
+	u, err := repos.user.GetByUsername(args.Username)
+	if err != nil {
+		return errors.Wrapf(err, "could not get user %s", args.Username)
+	}
+
+	if u == nil {
+		u = &auth.User{Username: args.Username}
+	}
+
+	u.Role = auth.Role(args.Role)
+
+	if err := u.SetPassword(args.Password); err != nil {
+		return errors.Wrap(err, "could not set password")
+	}
+
+	if err := repos.user.Save(u); err != nil {
+		return errors.Wrapf(err, "could not save user %s", u.Username)
+	}
+
+	logger.Infof("Saved %s user %s.", u.Role, u.Username)
+
+	return nil
 }
 
 func checkAndInitSettings(args initArgs, settingsRepo *database.SettingsRepo, logger *logrus.Logger,
@@ -293,26 +772,26 @@ func checkAndInitSettings(args initArgs, settingsRepo *database.SettingsRepo, lo
 		return errors.Wrap(err, "could not generate password hash")
 	}
 
-	letters := "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
-	secretKeyLength := 64
-
-	b := make([]byte, secretKeyLength)
-
-	for i := 0; i < secretKeyLength; i++ {
-		num, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-		b[i] = letters[num.Int64()]
+	secret, err := auth.NewSecret()
+	if err != nil {
+		return errors.Wrap(err, "could not create auth secret")
 	}
 
 	s = &settings.Settings{
 		AppSettings: settings.AppSettings{
-			AuthSecret:          string(b),
+			AuthSecret:          secret,
 			TemperatureUnits:    "Celsius",
 			BrewfatherAPIUserID: args.BrewfatherUserID,
 			BrewfatherAPIKey:    args.BrewfatherKey,
 			BrewfatherLogURL:    args.BrewfatherLogURL,
+			BrewfatherUpdates:   args.BrewfatherUpdate,
 			InfluxDBURL:         args.InfluxDBURL,
 			InfluxDBReadToken:   args.InfluxDBToken,
+			InfluxDBWriteToken:  args.InfluxDBWriteToken,
+			InfluxDBOrg:         args.InfluxDBOrg,
+			InfluxDBBucket:      args.InfluxDBBucket,
 			StatsDAddress:       args.StatsDAddress,
+			MetricsSink:         args.MetricsSink,
 		},
 		Credentials: auth.Credentials{
 			Username: args.Username,
@@ -328,13 +807,30 @@ func checkAndInitSettings(args initArgs, settingsRepo *database.SettingsRepo, lo
 	return nil
 }
 
-func startUpdateSettingsChannel(brewfatherClient *brewfather.ServiceClient) chan settings.Settings {
+func startUpdateSettingsChannel(brewfatherClient *brewfather.ServiceClient, brewfatherCache *brewfather.Cache,
+	brewfatherUserID string, alertEngine *alert.Engine, mqttBridge *mqtt.Bridge, sink *metricsSink,
+	logger *logrus.Logger,
+) chan settings.Settings {
 	settingsCh := make(chan settings.Settings)
 
 	go func() {
 		for {
 			update := <-settingsCh
-			brewfatherClient.UpdateSettings(update.BrewfatherAPIUserID, update.BrewfatherAPIKey, update.BrewfatherLogURL)
+			brewfatherClient.UpdateSettings(update.BrewfatherAPIUserID, update.BrewfatherAPIKey, update.BrewfatherLogURL,
+				update.BrewfatherUpdates)
+
+			// batches of a different Brewfather account must not be served from the cache
+			if update.BrewfatherAPIUserID != brewfatherUserID {
+				if err := brewfatherCache.Clear(); err != nil {
+					logger.WithError(err).Error("Could not clear Brewfather cache.")
+				}
+
+				brewfatherUserID = update.BrewfatherAPIUserID
+			}
+
+			alertEngine.Configure(update.Alerts)
+			mqttBridge.Configure(update.MQTT)
+			sink.configure(update.AppSettings)
 		}
 	}()
 
@@ -1,5 +1,13 @@
 package simulator
 
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+)
+
 const (
 	// Borrowed from https://github.com/BrewPi/firmware/blob/0.5.10/lib/test/SimulationTest.cpp#L115
 	beerCapacity = 4.2 * 1.0 * 20        // heat capacity water * density of water * 20L volume (in kJ per kelvin).
@@ -19,6 +27,8 @@ const (
 	initialAirTemp         = 20.0
 	initialHeaterTemp      = 20.0
 	initialEnvironmentTemp = 20.0
+	updatePeriod           = 1 * time.Second // amount of simulated time that passes with each update
+	runPollInterval        = 1 * time.Millisecond
 )
 
 type Simulator struct {
@@ -33,28 +43,39 @@ type Simulator struct {
 }
 
 type Actuator struct {
-	isOn bool
+	isOn  bool
+	mutex sync.Mutex
 }
 
 func (a *Actuator) On() error {
-	if !a.isOn {
-		a.isOn = true
-	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.isOn = true
 
 	return nil
 }
 
 func (a *Actuator) Off() error {
-	if a.isOn {
-		a.isOn = false
-	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.isOn = false
 
 	return nil
 }
 
+func (a *Actuator) IsOn() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.isOn
+}
+
 type Thermometer struct {
 	id          string
 	currentTemp float64
+	mutex       sync.Mutex
 }
 
 func (t *Thermometer) GetID() string {
@@ -62,9 +83,19 @@ func (t *Thermometer) GetID() string {
 }
 
 func (t *Thermometer) GetTemperature() (float64, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
 	return t.currentTemp, nil
 }
 
+func (t *Thermometer) setTemperature(temperature float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.currentTemp = temperature
+}
+
 func New(initialBeerTemp float64) *Simulator {
 	return &Simulator{
 		beerTemp:        initialBeerTemp,
@@ -86,11 +117,11 @@ func (s *Simulator) Update() {
 
 	beerTempNew += (s.airTemp - s.beerTemp) * airBeerTransfer / beerCapacity
 
-	if s.Heater.isOn {
+	if s.Heater.IsOn() {
 		heaterTempNew += heaterPower / heaterCapacity
 	}
 
-	if s.Chiller.isOn {
+	if s.Chiller.IsOn() {
 		wallTempNew -= coolerPower / wallCapacity
 	}
 
@@ -109,5 +140,26 @@ func (s *Simulator) Update() {
 	s.beerTemp = beerTempNew
 	s.wallTemp = wallTempNew
 	s.heaterTemp = heaterTempNew
-	s.Thermometer.currentTemp = s.beerTemp
+	s.Thermometer.setTemperature(s.beerTemp)
+}
+
+// Run updates the simulation once for every second that passes on the given clock until the context is canceled.
+// This keeps the simulation in step with a dilated clock regardless of how quickly the updates can be performed.
+func (s *Simulator) Run(ctx context.Context, clock clock.Clock) {
+	startTime := clock.Now()
+	updates := int64(0)
+
+	for {
+		elapsed := int64(clock.Since(startTime) / updatePeriod)
+
+		for ; updates < elapsed; updates++ {
+			s.Update()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(runPollInterval):
+		}
+	}
 }
@@ -0,0 +1,350 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FiringStatus   Status = "firing"
+	ResolvedStatus Status = "resolved"
+
+	defaultInterval   = 1 * time.Minute
+	maxResolvedAlerts = 100
+)
+
+// Status is the status of an alert.
+type Status string
+
+// Alert is raised when a rule is violated for a chamber. While the violation persists the same alert stays firing.
+type Alert struct {
+	ID               string     `json:"id"`
+	ChamberID        string     `json:"chamberId"`
+	ChamberName      string     `json:"chamberName"`
+	Rule             RuleType   `json:"rule"`
+	Subject          string     `json:"subject,omitempty"`
+	Message          string     `json:"message"`
+	Status           Status     `json:"status"`
+	StartTime        time.Time  `json:"startTime"`
+	LastSeenTime     time.Time  `json:"lastSeenTime"`
+	ResolvedTime     *time.Time `json:"resolvedTime,omitempty"`
+	AcknowledgedTime *time.Time `json:"acknowledgedTime,omitempty"`
+	lastNotifiedTime time.Time
+}
+
+// Title returns a short summary of the alert.
+func (a *Alert) Title() string {
+	if a.Status == ResolvedStatus {
+		return fmt.Sprintf("Resolved: %s alert for chamber %s", a.Rule, a.ChamberName)
+	}
+
+	return fmt.Sprintf("%s alert for chamber %s", a.Rule, a.ChamberName)
+}
+
+// Controller provides access to alerts.
+type Controller interface {
+	GetAll() []*Alert
+	Acknowledge(id string) (*Alert, error)
+}
+
+// ChamberLister lists the chambers whose readings are evaluated.
+type ChamberLister interface {
+	GetAll() ([]*chamber.Chamber, error)
+}
+
+var _ Controller = (*Engine)(nil)
+
+// Engine periodically evaluates rules against the recent readings of every chamber and sends alerts to notifiers.
+// Violations of the same rule for the same chamber and subject are de-duplicated into a single alert, which is
+// resolved once the rule is no longer violated.
+type Engine struct {
+	chambers       ChamberLister
+	readingsRepo   chamber.ReadingsRepo
+	rules          []Rule
+	notifiers      []Notifier
+	extraNotifiers []Notifier
+	repeatInterval time.Duration
+	interval       time.Duration
+	clock          clock.Clock
+	logger         *logrus.Logger
+	mutex          sync.Mutex
+	active         map[string]*Alert
+	resolved       []*Alert
+}
+
+func NewEngine(chambers ChamberLister, readingsRepo chamber.ReadingsRepo, logger *logrus.Logger,
+	options ...OptionsFunc,
+) *Engine {
+	e := &Engine{
+		chambers:     chambers,
+		readingsRepo: readingsRepo,
+		interval:     defaultInterval,
+		clock:        clock.NewRealClock(),
+		logger:       logger,
+		active:       make(map[string]*Alert),
+	}
+
+	for _, option := range options {
+		option(e)
+	}
+
+	e.notifiers = e.extraNotifiers
+
+	return e
+}
+
+type OptionsFunc func(*Engine)
+
+func SetClock(clock clock.Clock) OptionsFunc {
+	return func(e *Engine) {
+		e.clock = clock
+	}
+}
+
+// Interval sets how often the rules are evaluated.
+func Interval(interval time.Duration) OptionsFunc {
+	return func(e *Engine) {
+		e.interval = interval
+	}
+}
+
+// AddNotifier adds a notifier that alerts are sent to in addition to those enabled in the engine's settings.
+func AddNotifier(notifier Notifier) OptionsFunc {
+	return func(e *Engine) {
+		e.extraNotifiers = append(e.extraNotifiers, notifier)
+	}
+}
+
+// Configure replaces the engine's rules and notifiers with those enabled in the given settings.
+func (e *Engine) Configure(s Settings) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.rules = NewRules(s)
+	e.notifiers = append(NewNotifiers(s), e.extraNotifiers...)
+	e.repeatInterval = time.Duration(s.RepeatMinutes) * time.Minute
+}
+
+// Run evaluates the rules every interval until the context is canceled.
+func (e *Engine) Run(ctx context.Context) {
+	for {
+		e.Evaluate(ctx)
+
+		timer := e.clock.NewTimer(e.interval)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		}
+	}
+}
+
+// Evaluate evaluates the rules once and sends notifications for alerts that have started firing, are due to be
+// repeated or have been resolved.
+func (e *Engine) Evaluate(ctx context.Context) {
+	e.mutex.Lock()
+	rules := e.rules
+	e.mutex.Unlock()
+
+	chambers, err := e.chambers.GetAll()
+	if err != nil {
+		e.logger.WithError(err).Error("could not get chambers to evaluate alerts")
+
+		return
+	}
+
+	now := e.clock.Now()
+	violations := make(map[string]*Alert)
+	skipped := make(map[string]bool)
+
+	for _, c := range chambers {
+		if err := e.evaluateChamber(c, rules, now, violations); err != nil {
+			e.logger.WithError(err).Errorf("could not evaluate alerts for chamber %s", c.Name)
+
+			skipped[c.ID] = true
+		}
+	}
+
+	for _, a := range e.update(violations, skipped, now) {
+		e.notify(ctx, a)
+	}
+}
+
+func (e *Engine) evaluateChamber(c *chamber.Chamber, rules []Rule, now time.Time, violations map[string]*Alert) error {
+	var window time.Duration
+
+	for _, rule := range rules {
+		if rule.Window() > window {
+			window = rule.Window()
+		}
+	}
+
+	if window == 0 {
+		return nil
+	}
+
+	readings, err := e.readingsRepo.GetReadings(c.ID, now.Add(-window), now)
+	if err != nil {
+		return errors.Wrap(err, "could not get readings")
+	}
+
+	for _, rule := range rules {
+		from := now.Add(-rule.Window())
+		start := sort.Search(len(readings), func(i int) bool { return !readings[i].Time.Before(from) })
+
+		for _, v := range rule.Evaluate(c, readings[start:], now) {
+			violations[alertKey(c.ID, rule.Type(), v.Subject)] = &Alert{
+				ChamberID:   c.ID,
+				ChamberName: c.Name,
+				Rule:        rule.Type(),
+				Subject:     v.Subject,
+				Message:     v.Message,
+			}
+		}
+	}
+
+	return nil
+}
+
+// update applies the violations to the alerts and returns copies of the alerts that need to be sent. The alerts of
+// skipped chambers are left unchanged.
+func (e *Engine) update(violations map[string]*Alert, skipped map[string]bool, now time.Time) []*Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var toNotify []*Alert
+
+	for key, v := range violations {
+		a, ok := e.active[key]
+		if !ok {
+			a = v
+			a.ID = uuid.New().String()
+			a.Status = FiringStatus
+			a.StartTime = now
+			e.active[key] = a
+		}
+
+		a.ChamberName = v.ChamberName
+		a.Message = v.Message
+		a.LastSeenTime = now
+
+		isRepeatDue := e.repeatInterval > 0 && a.AcknowledgedTime == nil &&
+			now.Sub(a.lastNotifiedTime) >= e.repeatInterval
+
+		if !ok || isRepeatDue {
+			a.lastNotifiedTime = now
+			toNotify = append(toNotify, copyAlert(a))
+		}
+	}
+
+	for key, a := range e.active {
+		if _, ok := violations[key]; ok || skipped[a.ChamberID] {
+			continue
+		}
+
+		resolvedTime := now
+		a.Status = ResolvedStatus
+		a.ResolvedTime = &resolvedTime
+
+		delete(e.active, key)
+		e.resolved = append(e.resolved, a)
+		toNotify = append(toNotify, copyAlert(a))
+	}
+
+	if len(e.resolved) > maxResolvedAlerts {
+		e.resolved = e.resolved[len(e.resolved)-maxResolvedAlerts:]
+	}
+
+	return toNotify
+}
+
+func (e *Engine) notify(ctx context.Context, a *Alert) {
+	e.mutex.Lock()
+	notifiers := e.notifiers
+	e.mutex.Unlock()
+
+	e.logger.Infof("%s: %s", a.Title(), a.Message)
+
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, a); err != nil {
+			e.logger.WithError(err).Errorf("could not send alert %s", a.ID)
+		}
+	}
+}
+
+// GetAll returns the firing alerts followed by the most recently resolved alerts. Each group is ordered from newest
+// to oldest.
+func (e *Engine) GetAll() []*Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	alerts := make([]*Alert, 0, len(e.active)+len(e.resolved))
+
+	for _, a := range e.active {
+		alerts = append(alerts, copyAlert(a))
+	}
+
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].StartTime.After(alerts[j].StartTime) })
+
+	for i := len(e.resolved) - 1; i >= 0; i-- {
+		alerts = append(alerts, copyAlert(e.resolved[i]))
+	}
+
+	return alerts
+}
+
+// Acknowledge marks an alert as acknowledged, which stops it from being sent again while it is firing.
+func (e *Engine) Acknowledge(id string) (*Alert, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	a := e.find(id)
+	if a == nil {
+		return nil, ErrNotFound
+	}
+
+	if a.AcknowledgedTime == nil {
+		now := e.clock.Now()
+		a.AcknowledgedTime = &now
+	}
+
+	return copyAlert(a), nil
+}
+
+func (e *Engine) find(id string) *Alert {
+	for _, a := range e.active {
+		if a.ID == id {
+			return a
+		}
+	}
+
+	for _, a := range e.resolved {
+		if a.ID == id {
+			return a
+		}
+	}
+
+	return nil
+}
+
+func alertKey(chamberID string, rule RuleType, subject string) string {
+	return fmt.Sprintf("%s/%s/%s", chamberID, rule, subject)
+}
+
+func copyAlert(a *Alert) *Alert {
+	c := *a
+
+	return &c
+}
@@ -0,0 +1,212 @@
+package alert_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *testClock) NewTimer(d time.Duration) *time.Timer {
+	return time.NewTimer(d)
+}
+
+func (c *testClock) add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+type testNotifier struct {
+	mutex  sync.Mutex
+	alerts []*alert.Alert
+}
+
+func (n *testNotifier) Notify(_ context.Context, a *alert.Alert) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.alerts = append(n.alerts, a)
+
+	return nil
+}
+
+func (n *testNotifier) sent() []*alert.Alert {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.alerts
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestEngine(t *testing.T) {
+	t.Parallel()
+	t.Run("fireAndResolve", fireAndResolve)
+	t.Run("repeatUntilAcknowledged", repeatUntilAcknowledged)
+	t.Run("acknowledgeNotFound", acknowledgeNotFound)
+	t.Run("readingsErrorKeepsAlerts", readingsErrorKeepsAlerts)
+	t.Run("noRulesConfigured", noRulesConfigured)
+}
+
+func fireAndResolve(t *testing.T) {
+	t.Parallel()
+
+	c := createTestChamber()
+	clock := &testClock{now: now}
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	hotReadings := []*chamber.Reading{{Time: now, BeerTemperature: float(25), SetPoint: float(20)}}
+	readingsRepoMock.On("GetReadings", c.ID, mock.Anything, mock.Anything).Return(hotReadings, nil).Twice()
+
+	engine, notifier := setupEngineTest(t, c, readingsRepoMock, clock, alert.Settings{TemperatureThreshold: 3})
+
+	engine.Evaluate(context.Background())
+	clock.add(time.Minute)
+	engine.Evaluate(context.Background()) // the same violation is de-duplicated
+
+	alerts := engine.GetAll()
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, alert.FiringStatus, alerts[0].Status)
+	assert.Equal(t, alert.ThresholdRuleType, alerts[0].Rule)
+	assert.Equal(t, c.Name, alerts[0].ChamberName)
+	assert.Equal(t, now, alerts[0].StartTime)
+	assert.Equal(t, now.Add(time.Minute), alerts[0].LastSeenTime)
+	assert.Len(t, notifier.sent(), 1)
+
+	readingsRepoMock.On("GetReadings", c.ID, mock.Anything, mock.Anything).
+		Return([]*chamber.Reading{{Time: now, BeerTemperature: float(20), SetPoint: float(20)}}, nil)
+
+	clock.add(time.Minute)
+	engine.Evaluate(context.Background())
+
+	alerts = engine.GetAll()
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, alert.ResolvedStatus, alerts[0].Status)
+	assert.Equal(t, now.Add(2*time.Minute), *alerts[0].ResolvedTime)
+
+	sent := notifier.sent()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, alert.ResolvedStatus, sent[1].Status)
+	assert.Equal(t, sent[0].ID, sent[1].ID)
+}
+
+func repeatUntilAcknowledged(t *testing.T) {
+	t.Parallel()
+
+	c := createTestChamber()
+	clock := &testClock{now: now}
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	readingsRepoMock.On("GetReadings", c.ID, mock.Anything, mock.Anything).
+		Return(func(_ string, _, to time.Time) []*chamber.Reading {
+			return []*chamber.Reading{{Time: to.Add(-time.Second), BeerTemperature: float(25), SetPoint: float(20)}}
+		}, nil)
+
+	engine, notifier := setupEngineTest(t, c, readingsRepoMock, clock,
+		alert.Settings{TemperatureThreshold: 3, RepeatMinutes: 30})
+
+	engine.Evaluate(context.Background())
+	clock.add(15 * time.Minute)
+	engine.Evaluate(context.Background())
+	assert.Len(t, notifier.sent(), 1)
+
+	clock.add(15 * time.Minute)
+	engine.Evaluate(context.Background())
+	assert.Len(t, notifier.sent(), 2)
+
+	a, err := engine.Acknowledge(engine.GetAll()[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(30*time.Minute), *a.AcknowledgedTime)
+
+	clock.add(time.Hour)
+	engine.Evaluate(context.Background())
+	assert.Len(t, notifier.sent(), 2)
+}
+
+func acknowledgeNotFound(t *testing.T) {
+	t.Parallel()
+
+	engine, _ := setupEngineTest(t, createTestChamber(), &mocks.ReadingsRepo{}, &testClock{now: now},
+		alert.Settings{})
+
+	_, err := engine.Acknowledge("missing")
+	assert.ErrorIs(t, err, alert.ErrNotFound)
+}
+
+func readingsErrorKeepsAlerts(t *testing.T) {
+	t.Parallel()
+
+	c := createTestChamber()
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	readingsRepoMock.On("GetReadings", c.ID, mock.Anything, mock.Anything).
+		Return([]*chamber.Reading{{Time: now, BeerTemperature: float(25), SetPoint: float(20)}}, nil).Once()
+	readingsRepoMock.On("GetReadings", c.ID, mock.Anything, mock.Anything).
+		Return(nil, errors.New("readingsRepoMock error"))
+
+	engine, notifier := setupEngineTest(t, c, readingsRepoMock, &testClock{now: now},
+		alert.Settings{TemperatureThreshold: 3})
+
+	engine.Evaluate(context.Background())
+	engine.Evaluate(context.Background())
+
+	alerts := engine.GetAll()
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, alert.FiringStatus, alerts[0].Status)
+	assert.Len(t, notifier.sent(), 1)
+}
+
+func noRulesConfigured(t *testing.T) {
+	t.Parallel()
+
+	readingsRepoMock := &mocks.ReadingsRepo{}
+
+	engine, notifier := setupEngineTest(t, createTestChamber(), readingsRepoMock, &testClock{now: now},
+		alert.Settings{})
+
+	engine.Evaluate(context.Background())
+
+	readingsRepoMock.AssertNotCalled(t, "GetReadings", mock.Anything, mock.Anything, mock.Anything)
+	assert.Empty(t, engine.GetAll())
+	assert.Empty(t, notifier.sent())
+}
+
+func setupEngineTest(t *testing.T, c *chamber.Chamber, readingsRepo chamber.ReadingsRepo, clock *testClock,
+	settings alert.Settings,
+) (*alert.Engine, *testNotifier) {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+
+	repoMock := &mocks.ChamberRepo{}
+	repoMock.On("GetAll").Return([]*chamber.Chamber{c}, nil)
+
+	notifier := &testNotifier{}
+
+	engine := alert.NewEngine(repoMock, readingsRepo, l, alert.SetClock(clock), alert.AddNotifier(notifier))
+	engine.Configure(settings)
+
+	return engine, notifier
+}
@@ -0,0 +1,9 @@
+package alert
+
+const ErrNotFound = Error("alert not found")
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
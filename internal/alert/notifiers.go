@@ -0,0 +1,182 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/pkg/errors"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// Notifier sends an alert to an external service. Notify is called when an alert starts firing, periodically while
+// it fires if it is not acknowledged and when it is resolved.
+type Notifier interface {
+	Notify(ctx context.Context, alert *Alert) error
+}
+
+var (
+	_ Notifier = (*WebhookNotifier)(nil)
+	_ Notifier = (*NtfyNotifier)(nil)
+	_ Notifier = (*SMTPNotifier)(nil)
+	_ Notifier = (*EventNotifier)(nil)
+)
+
+// WebhookNotifier POSTs alerts as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: notifyTimeout},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert *Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal alert")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewBuffer(data))
+	if err != nil {
+		return errors.Wrap(err, "could not create POST request for webhook")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(n.client, req)
+}
+
+// NtfyNotifier pushes alerts to an ntfy style topic URL, such as https://ntfy.sh/my-topic. The message is the body
+// of the request and the title, priority and tags are set with headers.
+type NtfyNotifier struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewNtfyNotifier returns a NtfyNotifier that pushes to the given topic URL. If token is not empty it is sent as a
+// bearer token.
+func NewNtfyNotifier(url, token string) *NtfyNotifier {
+	return &NtfyNotifier{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: notifyTimeout},
+	}
+}
+
+func (n *NtfyNotifier) Notify(ctx context.Context, alert *Alert) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, strings.NewReader(alert.Message))
+	if err != nil {
+		return errors.Wrap(err, "could not create POST request for ntfy")
+	}
+
+	priority, tags := "high", "warning"
+	if alert.Status == ResolvedStatus {
+		priority, tags = "default", "white_check_mark"
+	}
+
+	req.Header.Set("Title", alert.Title())
+	req.Header.Set("Priority", priority)
+	req.Header.Set("Tags", tags)
+
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	return doRequest(n.client, req)
+}
+
+// SMTPNotifier emails alerts.
+type SMTPNotifier struct {
+	address string
+	auth    smtp.Auth
+	from    string
+	to      []string
+}
+
+// NewSMTPNotifier returns a SMTPNotifier that sends email from and to the given addresses using the SMTP server at
+// host and port. PLAIN authentication is used if username is not empty.
+func NewSMTPNotifier(host string, port int, username, password, from string, to []string) *SMTPNotifier {
+	n := &SMTPNotifier{
+		address: net.JoinHostPort(host, strconv.Itoa(port)),
+		from:    from,
+		to:      to,
+	}
+
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return n
+}
+
+func (n *SMTPNotifier) Notify(_ context.Context, alert *Alert) error {
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", alert.Title())
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n", alert.Message)
+
+	if err := smtp.SendMail(n.address, n.auth, n.from, n.to, msg.Bytes()); err != nil {
+		return errors.Wrapf(err, "could not send email to %s", strings.Join(n.to, ", "))
+	}
+
+	return nil
+}
+
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "could not POST to %s", req.URL.Host)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("%s responded with status %d", req.URL.Host, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// EventNotifier publishes alerts as events.
+type EventNotifier struct {
+	publisher event.Publisher
+}
+
+func NewEventNotifier(publisher event.Publisher) *EventNotifier {
+	return &EventNotifier{publisher: publisher}
+}
+
+func (n *EventNotifier) Notify(_ context.Context, alert *Alert) error {
+	t := alert.LastSeenTime
+	if alert.ResolvedTime != nil {
+		t = *alert.ResolvedTime
+	}
+
+	n.publisher.Publish(event.Event{
+		Type:      event.AlertType,
+		ChamberID: alert.ChamberID,
+		Time:      t,
+		Data:      alert,
+	})
+
+	return nil
+}
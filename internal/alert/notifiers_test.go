@@ -0,0 +1,221 @@
+package alert_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestAlert() *alert.Alert {
+	return &alert.Alert{
+		ID:          "a0c4b1e8-4a4c-4a39-8e55-cb3d7d1c1f5a",
+		ChamberID:   "96f58a65-03c0-49f3-83ca-ab751bbf3768",
+		ChamberName: "Chamber 1",
+		Rule:        alert.ThresholdRuleType,
+		Subject:     alert.BeerTemperatureSubject,
+		Message:     "Beer temperature 23.50°C is 3.50°C above the set point of 20.00°C",
+		Status:      alert.FiringStatus,
+		StartTime:   now,
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	t.Parallel()
+
+	var received alert.Alert
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	a := createTestAlert()
+
+	err := alert.NewWebhookNotifier(server.URL).Notify(context.Background(), a)
+	assert.NoError(t, err)
+	assert.Equal(t, a.ID, received.ID)
+	assert.Equal(t, a.Message, received.Message)
+	assert.True(t, a.StartTime.Equal(received.StartTime))
+}
+
+func TestWebhookNotifierErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := alert.NewWebhookNotifier(server.URL).Notify(context.Background(), createTestAlert())
+	assert.Contains(t, err.Error(), "responded with status 500")
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		path, title, priority, tags, authorization, body string
+	}
+
+	requests := make(chan request, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{
+			path: r.URL.Path, title: r.Header.Get("Title"), priority: r.Header.Get("Priority"),
+			tags: r.Header.Get("Tags"), authorization: r.Header.Get("Authorization"), body: string(body),
+		}
+	}))
+	defer server.Close()
+
+	notifier := alert.NewNtfyNotifier(server.URL+"/zymurgauge", "tk_token")
+	a := createTestAlert()
+
+	err := notifier.Notify(context.Background(), a)
+	assert.NoError(t, err)
+
+	r := <-requests
+	assert.Equal(t, "/zymurgauge", r.path)
+	assert.Equal(t, "threshold alert for chamber Chamber 1", r.title)
+	assert.Equal(t, "high", r.priority)
+	assert.Equal(t, "warning", r.tags)
+	assert.Equal(t, "Bearer tk_token", r.authorization)
+	assert.Equal(t, a.Message, r.body)
+
+	a.Status = alert.ResolvedStatus
+
+	err = notifier.Notify(context.Background(), a)
+	assert.NoError(t, err)
+
+	r = <-requests
+	assert.Equal(t, "Resolved: threshold alert for chamber Chamber 1", r.title)
+	assert.Equal(t, "default", r.priority)
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	defer listener.Close()
+
+	messages := make(chan string, 1)
+
+	go serveSMTP(listener, messages)
+
+	addr, _ := listener.Addr().(*net.TCPAddr)
+	notifier := alert.NewSMTPNotifier("127.0.0.1", addr.Port, "", "", "zym@example.com",
+		[]string{"brewer@example.com"})
+
+	a := createTestAlert()
+
+	err = notifier.Notify(context.Background(), a)
+	assert.NoError(t, err)
+
+	msg := <-messages
+	assert.Contains(t, msg, "From: zym@example.com")
+	assert.Contains(t, msg, "To: brewer@example.com")
+	assert.Contains(t, msg, "Subject: threshold alert for chamber Chamber 1")
+	assert.Contains(t, msg, a.Message)
+}
+
+func TestSMTPNotifierError(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	addr, _ := listener.Addr().(*net.TCPAddr)
+	listener.Close() // nothing is listening
+
+	notifier := alert.NewSMTPNotifier("127.0.0.1", addr.Port, "", "", "zym@example.com",
+		[]string{"brewer@example.com"})
+
+	err = notifier.Notify(context.Background(), createTestAlert())
+	assert.Contains(t, err.Error(), "could not send email to brewer@example.com")
+}
+
+func TestEventNotifier(t *testing.T) {
+	t.Parallel()
+
+	bus := event.NewBus()
+	events, unsubscribe := bus.Subscribe()
+
+	defer unsubscribe()
+
+	a := createTestAlert()
+	a.LastSeenTime = now
+
+	err := alert.NewEventNotifier(bus).Notify(context.Background(), a)
+	assert.NoError(t, err)
+
+	e := <-events
+	assert.Equal(t, event.AlertType, e.Type)
+	assert.Equal(t, a.ChamberID, e.ChamberID)
+	assert.Equal(t, now, e.Time)
+	assert.Equal(t, a, e.Data)
+}
+
+// serveSMTP accepts a single connection and implements just enough of SMTP to receive one message.
+func serveSMTP(listener net.Listener, messages chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(code int, msg string) { _, _ = conn.Write([]byte(strconv.Itoa(code) + " " + msg + "\r\n")) }
+
+	reply(220, "localhost ESMTP") //nolint:gomnd // SMTP reply codes
+
+	var data strings.Builder
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch command := strings.ToUpper(strings.Fields(line + " x")[0]); command {
+		case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+			reply(250, "OK") //nolint:gomnd // SMTP reply codes
+		case "DATA":
+			reply(354, "Go ahead") //nolint:gomnd // SMTP reply codes
+
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+
+				data.WriteString(line)
+			}
+
+			messages <- data.String()
+
+			reply(250, "OK") //nolint:gomnd // SMTP reply codes
+		case "QUIT":
+			reply(221, "Bye") //nolint:gomnd // SMTP reply codes
+
+			return
+		default:
+			reply(502, "Not implemented") //nolint:gomnd // SMTP reply codes
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+)
+
+const (
+	ThresholdRuleType      RuleType = "threshold"
+	RateOfChangeRuleType   RuleType = "rateOfChange"
+	SensorMissingRuleType  RuleType = "sensorMissing"
+	GravityStalledRuleType RuleType = "gravityStalled"
+
+	BeerTemperatureSubject      = "beerTemperature"
+	AuxiliaryTemperatureSubject = "auxiliaryTemperature"
+	ExternalTemperatureSubject  = "externalTemperature"
+	HydrometerGravitySubject    = "hydrometerGravity"
+
+	// latestReadingWindow is how recent a reading must be for it to be considered the chamber's current reading.
+	latestReadingWindow = 10 * time.Minute
+	// rateOfChangeWindow is the period over which the rate of change of the beer temperature is measured.
+	rateOfChangeWindow = 1 * time.Hour
+	// minRateOfChangePeriod is the shortest period that the rate of change of the beer temperature is measured over.
+	minRateOfChangePeriod = 15 * time.Minute
+	// coverageMargin is how far back, past its duration, a rule looks for readings that prove that the chamber was
+	// recording for the whole duration.
+	coverageMargin = 1 * time.Hour
+)
+
+// RuleType identifies the kind of rule that raised an alert.
+type RuleType string
+
+// Rule checks the recent readings of a chamber for conditions that should raise an alert.
+type Rule interface {
+	// Type returns the type of the rule.
+	Type() RuleType
+	// Window returns how far back before now the readings passed to Evaluate must go.
+	Window() time.Duration
+	// Evaluate returns the conditions that are currently violated given the chamber's readings, in chronological
+	// order, since now minus Window.
+	Evaluate(c *chamber.Chamber, readings []*chamber.Reading, now time.Time) []Violation
+}
+
+// Violation is a condition that is violated. Subject distinguishes between violations of the same rule, such as
+// different sensors being missing.
+type Violation struct {
+	Subject string
+	Message string
+}
+
+// ThresholdRule is violated when the beer temperature is further than Threshold degrees from the set point.
+type ThresholdRule struct {
+	Threshold float64
+}
+
+func (r *ThresholdRule) Type() RuleType { return ThresholdRuleType }
+
+func (r *ThresholdRule) Window() time.Duration { return latestReadingWindow }
+
+func (r *ThresholdRule) Evaluate(_ *chamber.Chamber, readings []*chamber.Reading, _ time.Time) []Violation {
+	for i := len(readings) - 1; i >= 0; i-- {
+		reading := readings[i]
+		if reading.BeerTemperature == nil || reading.SetPoint == nil {
+			continue
+		}
+
+		difference := *reading.BeerTemperature - *reading.SetPoint
+		if math.Abs(difference) <= r.Threshold {
+			return nil
+		}
+
+		direction := "above"
+		if difference < 0 {
+			direction = "below"
+		}
+
+		return []Violation{{
+			Subject: BeerTemperatureSubject,
+			Message: fmt.Sprintf("Beer temperature %.2f°C is %.2f°C %s the set point of %.2f°C",
+				*reading.BeerTemperature, math.Abs(difference), direction, *reading.SetPoint),
+		}}
+	}
+
+	return nil
+}
+
+// RateOfChangeRule is violated when the beer temperature changes faster than Rate degrees per hour.
+type RateOfChangeRule struct {
+	Rate float64
+}
+
+func (r *RateOfChangeRule) Type() RuleType { return RateOfChangeRuleType }
+
+func (r *RateOfChangeRule) Window() time.Duration { return rateOfChangeWindow }
+
+func (r *RateOfChangeRule) Evaluate(_ *chamber.Chamber, readings []*chamber.Reading, _ time.Time) []Violation {
+	var first, last *chamber.Reading
+
+	for _, reading := range readings {
+		if reading.BeerTemperature == nil {
+			continue
+		}
+
+		if first == nil {
+			first = reading
+		}
+
+		last = reading
+	}
+
+	if first == nil || last.Time.Sub(first.Time) < minRateOfChangePeriod {
+		return nil
+	}
+
+	rate := (*last.BeerTemperature - *first.BeerTemperature) / last.Time.Sub(first.Time).Hours()
+	if math.Abs(rate) <= r.Rate {
+		return nil
+	}
+
+	direction := "rising"
+	if rate < 0 {
+		direction = "falling"
+	}
+
+	return []Violation{{
+		Subject: BeerTemperatureSubject,
+		Message: fmt.Sprintf("Beer temperature is %s at %.2f°C per hour", direction, math.Abs(rate)),
+	}}
+}
+
+// SensorMissingRule is violated when a configured sensor has not produced a reading for Duration.
+type SensorMissingRule struct {
+	Duration time.Duration
+}
+
+func (r *SensorMissingRule) Type() RuleType { return SensorMissingRuleType }
+
+func (r *SensorMissingRule) Window() time.Duration { return r.Duration + coverageMargin }
+
+func (r *SensorMissingRule) Evaluate(c *chamber.Chamber, readings []*chamber.Reading, now time.Time) []Violation {
+	cutoff := now.Add(-r.Duration)
+
+	// the chamber must have been recording readings since before the cutoff
+	if len(readings) == 0 || readings[0].Time.After(cutoff) {
+		return nil
+	}
+
+	sensors := []struct {
+		subject string
+		name    string
+		id      string
+		value   func(*chamber.Reading) *float64
+	}{
+		{
+			subject: BeerTemperatureSubject, name: "Beer thermometer", id: c.DeviceConfig.BeerThermometerID,
+			value: func(r *chamber.Reading) *float64 { return r.BeerTemperature },
+		},
+		{
+			subject: AuxiliaryTemperatureSubject, name: "Auxiliary thermometer",
+			id:    c.DeviceConfig.AuxiliaryThermometerID,
+			value: func(r *chamber.Reading) *float64 { return r.AuxiliaryTemperature },
+		},
+		{
+			subject: ExternalTemperatureSubject, name: "External thermometer", id: c.DeviceConfig.ExternalThermometerID,
+			value: func(r *chamber.Reading) *float64 { return r.ExternalTemperature },
+		},
+		{
+			subject: HydrometerGravitySubject, name: "Hydrometer", id: c.DeviceConfig.HydrometerID,
+			value: func(r *chamber.Reading) *float64 { return r.HydrometerGravity },
+		},
+	}
+
+	var violations []Violation
+
+	for _, sensor := range sensors {
+		if sensor.id == "" || hasValueSince(readings, cutoff, sensor.value) {
+			continue
+		}
+
+		violations = append(violations, Violation{
+			Subject: sensor.subject,
+			Message: fmt.Sprintf("%s %s has not reported a reading for %s", sensor.name, sensor.id,
+				formatDuration(r.Duration)),
+		})
+	}
+
+	return violations
+}
+
+func hasValueSince(readings []*chamber.Reading, cutoff time.Time, value func(*chamber.Reading) *float64) bool {
+	for i := len(readings) - 1; i >= 0 && !readings[i].Time.Before(cutoff); i-- {
+		if value(readings[i]) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GravityStalledRule is violated when the hydrometer gravity has changed by less than Tolerance over Duration and
+// the current batch has not reached its final gravity.
+type GravityStalledRule struct {
+	Duration  time.Duration
+	Tolerance float64
+}
+
+func (r *GravityStalledRule) Type() RuleType { return GravityStalledRuleType }
+
+func (r *GravityStalledRule) Window() time.Duration { return r.Duration + coverageMargin }
+
+func (r *GravityStalledRule) Evaluate(c *chamber.Chamber, readings []*chamber.Reading, now time.Time) []Violation {
+	cutoff := now.Add(-r.Duration)
+
+	var (
+		baseline *float64
+		current  *float64
+		lowest   = math.Inf(1)
+		highest  = math.Inf(-1)
+	)
+
+	for _, reading := range readings {
+		if reading.HydrometerGravity == nil {
+			continue
+		}
+
+		if reading.Time.Before(cutoff) {
+			baseline = reading.HydrometerGravity
+
+			continue
+		}
+
+		current = reading.HydrometerGravity
+		lowest = math.Min(lowest, *current)
+		highest = math.Max(highest, *current)
+	}
+
+	// the gravity must have been recorded since before the cutoff
+	if baseline == nil || current == nil {
+		return nil
+	}
+
+	lowest = math.Min(lowest, *baseline)
+	highest = math.Max(highest, *baseline)
+
+	if highest-lowest >= r.Tolerance {
+		return nil
+	}
+
+	if c.CurrentBatch != nil && c.CurrentBatch.Recipe.FinalGravity > 0 &&
+		*current <= c.CurrentBatch.Recipe.FinalGravity+r.Tolerance {
+		return nil
+	}
+
+	return []Violation{{
+		Subject: HydrometerGravitySubject,
+		Message: fmt.Sprintf("Gravity has stalled at %.3f for %s", *current, formatDuration(r.Duration)),
+	}}
+}
+
+func formatDuration(d time.Duration) string {
+	const day = 24 * time.Hour
+
+	switch {
+	case d >= day && d%day == 0:
+		return fmt.Sprintf("%d days", d/day)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
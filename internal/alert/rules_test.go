@@ -0,0 +1,280 @@
+package alert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func createTestChamber() *chamber.Chamber {
+	return &chamber.Chamber{
+		ID:   "96f58a65-03c0-49f3-83ca-ab751bbf3768",
+		Name: "Chamber 1",
+		DeviceConfig: chamber.DeviceConfig{
+			BeerThermometerType: "ds18b20",
+			BeerThermometerID:   "28-0000071cbc72",
+			HydrometerType:      "tilt",
+			HydrometerID:        "orange",
+		},
+		CurrentBatch: &batch.Detail{
+			Recipe: batch.Recipe{FinalGravity: 1.010},
+		},
+	}
+}
+
+func TestThresholdRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		readings []*chamber.Reading
+		message  string
+	}{
+		{
+			name: "withinThreshold",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-time.Minute), BeerTemperature: float(22.9), SetPoint: float(20)},
+			},
+		},
+		{
+			name: "above",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-time.Minute), BeerTemperature: float(23.5), SetPoint: float(20)},
+			},
+			message: "Beer temperature 23.50°C is 3.50°C above the set point of 20.00°C",
+		},
+		{
+			name: "below",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-time.Minute), BeerTemperature: float(16), SetPoint: float(20)},
+			},
+			message: "Beer temperature 16.00°C is 4.00°C below the set point of 20.00°C",
+		},
+		{
+			name: "latestReadingWithinThreshold",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-2 * time.Minute), BeerTemperature: float(24), SetPoint: float(20)},
+				{Time: now.Add(-time.Minute), BeerTemperature: float(21), SetPoint: float(20)},
+				{Time: now, SetPoint: float(20)},
+			},
+		},
+		{name: "noReadings"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := &alert.ThresholdRule{Threshold: 3}
+			assertViolation(t, rule.Evaluate(createTestChamber(), tc.readings, now), alert.BeerTemperatureSubject,
+				tc.message)
+		})
+	}
+}
+
+func TestRateOfChangeRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		readings []*chamber.Reading
+		message  string
+	}{
+		{
+			name: "slow",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-time.Hour), BeerTemperature: float(20)},
+				{Time: now, BeerTemperature: float(20.5)},
+			},
+		},
+		{
+			name: "rising",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-30 * time.Minute), BeerTemperature: float(20)},
+				{Time: now.Add(-15 * time.Minute)},
+				{Time: now, BeerTemperature: float(21.5)},
+			},
+			message: "Beer temperature is rising at 3.00°C per hour",
+		},
+		{
+			name: "falling",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-time.Hour), BeerTemperature: float(20)},
+				{Time: now, BeerTemperature: float(17)},
+			},
+			message: "Beer temperature is falling at 3.00°C per hour",
+		},
+		{
+			name: "periodTooShort",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-5 * time.Minute), BeerTemperature: float(20)},
+				{Time: now, BeerTemperature: float(23)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := &alert.RateOfChangeRule{Rate: 2}
+			assertViolation(t, rule.Evaluate(createTestChamber(), tc.readings, now), alert.BeerTemperatureSubject,
+				tc.message)
+		})
+	}
+}
+
+func TestSensorMissingRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		readings []*chamber.Reading
+		subject  string
+		message  string
+	}{
+		{
+			name: "allPresent",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-45 * time.Minute), BeerTemperature: float(20), HydrometerGravity: float(1.050)},
+				{Time: now.Add(-5 * time.Minute), BeerTemperature: float(20), HydrometerGravity: float(1.050)},
+			},
+		},
+		{
+			name: "hydrometerMissing",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-45 * time.Minute), BeerTemperature: float(20), HydrometerGravity: float(1.050)},
+				{Time: now.Add(-29 * time.Minute), BeerTemperature: float(20)},
+				{Time: now.Add(-5 * time.Minute), BeerTemperature: float(20)},
+			},
+			subject: alert.HydrometerGravitySubject,
+			message: "Hydrometer orange has not reported a reading for 30 minutes",
+		},
+		{
+			name: "beerThermometerMissing",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-31 * time.Minute), HydrometerGravity: float(1.050)},
+				{Time: now.Add(-5 * time.Minute), HydrometerGravity: float(1.050)},
+			},
+			subject: alert.BeerTemperatureSubject,
+			message: "Beer thermometer 28-0000071cbc72 has not reported a reading for 30 minutes",
+		},
+		{
+			name: "notRecordingLongEnough",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-5 * time.Minute)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := &alert.SensorMissingRule{Duration: 30 * time.Minute}
+			assertViolation(t, rule.Evaluate(createTestChamber(), tc.readings, now), tc.subject, tc.message)
+		})
+	}
+}
+
+func TestGravityStalledRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		readings []*chamber.Reading
+		message  string
+	}{
+		{
+			name: "fermenting",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-50 * time.Hour), HydrometerGravity: float(1.030)},
+				{Time: now.Add(-24 * time.Hour), HydrometerGravity: float(1.025)},
+				{Time: now, HydrometerGravity: float(1.020)},
+			},
+		},
+		{
+			name: "stalled",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-49 * time.Hour), HydrometerGravity: float(1.020)},
+				{Time: now.Add(-24 * time.Hour), HydrometerGravity: float(1.0205)},
+				{Time: now, HydrometerGravity: float(1.020)},
+			},
+			message: "Gravity has stalled at 1.020 for 2 days",
+		},
+		{
+			name: "finished",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-49 * time.Hour), HydrometerGravity: float(1.010)},
+				{Time: now, HydrometerGravity: float(1.010)},
+			},
+		},
+		{
+			name: "notRecordingLongEnough",
+			readings: []*chamber.Reading{
+				{Time: now.Add(-47 * time.Hour), HydrometerGravity: float(1.020)},
+				{Time: now, HydrometerGravity: float(1.020)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := &alert.GravityStalledRule{Duration: 48 * time.Hour, Tolerance: 0.001}
+			assertViolation(t, rule.Evaluate(createTestChamber(), tc.readings, now), alert.HydrometerGravitySubject,
+				tc.message)
+		})
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, alert.NewRules(alert.Settings{}))
+
+	rules := alert.NewRules(alert.Settings{
+		TemperatureThreshold:    3,
+		TemperatureRateOfChange: 2,
+		SensorMissingMinutes:    30,
+		GravityStalledDays:      3,
+	})
+
+	assert.Equal(t, []alert.Rule{
+		&alert.ThresholdRule{Threshold: 3},
+		&alert.RateOfChangeRule{Rate: 2},
+		&alert.SensorMissingRule{Duration: 30 * time.Minute},
+		&alert.GravityStalledRule{Duration: 72 * time.Hour, Tolerance: 0.001},
+	}, rules)
+}
+
+func assertViolation(t *testing.T, violations []alert.Violation, subject, message string) {
+	t.Helper()
+
+	if message == "" {
+		assert.Empty(t, violations)
+
+		return
+	}
+
+	assert.Equal(t, []alert.Violation{{Subject: subject, Message: message}}, violations)
+}
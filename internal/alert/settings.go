@@ -0,0 +1,83 @@
+package alert
+
+import "time"
+
+const defaultGravityStalledTolerance = 0.001
+
+// Settings configures the rules that are evaluated and the notifiers that alerts are sent to. A rule is disabled when
+// its value is zero and a notifier is disabled when its URL or host is empty.
+type Settings struct {
+	// TemperatureThreshold is how far, in degrees, the beer temperature can drift from the set point.
+	TemperatureThreshold float64 `json:"temperatureThreshold,omitempty"`
+	// TemperatureRateOfChange is how fast, in degrees per hour, the beer temperature can change.
+	TemperatureRateOfChange float64 `json:"temperatureRateOfChange,omitempty"`
+	// SensorMissingMinutes is how long a configured sensor can go without reporting a reading.
+	SensorMissingMinutes int `json:"sensorMissingMinutes,omitempty"`
+	// GravityStalledDays is how long the gravity can go unchanged before the fermentation is considered stalled.
+	GravityStalledDays int `json:"gravityStalledDays,omitempty"`
+	// GravityStalledTolerance is the change in gravity that is considered unchanged. It defaults to 0.001.
+	GravityStalledTolerance float64 `json:"gravityStalledTolerance,omitempty"`
+	// RepeatMinutes is how often a firing alert that has not been acknowledged is sent again. Alerts are only sent
+	// once if it is zero.
+	RepeatMinutes int      `json:"repeatMinutes,omitempty"`
+	WebhookURL    string   `json:"webhookUrl,omitempty"`
+	NtfyURL       string   `json:"ntfyUrl,omitempty"`
+	NtfyToken     string   `json:"ntfyToken,omitempty"`
+	SMTPHost      string   `json:"smtpHost,omitempty"`
+	SMTPPort      int      `json:"smtpPort,omitempty"`
+	SMTPUsername  string   `json:"smtpUsername,omitempty"`
+	SMTPPassword  string   `json:"smtpPassword,omitempty"`
+	SMTPFrom      string   `json:"smtpFrom,omitempty"`
+	SMTPTo        []string `json:"smtpTo,omitempty"`
+}
+
+// NewRules returns the rules that are enabled in the given settings.
+func NewRules(s Settings) []Rule {
+	var rules []Rule
+
+	if s.TemperatureThreshold > 0 {
+		rules = append(rules, &ThresholdRule{Threshold: s.TemperatureThreshold})
+	}
+
+	if s.TemperatureRateOfChange > 0 {
+		rules = append(rules, &RateOfChangeRule{Rate: s.TemperatureRateOfChange})
+	}
+
+	if s.SensorMissingMinutes > 0 {
+		rules = append(rules, &SensorMissingRule{Duration: time.Duration(s.SensorMissingMinutes) * time.Minute})
+	}
+
+	if s.GravityStalledDays > 0 {
+		tolerance := s.GravityStalledTolerance
+		if tolerance <= 0 {
+			tolerance = defaultGravityStalledTolerance
+		}
+
+		rules = append(rules, &GravityStalledRule{
+			Duration:  time.Duration(s.GravityStalledDays) * 24 * time.Hour, //nolint:gomnd // hours in a day
+			Tolerance: tolerance,
+		})
+	}
+
+	return rules
+}
+
+// NewNotifiers returns the notifiers that are enabled in the given settings.
+func NewNotifiers(s Settings) []Notifier {
+	var notifiers []Notifier
+
+	if s.WebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(s.WebhookURL))
+	}
+
+	if s.NtfyURL != "" {
+		notifiers = append(notifiers, NewNtfyNotifier(s.NtfyURL, s.NtfyToken))
+	}
+
+	if s.SMTPHost != "" && len(s.SMTPTo) > 0 {
+		notifiers = append(notifiers, NewSMTPNotifier(s.SMTPHost, s.SMTPPort, s.SMTPUsername, s.SMTPPassword,
+			s.SMTPFrom, s.SMTPTo))
+	}
+
+	return notifiers
+}
@@ -0,0 +1,74 @@
+// Package audit records who did what, and from where, to zymurgauge.
+package audit
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LoginAction               Action = "login"
+	LoginFailedAction         Action = "loginFailed"
+	LoginThrottledAction      Action = "loginThrottled"
+	LogoutAction              Action = "logout"
+	CredentialsUpdatedAction  Action = "credentialsUpdated"
+	SecretRotatedAction       Action = "secretRotated"
+	SettingsSavedAction       Action = "settingsSaved"
+	ChamberSavedAction        Action = "chamberSaved"
+	ChamberDeletedAction      Action = "chamberDeleted"
+	BatchImportedAction       Action = "batchImported"
+	FermentationStartedAction Action = "fermentationStarted"
+	FermentationStoppedAction Action = "fermentationStopped"
+	AutoTuneStartedAction     Action = "autoTuneStarted"
+	AutoTuneStoppedAction     Action = "autoTuneStopped"
+)
+
+// Action is something a user did that is recorded in the audit log.
+type Action string
+
+// Entry is a record of an action in the audit log. Username is the user that took the action, or tried to log in as,
+// and Target is the ID of what the action was taken on, such as a chamber.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Action   Action    `json:"action"`
+	Username string    `json:"username,omitempty"`
+	SourceIP string    `json:"sourceIp,omitempty"`
+	Target   string    `json:"target,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+// Repo persists the audit log.
+type Repo interface {
+	// GetAll returns the entries recorded from, inclusive, to, exclusive, in chronological order.
+	GetAll(from, to time.Time) ([]*Entry, error)
+	Save(e *Entry) error
+}
+
+// Recorder adds entries to the audit log. A nil Recorder records nothing.
+type Recorder struct {
+	repo   Repo
+	logger *logrus.Logger
+}
+
+// NewRecorder creates a new Recorder that saves entries to the given repository.
+func NewRecorder(repo Repo, logger *logrus.Logger) *Recorder {
+	return &Recorder{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Record adds an entry to the audit log at the current time. Failing to save it is logged rather than returned, since
+// the action being recorded has already been taken.
+func (r *Recorder) Record(e *Entry) {
+	if r == nil {
+		return
+	}
+
+	e.Time = time.Now().UTC()
+
+	if err := r.repo.Save(e); err != nil {
+		r.logger.WithError(err).Errorf("could not record %s action of user '%s' in audit log", e.Action, e.Username)
+	}
+}
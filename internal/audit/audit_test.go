@@ -0,0 +1,64 @@
+package audit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestRecord(t *testing.T) {
+	t.Parallel()
+	t.Run("recordSetsTime", recordSetsTime)
+	t.Run("recordSaveError", recordSaveError)
+	t.Run("recordNilRecorder", recordNilRecorder)
+}
+
+func recordSetsTime(t *testing.T) {
+	t.Parallel()
+
+	l, hook := logtest.NewNullLogger()
+
+	auditMock := &mocks.AuditRepo{}
+	auditMock.On("Save", mock.Anything).Return(nil)
+
+	e := &audit.Entry{Action: audit.LoginAction, Username: "brewer", SourceIP: "10.0.0.1"}
+
+	audit.NewRecorder(auditMock, l).Record(e)
+
+	auditMock.AssertCalled(t, "Save", e)
+	assert.WithinDuration(t, time.Now(), e.Time, time.Second)
+	assert.Equal(t, time.UTC, e.Time.Location())
+	assert.Empty(t, hook.AllEntries())
+}
+
+func recordSaveError(t *testing.T) {
+	t.Parallel()
+
+	l, hook := logtest.NewNullLogger()
+
+	auditMock := &mocks.AuditRepo{}
+	auditMock.On("Save", mock.Anything).Return(errors.New("saveError"))
+
+	audit.NewRecorder(auditMock, l).Record(&audit.Entry{Action: audit.LogoutAction, Username: "brewer"})
+
+	assert.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+	assert.Equal(t, "could not record logout action of user 'brewer' in audit log", hook.LastEntry().Message)
+	assert.EqualError(t, hook.LastEntry().Data[logrus.ErrorKey].(error), "saveError")
+}
+
+func recordNilRecorder(t *testing.T) {
+	t.Parallel()
+
+	var recorder *audit.Recorder
+
+	assert.NotPanics(t, func() { recorder.Record(&audit.Entry{Action: audit.LoginAction}) })
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// APITokenPrefix is the prefix of all API tokens. It distinguishes them from JWTs.
+const APITokenPrefix = "zym_"
+
+const tokenSecretLength = 32
+
+// Scope determines what an API token is allowed to do.
+type Scope string
+
+const (
+	// ReadOnlyScope allows the same as the viewer role.
+	ReadOnlyScope Scope = "read-only"
+	// ControlScope allows the same as the brewer role.
+	ControlScope Scope = "control"
+	// AdminScope allows the same as the admin role.
+	AdminScope Scope = "admin"
+)
+
+var scopeRoles = map[Scope]Role{
+	ReadOnlyScope: ViewerRole,
+	ControlScope:  BrewerRole,
+	AdminScope:    AdminRole,
+}
+
+// IsValid reports whether s is a known scope.
+func (s Scope) IsValid() bool {
+	_, ok := scopeRoles[s]
+
+	return ok
+}
+
+// Role returns the role that s allows the same as.
+func (s Scope) Role() Role {
+	return scopeRoles[s]
+}
+
+// APIToken is a long-lived token for scripts and integrations. Hash holds the SHA-256 hash of the token, which itself
+// is only returned once when the token is created.
+type APIToken struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Scope        Scope      `json:"scope"`
+	Hash         string     `json:"hash,omitempty"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedTime  time.Time  `json:"createdTime"`
+	LastUsedTime *time.Time `json:"lastUsedTime,omitempty"`
+}
+
+// NewAPIToken creates a new APIToken and returns it along with the token itself.
+func NewAPIToken(name string, scope Scope, createdBy string) (*APIToken, string, error) {
+	secret, err := newTokenSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	t := &APIToken{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Scope:       scope,
+		CreatedBy:   createdBy,
+		CreatedTime: time.Now().UTC(),
+	}
+
+	token := APITokenPrefix + t.ID + "_" + secret
+	t.Hash = hashToken(token)
+
+	return t, token, nil
+}
+
+// Check reports whether the given token matches the hash of t.
+func (t *APIToken) Check(token string) bool {
+	return checkTokenHash(t.Hash, token)
+}
+
+// IsAPIToken reports whether the given token is an API token rather than a JWT.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// ParseAPITokenID returns the ID of the APIToken that the given token belongs to.
+func ParseAPITokenID(token string) (string, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, APITokenPrefix), "_")
+	if !IsAPIToken(token) || !ok || id == "" || secret == "" {
+		return "", ErrInvalidToken
+	}
+
+	return id, nil
+}
+
+// newTokenSecret returns the random part of an API or refresh token.
+func newTokenSecret() (string, error) {
+	secret := make([]byte, tokenSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "could not generate secret")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashToken returns the hash of an API or refresh token that is stored in its place.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func checkTokenHash(hash, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(token))) == 1
+}
+
+// APITokenRepo persists API tokens.
+type APITokenRepo interface {
+	GetAll() ([]*APIToken, error)
+	Get(id string) (*APIToken, error)
+	Save(t *APIToken) error
+	Delete(id string) error
+	SetLastUsedTime(id string, lastUsedTime time.Time) error
+}
@@ -0,0 +1,67 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeRole(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, auth.ViewerRole, auth.ReadOnlyScope.Role())
+	assert.Equal(t, auth.BrewerRole, auth.ControlScope.Role())
+	assert.Equal(t, auth.AdminRole, auth.AdminScope.Role())
+	assert.True(t, auth.ControlScope.IsValid())
+	assert.False(t, auth.Scope("write").IsValid())
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestAPIToken(t *testing.T) {
+	t.Parallel()
+	t.Run("newAPIToken", newAPIToken)
+	t.Run("checkAPITokenWrongToken", checkAPITokenWrongToken)
+	t.Run("parseAPITokenIDInvalid", parseAPITokenIDInvalid)
+}
+
+func newAPIToken(t *testing.T) {
+	t.Parallel()
+
+	apiToken, token, err := auth.NewAPIToken("grafana", auth.ReadOnlyScope, "admin")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, apiToken.ID)
+	assert.Equal(t, "grafana", apiToken.Name)
+	assert.Equal(t, auth.ReadOnlyScope, apiToken.Scope)
+	assert.Equal(t, "admin", apiToken.CreatedBy)
+	assert.False(t, apiToken.CreatedTime.IsZero())
+	assert.Nil(t, apiToken.LastUsedTime)
+	assert.NotContains(t, apiToken.Hash, token)
+
+	assert.True(t, auth.IsAPIToken(token))
+	assert.True(t, apiToken.Check(token))
+
+	id, err := auth.ParseAPITokenID(token)
+	assert.NoError(t, err)
+	assert.Equal(t, apiToken.ID, id)
+}
+
+func checkAPITokenWrongToken(t *testing.T) {
+	t.Parallel()
+
+	apiToken, token, err := auth.NewAPIToken("grafana", auth.ReadOnlyScope, "admin")
+	assert.NoError(t, err)
+
+	assert.False(t, apiToken.Check(token+"x"))
+	assert.False(t, apiToken.Check(strings.TrimSuffix(token, token[len(token)-1:])))
+}
+
+func parseAPITokenIDInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, token := range []string{"eyJhbGciOiJIUzI1NiJ9.e30.x", "zym_", "zym_id", "zym_id_", "zym__secret"} {
+		_, err := auth.ParseAPITokenID(token)
+		assert.ErrorIs(t, err, auth.ErrInvalidToken, token)
+	}
+}
@@ -1,27 +1,65 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"math/big"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
-var ErrInvalidToken = errors.New("token is invalid")
+const (
+	secretLetters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	secretLength  = 64
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrRevokedToken = errors.New("token has been revoked")
+)
 
 type Claims struct {
+	// UserID is the ID of the user the token was issued to. Unlike the username, it does not change. It is empty for
+	// API tokens.
+	UserID   string `json:"uid,omitempty"`
 	Username string `json:"username"`
+	Role     Role   `json:"role,omitempty"`
+	// SessionID is the ID of the session the token was issued for. It is empty for tokens that can not be refreshed.
+	SessionID string `json:"sid,omitempty"`
+	// APITokenID is the ID of the API token the claims belong to. It is empty for JWTs.
+	APITokenID string `json:"-"`
 	jwt.StandardClaims
 }
 
-func CreateToken(secret, username string, expiresIn time.Duration) (string, error) {
-	claims := &Claims{
-		Username: username,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(expiresIn).Unix(),
-		},
+// GetRole returns the role of the claims. Tokens issued before users had roles belong to the admin.
+func (c *Claims) GetRole() Role {
+	if c.Role == "" {
+		return AdminRole
+	}
+
+	return c.Role
+}
+
+// GetExpiresTime returns the time the token of the claims expires.
+func (c *Claims) GetExpiresTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// CreateToken returns a signed token for the given claims that expires after expiresIn. The ID, issue and expiry times
+// of the claims are set.
+func CreateToken(secret string, claims *Claims, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+
+	if claims.Id == "" {
+		claims.Id = uuid.NewString()
 	}
 
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(expiresIn).Unix()
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	tokenString, err := token.SignedString([]byte(secret))
@@ -32,19 +70,74 @@ func CreateToken(secret, username string, expiresIn time.Duration) (string, erro
 	return tokenString, nil
 }
 
-func IsAuthorized(secret, tokenString string) (bool, error) {
+// ParseToken returns the claims of the given token if it is valid.
+func ParseToken(secret, tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
 	})
 	if err != nil {
-		return false, errors.Wrap(err, "could not parse claims")
+		return nil, errors.Wrap(err, "could not parse claims")
 	}
 
 	if !token.Valid {
-		return false, ErrInvalidToken
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// IsAuthorized returns the claims of the given token if it is valid and has not been revoked. Tokens without an ID
+// were issued before tokens could be revoked and are no longer accepted.
+func IsAuthorized(secret, tokenString string, denylist Denylist) (*Claims, error) {
+	claims, err := ParseToken(secret, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Id == "" {
+		return nil, ErrInvalidToken
 	}
 
-	return true, nil
+	revoked, err := denylist.IsRevoked(claims.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not check if token is revoked")
+	}
+
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+
+	return claims, nil
+}
+
+// NewSecret returns a new random secret to sign tokens with.
+func NewSecret() (string, error) {
+	b := make([]byte, secretLength)
+
+	for i := range b {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(secretLetters))))
+		if err != nil {
+			return "", errors.Wrap(err, "could not generate random number")
+		}
+
+		b[i] = secretLetters[num.Int64()]
+	}
+
+	return string(b), nil
+}
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx that carries the given claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims carried by ctx, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+
+	return claims, ok
 }
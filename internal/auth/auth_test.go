@@ -0,0 +1,154 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "my-auth-secret"
+
+func TestRoleAllows(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, auth.AdminRole.Allows(auth.BrewerRole))
+	assert.True(t, auth.BrewerRole.Allows(auth.BrewerRole))
+	assert.True(t, auth.BrewerRole.Allows(auth.ViewerRole))
+	assert.False(t, auth.ViewerRole.Allows(auth.BrewerRole))
+	assert.False(t, auth.BrewerRole.Allows(auth.AdminRole))
+	assert.False(t, auth.Role("owner").Allows(auth.ViewerRole))
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestToken(t *testing.T) {
+	t.Parallel()
+	t.Run("parseTokenWithRole", parseTokenWithRole)
+	t.Run("parseTokenWithoutRole", parseTokenWithoutRole)
+	t.Run("parseTokenWrongSecret", parseTokenWrongSecret)
+	t.Run("parseTokenExpired", parseTokenExpired)
+	t.Run("isAuthorized", isAuthorized)
+	t.Run("isAuthorizedRevoked", isAuthorizedRevoked)
+	t.Run("isAuthorizedWithoutID", isAuthorizedWithoutID)
+}
+
+type denylist map[string]time.Time
+
+func (d denylist) Revoke(tokenID string, expiresTime time.Time) error {
+	d[tokenID] = expiresTime
+
+	return nil
+}
+
+func (d denylist) IsRevoked(tokenID string) (bool, error) {
+	_, ok := d[tokenID]
+
+	return ok, nil
+}
+
+func parseTokenWithRole(t *testing.T) {
+	t.Parallel()
+
+	token, err := auth.CreateToken(secret, &auth.Claims{Username: "brewer", Role: auth.BrewerRole}, time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := auth.ParseToken(secret, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "brewer", claims.Username)
+	assert.Equal(t, auth.BrewerRole, claims.GetRole())
+	assert.NotEmpty(t, claims.Id)
+
+	ctx := auth.NewContext(context.Background(), claims)
+	result, ok := auth.FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, claims, result)
+}
+
+func parseTokenWithoutRole(t *testing.T) {
+	t.Parallel()
+
+	token, err := auth.CreateToken(secret, &auth.Claims{Username: "admin"}, time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := auth.ParseToken(secret, token)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.AdminRole, claims.GetRole())
+}
+
+func parseTokenWrongSecret(t *testing.T) {
+	t.Parallel()
+
+	token, err := auth.CreateToken(secret, &auth.Claims{Username: "brewer", Role: auth.BrewerRole}, time.Minute)
+	assert.NoError(t, err)
+
+	_, err = auth.ParseToken("other-secret", token)
+	assert.Error(t, err)
+}
+
+func parseTokenExpired(t *testing.T) {
+	t.Parallel()
+
+	token, err := auth.CreateToken(secret, &auth.Claims{Username: "brewer", Role: auth.BrewerRole}, -time.Minute)
+	assert.NoError(t, err)
+
+	_, err = auth.ParseToken(secret, token)
+	assert.Error(t, err)
+}
+
+func isAuthorized(t *testing.T) {
+	t.Parallel()
+
+	token, err := auth.CreateToken(secret, &auth.Claims{Username: "brewer", Role: auth.BrewerRole, SessionID: "session"},
+		time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := auth.IsAuthorized(secret, token, denylist{})
+	assert.NoError(t, err)
+	assert.Equal(t, "brewer", claims.Username)
+	assert.Equal(t, "session", claims.SessionID)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), claims.GetExpiresTime(), time.Second)
+}
+
+func isAuthorizedRevoked(t *testing.T) {
+	t.Parallel()
+
+	claims := &auth.Claims{Username: "brewer", Role: auth.BrewerRole}
+
+	token, err := auth.CreateToken(secret, claims, time.Minute)
+	assert.NoError(t, err)
+
+	d := denylist{}
+	err = d.Revoke(claims.Id, claims.GetExpiresTime())
+	assert.NoError(t, err)
+
+	_, err = auth.IsAuthorized(secret, token, d)
+	assert.ErrorIs(t, err, auth.ErrRevokedToken)
+}
+
+func isAuthorizedWithoutID(t *testing.T) {
+	t.Parallel()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+		Username:       "admin",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()},
+	}).SignedString([]byte(secret))
+	assert.NoError(t, err)
+
+	_, err = auth.IsAuthorized(secret, token, denylist{})
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestNewSecret(t *testing.T) {
+	t.Parallel()
+
+	s1, err := auth.NewSecret()
+	assert.NoError(t, err)
+	assert.Len(t, s1, 64)
+
+	s2, err := auth.NewSecret()
+	assert.NoError(t, err)
+	assert.NotEqual(t, s1, s2)
+}
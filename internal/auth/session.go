@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login of a user that can be kept alive with refresh tokens. Each refresh token can only be used once,
+// after which it is replaced by a new one. Hash holds the SHA-256 hash of the current refresh token.
+type Session struct {
+	ID                string    `json:"id"`
+	UserID            string    `json:"userId"`
+	Hash              string    `json:"hash"`
+	AccessTokenID     string    `json:"accessTokenId"`
+	AccessExpiresTime time.Time `json:"accessExpiresTime"`
+	CreatedTime       time.Time `json:"createdTime"`
+	ExpiresTime       time.Time `json:"expiresTime"`
+}
+
+// NewSession creates a new Session for the user with the given ID.
+func NewSession(userID string) *Session {
+	return &Session{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		CreatedTime: time.Now().UTC(),
+	}
+}
+
+// Rotate replaces the refresh token of s with a new one that expires after expiresIn and returns it.
+func (s *Session) Rotate(expiresIn time.Duration) (string, error) {
+	secret, err := newTokenSecret()
+	if err != nil {
+		return "", err
+	}
+
+	token := s.ID + "." + secret
+	s.Hash = hashToken(token)
+	s.ExpiresTime = time.Now().Add(expiresIn).UTC()
+
+	return token, nil
+}
+
+// SetAccessToken records the access token that was last issued for s, so that it can be revoked with s.
+func (s *Session) SetAccessToken(claims *Claims) {
+	s.AccessTokenID = claims.Id
+	s.AccessExpiresTime = claims.GetExpiresTime().UTC()
+}
+
+// Check reports whether the given refresh token is the current refresh token of s.
+func (s *Session) Check(refreshToken string) bool {
+	return checkTokenHash(s.Hash, refreshToken)
+}
+
+// IsExpired reports whether the refresh token of s has expired.
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresTime)
+}
+
+// ParseSessionID returns the ID of the Session that the given refresh token belongs to.
+func ParseSessionID(refreshToken string) (string, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || id == "" || secret == "" {
+		return "", ErrInvalidToken
+	}
+
+	return id, nil
+}
+
+// Denylist holds the IDs of revoked tokens until they expire.
+type Denylist interface {
+	Revoke(tokenID string, expiresTime time.Time) error
+	IsRevoked(tokenID string) (bool, error)
+}
+
+// SessionRepo persists sessions and the IDs of revoked access tokens.
+type SessionRepo interface {
+	Denylist
+	Get(id string) (*Session, error)
+	Save(s *Session) error
+	Delete(id string) error
+	// DeleteByUser deletes all sessions of a user and revokes the access tokens that were last issued for them.
+	DeleteByUser(userID string) error
+	DeleteAll() error
+}
@@ -0,0 +1,57 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSession(t *testing.T) {
+	t.Parallel()
+	t.Run("rotateSession", rotateSession)
+	t.Run("expiredSession", expiredSession)
+	t.Run("parseSessionIDInvalid", parseSessionIDInvalid)
+}
+
+func rotateSession(t *testing.T) {
+	t.Parallel()
+
+	s := auth.NewSession("user")
+
+	token1, err := s.Rotate(time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, s.Check(token1))
+	assert.False(t, s.IsExpired())
+
+	id, err := auth.ParseSessionID(token1)
+	assert.NoError(t, err)
+	assert.Equal(t, s.ID, id)
+
+	token2, err := s.Rotate(time.Hour)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token1, token2)
+	assert.True(t, s.Check(token2))
+	assert.False(t, s.Check(token1))
+}
+
+func expiredSession(t *testing.T) {
+	t.Parallel()
+
+	s := auth.NewSession("user")
+
+	_, err := s.Rotate(-time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, s.IsExpired())
+}
+
+func parseSessionIDInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, token := range []string{"", "session", ".secret", "session."} {
+		_, err := auth.ParseSessionID(token)
+		assert.ErrorIs(t, err, auth.ErrInvalidToken, token)
+	}
+}
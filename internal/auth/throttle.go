@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+)
+
+const (
+	userKeyPrefix = "user:"
+	ipKeyPrefix   = "ip:"
+	// defaultMaxThrottleEntries is how many usernames and source IPs failed logins are recorded for at most.
+	defaultMaxThrottleEntries = 10000
+)
+
+// LoginThrottle limits failed logins per username and per source IP. Once a username or source IP has failed to log
+// in too many times, logins for it are refused until the lockout period has passed since its last failure. Failures
+// older than the lockout period are forgotten, as are those of the username or source IP that failed longest ago once
+// failures are recorded for the maximum number of them.
+type LoginThrottle struct {
+	maxPerUser int
+	maxPerIP   int
+	maxEntries int
+	lockout    time.Duration
+	clock      clock.Clock
+	failures   map[string]*loginFailures
+	mu         sync.Mutex
+}
+
+type loginFailures struct {
+	count    int
+	lastTime time.Time
+}
+
+// NewLoginThrottle creates a new LoginThrottle that allows maxPerUser failed logins per username and maxPerIP failed
+// logins per source IP within the lockout period.
+func NewLoginThrottle(maxPerUser, maxPerIP int, lockout time.Duration, options ...ThrottleOptionsFunc) *LoginThrottle {
+	t := &LoginThrottle{
+		maxPerUser: maxPerUser,
+		maxPerIP:   maxPerIP,
+		maxEntries: defaultMaxThrottleEntries,
+		lockout:    lockout,
+		clock:      clock.NewRealClock(),
+		failures:   make(map[string]*loginFailures),
+	}
+
+	for _, option := range options {
+		option(t)
+	}
+
+	return t
+}
+
+type ThrottleOptionsFunc func(*LoginThrottle)
+
+func SetThrottleClock(clock clock.Clock) ThrottleOptionsFunc {
+	return func(t *LoginThrottle) {
+		t.clock = clock
+	}
+}
+
+// SetThrottleMaxEntries sets how many usernames and source IPs failed logins are recorded for at most.
+func SetThrottleMaxEntries(maxEntries int) ThrottleOptionsFunc {
+	return func(t *LoginThrottle) {
+		t.maxEntries = maxEntries
+	}
+}
+
+// Check returns how long logins for the given username from the given source IP are refused for. It returns zero if
+// they are allowed.
+func (t *LoginThrottle) Check(username, ip string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	userWait := t.wait(userKeyPrefix+username, t.maxPerUser)
+	ipWait := t.wait(ipKeyPrefix+ip, t.maxPerIP)
+
+	if userWait > ipWait {
+		return userWait
+	}
+
+	return ipWait
+}
+
+// Fail records a failed login for the given username from the given source IP.
+func (t *LoginThrottle) Fail(username, ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.removeExpired()
+
+	now := t.clock.Now()
+
+	for _, key := range []string{userKeyPrefix + username, ipKeyPrefix + ip} {
+		f, ok := t.failures[key]
+		if !ok {
+			if len(t.failures) >= t.maxEntries {
+				t.removeOldest()
+			}
+
+			f = &loginFailures{}
+			t.failures[key] = f
+		}
+
+		f.count++
+		f.lastTime = now
+	}
+}
+
+// Reset forgets the failed logins for the given username after it has logged in. The failed logins of the source IP
+// are kept, so that logging in to one account does not allow guessing the passwords of others.
+func (t *LoginThrottle) Reset(username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.failures, userKeyPrefix+username)
+}
+
+func (t *LoginThrottle) wait(key string, maxFailures int) time.Duration {
+	f, ok := t.failures[key]
+	if !ok || f.count < maxFailures {
+		return 0
+	}
+
+	if wait := t.lockout - t.clock.Since(f.lastTime); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+func (t *LoginThrottle) removeOldest() {
+	var (
+		oldestKey  string
+		oldestTime time.Time
+	)
+
+	for key, f := range t.failures {
+		if oldestKey == "" || f.lastTime.Before(oldestTime) {
+			oldestKey, oldestTime = key, f.lastTime
+		}
+	}
+
+	delete(t.failures, oldestKey)
+}
+
+func (t *LoginThrottle) removeExpired() {
+	for key, f := range t.failures {
+		if t.clock.Since(f.lastTime) >= t.lockout {
+			delete(t.failures, key)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	maxPerUser = 3
+	maxPerIP   = 5
+	lockout    = 15 * time.Minute
+)
+
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+func (c *manualClock) Since(t time.Time) time.Duration {
+	return c.now.Sub(t)
+}
+
+func (c *manualClock) NewTimer(d time.Duration) *time.Timer {
+	return time.NewTimer(d)
+}
+
+func (c *manualClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func createThrottle() (*auth.LoginThrottle, *manualClock) {
+	clk := &manualClock{now: time.Now()}
+
+	return auth.NewLoginThrottle(maxPerUser, maxPerIP, lockout, auth.SetThrottleClock(clk)), clk
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestLoginThrottle(t *testing.T) {
+	t.Parallel()
+	t.Run("throttleUser", throttleUser)
+	t.Run("throttleIP", throttleIP)
+	t.Run("throttleLockoutExpires", throttleLockoutExpires)
+	t.Run("throttleReset", throttleReset)
+	t.Run("throttleMaxEntries", throttleMaxEntries)
+}
+
+func throttleUser(t *testing.T) {
+	t.Parallel()
+
+	throttle, clk := createThrottle()
+
+	for i := 0; i < maxPerUser; i++ {
+		assert.Zero(t, throttle.Check("brewer", "10.0.0.1"))
+		throttle.Fail("brewer", "10.0.0.1")
+	}
+
+	clk.advance(time.Minute)
+
+	assert.Equal(t, lockout-time.Minute, throttle.Check("brewer", "10.0.0.1"))
+	assert.Equal(t, lockout-time.Minute, throttle.Check("brewer", "10.0.0.2"))
+	assert.Zero(t, throttle.Check("admin", "10.0.0.2"))
+}
+
+func throttleIP(t *testing.T) {
+	t.Parallel()
+
+	throttle, _ := createThrottle()
+
+	for i := 0; i < maxPerIP; i++ {
+		throttle.Fail(string(rune('a'+i)), "10.0.0.1")
+	}
+
+	assert.Equal(t, lockout, throttle.Check("admin", "10.0.0.1"))
+	assert.Zero(t, throttle.Check("admin", "10.0.0.2"))
+}
+
+func throttleLockoutExpires(t *testing.T) {
+	t.Parallel()
+
+	throttle, clk := createThrottle()
+
+	for i := 0; i < maxPerUser; i++ {
+		throttle.Fail("brewer", "10.0.0.1")
+	}
+
+	clk.advance(lockout)
+	assert.Zero(t, throttle.Check("brewer", "10.0.0.1"))
+
+	// Failures from before the lockout period are forgotten.
+	throttle.Fail("brewer", "10.0.0.1")
+	assert.Zero(t, throttle.Check("brewer", "10.0.0.1"))
+}
+
+func throttleReset(t *testing.T) {
+	t.Parallel()
+
+	throttle, _ := createThrottle()
+
+	for i := 0; i < maxPerUser; i++ {
+		throttle.Fail("brewer", "10.0.0.1")
+	}
+
+	throttle.Fail("admin", "10.0.0.1")
+	throttle.Fail("admin", "10.0.0.1")
+	throttle.Reset("brewer")
+
+	assert.Zero(t, throttle.Check("brewer", "10.0.0.2"))
+	assert.Equal(t, lockout, throttle.Check("brewer", "10.0.0.1"))
+}
+
+func throttleMaxEntries(t *testing.T) {
+	t.Parallel()
+
+	clk := &manualClock{now: time.Now()}
+	throttle := auth.NewLoginThrottle(maxPerUser, maxPerIP, lockout, auth.SetThrottleClock(clk),
+		auth.SetThrottleMaxEntries(4))
+
+	for i := 0; i < maxPerUser; i++ {
+		throttle.Fail("brewer", "10.0.0.1")
+	}
+
+	assert.Equal(t, lockout, throttle.Check("brewer", "10.0.0.2"))
+
+	// the failures of brewer and 10.0.0.1 are the oldest, so they are forgotten to make room for newer ones
+	clk.advance(time.Minute)
+	throttle.Fail("a", "10.0.0.2")
+	clk.advance(time.Minute)
+	throttle.Fail("b", "10.0.0.3")
+
+	assert.Zero(t, throttle.Check("brewer", "10.0.0.1"))
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role determines what a user is allowed to do. Each role is allowed to do everything the roles below it can.
+type Role string
+
+const (
+	// ViewerRole can view chambers, batches, readings and alerts.
+	ViewerRole Role = "viewer"
+	// BrewerRole can also start and stop fermentation, manage batches and acknowledge alerts.
+	BrewerRole Role = "brewer"
+	// AdminRole can also edit chambers, settings and users.
+	AdminRole Role = "admin"
+)
+
+// placeholderPasswordHash is a bcrypt hash with the default cost that no password is expected to match.
+const placeholderPasswordHash = "$2a$10$dzP.sRScI2U/AZBPQ0OFGudgXEaVnKbkxnDHekZx6cBAkJXTy28JK"
+
+var roleRanks = map[Role]int{
+	ViewerRole: 1,
+	BrewerRole: 2,
+	AdminRole:  3,
+}
+
+// IsValid reports whether r is a known role.
+func (r Role) IsValid() bool {
+	_, ok := roleRanks[r]
+
+	return ok
+}
+
+// Allows reports whether r is allowed to do what the given role can.
+func (r Role) Allows(role Role) bool {
+	return r.IsValid() && roleRanks[r] >= roleRanks[role]
+}
+
+// User is a user that can log in. Password holds the bcrypt hash of the password and is never returned by the API.
+type User struct {
+	ID       string    `json:"id"`
+	Username string    `json:"username"`
+	Password string    `json:"password,omitempty"`
+	Role     Role      `json:"role"`
+	ModTime  time.Time `json:"modTime"`
+}
+
+// SetPassword sets the password of the user to the hash of the given password.
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(err, "could not generate password hash")
+	}
+
+	u.Password = string(hash)
+
+	return nil
+}
+
+// CheckPassword reports whether the given password matches the password of the user. If u is nil the password is
+// checked against a placeholder hash, so that logging in as an unknown user takes as long as with a wrong password.
+func (u *User) CheckPassword(password string) bool {
+	if u == nil {
+		_ = bcrypt.CompareHashAndPassword([]byte(placeholderPasswordHash), []byte(password))
+
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+}
+
+// UserRepo persists users.
+type UserRepo interface {
+	GetAll() ([]*User, error)
+	Get(id string) (*User, error)
+	GetByUsername(username string) (*User, error)
+	Save(u *User) error
+	Delete(id string) error
+}
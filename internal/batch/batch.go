@@ -2,16 +2,40 @@ package batch
 
 import "github.com/benjaminbartels/zymurgauge/internal/brewfather"
 
+// Sources of a batch.
+const (
+	LocalSource      = "local"
+	BrewfatherSource = "brewfather"
+)
+
 type Summary struct {
 	ID         string `json:"id"`
 	Number     int    `json:"number"`
 	RecipeName string `json:"recipeName"`
+	Source     string `json:"source,omitempty"`
 }
 
 type Detail struct {
 	ID     string `json:"id"`
 	Number int    `json:"number"`
 	Recipe Recipe `json:"recipe"`
+	Source string `json:"source,omitempty"`
+}
+
+// Summarize returns the summary of the batch.
+func (d *Detail) Summarize() Summary {
+	return Summary{
+		ID:         d.ID,
+		Number:     d.Number,
+		RecipeName: d.Recipe.Name,
+		Source:     d.Source,
+	}
+}
+
+// IsFromBrewfather reports whether the batch was fetched from Brewfather. Batches attached to a chamber before
+// sources were recorded have no source and were always fetched from Brewfather.
+func (d *Detail) IsFromBrewfather() bool {
+	return d.Source == BrewfatherSource || d.Source == ""
 }
 
 type Recipe struct {
@@ -30,6 +54,9 @@ type FermentationStep struct {
 	Name        string  `json:"name"`
 	Temperature float64 `json:"temperature"`
 	Duration    int     `json:"duration"`
+	// RampRate is the rate, in degrees per hour, at which the set point moves to Temperature from the previous step's
+	// temperature. A RampRate of zero falls back to the chamber's ramp rate.
+	RampRate float64 `json:"rampRate,omitempty"`
 }
 
 func ConvertSummaries(batches []brewfather.BatchSummary) []Summary {
@@ -46,6 +73,7 @@ func convertSummary(b brewfather.BatchSummary) Summary {
 		ID:         b.ID,
 		Number:     b.BatchNo,
 		RecipeName: b.Recipe.Name,
+		Source:     BrewfatherSource,
 	}
 }
 
@@ -54,6 +82,7 @@ func ConvertDetail(b *brewfather.BatchDetail) *Detail {
 		ID:     b.ID,
 		Number: b.BatchNo,
 		Recipe: convertRecipe(b.Recipe),
+		Source: BrewfatherSource,
 	}
 }
 
@@ -0,0 +1,208 @@
+//nolint:tagliatelle // field names are defined by the BeerJSON schema
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	fahrenheitOffset = 32
+	fahrenheitScale  = 1.8
+	daysPerWeek      = 7
+	minutesPerDay    = 24 * 60
+	secondsPerDay    = minutesPerDay * 60
+)
+
+type beerJSONDocument struct {
+	BeerJSON *struct {
+		Recipes []beerJSONRecipe `json:"recipes"`
+	} `json:"beerjson"`
+}
+
+type beerJSONRecipe struct {
+	Name            string                `json:"name"`
+	OriginalGravity *beerJSONUnitValue    `json:"original_gravity"`
+	FinalGravity    *beerJSONUnitValue    `json:"final_gravity"`
+	Fermentation    *beerJSONFermentation `json:"fermentation"`
+}
+
+type beerJSONFermentation struct {
+	Name              string                     `json:"name"`
+	FermentationSteps []beerJSONFermentationStep `json:"fermentation_steps"`
+}
+
+type beerJSONFermentationStep struct {
+	Name             string             `json:"name"`
+	StartTemperature *beerJSONUnitValue `json:"start_temperature"`
+	EndTemperature   *beerJSONUnitValue `json:"end_temperature"`
+	StepTime         *beerJSONUnitValue `json:"step_time"`
+}
+
+type beerJSONUnitValue struct {
+	Unit  string  `json:"unit"`
+	Value float64 `json:"value"`
+}
+
+func importBeerJSON(data []byte) (*Detail, error) {
+	var doc beerJSONDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, importError("beerjson", "could not parse BeerJSON: %s", err)
+	}
+
+	if doc.BeerJSON == nil {
+		return nil, importError("beerjson", "is required")
+	}
+
+	if len(doc.BeerJSON.Recipes) == 0 {
+		return nil, importError("beerjson.recipes", "does not contain a recipe")
+	}
+
+	return doc.BeerJSON.Recipes[0].convert("beerjson.recipes[0]")
+}
+
+func (r *beerJSONRecipe) convert(path string) (*Detail, error) {
+	if strings.TrimSpace(r.Name) == "" {
+		return nil, importError(path+".name", "is required")
+	}
+
+	og, err := r.OriginalGravity.toSpecificGravity(path + ".original_gravity")
+	if err != nil {
+		return nil, err
+	}
+
+	fg, err := r.FinalGravity.toSpecificGravity(path + ".final_gravity")
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Fermentation == nil || len(r.Fermentation.FermentationSteps) == 0 {
+		return nil, importError(path+".fermentation.fermentation_steps", "does not contain a step")
+	}
+
+	steps := []FermentationStep{}
+
+	for i, s := range r.Fermentation.FermentationSteps {
+		step, err := s.convert(fmt.Sprintf("%s.fermentation.fermentation_steps[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, step)
+	}
+
+	return &Detail{
+		Recipe: Recipe{
+			Name:            strings.TrimSpace(r.Name),
+			Fermentation:    Fermentation{Name: r.Fermentation.Name, Steps: steps},
+			OriginalGravity: og,
+			FinalGravity:    fg,
+		},
+	}, nil
+}
+
+// convert returns the step's end temperature, or its start temperature if there is no end temperature. If the two
+// differ the step ramps between them over the step time.
+func (s *beerJSONFermentationStep) convert(path string) (FermentationStep, error) {
+	if strings.TrimSpace(s.Name) == "" {
+		return FermentationStep{}, importError(path+".name", "is required")
+	}
+
+	if s.StartTemperature == nil && s.EndTemperature == nil {
+		return FermentationStep{}, importError(path+".start_temperature", "is required")
+	}
+
+	step := FermentationStep{Name: strings.TrimSpace(s.Name)}
+
+	days, err := s.StepTime.toDays(path + ".step_time")
+	if err != nil {
+		return FermentationStep{}, err
+	}
+
+	step.Duration = toDays(days)
+
+	start, err := s.StartTemperature.toCelsius(path + ".start_temperature")
+	if err != nil {
+		return FermentationStep{}, err
+	}
+
+	end, err := s.EndTemperature.toCelsius(path + ".end_temperature")
+	if err != nil {
+		return FermentationStep{}, err
+	}
+
+	switch {
+	case s.EndTemperature == nil:
+		step.Temperature = start
+	case s.StartTemperature == nil:
+		step.Temperature = end
+	default:
+		step.Temperature = end
+
+		if start != end && days > 0 {
+			step.RampRate = math.Abs(end-start) / (days * hoursPerDay)
+		}
+	}
+
+	return step, nil
+}
+
+// toSpecificGravity converts the gravity to specific gravity. Brix is treated as Plato, which is accurate enough for
+// unfermented wort. A missing gravity is returned as zero.
+func (v *beerJSONUnitValue) toSpecificGravity(element string) (float64, error) {
+	if v == nil {
+		return 0, nil
+	}
+
+	switch strings.ToLower(v.Unit) {
+	case "sg":
+		return v.Value, nil
+	case "plato", "brix":
+		return 1 + v.Value/(258.6-v.Value/258.2*227.1), nil //nolint:gomnd // standard Plato conversion
+	default:
+		return 0, importError(element+".unit", "unit '%s' is not supported", v.Unit)
+	}
+}
+
+func (v *beerJSONUnitValue) toCelsius(element string) (float64, error) {
+	if v == nil {
+		return 0, nil
+	}
+
+	switch v.Unit {
+	case "C":
+		return v.Value, nil
+	case "F":
+		return (v.Value - fahrenheitOffset) / fahrenheitScale, nil
+	default:
+		return 0, importError(element+".unit", "unit '%s' is not supported", v.Unit)
+	}
+}
+
+// toDays converts the time to days. A missing time is returned as zero.
+func (v *beerJSONUnitValue) toDays(element string) (float64, error) {
+	if v == nil {
+		return 0, nil
+	}
+
+	if v.Value < 0 {
+		return 0, importError(element+".value", "must not be negative")
+	}
+
+	switch v.Unit {
+	case "sec":
+		return v.Value / secondsPerDay, nil
+	case "min":
+		return v.Value / minutesPerDay, nil
+	case "hr":
+		return v.Value / hoursPerDay, nil
+	case "day":
+		return v.Value, nil
+	case "week":
+		return v.Value * daysPerWeek, nil
+	default:
+		return 0, importError(element+".unit", "unit '%s' is not supported", v.Unit)
+	}
+}
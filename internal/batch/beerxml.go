@@ -0,0 +1,212 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const maxBeerXMLStages = 3
+
+type beerXMLRecipes struct {
+	XMLName xml.Name        `xml:"RECIPES"`
+	Recipes []beerXMLRecipe `xml:"RECIPE"`
+}
+
+// beerXMLRecipe holds the fields of a BeerXML 1.0 recipe that are imported. Numbers are kept as text so that invalid
+// values can be reported along with their element. Ages are in days and temperatures in degrees Celsius.
+type beerXMLRecipe struct {
+	Name               string `xml:"NAME"`
+	OG                 string `xml:"OG"`
+	FG                 string `xml:"FG"`
+	FermentationStages string `xml:"FERMENTATION_STAGES"`
+	PrimaryAge         string `xml:"PRIMARY_AGE"`
+	PrimaryTemp        string `xml:"PRIMARY_TEMP"`
+	SecondaryAge       string `xml:"SECONDARY_AGE"`
+	SecondaryTemp      string `xml:"SECONDARY_TEMP"`
+	TertiaryAge        string `xml:"TERTIARY_AGE"`
+	TertiaryTemp       string `xml:"TERTIARY_TEMP"`
+	Age                string `xml:"AGE"`
+	AgeTemp            string `xml:"AGE_TEMP"`
+}
+
+type beerXMLStage struct {
+	name        string
+	age         string
+	ageElement  string
+	temp        string
+	tempElement string
+}
+
+func importBeerXML(data []byte) (*Detail, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charsetReader
+
+	var recipes beerXMLRecipes
+	if err := decoder.Decode(&recipes); err != nil {
+		return nil, importError("RECIPES", "could not parse BeerXML: %s", err)
+	}
+
+	if len(recipes.Recipes) == 0 {
+		return nil, importError("RECIPES", "does not contain a RECIPE")
+	}
+
+	return recipes.Recipes[0].convert("RECIPES/RECIPE[1]")
+}
+
+func (r *beerXMLRecipe) convert(path string) (*Detail, error) {
+	if strings.TrimSpace(r.Name) == "" {
+		return nil, importError(path+"/NAME", "is required")
+	}
+
+	og, err := parseBeerXMLNumber(path+"/OG", r.OG)
+	if err != nil {
+		return nil, err
+	}
+
+	fg, err := parseBeerXMLNumber(path+"/FG", r.FG)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := r.convertStages(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Detail{
+		Recipe: Recipe{
+			Name:            strings.TrimSpace(r.Name),
+			Fermentation:    Fermentation{Steps: steps},
+			OriginalGravity: og,
+			FinalGravity:    fg,
+		},
+	}, nil
+}
+
+// convertStages returns a step for each fermentation stage and, if the recipe is aged, a final aging step. If
+// FERMENTATION_STAGES is not set the number of stages is inferred from the stages that have a temperature.
+func (r *beerXMLRecipe) convertStages(path string) ([]FermentationStep, error) {
+	stages := []beerXMLStage{
+		{"Primary", r.PrimaryAge, "PRIMARY_AGE", r.PrimaryTemp, "PRIMARY_TEMP"},
+		{"Secondary", r.SecondaryAge, "SECONDARY_AGE", r.SecondaryTemp, "SECONDARY_TEMP"},
+		{"Tertiary", r.TertiaryAge, "TERTIARY_AGE", r.TertiaryTemp, "TERTIARY_TEMP"},
+	}
+
+	count := 0
+
+	if strings.TrimSpace(r.FermentationStages) != "" {
+		n, err := strconv.Atoi(strings.TrimSpace(r.FermentationStages))
+		if err != nil || n < 1 || n > maxBeerXMLStages {
+			return nil, importError(path+"/FERMENTATION_STAGES", "must be 1, 2 or 3 but is '%s'", r.FermentationStages)
+		}
+
+		count = n
+	} else {
+		for i, stage := range stages {
+			if strings.TrimSpace(stage.temp) != "" {
+				count = i + 1
+			}
+		}
+
+		if count == 0 {
+			return nil, importError(path+"/PRIMARY_TEMP", "is required")
+		}
+	}
+
+	steps := []FermentationStep{}
+
+	for _, stage := range stages[:count] {
+		step, err := convertBeerXMLStage(path, stage)
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, step)
+	}
+
+	age, err := parseBeerXMLNumber(path+"/AGE", r.Age)
+	if err != nil {
+		return nil, err
+	}
+
+	if age > 0 {
+		step, err := convertBeerXMLStage(path, beerXMLStage{"Aging", r.Age, "AGE", r.AgeTemp, "AGE_TEMP"})
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func convertBeerXMLStage(path string, stage beerXMLStage) (FermentationStep, error) {
+	tempElement := fmt.Sprintf("%s/%s", path, stage.tempElement)
+	ageElement := fmt.Sprintf("%s/%s", path, stage.ageElement)
+
+	if strings.TrimSpace(stage.temp) == "" {
+		return FermentationStep{}, importError(tempElement, "is required")
+	}
+
+	temp, err := parseBeerXMLNumber(tempElement, stage.temp)
+	if err != nil {
+		return FermentationStep{}, err
+	}
+
+	age, err := parseBeerXMLNumber(ageElement, stage.age)
+	if err != nil {
+		return FermentationStep{}, err
+	}
+
+	if age < 0 {
+		return FermentationStep{}, importError(ageElement, "must not be negative")
+	}
+
+	return FermentationStep{Name: stage.name, Temperature: temp, Duration: toDays(age)}, nil
+}
+
+// parseBeerXMLNumber parses the text of the given element. Empty text is treated as zero since most BeerXML fields are
+// optional.
+func parseBeerXMLNumber(element, value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, importError(element, "'%s' is not a number", value)
+	}
+
+	return f, nil
+}
+
+// charsetReader converts ISO-8859-1 to UTF-8, since that is the encoding declared by most BeerXML files. Windows-1252
+// is treated as ISO-8859-1, which only differs in rarely used punctuation.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "iso8859-1", "latin1", "windows-1252", "us-ascii":
+	default:
+		return nil, errors.Errorf("encoding %s is not supported", charset)
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read input")
+	}
+
+	buf := make([]byte, 0, len(data))
+	for _, b := range data {
+		buf = utf8.AppendRune(buf, rune(b))
+	}
+
+	return bytes.NewReader(buf), nil
+}
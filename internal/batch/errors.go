@@ -0,0 +1,34 @@
+package batch
+
+const (
+	ErrNotFound      = Error("batch not found")
+	ErrUnknownFormat = Error("recipe is not in BeerXML or BeerJSON format")
+)
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+type InvalidBatchError struct {
+	problems []error
+}
+
+func (e InvalidBatchError) Error() string {
+	return "batch is invalid"
+}
+
+func (e InvalidBatchError) Problems() []error {
+	return e.problems
+}
+
+// ImportError describes a problem with an element of an imported recipe file.
+type ImportError struct {
+	Element string
+	Problem string
+}
+
+func (e ImportError) Error() string {
+	return e.Element + ": " + e.Problem
+}
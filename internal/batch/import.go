@@ -0,0 +1,49 @@
+package batch
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+const hoursPerDay = 24
+
+// Import maps the first recipe of a BeerXML 1.0 or BeerJSON 1.0 document to a Detail. The format is detected from the
+// content. Problems with the document are returned as an ImportError that names the offending element.
+func Import(data []byte) (*Detail, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	var (
+		d   *Detail
+		err error
+	)
+
+	switch {
+	case bytes.HasPrefix(data, []byte("<")):
+		d, err = importBeerXML(data)
+	case bytes.HasPrefix(data, []byte("{")):
+		d, err = importBeerJSON(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.Source = LocalSource
+
+	if errs := validate(d); errs != nil {
+		return nil, &InvalidBatchError{problems: errs}
+	}
+
+	return d, nil
+}
+
+func importError(element, format string, a ...interface{}) error {
+	return &ImportError{Element: element, Problem: fmt.Sprintf(format, a...)}
+}
+
+func toDays(value float64) int {
+	return int(math.Round(value))
+}
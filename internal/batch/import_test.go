@@ -0,0 +1,244 @@
+package batch_test
+
+import (
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/stretchr/testify/assert"
+)
+
+const beerXML = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<RECIPES>
+  <RECIPE>
+    <NAME>Pale Ale</NAME>
+    <VERSION>1</VERSION>
+    <OG>1.052</OG>
+    <FG>1.011</FG>
+    <FERMENTATION_STAGES>2</FERMENTATION_STAGES>
+    <PRIMARY_AGE>7</PRIMARY_AGE>
+    <PRIMARY_TEMP>19.4</PRIMARY_TEMP>
+    <SECONDARY_AGE>3.6</SECONDARY_AGE>
+    <SECONDARY_TEMP>21</SECONDARY_TEMP>
+    <TERTIARY_AGE>5</TERTIARY_AGE>
+    <TERTIARY_TEMP>2</TERTIARY_TEMP>
+    <AGE>14</AGE>
+    <AGE_TEMP>2</AGE_TEMP>
+  </RECIPE>
+</RECIPES>`
+
+const beerJSON = `{
+  "beerjson": {
+    "version": 1.0,
+    "recipes": [
+      {
+        "name": "Lager",
+        "original_gravity": {"unit": "sg", "value": 1.048},
+        "final_gravity": {"unit": "plato", "value": 2.5},
+        "fermentation": {
+          "name": "Lager Schedule",
+          "fermentation_steps": [
+            {
+              "name": "Primary",
+              "start_temperature": {"unit": "C", "value": 10},
+              "step_time": {"unit": "week", "value": 2}
+            },
+            {
+              "name": "Diacetyl Rest",
+              "start_temperature": {"unit": "C", "value": 10},
+              "end_temperature": {"unit": "F", "value": 64.4},
+              "step_time": {"unit": "hr", "value": 48}
+            },
+            {
+              "name": "Lagering",
+              "end_temperature": {"unit": "C", "value": 1},
+              "step_time": {"unit": "day", "value": 28}
+            }
+          ]
+        }
+      }
+    ]
+  }
+}`
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		data     string
+		expected *batch.Detail
+	}{
+		{
+			name: "beerXML",
+			data: beerXML,
+			expected: &batch.Detail{
+				Recipe: batch.Recipe{
+					Name: "Pale Ale",
+					Fermentation: batch.Fermentation{
+						Steps: []batch.FermentationStep{
+							{Name: "Primary", Temperature: 19.4, Duration: 7},
+							{Name: "Secondary", Temperature: 21, Duration: 4},
+							{Name: "Aging", Temperature: 2, Duration: 14},
+						},
+					},
+					OriginalGravity: 1.052,
+					FinalGravity:    1.011,
+				},
+				Source: batch.LocalSource,
+			},
+		},
+		{
+			name: "beerXMLInferredStages",
+			data: "\xef\xbb\xbf <RECIPES><RECIPE><NAME>Stout</NAME><PRIMARY_AGE>10</PRIMARY_AGE>" +
+				"<PRIMARY_TEMP>18</PRIMARY_TEMP></RECIPE></RECIPES>",
+			expected: &batch.Detail{
+				Recipe: batch.Recipe{
+					Name: "Stout",
+					Fermentation: batch.Fermentation{
+						Steps: []batch.FermentationStep{{Name: "Primary", Temperature: 18, Duration: 10}},
+					},
+				},
+				Source: batch.LocalSource,
+			},
+		},
+		{
+			name: "beerJSON",
+			data: beerJSON,
+			expected: &batch.Detail{
+				Recipe: batch.Recipe{
+					Name: "Lager",
+					Fermentation: batch.Fermentation{
+						Name: "Lager Schedule",
+						Steps: []batch.FermentationStep{
+							{Name: "Primary", Temperature: 10, Duration: 14},
+							{Name: "Diacetyl Rest", Temperature: 18, Duration: 2, RampRate: 8.0 / 48},
+							{Name: "Lagering", Temperature: 1, Duration: 28},
+						},
+					},
+					OriginalGravity: 1.048,
+					FinalGravity:    1 + 2.5/(258.6-2.5/258.2*227.1),
+				},
+				Source: batch.LocalSource,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := batch.Import([]byte(tc.data))
+			assert.NoError(t, err)
+
+			// compare converted values separately since they are not exact
+			assert.InDelta(t, tc.expected.Recipe.FinalGravity, d.Recipe.FinalGravity, 0.0001)
+
+			d.Recipe.FinalGravity = tc.expected.Recipe.FinalGravity
+
+			for i := range d.Recipe.Fermentation.Steps {
+				assert.InDelta(t, tc.expected.Recipe.Fermentation.Steps[i].Temperature,
+					d.Recipe.Fermentation.Steps[i].Temperature, 0.0001)
+				assert.InDelta(t, tc.expected.Recipe.Fermentation.Steps[i].RampRate,
+					d.Recipe.Fermentation.Steps[i].RampRate, 0.0001)
+				d.Recipe.Fermentation.Steps[i].Temperature = tc.expected.Recipe.Fermentation.Steps[i].Temperature
+				d.Recipe.Fermentation.Steps[i].RampRate = tc.expected.Recipe.Fermentation.Steps[i].RampRate
+			}
+
+			assert.Equal(t, tc.expected, d)
+		})
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "unknownFormat", data: "NAME,OG\nPale,1.050", expected: batch.ErrUnknownFormat.Error()},
+		{name: "beerXMLMalformed", data: "<RECIPES><RECIPE>", expected: "RECIPES: could not parse BeerXML"},
+		{name: "beerXMLNoRecipe", data: "<RECIPES></RECIPES>", expected: "RECIPES: does not contain a RECIPE"},
+		{
+			name:     "beerXMLNoName",
+			data:     "<RECIPES><RECIPE><PRIMARY_TEMP>18</PRIMARY_TEMP></RECIPE></RECIPES>",
+			expected: "RECIPES/RECIPE[1]/NAME: is required",
+		},
+		{
+			name:     "beerXMLBadNumber",
+			data:     "<RECIPES><RECIPE><NAME>A</NAME><OG>high</OG><PRIMARY_TEMP>18</PRIMARY_TEMP></RECIPE></RECIPES>",
+			expected: "RECIPES/RECIPE[1]/OG: 'high' is not a number",
+		},
+		{
+			name: "beerXMLBadStages",
+			data: "<RECIPES><RECIPE><NAME>A</NAME><FERMENTATION_STAGES>4</FERMENTATION_STAGES>" +
+				"</RECIPE></RECIPES>",
+			expected: "RECIPES/RECIPE[1]/FERMENTATION_STAGES: must be 1, 2 or 3 but is '4'",
+		},
+		{
+			name:     "beerXMLNoStages",
+			data:     "<RECIPES><RECIPE><NAME>A</NAME></RECIPE></RECIPES>",
+			expected: "RECIPES/RECIPE[1]/PRIMARY_TEMP: is required",
+		},
+		{
+			name: "beerXMLNegativeAge",
+			data: "<RECIPES><RECIPE><NAME>A</NAME><PRIMARY_TEMP>18</PRIMARY_TEMP><PRIMARY_AGE>-1</PRIMARY_AGE>" +
+				"</RECIPE></RECIPES>",
+			expected: "RECIPES/RECIPE[1]/PRIMARY_AGE: must not be negative",
+		},
+		{
+			name:     "beerXMLAgingTemp",
+			data:     "<RECIPES><RECIPE><NAME>A</NAME><PRIMARY_TEMP>18</PRIMARY_TEMP><AGE>3</AGE></RECIPE></RECIPES>",
+			expected: "RECIPES/RECIPE[1]/AGE_TEMP: is required",
+		},
+		{name: "beerJSONMalformed", data: `{"beerjson": [}`, expected: "beerjson: could not parse BeerJSON"},
+		{name: "beerJSONMissing", data: `{}`, expected: "beerjson: is required"},
+		{name: "beerJSONNoRecipe", data: `{"beerjson": {}}`, expected: "beerjson.recipes: does not contain a recipe"},
+		{
+			name:     "beerJSONNoSteps",
+			data:     `{"beerjson": {"recipes": [{"name": "A"}]}}`,
+			expected: "beerjson.recipes[0].fermentation.fermentation_steps: does not contain a step",
+		},
+		{
+			name:     "beerJSONGravityUnit",
+			data:     `{"beerjson": {"recipes": [{"name": "A", "original_gravity": {"unit": "gu", "value": 50}}]}}`,
+			expected: "beerjson.recipes[0].original_gravity.unit: unit 'gu' is not supported",
+		},
+		{
+			name: "beerJSONNoTemperature",
+			data: `{"beerjson": {"recipes": [{"name": "A", "fermentation": {"fermentation_steps": [` +
+				`{"name": "Primary"}]}}]}}`,
+			expected: "beerjson.recipes[0].fermentation.fermentation_steps[0].start_temperature: is required",
+		},
+		{
+			name: "beerJSONTimeUnit",
+			data: `{"beerjson": {"recipes": [{"name": "A", "fermentation": {"fermentation_steps": [` +
+				`{"name": "Primary", "start_temperature": {"unit": "C", "value": 18}, ` +
+				`"step_time": {"unit": "fortnight", "value": 1}}]}}]}}`,
+			expected: "beerjson.recipes[0].fermentation.fermentation_steps[0].step_time.unit: unit 'fortnight' " +
+				"is not supported",
+		},
+		{
+			name: "beerJSONDuplicateStep",
+			data: `{"beerjson": {"recipes": [{"name": "A", "fermentation": {"fermentation_steps": [` +
+				`{"name": "Primary", "start_temperature": {"unit": "C", "value": 18}}, ` +
+				`{"name": "Primary", "start_temperature": {"unit": "C", "value": 20}}]}}]}}`,
+			expected: "batch is invalid",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := batch.Import([]byte(tc.data))
+			// TODO: Waiting on PR for ErrorContains(): https://github.com/stretchr/testify/pull/1022
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
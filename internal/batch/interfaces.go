@@ -0,0 +1,19 @@
+package batch
+
+import "context"
+
+// Controller lists and manages both the locally defined batches and the batches in Brewfather.
+type Controller interface {
+	GetAll(ctx context.Context) ([]Summary, error)
+	Get(ctx context.Context, id string) (*Detail, error)
+	Save(d *Detail) error
+	Delete(id string) error
+}
+
+// Repo persists locally defined batches.
+type Repo interface {
+	GetAll() ([]*Detail, error)
+	Get(id string) (*Detail, error)
+	Save(d *Detail) error
+	Delete(id string) error
+}
@@ -0,0 +1,162 @@
+package batch
+
+import (
+	"context"
+	"sort"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var _ Controller = (*Library)(nil)
+
+// Library merges the locally defined batches with the batches in Brewfather. Local batches can be saved and deleted
+// while Brewfather batches are read only.
+type Library struct {
+	repo    Repo
+	service brewfather.Service
+	logger  *logrus.Logger
+}
+
+// NewLibrary returns a Library that stores local batches in the given repo and reads the remaining batches from the
+// given Brewfather service.
+func NewLibrary(repo Repo, service brewfather.Service, logger *logrus.Logger) *Library {
+	return &Library{
+		repo:    repo,
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetAll returns the summaries of the local batches, ordered by number, followed by those of the Brewfather batches.
+// If Brewfather can not be reached only the local batches are returned so that the library remains usable without it.
+func (l *Library) GetAll(ctx context.Context) ([]Summary, error) {
+	details, err := l.repo.GetAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get all local batches from repository")
+	}
+
+	sort.SliceStable(details, func(i, j int) bool { return details[i].Number < details[j].Number })
+
+	summaries := []Summary{}
+	for _, d := range details {
+		summaries = append(summaries, d.Summarize())
+	}
+
+	batchSummaries, err := l.service.GetAllBatchSummaries(ctx)
+	if err != nil {
+		l.logger.WithError(err).Warn("Could not get batches from Brewfather, returning local batches only.")
+
+		return summaries, nil
+	}
+
+	return append(summaries, ConvertSummaries(batchSummaries)...), nil
+}
+
+// Get returns the local batch with the given ID or, if there is none, the Brewfather batch with that ID.
+func (l *Library) Get(ctx context.Context, id string) (*Detail, error) {
+	d, err := l.repo.Get(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get local batch %s from repository", id)
+	}
+
+	if d != nil {
+		return d, nil
+	}
+
+	batchDetail, err := l.service.GetBatchDetail(ctx, id)
+	if err != nil {
+		if errors.Is(err, brewfather.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrapf(err, "could not get batch %s from brewfather", id)
+	}
+
+	if batchDetail == nil {
+		return nil, ErrNotFound
+	}
+
+	return ConvertDetail(batchDetail), nil
+}
+
+// Save creates or updates a local batch. A batch without an ID is created, otherwise the local batch with that ID
+// must exist.
+func (l *Library) Save(d *Detail) error {
+	if errs := validate(d); errs != nil {
+		return &InvalidBatchError{problems: errs}
+	}
+
+	if d.ID != "" {
+		existing, err := l.repo.Get(d.ID)
+		if err != nil {
+			return errors.Wrapf(err, "could not get local batch %s from repository", d.ID)
+		}
+
+		if existing == nil {
+			return ErrNotFound
+		}
+	}
+
+	d.Source = LocalSource
+
+	if err := l.repo.Save(d); err != nil {
+		return errors.Wrap(err, "could not save local batch to repository")
+	}
+
+	return nil
+}
+
+// Delete permanently removes a local batch.
+func (l *Library) Delete(id string) error {
+	d, err := l.repo.Get(id)
+	if err != nil {
+		return errors.Wrapf(err, "could not get local batch %s from repository", id)
+	}
+
+	if d == nil {
+		return ErrNotFound
+	}
+
+	if err := l.repo.Delete(id); err != nil {
+		return errors.Wrapf(err, "could not delete local batch %s from repository", id)
+	}
+
+	return nil
+}
+
+func validate(d *Detail) []error {
+	var errs []error
+
+	if d.Recipe.Name == "" {
+		errs = append(errs, errors.New("recipe name is required"))
+	}
+
+	if len(d.Recipe.Fermentation.Steps) == 0 {
+		errs = append(errs, errors.New("at least one fermentation step is required"))
+	}
+
+	names := make(map[string]bool)
+
+	for i, step := range d.Recipe.Fermentation.Steps {
+		switch {
+		case step.Name == "":
+			errs = append(errs, errors.Errorf("name of step %d is required", i+1))
+		case names[step.Name]:
+			errs = append(errs, errors.Errorf("step name %s is not unique", step.Name))
+		}
+
+		names[step.Name] = true
+
+		if step.Duration < 0 {
+			errs = append(errs, errors.Errorf("duration of step %d is negative", i+1))
+		}
+
+		if step.RampRate < 0 {
+			errs = append(errs, errors.Errorf("ramp rate of step %d is negative", i+1))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,265 @@
+package batch_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	localID      = "59679696-1263-4340-a256-6c46876b4a13"
+	brewfatherID = "KBTM3F9soO5TtbAx0A5mBZTAUsNZyg"
+)
+
+var errSomeError = errors.New("some error")
+
+func newTestBatch(id string, number int) *batch.Detail {
+	return &batch.Detail{
+		ID:     id,
+		Number: number,
+		Recipe: batch.Recipe{
+			Name: "My Recipe",
+			Fermentation: batch.Fermentation{
+				Steps: []batch.FermentationStep{{Name: "Primary", Temperature: 20, Duration: 7}},
+			},
+		},
+		Source: batch.LocalSource,
+	}
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestLibrary(t *testing.T) {
+	t.Parallel()
+	t.Run("getAllMergesBatches", getAllMergesBatches)
+	t.Run("getAllBrewfatherUnavailable", getAllBrewfatherUnavailable)
+	t.Run("getLocalBatch", getLocalBatch)
+	t.Run("getBrewfatherBatch", getBrewfatherBatch)
+	t.Run("getBatchNotFound", getBatchNotFound)
+	t.Run("saveNewBatch", saveNewBatch)
+	t.Run("saveUnknownBatch", saveUnknownBatch)
+	t.Run("saveInvalidBatch", saveInvalidBatch)
+	t.Run("deleteBatch", deleteBatch)
+	t.Run("deleteBrewfatherBatch", deleteBrewfatherBatch)
+}
+
+func getAllMergesBatches(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("GetAll").Return([]*batch.Detail{newTestBatch("b", 2), newTestBatch(localID, 1)}, nil)
+
+	summary := brewfather.BatchSummary{ID: brewfatherID, BatchNo: 1}
+	summary.Recipe.Name = "Their Recipe"
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("GetAllBatchSummaries", mock.Anything).Return([]brewfather.BatchSummary{summary}, nil)
+
+	library := batch.NewLibrary(repoMock, serviceMock, l)
+
+	summaries, err := library.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []batch.Summary{
+		{ID: localID, Number: 1, RecipeName: "My Recipe", Source: batch.LocalSource},
+		{ID: "b", Number: 2, RecipeName: "My Recipe", Source: batch.LocalSource},
+		{ID: brewfatherID, Number: 1, RecipeName: "Their Recipe", Source: batch.BrewfatherSource},
+	}, summaries)
+}
+
+func getAllBrewfatherUnavailable(t *testing.T) {
+	t.Parallel()
+
+	l, hook := logtest.NewNullLogger()
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("GetAll").Return([]*batch.Detail{newTestBatch(localID, 1)}, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("GetAllBatchSummaries", mock.Anything).Return(nil, errSomeError)
+
+	library := batch.NewLibrary(repoMock, serviceMock, l)
+
+	summaries, err := library.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, localID, summaries[0].ID)
+	assert.Len(t, hook.Entries, 1)
+}
+
+func getLocalBatch(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	expected := newTestBatch(localID, 1)
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("Get", localID).Return(expected, nil)
+
+	serviceMock := &mocks.Service{}
+
+	library := batch.NewLibrary(repoMock, serviceMock, l)
+
+	d, err := library.Get(context.Background(), localID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, d)
+	serviceMock.AssertNotCalled(t, "GetBatchDetail", mock.Anything, mock.Anything)
+}
+
+func getBrewfatherBatch(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("Get", brewfatherID).Return(nil, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("GetBatchDetail", mock.Anything, brewfatherID).Return(&brewfather.BatchDetail{
+		ID: brewfatherID,
+		Recipe: brewfather.Recipe{
+			Name: "Their Recipe",
+			Fermentation: brewfather.Fermentation{
+				Steps: []brewfather.FermentationSteps{{Type: "Primary", StepTemp: 19, StepTime: 10}},
+			},
+		},
+	}, nil)
+
+	library := batch.NewLibrary(repoMock, serviceMock, l)
+
+	d, err := library.Get(context.Background(), brewfatherID)
+	assert.NoError(t, err)
+	assert.Equal(t, &batch.Detail{
+		ID: brewfatherID,
+		Recipe: batch.Recipe{
+			Name: "Their Recipe",
+			Fermentation: batch.Fermentation{
+				Steps: []batch.FermentationStep{{Name: "Primary", Temperature: 19, Duration: 10}},
+			},
+		},
+		Source: batch.BrewfatherSource,
+	}, d)
+}
+
+func getBatchNotFound(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("Get", brewfatherID).Return(nil, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("GetBatchDetail", mock.Anything, brewfatherID).Return(nil, brewfather.ErrNotFound)
+
+	library := batch.NewLibrary(repoMock, serviceMock, l)
+
+	_, err := library.Get(context.Background(), brewfatherID)
+	assert.ErrorIs(t, err, batch.ErrNotFound)
+}
+
+func saveNewBatch(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	d := newTestBatch("", 1)
+	d.Source = ""
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("Save", d).Return(nil)
+
+	library := batch.NewLibrary(repoMock, &mocks.Service{}, l)
+
+	err := library.Save(d)
+	assert.NoError(t, err)
+	assert.Equal(t, batch.LocalSource, d.Source)
+	repoMock.AssertExpectations(t)
+}
+
+func saveUnknownBatch(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("Get", brewfatherID).Return(nil, nil)
+
+	library := batch.NewLibrary(repoMock, &mocks.Service{}, l)
+
+	err := library.Save(newTestBatch(brewfatherID, 1))
+	assert.ErrorIs(t, err, batch.ErrNotFound)
+	repoMock.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func saveInvalidBatch(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	d := newTestBatch(localID, 1)
+	d.Recipe.Fermentation.Steps = append(d.Recipe.Fermentation.Steps,
+		batch.FermentationStep{Name: "Primary", Duration: -1}, batch.FermentationStep{RampRate: -1})
+
+	library := batch.NewLibrary(&mocks.BatchRepo{}, &mocks.Service{}, l)
+
+	err := library.Save(d)
+
+	var batchErr *batch.InvalidBatchError
+
+	assert.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []string{
+		"step name Primary is not unique",
+		"duration of step 2 is negative",
+		"name of step 3 is required",
+		"ramp rate of step 3 is negative",
+	}, problemStrings(batchErr.Problems()))
+}
+
+func deleteBatch(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("Get", localID).Return(newTestBatch(localID, 1), nil)
+	repoMock.On("Delete", localID).Return(nil)
+
+	library := batch.NewLibrary(repoMock, &mocks.Service{}, l)
+
+	err := library.Delete(localID)
+	assert.NoError(t, err)
+	repoMock.AssertExpectations(t)
+}
+
+func deleteBrewfatherBatch(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	repoMock := &mocks.BatchRepo{}
+	repoMock.On("Get", brewfatherID).Return(nil, nil)
+
+	library := batch.NewLibrary(repoMock, &mocks.Service{}, l)
+
+	err := library.Delete(brewfatherID)
+	assert.ErrorIs(t, err, batch.ErrNotFound)
+	repoMock.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func problemStrings(problems []error) []string {
+	s := []string{}
+	for _, p := range problems {
+		s = append(s, p.Error())
+	}
+
+	return s
+}
@@ -1,12 +1,47 @@
 //nolint:tagliatelle // this file was manual generated and includes minor changes
 package brewfather
 
-import "context"
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+const (
+	FermentingStatus   = "Fermenting"
+	ConditioningStatus = "Conditioning"
+)
 
 type Service interface {
 	GetAllBatchSummaries(ctx context.Context) ([]BatchSummary, error)
 	GetBatchDetail(ctx context.Context, id string) (*BatchDetail, error)
 	Log(ctx context.Context, log LogEntry) error
+	UpdateBatch(ctx context.Context, id string, update BatchUpdate) error
+}
+
+// BatchUpdate holds the fields of a batch to update. Empty fields are left unchanged.
+type BatchUpdate struct {
+	Status     string
+	MeasuredOG *float64
+	MeasuredFG *float64
+}
+
+func (u BatchUpdate) query() url.Values {
+	v := url.Values{}
+
+	if u.Status != "" {
+		v.Set("status", u.Status)
+	}
+
+	if u.MeasuredOG != nil {
+		v.Set("measuredOg", strconv.FormatFloat(*u.MeasuredOG, 'f', 3, 64))
+	}
+
+	if u.MeasuredFG != nil {
+		v.Set("measuredFg", strconv.FormatFloat(*u.MeasuredFG, 'f', 3, 64))
+	}
+
+	return v
 }
 
 type LogEntry struct {
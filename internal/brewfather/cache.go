@@ -0,0 +1,269 @@
+package brewfather
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCacheTTL  = 5 * time.Minute
+	batchesCacheKey  = "batches"
+	cacheHitMetric   = "zymurgauge.brewfather.cache_hit"
+	cacheMissMetric  = "zymurgauge.brewfather.cache_miss"
+	cacheStaleMetric = "zymurgauge.brewfather.cache_stale"
+)
+
+var _ Service = (*Cache)(nil)
+
+// ConditionalService is a Service that only returns batches that have changed since they were last fetched.
+type ConditionalService interface {
+	Service
+	GetAllBatchSummariesIfChanged(ctx context.Context, etag string) ([]BatchSummary, string, error)
+	GetBatchDetailIfChanged(ctx context.Context, id, etag string) (*BatchDetail, string, error)
+}
+
+// CacheEntry is a Brewfather response along with its ETag and the time it was fetched.
+type CacheEntry struct {
+	ETag      string          `json:"etag,omitempty"`
+	FetchTime time.Time       `json:"fetchTime"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CacheRepo persists cache entries so that the last known batches are available while Brewfather can not be reached.
+type CacheRepo interface {
+	GetEntry(key string) (*CacheEntry, error)
+	SaveEntry(key string, entry *CacheEntry) error
+	DeleteAll() error
+}
+
+type refreshKey struct{}
+
+// WithRefresh returns a copy of ctx that makes a Cache fetch batches from Brewfather even if its copies have not
+// expired.
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// IsRefresh reports whether ctx was returned by WithRefresh.
+func IsRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+
+	return refresh
+}
+
+// Cache is a Service that keeps copies of the batches returned by a ConditionalService. Copies are returned until
+// their TTL expires, after which they are revalidated using their ETag. If Brewfather can not be reached the last
+// known copy is returned.
+type Cache struct {
+	service ConditionalService
+	repo    CacheRepo
+	logger  *logrus.Logger
+	metrics metrics.Metrics
+	clock   clock.Clock
+	ttl     time.Duration
+	entries map[string]*CacheEntry
+	mutex   sync.Mutex
+}
+
+// NewCache creates a new Cache around the given service.
+func NewCache(service ConditionalService, repo CacheRepo, logger *logrus.Logger, metrics metrics.Metrics,
+	options ...CacheOptionsFunc,
+) *Cache {
+	c := &Cache{
+		service: service,
+		repo:    repo,
+		logger:  logger,
+		metrics: metrics,
+		clock:   clock.NewRealClock(),
+		ttl:     defaultCacheTTL,
+		entries: make(map[string]*CacheEntry),
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+type CacheOptionsFunc func(*Cache)
+
+// CacheTTL sets how long copies are returned before they are revalidated.
+func CacheTTL(ttl time.Duration) CacheOptionsFunc {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+func SetCacheClock(clock clock.Clock) CacheOptionsFunc {
+	return func(c *Cache) {
+		c.clock = clock
+	}
+}
+
+func (c *Cache) GetAllBatchSummaries(ctx context.Context) ([]BatchSummary, error) {
+	var batches []BatchSummary
+
+	if err := c.get(ctx, batchesCacheKey, &batches, func(etag string) (interface{}, string, error) {
+		return c.service.GetAllBatchSummariesIfChanged(ctx, etag)
+	}); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+func (c *Cache) GetBatchDetail(ctx context.Context, id string) (*BatchDetail, error) {
+	var batch *BatchDetail
+
+	if err := c.get(ctx, batchesCacheKey+"/"+id, &batch, func(etag string) (interface{}, string, error) {
+		return c.service.GetBatchDetailIfChanged(ctx, id, etag)
+	}); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+func (c *Cache) Log(ctx context.Context, log LogEntry) error {
+	return c.service.Log(ctx, log)
+}
+
+// UpdateBatch updates the batch in Brewfather and expires the copies of it, so that they are revalidated on their next
+// use. The expired copies are still returned while Brewfather can not be reached.
+func (c *Cache) UpdateBatch(ctx context.Context, id string, update BatchUpdate) error {
+	if err := c.service.UpdateBatch(ctx, id, update); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, key := range []string{batchesCacheKey, batchesCacheKey + "/" + id} {
+		if entry := c.getEntry(key); entry != nil {
+			entry.FetchTime = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// Clear removes all copies, for example when the Brewfather account changes.
+func (c *Cache) Clear() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[string]*CacheEntry)
+
+	if err := c.repo.DeleteAll(); err != nil {
+		return errors.Wrap(err, "could not delete all from repository")
+	}
+
+	return nil
+}
+
+// get decodes the copy stored under key into v, using fetch to refresh it first if it has expired. Fetches are
+// serialized so that concurrent requests for an expired copy only reach Brewfather once.
+func (c *Cache) get(ctx context.Context, key string, v interface{},
+	fetch func(etag string) (interface{}, string, error),
+) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.getEntry(key)
+
+	if entry != nil && !IsRefresh(ctx) && c.clock.Since(entry.FetchTime) < c.ttl {
+		c.increment(cacheHitMetric)
+
+		return decodeEntry(entry, v)
+	}
+
+	c.increment(cacheMissMetric)
+
+	etag := ""
+	if entry != nil {
+		etag = entry.ETag
+	}
+
+	data, etag, err := fetch(etag)
+
+	switch {
+	case entry != nil && errors.Is(err, ErrNotModified):
+		entry.FetchTime = c.clock.Now()
+	case errors.Is(err, ErrNotFound):
+		return err
+	case err != nil:
+		if entry == nil {
+			return err
+		}
+
+		c.logger.WithError(err).Warnf("Could not refresh %s from Brewfather, using copy from %s.", key,
+			entry.FetchTime.Format(time.RFC3339))
+		c.increment(cacheStaleMetric)
+
+		return decodeEntry(entry, v)
+	default:
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return errors.Wrapf(err, "could not marshal %s", key)
+		}
+
+		entry = &CacheEntry{ETag: etag, FetchTime: c.clock.Now(), Data: raw}
+	}
+
+	c.entries[key] = entry
+
+	if err := c.repo.SaveEntry(key, entry); err != nil {
+		c.logger.WithError(err).Warnf("Could not persist %s.", key)
+	}
+
+	return decodeEntry(entry, v)
+}
+
+func (c *Cache) getEntry(key string) *CacheEntry {
+	if entry, ok := c.entries[key]; ok {
+		return entry
+	}
+
+	entry, err := c.repo.GetEntry(key)
+	if err != nil {
+		c.logger.WithError(err).Warnf("Could not get persisted %s.", key)
+
+		return nil
+	}
+
+	if entry != nil {
+		c.entries[key] = entry
+	}
+
+	return entry
+}
+
+func (c *Cache) increment(bucket string) {
+	if isNil(c.metrics) {
+		return
+	}
+
+	c.metrics.Increment(bucket)
+}
+
+// isNil reports whether m is nil, including a nil pointer such as a *statsd.Client that could not be created.
+func isNil(m metrics.Metrics) bool {
+	return m == nil || reflect.ValueOf(m).IsNil()
+}
+
+func decodeEntry(entry *CacheEntry, v interface{}) error {
+	if err := json.Unmarshal(entry.Data, v); err != nil {
+		return errors.Wrap(err, "could not unmarshal cache entry")
+	}
+
+	return nil
+}
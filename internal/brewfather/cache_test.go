@@ -0,0 +1,332 @@
+package brewfather_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	batchID  = "KBTM3F9soO5TtbAx0A5mBZTAUsNZyg"
+	etag     = `"v1"`
+	cacheTTL = 5 * time.Minute
+)
+
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *testClock) NewTimer(d time.Duration) *time.Timer {
+	return time.NewTimer(d)
+}
+
+func (c *testClock) add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+type testCacheRepo struct {
+	mutex   sync.Mutex
+	entries map[string]*brewfather.CacheEntry
+}
+
+func (r *testCacheRepo) GetEntry(key string) (*brewfather.CacheEntry, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.entries[key], nil
+}
+
+func (r *testCacheRepo) SaveEntry(key string, entry *brewfather.CacheEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.entries[key] = entry
+
+	return nil
+}
+
+func (r *testCacheRepo) DeleteAll() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.entries = make(map[string]*brewfather.CacheEntry)
+
+	return nil
+}
+
+// testServer serves a single batch with a fixed ETag. It responds with 304 to matching conditional requests and with
+// 429 while it is down. PATCH requests always succeed.
+type testServer struct {
+	*httptest.Server
+	requests    int32
+	conditional int32
+	patches     int32
+	down        atomic.Bool
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	s := &testServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+
+		if s.down.Load() {
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		if r.Method == http.MethodPatch {
+			atomic.AddInt32(&s.patches, 1)
+
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&s.conditional, 1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/batches":
+			_, _ = w.Write([]byte(`[{"_id":"` + batchID + `","name":"Batch","batchNo":1}]`))
+		case "/batches/" + batchID:
+			_, _ = w.Write([]byte(`{"_id":"` + batchID + `","name":"Batch","batchNo":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func setupCacheTest(t *testing.T, server *testServer, repo *testCacheRepo) (*brewfather.Cache, *testClock,
+	*mocks.Metrics,
+) {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+
+	metricsMock := &mocks.Metrics{}
+	metricsMock.On("Increment", "zymurgauge.brewfather.cache_hit").Return()
+	metricsMock.On("Increment", "zymurgauge.brewfather.cache_miss").Return()
+	metricsMock.On("Increment", "zymurgauge.brewfather.cache_stale").Return()
+
+	clock := &testClock{now: time.Now()}
+	client := brewfather.New("user", "key", "", brewfather.SetAPIURL(server.URL))
+	client.UpdateSettings("user", "key", "", true)
+	cache := brewfather.NewCache(client, repo, l, metricsMock, brewfather.CacheTTL(cacheTTL),
+		brewfather.SetCacheClock(clock))
+
+	return cache, clock, metricsMock
+}
+
+func newTestCacheRepo() *testCacheRepo {
+	return &testCacheRepo{entries: make(map[string]*brewfather.CacheEntry)}
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestCache(t *testing.T) {
+	t.Parallel()
+	t.Run("cacheHit", cacheHit)
+	t.Run("cacheRevalidate", cacheRevalidate)
+	t.Run("cacheRefresh", cacheRefresh)
+	t.Run("cacheStale", cacheStale)
+	t.Run("cachePersisted", cachePersisted)
+	t.Run("cacheUnavailable", cacheUnavailable)
+	t.Run("cacheNotFound", cacheNotFound)
+	t.Run("cacheClear", cacheClear)
+	t.Run("cacheUpdateBatch", cacheUpdateBatch)
+}
+
+func cacheHit(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	cache, _, metricsMock := setupCacheTest(t, server, newTestCacheRepo())
+
+	for i := 0; i < 2; i++ {
+		batches, err := cache.GetAllBatchSummaries(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		assert.Equal(t, batchID, batches[0].ID)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.requests))
+	metricsMock.AssertNumberOfCalls(t, "Increment", 2)
+	metricsMock.AssertCalled(t, "Increment", "zymurgauge.brewfather.cache_miss")
+	metricsMock.AssertCalled(t, "Increment", "zymurgauge.brewfather.cache_hit")
+}
+
+func cacheRevalidate(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	cache, clock, _ := setupCacheTest(t, server, newTestCacheRepo())
+
+	_, err := cache.GetBatchDetail(context.Background(), batchID)
+	assert.NoError(t, err)
+
+	clock.add(cacheTTL)
+
+	detail, err := cache.GetBatchDetail(context.Background(), batchID)
+	assert.NoError(t, err)
+	assert.Equal(t, batchID, detail.ID)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.conditional))
+
+	// the revalidated copy is fresh again
+	_, err = cache.GetBatchDetail(context.Background(), batchID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.requests))
+}
+
+func cacheRefresh(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	cache, _, _ := setupCacheTest(t, server, newTestCacheRepo())
+
+	_, err := cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+
+	_, err = cache.GetAllBatchSummaries(brewfather.WithRefresh(context.Background()))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.requests))
+}
+
+func cacheStale(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	cache, clock, metricsMock := setupCacheTest(t, server, newTestCacheRepo())
+
+	_, err := cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+
+	server.down.Store(true)
+	clock.add(cacheTTL)
+
+	batches, err := cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+	metricsMock.AssertCalled(t, "Increment", "zymurgauge.brewfather.cache_stale")
+}
+
+func cachePersisted(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	repo := newTestCacheRepo()
+	cache, _, _ := setupCacheTest(t, server, repo)
+
+	_, err := cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+
+	// a new cache, as after a restart, returns the persisted copy while Brewfather is down
+	server.down.Store(true)
+
+	restarted, _, _ := setupCacheTest(t, server, repo)
+
+	batches, err := restarted.GetAllBatchSummaries(brewfather.WithRefresh(context.Background()))
+	assert.NoError(t, err)
+	assert.Len(t, batches, 1)
+}
+
+func cacheUnavailable(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.down.Store(true)
+
+	cache, _, _ := setupCacheTest(t, server, newTestCacheRepo())
+
+	_, err := cache.GetAllBatchSummaries(context.Background())
+	assert.ErrorIs(t, err, brewfather.ErrTooManyRequests)
+}
+
+func cacheNotFound(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	cache, _, _ := setupCacheTest(t, server, newTestCacheRepo())
+
+	_, err := cache.GetBatchDetail(context.Background(), "someOtherID")
+	assert.ErrorIs(t, err, brewfather.ErrNotFound)
+}
+
+func cacheClear(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	repo := newTestCacheRepo()
+	cache, _, _ := setupCacheTest(t, server, repo)
+
+	_, err := cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+
+	err = cache.Clear()
+	assert.NoError(t, err)
+	assert.Empty(t, repo.entries)
+
+	_, err = cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.requests))
+}
+
+func cacheUpdateBatch(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	cache, _, _ := setupCacheTest(t, server, newTestCacheRepo())
+
+	_, err := cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+
+	_, err = cache.GetBatchDetail(context.Background(), batchID)
+	assert.NoError(t, err)
+
+	err = cache.UpdateBatch(context.Background(), batchID, brewfather.BatchUpdate{Status: brewfather.FermentingStatus})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.patches))
+
+	// both copies are revalidated even though their TTL has not expired
+	_, err = cache.GetAllBatchSummaries(context.Background())
+	assert.NoError(t, err)
+
+	_, err = cache.GetBatchDetail(context.Background(), batchID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&server.requests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.conditional))
+}
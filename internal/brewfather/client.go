@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/pkg/errors"
@@ -16,7 +17,6 @@ import (
 const (
 	apiURL              = "https://api.brewfather.app/v2"
 	batchesPath         = "batches"
-	logInterval         = 15 * time.Minute
 	requestTimeout      = 10 * time.Second
 	dialTimeout         = 10 * time.Second
 	tlsHandshakeTimeout = 10 * time.Second
@@ -27,6 +27,8 @@ var (
 	ErrNotFound         = errors.New("resource not found")
 	ErrTooManyRequests  = errors.New("too many request")
 	ErrLogURLNotSet     = errors.New("log url is not set")
+	ErrNotModified      = errors.New("resource not modified")
+	ErrUpdatesDisabled  = errors.New("batch updates are disabled")
 )
 
 // _ model.Repo = (*Client)(nil).
@@ -34,22 +36,43 @@ var _ Service = (*ServiceClient)(nil)
 
 type ServiceClient struct {
 	client       *http.Client
+	apiURL       string
 	userID       string
 	apiKey       string
 	logURL       string
-	nextSendTime time.Time
+	batchUpdates bool
 }
 
-func New(userID, apiKey, logURL string) *ServiceClient {
+func New(userID, apiKey, logURL string, options ...OptionsFunc) *ServiceClient {
 	c := &ServiceClient{
-		client:       createHTTPClient(userID, apiKey),
-		logURL:       logURL,
-		nextSendTime: time.Now(),
+		client: createHTTPClient(userID, apiKey),
+		apiURL: apiURL,
+		logURL: logURL,
+	}
+
+	for _, option := range options {
+		option(c)
 	}
 
 	return c
 }
 
+type OptionsFunc func(*ServiceClient)
+
+// EnableBatchUpdates sets whether UpdateBatch writes to Brewfather batches.
+func EnableBatchUpdates(enabled bool) OptionsFunc {
+	return func(s *ServiceClient) {
+		s.batchUpdates = enabled
+	}
+}
+
+// SetAPIURL sets the base URL of the Brewfather API.
+func SetAPIURL(url string) OptionsFunc {
+	return func(s *ServiceClient) {
+		s.apiURL = url
+	}
+}
+
 func createHTTPClient(userID, apiKey string) *http.Client {
 	t := &transport{
 		userID: userID,
@@ -69,94 +92,150 @@ func createHTTPClient(userID, apiKey string) *http.Client {
 }
 
 func (s *ServiceClient) GetAllBatchSummaries(ctx context.Context) ([]BatchSummary, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s", apiURL, batchesPath), nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not create GET request for Batches")
-	}
+	batches, _, err := s.GetAllBatchSummariesIfChanged(ctx, "")
 
-	resp, err := s.client.Do(req)
+	return batches, err
+}
+
+func (s *ServiceClient) GetBatchDetail(ctx context.Context, id string) (*BatchDetail, error) {
+	batch, _, err := s.GetBatchDetailIfChanged(ctx, id, "")
+
+	return batch, err
+}
+
+// GetAllBatchSummariesIfChanged returns all batch summaries along with their ETag. If etag is not empty and the
+// batches have not changed since it was returned, ErrNotModified is returned instead.
+func (s *ServiceClient) GetAllBatchSummariesIfChanged(ctx context.Context, etag string) ([]BatchSummary, string,
+	error,
+) {
+	var batches []BatchSummary
+
+	etag, err := s.getIfChanged(ctx, fmt.Sprintf("%s/%s", s.apiURL, batchesPath), etag, "Batches", &batches)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not GET Batches")
+		return nil, "", err
 	}
 
-	defer resp.Body.Close()
+	return batches, etag, nil
+}
 
-	if err := parseStatusCode(resp.StatusCode); err != nil {
-		return nil, err
-	}
+// GetBatchDetailIfChanged returns the detail of a batch along with its ETag. If etag is not empty and the batch has
+// not changed since it was returned, ErrNotModified is returned instead.
+func (s *ServiceClient) GetBatchDetailIfChanged(ctx context.Context, id, etag string) (*BatchDetail, string,
+	error,
+) {
+	var batch BatchDetail
 
-	var batches []BatchSummary
-	if err = json.NewDecoder(resp.Body).Decode(&batches); err != nil {
-		return nil, errors.Wrap(err, "could not decode Batches")
+	etag, err := s.getIfChanged(ctx, fmt.Sprintf("%s/%s/%s", s.apiURL, batchesPath, id), etag, "Batch", &batch)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return batches, nil
+	return &batch, etag, nil
 }
 
-func (s *ServiceClient) GetBatchDetail(ctx context.Context, id string) (*BatchDetail, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s/%s", apiURL, batchesPath, id), nil)
+func (s *ServiceClient) getIfChanged(ctx context.Context, url, etag, name string, v interface{}) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not create GET request for Batch")
+		return "", errors.Wrapf(err, "could not create GET request for %s", name)
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
 	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not GET Batch")
+		return "", errors.Wrapf(err, "could not GET %s", name)
 	}
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return "", ErrNotModified
+	}
+
 	if err := parseStatusCode(resp.StatusCode); err != nil {
-		return nil, err
+		return "", err
 	}
 
-	var batch BatchDetail
-	if err = json.NewDecoder(resp.Body).Decode(&batch); err != nil {
-		return nil, errors.Wrap(err, "could not decode Batch")
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", errors.Wrapf(err, "could not decode %s", name)
 	}
 
-	return &batch, nil
+	return resp.Header.Get("ETag"), nil
 }
 
+// Log posts the entry to the Brewfather log URL. Brewfather only accepts one entry per device every 15 minutes, which
+// is left to the caller. Outbox can be used to queue entries until they can be sent.
 func (s *ServiceClient) Log(ctx context.Context, log LogEntry) error {
 	if s.logURL == "" {
 		return ErrLogURLNotSet
 	}
 
-	if time.Now().After(s.nextSendTime) {
-		data, err := json.Marshal(log)
-		if err != nil {
-			return errors.Wrap(err, "could not marshal Tilt log entry")
-		}
+	data, err := json.Marshal(log)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal Tilt log entry")
+	}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.logURL, bytes.NewBuffer(data))
-		if err != nil {
-			return errors.Wrap(err, "could not create POST request for Tilt log entry")
-		}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.logURL, bytes.NewBuffer(data))
+	if err != nil {
+		return errors.Wrap(err, "could not create POST request for Tilt log entry")
+	}
 
-		resp, err := s.client.Do(req)
-		if err != nil {
-			return errors.Wrap(err, "could not POST Tilt log entry")
-		}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not POST Tilt log entry")
+	}
 
-		defer resp.Body.Close()
+	defer resp.Body.Close()
 
-		if err := parseStatusCode(resp.StatusCode); err != nil {
-			return err
-		}
+	if err := parseStatusCode(resp.StatusCode); err != nil {
+		return err
+	}
 
-		s.nextSendTime = time.Now().Add(logInterval)
+	// other errors must be reported so that the entry is sent again
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("could not POST Tilt log entry: %s", resp.Status)
 	}
 
 	return nil
 }
 
-func (s *ServiceClient) UpdateSettings(userID, apiKey, tiltURL string) {
+// UpdateBatch patches the batch with the given ID. ErrUpdatesDisabled is returned if batch updates are not enabled.
+func (s *ServiceClient) UpdateBatch(ctx context.Context, id string, update BatchUpdate) error {
+	if !s.batchUpdates {
+		return ErrUpdatesDisabled
+	}
+
+	u, err := url.Parse(fmt.Sprintf("%s/%s/%s", s.apiURL, batchesPath, url.PathEscape(id)))
+	if err != nil {
+		return errors.Wrap(err, "could not parse Batch url")
+	}
+
+	u.RawQuery = update.query().Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, u.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "could not create PATCH request for Batch")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not PATCH Batch")
+	}
+
+	defer resp.Body.Close()
+
+	return parseStatusCode(resp.StatusCode)
+}
+
+func (s *ServiceClient) UpdateSettings(userID, apiKey, tiltURL string, batchUpdates bool) {
 	if userID != s.userID || apiKey != s.apiKey {
 		s.client = createHTTPClient(userID, apiKey)
 	}
 
 	s.logURL = tiltURL
+	s.batchUpdates = batchUpdates
 }
 
 type transport struct {
@@ -0,0 +1,108 @@
+package brewfather_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestUpdateBatch(t *testing.T) {
+	t.Parallel()
+	t.Run("updateBatch", updateBatch)
+	t.Run("updateBatchStatusOnly", updateBatchStatusOnly)
+	t.Run("updateBatchNotFound", updateBatchNotFound)
+	t.Run("updateBatchDisabled", updateBatchDisabled)
+}
+
+type patchRequest struct {
+	method string
+	path   string
+	query  url.Values
+	user   string
+	key    string
+}
+
+func setupUpdateBatchTest(t *testing.T, status int, enabled bool) (*brewfather.ServiceClient, chan patchRequest) {
+	t.Helper()
+
+	requests := make(chan patchRequest, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, key, _ := r.BasicAuth()
+		requests <- patchRequest{method: r.Method, path: r.URL.Path, query: r.URL.Query(), user: user, key: key}
+
+		w.WriteHeader(status)
+	}))
+
+	t.Cleanup(server.Close)
+
+	client := brewfather.New("user", "key", "", brewfather.SetAPIURL(server.URL),
+		brewfather.EnableBatchUpdates(enabled))
+
+	return client, requests
+}
+
+func updateBatch(t *testing.T) {
+	t.Parallel()
+
+	client, requests := setupUpdateBatchTest(t, http.StatusOK, true)
+
+	og, fg := 1.0521, 1.011
+	err := client.UpdateBatch(context.Background(), batchID, brewfather.BatchUpdate{
+		Status:     brewfather.ConditioningStatus,
+		MeasuredOG: &og,
+		MeasuredFG: &fg,
+	})
+	assert.NoError(t, err)
+
+	r := <-requests
+	assert.Equal(t, http.MethodPatch, r.method)
+	assert.Equal(t, "/batches/"+batchID, r.path)
+	assert.Equal(t, url.Values{
+		"status":     {"Conditioning"},
+		"measuredOg": {"1.052"},
+		"measuredFg": {"1.011"},
+	}, r.query)
+	assert.Equal(t, "user", r.user)
+	assert.Equal(t, "key", r.key)
+}
+
+func updateBatchStatusOnly(t *testing.T) {
+	t.Parallel()
+
+	client, requests := setupUpdateBatchTest(t, http.StatusOK, true)
+
+	err := client.UpdateBatch(context.Background(), batchID,
+		brewfather.BatchUpdate{Status: brewfather.FermentingStatus})
+	assert.NoError(t, err)
+
+	r := <-requests
+	assert.Equal(t, url.Values{"status": {"Fermenting"}}, r.query)
+}
+
+func updateBatchNotFound(t *testing.T) {
+	t.Parallel()
+
+	client, _ := setupUpdateBatchTest(t, http.StatusNotFound, true)
+
+	err := client.UpdateBatch(context.Background(), batchID,
+		brewfather.BatchUpdate{Status: brewfather.FermentingStatus})
+	assert.ErrorIs(t, err, brewfather.ErrNotFound)
+}
+
+func updateBatchDisabled(t *testing.T) {
+	t.Parallel()
+
+	client, requests := setupUpdateBatchTest(t, http.StatusOK, false)
+
+	err := client.UpdateBatch(context.Background(), batchID,
+		brewfather.BatchUpdate{Status: brewfather.FermentingStatus})
+	assert.ErrorIs(t, err, brewfather.ErrUpdatesDisabled)
+	assert.Empty(t, requests)
+}
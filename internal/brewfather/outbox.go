@@ -0,0 +1,477 @@
+package brewfather
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	logInterval             = 15 * time.Minute
+	defaultRetryDelay       = 30 * time.Second
+	defaultOutboxPollPeriod = 10 * time.Second
+	outboxDepthMetric       = "zymurgauge.brewfather.outbox_depth"
+	outboxSentMetric        = "zymurgauge.brewfather.outbox_sent"
+	outboxFailureMetric     = "zymurgauge.brewfather.outbox_failure"
+	floatBitSize            = 64
+)
+
+var _ Service = (*Outbox)(nil)
+
+// averagedFields are the numeric fields of a LogEntry that are averaged over the readings in a window. The other
+// fields are taken from the last reading.
+var averagedFields = map[string]func(l *LogEntry) *string{
+	"temp":     func(l *LogEntry) *string { return &l.BeerTemperature },
+	"aux_temp": func(l *LogEntry) *string { return &l.AuxiliaryTemperature },
+	"ext_temp": func(l *LogEntry) *string { return &l.ExternalTemperature },
+	"gravity":  func(l *LogEntry) *string { return &l.Gravity },
+	"pressure": func(l *LogEntry) *string { return &l.Pressure },
+	"ph":       func(l *LogEntry) *string { return &l.Ph },
+	"bpm":      func(l *LogEntry) *string { return &l.BPM },
+	"battery":  func(l *LogEntry) *string { return &l.Battery },
+}
+
+// OutboxItem holds the readings of a device that are waiting to be sent to Brewfather.
+type OutboxItem struct {
+	DeviceName  string             `json:"deviceName"`
+	Entry       *LogEntry          `json:"entry,omitempty"`
+	Sums        map[string]float64 `json:"sums,omitempty"`
+	Counts      map[string]int     `json:"counts,omitempty"`
+	Readings    int                `json:"readings"`
+	LastSent    time.Time          `json:"lastSent"`
+	Attempts    int                `json:"attempts"`
+	NextAttempt time.Time          `json:"nextAttempt"`
+	LastError   string             `json:"lastError,omitempty"`
+	sending     bool
+}
+
+// OutboxRepo persists outbox items so that readings survive restarts.
+type OutboxRepo interface {
+	GetAll() ([]*OutboxItem, error)
+	Save(item *OutboxItem) error
+}
+
+// OutboxReporter reports the status of an Outbox.
+type OutboxReporter interface {
+	Status() OutboxStatus
+}
+
+// OutboxStatus is the status of an Outbox. Depth is the number of devices with readings waiting to be sent and Sent
+// and Failures are counted since the outbox was started.
+type OutboxStatus struct {
+	Depth    int                  `json:"depth"`
+	Sent     int                  `json:"sent"`
+	Failures int                  `json:"failures"`
+	Devices  []OutboxDeviceStatus `json:"devices"`
+}
+
+type OutboxDeviceStatus struct {
+	DeviceName      string     `json:"deviceName"`
+	PendingReadings int        `json:"pendingReadings"`
+	LastSent        *time.Time `json:"lastSent,omitempty"`
+	NextSend        *time.Time `json:"nextSend,omitempty"`
+	Attempts        int        `json:"attempts"`
+	LastError       string     `json:"lastError,omitempty"`
+}
+
+// Outbox is a Service that queues log entries instead of sending them right away. The readings of each device are
+// aggregated and sent at most once per Brewfather's 15 minute log interval. Entries that can not be sent are retried
+// with exponential backoff, or after the log interval if Brewfather responds with 429.
+type Outbox struct {
+	service    Service
+	repo       OutboxRepo
+	logger     *logrus.Logger
+	metrics    metrics.Metrics
+	clock      clock.Clock
+	interval   time.Duration
+	retryDelay time.Duration
+	pollPeriod time.Duration
+	items      map[string]*OutboxItem
+	sent       int
+	failures   int
+	mutex      sync.Mutex
+}
+
+// NewOutbox creates a new Outbox around the given service and loads the items persisted in the given repo.
+func NewOutbox(service Service, repo OutboxRepo, logger *logrus.Logger, metrics metrics.Metrics,
+	options ...OutboxOptionsFunc,
+) (*Outbox, error) {
+	o := &Outbox{
+		service:    service,
+		repo:       repo,
+		logger:     logger,
+		metrics:    metrics,
+		clock:      clock.NewRealClock(),
+		interval:   logInterval,
+		retryDelay: defaultRetryDelay,
+		pollPeriod: defaultOutboxPollPeriod,
+		items:      make(map[string]*OutboxItem),
+	}
+
+	for _, option := range options {
+		option(o)
+	}
+
+	items, err := repo.GetAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get all outbox items from repository")
+	}
+
+	for _, item := range items {
+		o.items[item.DeviceName] = item
+	}
+
+	return o, nil
+}
+
+type OutboxOptionsFunc func(*Outbox)
+
+func SetOutboxClock(clock clock.Clock) OutboxOptionsFunc {
+	return func(o *Outbox) {
+		o.clock = clock
+	}
+}
+
+// LogInterval sets how often the readings of a device are sent.
+func LogInterval(interval time.Duration) OutboxOptionsFunc {
+	return func(o *Outbox) {
+		o.interval = interval
+	}
+}
+
+// RetryDelay sets the delay before the first retry. It doubles with each failed attempt, up to the log interval.
+func RetryDelay(delay time.Duration) OutboxOptionsFunc {
+	return func(o *Outbox) {
+		o.retryDelay = delay
+	}
+}
+
+// PollPeriod sets how often the outbox checks for entries that are due.
+func PollPeriod(period time.Duration) OutboxOptionsFunc {
+	return func(o *Outbox) {
+		o.pollPeriod = period
+	}
+}
+
+func (o *Outbox) GetAllBatchSummaries(ctx context.Context) ([]BatchSummary, error) {
+	return o.service.GetAllBatchSummaries(ctx)
+}
+
+func (o *Outbox) GetBatchDetail(ctx context.Context, id string) (*BatchDetail, error) {
+	return o.service.GetBatchDetail(ctx, id)
+}
+
+func (o *Outbox) UpdateBatch(ctx context.Context, id string, update BatchUpdate) error {
+	return o.service.UpdateBatch(ctx, id, update)
+}
+
+// Log adds the entry to the readings that are waiting to be sent for its device.
+func (o *Outbox) Log(_ context.Context, log LogEntry) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	item, ok := o.items[log.DeviceName]
+	if !ok {
+		item = &OutboxItem{DeviceName: log.DeviceName}
+		o.items[log.DeviceName] = item
+	}
+
+	item.add(log)
+
+	if err := o.repo.Save(item); err != nil {
+		return errors.Wrapf(err, "could not save outbox item for %s to repository", log.DeviceName)
+	}
+
+	o.gaugeDepth()
+
+	return nil
+}
+
+// Run sends the entries that are due until the context is canceled.
+func (o *Outbox) Run(ctx context.Context) {
+	for {
+		o.Flush(ctx)
+
+		timer := o.clock.NewTimer(o.pollPeriod)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		}
+	}
+}
+
+// Flush sends the entries that are due. The outbox is not locked while they are sent, so readings that are added in
+// the meantime are kept and sent with the next entry.
+func (o *Outbox) Flush(ctx context.Context) {
+	for _, s := range o.takeDue() {
+		o.complete(s, o.service.Log(ctx, s.entry))
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.gaugeDepth()
+}
+
+// outboxSend is an entry that is being sent along with the readings it was aggregated from.
+type outboxSend struct {
+	item     *OutboxItem
+	entry    LogEntry
+	sums     map[string]float64
+	counts   map[string]int
+	readings int
+	time     time.Time
+}
+
+// takeDue returns the entries that are due and marks their items as being sent so that a concurrent Flush does not
+// send them again.
+func (o *Outbox) takeDue() []*outboxSend {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	now := o.clock.Now()
+
+	var due []*outboxSend
+
+	for _, item := range o.items {
+		if item.sending || item.Readings == 0 || now.Before(item.due(o.interval)) {
+			continue
+		}
+
+		item.sending = true
+		sums, counts := item.copyAggregates()
+
+		due = append(due, &outboxSend{
+			item:     item,
+			entry:    item.aggregate(),
+			sums:     sums,
+			counts:   counts,
+			readings: item.Readings,
+			time:     now,
+		})
+	}
+
+	return due
+}
+
+// complete updates the item of the sent entry with the result of sending it.
+func (o *Outbox) complete(s *outboxSend, err error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	item := s.item
+	item.sending = false
+
+	switch {
+	case err == nil:
+		item.remove(s)
+		item.LastSent = s.time
+		o.sent++
+		o.increment(outboxSentMetric)
+	case errors.Is(err, ErrLogURLNotSet):
+		o.logger.Debugf("Discarding %d readings for %s since the Brewfather log URL is not set.", s.readings,
+			item.DeviceName)
+		item.remove(s)
+	default:
+		item.Attempts++
+		item.LastError = err.Error()
+		item.NextAttempt = s.time.Add(o.backoff(item.Attempts, err))
+		o.failures++
+		o.increment(outboxFailureMetric)
+
+		o.logger.WithError(err).Warnf("Could not send %d readings for %s to Brewfather, will retry at %s.",
+			s.readings, item.DeviceName, item.NextAttempt.Format(time.RFC3339))
+	}
+
+	if err := o.repo.Save(item); err != nil {
+		o.logger.WithError(err).Errorf("Could not save outbox item for %s.", item.DeviceName)
+	}
+}
+
+// backoff returns the delay before the given attempt is retried. Brewfather is not contacted again for a full log
+// interval if it is rate limiting.
+func (o *Outbox) backoff(attempts int, err error) time.Duration {
+	if errors.Is(err, ErrTooManyRequests) {
+		return o.interval
+	}
+
+	delay := o.retryDelay
+	for i := 1; i < attempts && delay < o.interval; i++ {
+		delay *= 2
+	}
+
+	if delay > o.interval {
+		delay = o.interval
+	}
+
+	return delay
+}
+
+// Status returns the status of the outbox, with devices sorted by name.
+func (o *Outbox) Status() OutboxStatus {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	status := OutboxStatus{
+		Depth:    o.depth(),
+		Sent:     o.sent,
+		Failures: o.failures,
+		Devices:  []OutboxDeviceStatus{},
+	}
+
+	for _, item := range o.items {
+		d := OutboxDeviceStatus{
+			DeviceName:      item.DeviceName,
+			PendingReadings: item.Readings,
+			Attempts:        item.Attempts,
+			LastError:       item.LastError,
+		}
+
+		if !item.LastSent.IsZero() {
+			lastSent := item.LastSent
+			d.LastSent = &lastSent
+		}
+
+		if item.Readings > 0 {
+			nextSend := item.due(o.interval)
+			d.NextSend = &nextSend
+		}
+
+		status.Devices = append(status.Devices, d)
+	}
+
+	sort.Slice(status.Devices, func(i, j int) bool {
+		return status.Devices[i].DeviceName < status.Devices[j].DeviceName
+	})
+
+	return status
+}
+
+func (o *Outbox) depth() int {
+	depth := 0
+
+	for _, item := range o.items {
+		if item.Readings > 0 {
+			depth++
+		}
+	}
+
+	return depth
+}
+
+func (o *Outbox) gaugeDepth() {
+	if isNil(o.metrics) {
+		return
+	}
+
+	o.metrics.Gauge(outboxDepthMetric, o.depth())
+}
+
+func (o *Outbox) increment(bucket string) {
+	if isNil(o.metrics) {
+		return
+	}
+
+	o.metrics.Increment(bucket)
+}
+
+// add aggregates the entry into the item.
+func (i *OutboxItem) add(log LogEntry) {
+	if i.Sums == nil {
+		i.Sums = make(map[string]float64)
+		i.Counts = make(map[string]int)
+	}
+
+	for name, field := range averagedFields {
+		if v, err := strconv.ParseFloat(*field(&log), floatBitSize); err == nil {
+			i.Sums[name] += v
+			i.Counts[name]++
+		}
+	}
+
+	i.Entry = &log
+	i.Readings++
+}
+
+// aggregate returns the last entry with its numeric fields replaced by their averages.
+func (i *OutboxItem) aggregate() LogEntry {
+	l := *i.Entry
+
+	for name, field := range averagedFields {
+		if i.Counts[name] > 0 {
+			*field(&l) = strconv.FormatFloat(i.Sums[name]/float64(i.Counts[name]), 'f', -1,
+				floatBitSize)
+		}
+	}
+
+	return l
+}
+
+// copyAggregates returns copies of the sums and counts of the item's readings.
+func (i *OutboxItem) copyAggregates() (map[string]float64, map[string]int) {
+	sums := make(map[string]float64, len(i.Sums))
+	counts := make(map[string]int, len(i.Counts))
+
+	for name, sum := range i.Sums {
+		sums[name] = sum
+		counts[name] = i.Counts[name]
+	}
+
+	return sums, counts
+}
+
+// remove removes the readings of the sent entry from the item, keeping those that were added while it was sent.
+func (i *OutboxItem) remove(s *outboxSend) {
+	if i.Readings <= s.readings {
+		i.reset()
+
+		return
+	}
+
+	for name, sum := range s.sums {
+		i.Sums[name] -= sum
+		i.Counts[name] -= s.counts[name]
+
+		if i.Counts[name] <= 0 {
+			delete(i.Sums, name)
+			delete(i.Counts, name)
+		}
+	}
+
+	i.Readings -= s.readings
+	i.Attempts = 0
+	i.NextAttempt = time.Time{}
+	i.LastError = ""
+}
+
+func (i *OutboxItem) reset() {
+	i.Entry = nil
+	i.Sums = nil
+	i.Counts = nil
+	i.Readings = 0
+	i.Attempts = 0
+	i.NextAttempt = time.Time{}
+	i.LastError = ""
+}
+
+// due returns when the item can be sent, which is one log interval after the last entry was sent or when the next
+// attempt is scheduled, whichever is later.
+func (i *OutboxItem) due(interval time.Duration) time.Time {
+	due := i.LastSent.Add(interval)
+	if i.NextAttempt.After(due) {
+		return i.NextAttempt
+	}
+
+	return due
+}
@@ -0,0 +1,290 @@
+package brewfather_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	deviceName    = "Chamber 1"
+	logInterval   = 15 * time.Minute
+	retryDelay    = 30 * time.Second
+	someErrMsg    = "some error"
+	outboxFailure = "zymurgauge.brewfather.outbox_failure"
+)
+
+var errSomeError = errors.New(someErrMsg)
+
+type testOutboxRepo struct {
+	mutex sync.Mutex
+	items map[string]brewfather.OutboxItem
+}
+
+func (r *testOutboxRepo) GetAll() ([]*brewfather.OutboxItem, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	items := []*brewfather.OutboxItem{}
+
+	for _, item := range r.items {
+		item := item
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+func (r *testOutboxRepo) Save(item *brewfather.OutboxItem) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.items[item.DeviceName] = *item
+
+	return nil
+}
+
+func newTestOutboxRepo() *testOutboxRepo {
+	return &testOutboxRepo{items: make(map[string]brewfather.OutboxItem)}
+}
+
+func setupOutboxTest(t *testing.T, serviceMock *mocks.Service, repo *testOutboxRepo, clock *testClock,
+) (*brewfather.Outbox, *mocks.Metrics) {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+
+	metricsMock := &mocks.Metrics{}
+	metricsMock.On("Gauge", mock.Anything, mock.Anything).Return()
+	metricsMock.On("Increment", mock.Anything).Return()
+
+	outbox, err := brewfather.NewOutbox(serviceMock, repo, l, metricsMock, brewfather.SetOutboxClock(clock),
+		brewfather.LogInterval(logInterval), brewfather.RetryDelay(retryDelay))
+	assert.NoError(t, err)
+
+	return outbox, metricsMock
+}
+
+func logEntries(t *testing.T, outbox *brewfather.Outbox, entries ...brewfather.LogEntry) {
+	t.Helper()
+
+	for _, e := range entries {
+		err := outbox.Log(context.Background(), e)
+		assert.NoError(t, err)
+	}
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestOutbox(t *testing.T) {
+	t.Parallel()
+	t.Run("outboxAggregates", outboxAggregates)
+	t.Run("outboxHonorsLogInterval", outboxHonorsLogInterval)
+	t.Run("outboxBacksOff", outboxBacksOff)
+	t.Run("outboxTooManyRequests", outboxTooManyRequests)
+	t.Run("outboxLogURLNotSet", outboxLogURLNotSet)
+	t.Run("outboxPersisted", outboxPersisted)
+	t.Run("outboxLogWhileSending", outboxLogWhileSending)
+}
+
+func outboxAggregates(t *testing.T) {
+	t.Parallel()
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, brewfather.LogEntry{
+		DeviceName:      deviceName,
+		BeerTemperature: "21",
+		Gravity:         "1.05",
+		TemperatureUnit: "C",
+		Comment:         "last",
+	}).Return(nil).Once()
+
+	outbox, metricsMock := setupOutboxTest(t, serviceMock, newTestOutboxRepo(), &testClock{now: time.Now()})
+
+	logEntries(t, outbox,
+		brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20", TemperatureUnit: "C", Comment: "first"},
+		brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "21", Gravity: "1.050", TemperatureUnit: "C"},
+		brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "22", TemperatureUnit: "C", Comment: "last"})
+
+	assert.Equal(t, 1, outbox.Status().Depth)
+
+	outbox.Flush(context.Background())
+
+	serviceMock.AssertExpectations(t)
+	metricsMock.AssertCalled(t, "Increment", "zymurgauge.brewfather.outbox_sent")
+	metricsMock.AssertCalled(t, "Gauge", "zymurgauge.brewfather.outbox_depth", 0)
+
+	status := outbox.Status()
+	assert.Equal(t, 0, status.Depth)
+	assert.Equal(t, 1, status.Sent)
+	assert.Equal(t, 0, status.Devices[0].PendingReadings)
+	assert.NotNil(t, status.Devices[0].LastSent)
+}
+
+func outboxHonorsLogInterval(t *testing.T) {
+	t.Parallel()
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	clock := &testClock{now: time.Now()}
+	outbox, _ := setupOutboxTest(t, serviceMock, newTestOutboxRepo(), clock)
+
+	logEntries(t, outbox, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20"})
+	outbox.Flush(context.Background())
+
+	clock.add(logInterval - time.Minute)
+	logEntries(t, outbox, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20"})
+	outbox.Flush(context.Background())
+
+	serviceMock.AssertNumberOfCalls(t, "Log", 1)
+
+	status := outbox.Status()
+	assert.Equal(t, 1, status.Devices[0].PendingReadings)
+	assert.Equal(t, clock.Now().Add(time.Minute), *status.Devices[0].NextSend)
+
+	clock.add(time.Minute)
+	outbox.Flush(context.Background())
+
+	serviceMock.AssertNumberOfCalls(t, "Log", 2)
+}
+
+func outboxBacksOff(t *testing.T) {
+	t.Parallel()
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(errSomeError)
+
+	clock := &testClock{now: time.Now()}
+	outbox, metricsMock := setupOutboxTest(t, serviceMock, newTestOutboxRepo(), clock)
+
+	logEntries(t, outbox, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20"})
+	outbox.Flush(context.Background())
+
+	status := outbox.Status()
+	assert.Equal(t, 1, status.Failures)
+	assert.Equal(t, 1, status.Devices[0].Attempts)
+	assert.Equal(t, someErrMsg, status.Devices[0].LastError)
+	assert.Equal(t, clock.Now().Add(retryDelay), *status.Devices[0].NextSend)
+	metricsMock.AssertCalled(t, "Increment", outboxFailure)
+
+	clock.add(retryDelay - time.Second)
+	outbox.Flush(context.Background())
+	serviceMock.AssertNumberOfCalls(t, "Log", 1)
+
+	clock.add(time.Second)
+	outbox.Flush(context.Background())
+	serviceMock.AssertNumberOfCalls(t, "Log", 2)
+
+	// the delay doubles with each attempt
+	status = outbox.Status()
+	assert.Equal(t, 2, status.Devices[0].Attempts)
+	assert.Equal(t, clock.Now().Add(2*retryDelay), *status.Devices[0].NextSend)
+
+	// the readings are kept until they are sent
+	assert.Equal(t, 1, status.Depth)
+	assert.Equal(t, 1, status.Devices[0].PendingReadings)
+}
+
+func outboxTooManyRequests(t *testing.T) {
+	t.Parallel()
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(brewfather.ErrTooManyRequests)
+
+	clock := &testClock{now: time.Now()}
+	outbox, _ := setupOutboxTest(t, serviceMock, newTestOutboxRepo(), clock)
+
+	logEntries(t, outbox, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20"})
+	outbox.Flush(context.Background())
+
+	status := outbox.Status()
+	assert.Equal(t, clock.Now().Add(logInterval), *status.Devices[0].NextSend)
+}
+
+func outboxLogURLNotSet(t *testing.T) {
+	t.Parallel()
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(brewfather.ErrLogURLNotSet)
+
+	outbox, _ := setupOutboxTest(t, serviceMock, newTestOutboxRepo(), &testClock{now: time.Now()})
+
+	logEntries(t, outbox, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20"})
+	outbox.Flush(context.Background())
+
+	status := outbox.Status()
+	assert.Equal(t, 0, status.Depth)
+	assert.Equal(t, 0, status.Failures)
+}
+
+func outboxPersisted(t *testing.T) {
+	t.Parallel()
+
+	repo := newTestOutboxRepo()
+	clock := &testClock{now: time.Now()}
+
+	failingMock := &mocks.Service{}
+	failingMock.On("Log", mock.Anything, mock.Anything).Return(errSomeError)
+
+	outbox, _ := setupOutboxTest(t, failingMock, repo, clock)
+
+	logEntries(t, outbox,
+		brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20"},
+		brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "22"})
+	outbox.Flush(context.Background())
+
+	// a new outbox, as after a restart, sends the persisted readings once the retry is due
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "21"}).
+		Return(nil).Once()
+
+	restarted, _ := setupOutboxTest(t, serviceMock, repo, clock)
+	assert.Equal(t, 1, restarted.Status().Depth)
+
+	clock.add(retryDelay)
+	restarted.Flush(context.Background())
+
+	serviceMock.AssertExpectations(t)
+}
+
+func outboxLogWhileSending(t *testing.T) {
+	t.Parallel()
+
+	var outbox *brewfather.Outbox
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "20"}).
+		Return(nil).Once().Run(func(args mock.Arguments) {
+		// would deadlock if the outbox was locked while sending
+		logEntries(t, outbox, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "30"})
+	})
+	serviceMock.On("Log", mock.Anything, brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "30"}).
+		Return(nil).Once()
+
+	clock := &testClock{now: time.Now()}
+	outbox, _ = setupOutboxTest(t, serviceMock, newTestOutboxRepo(), clock)
+
+	logEntries(t, outbox,
+		brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "19"},
+		brewfather.LogEntry{DeviceName: deviceName, BeerTemperature: "21"})
+	outbox.Flush(context.Background())
+
+	// the reading added while sending is kept for the next entry
+	status := outbox.Status()
+	assert.Equal(t, 1, status.Sent)
+	assert.Equal(t, 1, status.Devices[0].PendingReadings)
+
+	clock.add(logInterval)
+	outbox.Flush(context.Background())
+
+	serviceMock.AssertExpectations(t)
+	assert.Equal(t, 0, outbox.Status().Devices[0].PendingReadings)
+}
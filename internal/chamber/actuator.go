@@ -0,0 +1,188 @@
+package chamber
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/device"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/pkg/errors"
+)
+
+var _ device.Actuator = (*trackedActuator)(nil)
+
+// ActuatorStatus is the state of an actuator along with how much it has run today. Days start at local midnight, or
+// when the chamber was configured if that was later.
+type ActuatorStatus struct {
+	IsOn               bool       `json:"isOn"`
+	LastTransitionTime *time.Time `json:"lastTransitionTime,omitempty"`
+	// OnTime is the number of seconds the actuator has been on today.
+	OnTime int64 `json:"onTime"`
+	// Cycles is the number of times the actuator has been turned on today.
+	Cycles int `json:"cycles"`
+	// DutyPercent is the percentage of today that the actuator has been on.
+	DutyPercent float64 `json:"dutyPercent"`
+}
+
+// String returns a short summary of the status, such as "on, 2h13m0s today (45%), 12 cycles".
+func (s *ActuatorStatus) String() string {
+	state := "off"
+	if s.IsOn {
+		state = "on"
+	}
+
+	onTime := time.Duration(s.OnTime) * time.Second
+
+	return fmt.Sprintf("%s, %s today (%.0f%%), %d cycles", state, onTime, s.DutyPercent, s.Cycles)
+}
+
+// trackedActuator wraps an Actuator and keeps track of whether it is on and how long it has been on today. onChange is
+// called whenever the actuator is switched.
+type trackedActuator struct {
+	actuator           device.Actuator
+	clock              clock.Clock
+	onChange           func(isOn bool)
+	isOn               bool
+	lastTransitionTime time.Time
+	onSince            time.Time
+	periodStart        time.Time
+	onTime             time.Duration
+	cycles             int
+	mutex              sync.Mutex
+}
+
+// trackActuator wraps the given Actuator. A nil Actuator is returned as is so that nil checks continue to work.
+func trackActuator(actuator device.Actuator, clock clock.Clock, onChange func(isOn bool)) device.Actuator {
+	if actuator == nil {
+		return nil
+	}
+
+	return &trackedActuator{
+		actuator:    actuator,
+		clock:       clock,
+		onChange:    onChange,
+		periodStart: clock.Now(),
+	}
+}
+
+func (a *trackedActuator) On() error {
+	if err := a.actuator.On(); err != nil {
+		return errors.Wrap(err, "could not turn actuator on")
+	}
+
+	a.setIsOn(true)
+
+	return nil
+}
+
+func (a *trackedActuator) Off() error {
+	if err := a.actuator.Off(); err != nil {
+		return errors.Wrap(err, "could not turn actuator off")
+	}
+
+	a.setIsOn(false)
+
+	return nil
+}
+
+func (a *trackedActuator) setIsOn(isOn bool) {
+	a.mutex.Lock()
+
+	changed := a.isOn != isOn
+
+	if changed {
+		now := a.clock.Now()
+		a.rollOver(now)
+
+		if isOn {
+			a.cycles++
+			a.onSince = now
+		} else {
+			a.onTime += now.Sub(a.onSince)
+		}
+
+		a.isOn = isOn
+		a.lastTransitionTime = now
+	}
+
+	a.mutex.Unlock()
+
+	if changed && a.onChange != nil {
+		a.onChange(isOn)
+	}
+}
+
+func (a *trackedActuator) IsOn() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.isOn
+}
+
+func (a *trackedActuator) Status() *ActuatorStatus {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	now := a.clock.Now()
+	a.rollOver(now)
+
+	onTime := a.onTime
+	if a.isOn {
+		onTime += now.Sub(a.onSince)
+	}
+
+	status := &ActuatorStatus{
+		IsOn:   a.isOn,
+		OnTime: int64(onTime / time.Second),
+		Cycles: a.cycles,
+	}
+
+	if elapsed := now.Sub(a.periodStart); elapsed > 0 {
+		status.DutyPercent = float64(onTime) / float64(elapsed) * percent
+	}
+
+	if !a.lastTransitionTime.IsZero() {
+		t := a.lastTransitionTime
+		status.LastTransitionTime = &t
+	}
+
+	return status
+}
+
+// rollOver resets the on-time and cycle count when a new day has started. If the actuator is on, its on-time for the
+// new day is counted from midnight. rollOver must be called while holding mutex.
+func (a *trackedActuator) rollOver(now time.Time) {
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	if !a.periodStart.Before(midnight) {
+		return
+	}
+
+	a.periodStart = midnight
+	a.onTime = 0
+	a.cycles = 0
+
+	if a.isOn {
+		a.onSince = midnight
+	}
+}
+
+// isActuatorOn returns whether the given actuator is on. Actuators that are not tracked are reported as off.
+func isActuatorOn(actuator device.Actuator) bool {
+	if a, ok := actuator.(*trackedActuator); ok {
+		return a.IsOn()
+	}
+
+	return false
+}
+
+// getActuatorStatus returns the status of the given actuator or nil if it is not tracked.
+func getActuatorStatus(actuator device.Actuator) *ActuatorStatus {
+	if a, ok := actuator.(*trackedActuator); ok {
+		return a.Status()
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package chamber_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type manualClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *manualClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *manualClock) NewTimer(d time.Duration) *time.Timer {
+	return time.NewTimer(d)
+}
+
+func (c *manualClock) add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestActuatorStatus(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2023, 5, 10, 23, 0, 0, 0, time.UTC)
+	clock := &manualClock{now: start}
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	c := createTestChambers()[0]
+
+	err := c.Configure(configuratorMock, serviceMock, l, m, readingUpdateInterval, chamber.SetClock(clock))
+	assert.NoError(t, err)
+
+	status := getChamberStatus(t, c)
+	assert.Equal(t, &chamber.ActuatorStatus{}, status.Chiller)
+	assert.Equal(t, &chamber.ActuatorStatus{}, status.Heater)
+
+	err = c.StartFermentation(context.Background(), "Primary")
+	assert.NoError(t, err)
+
+	// the beer is warmer than the set point so the chiller is turned on
+	assert.Eventually(t, func() bool { return getChamberStatus(t, c).Chiller.IsOn }, time.Second,
+		10*time.Millisecond)
+
+	clock.add(30 * time.Minute)
+
+	status = getChamberStatus(t, c)
+	assert.Equal(t, &chamber.ActuatorStatus{
+		IsOn: true, LastTransitionTime: &start, OnTime: 1800, Cycles: 1, DutyPercent: 100,
+	}, status.Chiller)
+	assert.Equal(t, &chamber.ActuatorStatus{}, status.Heater)
+
+	clock.add(time.Hour) // a new day starts at midnight
+
+	status = getChamberStatus(t, c)
+	assert.Equal(t, &chamber.ActuatorStatus{
+		IsOn: true, LastTransitionTime: &start, OnTime: 1800, Cycles: 0, DutyPercent: 100,
+	}, status.Chiller)
+
+	err = c.StopFermentation()
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return !getChamberStatus(t, c).Chiller.IsOn }, time.Second,
+		10*time.Millisecond)
+
+	stopTime := start.Add(90 * time.Minute)
+
+	status = getChamberStatus(t, c)
+	assert.Equal(t, &chamber.ActuatorStatus{
+		IsOn: false, LastTransitionTime: &stopTime, OnTime: 1800, Cycles: 0, DutyPercent: 100,
+	}, status.Chiller)
+}
+
+func getChamberStatus(t *testing.T, c *chamber.Chamber) *chamber.Chamber {
+	t.Helper()
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+
+	var result chamber.Chamber
+
+	err = json.Unmarshal(data, &result)
+	assert.NoError(t, err)
+
+	return &result
+}
@@ -0,0 +1,139 @@
+package chamber
+
+import (
+	"context"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
+	"github.com/pkg/errors"
+)
+
+// StartAutoTune starts tuning the chamber's PID gains by oscillating the temperature around the given set point. The
+// tune runs in the background and can take several hours. When it succeeds, the chamber is switched to the PID
+// controller using the computed gains. onComplete is called with the outcome once the tune has finished.
+func (c *Chamber) StartAutoTune(ctx context.Context, setPoint float64, rule pid.TuningRule,
+	onComplete func(*pid.TuningResult, error),
+) error {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	if c.cancelFunc != nil {
+		return ErrFermenting
+	}
+
+	if c.autoTuneCancelFunc != nil {
+		return ErrAutoTuning
+	}
+
+	if rule != pid.ZieglerNichols && rule != pid.TyreusLuyben {
+		return pid.ErrInvalidTuningRule
+	}
+
+	options := []pid.AutoTunerOptionsFunc{pid.SetAutoTunerClock(c.clock)}
+
+	if c.ControllerConfig.ChillerMinOffTime > 0 {
+		options = append(options,
+			pid.TunerChillerMinOffTime(time.Duration(c.ControllerConfig.ChillerMinOffTime)*time.Second))
+	}
+
+	tuner := pid.NewAutoTuner(c.beerThermometer, c.chiller, c.heater, c.logger, options...)
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	c.autoTuneCancelFunc = cancelFunc
+
+	c.logger.Infof("Starting auto-tune for chamber %s at %.2f°C", c.Name, setPoint)
+
+	go func() {
+		result, err := tuner.Tune(ctx, setPoint, rule)
+		if err == nil {
+			err = c.applyTuningResult(result)
+		}
+
+		c.runMutex.Lock()
+		cancelFunc()
+		c.autoTuneCancelFunc = nil
+		c.runMutex.Unlock()
+
+		switch {
+		case errors.Is(err, context.Canceled):
+			c.logger.Infof("Auto-tune for chamber %s was stopped", c.Name)
+		case err != nil:
+			c.logger.WithError(err).Errorf("could not auto-tune chamber %s", c.Name)
+		default:
+			c.logger.Infof("Auto-tune for chamber %s complete, kP is %.4f, kI is %.4f and kD is %.4f", c.Name,
+				result.KP, result.KI, result.KD)
+		}
+
+		if onComplete != nil {
+			onComplete(result, err)
+		}
+	}()
+
+	return nil
+}
+
+// StopAutoTune cancels a running auto-tune. The chamber's controller configuration is left unchanged.
+func (c *Chamber) StopAutoTune() error {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	if c.autoTuneCancelFunc == nil {
+		return ErrNotAutoTuning
+	}
+
+	c.autoTuneCancelFunc()
+
+	return nil
+}
+
+// IsAutoTuning returns whether an auto-tune is running.
+func (c *Chamber) IsAutoTuning() bool {
+	c.runMutex.RLock()
+	defer c.runMutex.RUnlock()
+
+	return c.autoTuneCancelFunc != nil
+}
+
+// configCopy returns a copy of the configuration of the chamber, without its readings and status.
+func (c *Chamber) configCopy() *Chamber {
+	c.runMutex.RLock()
+	defer c.runMutex.RUnlock()
+
+	return &Chamber{
+		ID:                   c.ID,
+		Name:                 c.Name,
+		DeviceConfig:         c.DeviceConfig,
+		ControllerConfig:     c.ControllerConfig,
+		ChillingDifferential: c.ChillingDifferential,
+		HeatingDifferential:  c.HeatingDifferential,
+		RampRate:             c.RampRate,
+		Streams:              c.Streams,
+		CurrentBatch:         c.CurrentBatch,
+		ModTime:              c.ModTime,
+	}
+}
+
+func (c *Chamber) applyTuningResult(result *pid.TuningResult) error {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	config := c.ControllerConfig
+	config.Type = PIDControllerType
+	config.KP = result.KP
+	config.KI = result.KI
+	config.KD = result.KD
+
+	previous := c.ControllerConfig
+	c.ControllerConfig = config
+
+	temperatureController, err := c.createTemperatureController(c.logger)
+	if err != nil {
+		c.ControllerConfig = previous
+
+		return errors.Wrap(err, "could not create PID controller from tuning result")
+	}
+
+	c.temperatureController = temperatureController
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package chamber_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zymsim/simulator"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
+	"github.com/benjaminbartels/zymurgauge/internal/test/fakes"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// one hour of dilated time passes in 100ms.
+const autoTuneMultiplier = 36000
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestAutoTune(t *testing.T) {
+	t.Parallel()
+	t.Run("autoTuneStoresGains", autoTuneStoresGains)
+	t.Run("autoTuneStop", autoTuneStop)
+	t.Run("autoTuneWhileFermenting", autoTuneWhileFermenting)
+	t.Run("autoTuneNotFound", autoTuneNotFound)
+}
+
+func autoTuneStoresGains(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+
+	clock := fakes.NewDilatedClock(autoTuneMultiplier)
+	sim := simulator.New(20)
+
+	go sim.Run(ctx, clock)
+
+	savedCh := make(chan *chamber.Chamber, 1)
+	manager := setupAutoTuneTest(t, ctx, sim, clock, func(args mock.Arguments) {
+		savedCh <- args.Get(0).(*chamber.Chamber) //nolint:forcetypeassert // Always a chamber
+	})
+
+	err := manager.StartAutoTune(chamberID3, 18, pid.TyreusLuyben)
+	assert.NoError(t, err)
+
+	c, err := manager.Get(chamberID3)
+	assert.NoError(t, err)
+	assert.True(t, c.IsAutoTuning())
+
+	err = manager.StartFermentation(chamberID3, "Primary")
+	assert.ErrorIs(t, err, chamber.ErrAutoTuning)
+
+	select {
+	case saved := <-savedCh:
+		assert.NotSame(t, c, saved)
+		assert.Equal(t, chamber.PIDControllerType, saved.ControllerConfig.Type)
+		assert.Greater(t, saved.ControllerConfig.KP, 0.0)
+		assert.Greater(t, saved.ControllerConfig.KI, 0.0)
+		assert.Greater(t, saved.ControllerConfig.KD, 0.0)
+	case <-ctx.Done():
+		assert.Fail(t, "auto-tune should have completed by now")
+	}
+
+	assert.Eventually(t, func() bool { return !c.IsAutoTuning() }, 1*time.Second, 10*time.Millisecond)
+}
+
+func autoTuneStop(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+
+	clock := fakes.NewDilatedClock(autoTuneMultiplier)
+	sim := simulator.New(20)
+
+	manager := setupAutoTuneTest(t, ctx, sim, clock, nil)
+
+	err := manager.StopAutoTune(chamberID3)
+	assert.ErrorIs(t, err, chamber.ErrNotAutoTuning)
+
+	err = manager.StartAutoTune(chamberID3, 18, pid.ZieglerNichols)
+	assert.NoError(t, err)
+
+	err = manager.StartAutoTune(chamberID3, 18, pid.ZieglerNichols)
+	assert.ErrorIs(t, err, chamber.ErrAutoTuning)
+
+	err = manager.Delete(chamberID3)
+	assert.ErrorIs(t, err, chamber.ErrAutoTuning)
+
+	err = manager.StopAutoTune(chamberID3)
+	assert.NoError(t, err)
+
+	c, err := manager.Get(chamberID3)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return !c.IsAutoTuning() }, 1*time.Second, 10*time.Millisecond)
+	assert.Empty(t, c.ControllerConfig.Type)
+}
+
+func autoTuneWhileFermenting(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+
+	clock := fakes.NewDilatedClock(autoTuneMultiplier)
+	sim := simulator.New(20)
+
+	manager := setupAutoTuneTest(t, ctx, sim, clock, nil)
+
+	err := manager.StartFermentation(chamberID3, "Primary")
+	assert.NoError(t, err)
+
+	err = manager.StartAutoTune(chamberID3, 18, pid.ZieglerNichols)
+	assert.ErrorIs(t, err, chamber.ErrFermenting)
+
+	err = manager.StartAutoTune(chamberID3, 18, "guess")
+	assert.ErrorIs(t, err, chamber.ErrFermenting)
+
+	err = manager.StopFermentation(chamberID3)
+	assert.NoError(t, err)
+
+	err = manager.StartAutoTune(chamberID3, 18, "guess")
+	assert.ErrorIs(t, err, pid.ErrInvalidTuningRule)
+}
+
+func autoTuneNotFound(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+
+	manager := setupAutoTuneTest(t, ctx, simulator.New(20), fakes.NewDilatedClock(autoTuneMultiplier), nil)
+
+	err := manager.StartAutoTune("missing", 18, pid.ZieglerNichols)
+	assert.ErrorIs(t, err, chamber.ErrNotFound)
+
+	err = manager.StopAutoTune("missing")
+	assert.ErrorIs(t, err, chamber.ErrNotFound)
+}
+
+//nolint:revive // context-as-argument: testing.T is always first in helpers
+func setupAutoTuneTest(t *testing.T, ctx context.Context, sim *simulator.Simulator, clock clock.Clock,
+	onSave func(mock.Arguments),
+) *chamber.Manager {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	testChambers := createTestChambers()
+
+	repoMock := &mocks.ChamberRepo{}
+	repoMock.On("GetAll").Return(testChambers, nil)
+
+	saveCall := repoMock.On("Save", mock.Anything).Return(nil)
+	if onSave != nil {
+		saveCall.Run(onSave)
+	}
+
+	repoMock.On("Delete", mock.Anything).Return(nil)
+
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(sim.Thermometer, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", "GPIO5").Return(sim.Chiller, nil)
+	configuratorMock.On("CreateGPIOActuator", "GPIO6").Return(sim.Heater, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	manager, err := chamber.NewManager(ctx, repoMock, stateRepoMock, configuratorMock, serviceMock, l, m,
+		readingUpdateInterval, chamber.SetClock(clock))
+	assert.NoError(t, err)
+
+	return manager
+}
@@ -2,7 +2,7 @@ package chamber
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	"reflect"
 	"strings"
 	"sync"
@@ -12,8 +12,11 @@ import (
 	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
 	"github.com/benjaminbartels/zymurgauge/internal/device"
 	"github.com/benjaminbartels/zymurgauge/internal/device/tilt"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
-	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/hysteresis"
+	"github.com/benjaminbartels/zymurgauge/internal/stream"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -21,16 +24,24 @@ import (
 // Chamber represents an insulated box (fridge) with internal heating/cooling elements that reacts to changes in
 // monitored temperatures, by correcting small deviations from your desired fermentation temperature.
 type Chamber struct {
-	ID                      string        `json:"id,omitempty"`
-	Name                    string        `json:"name"`
-	DeviceConfig            DeviceConfig  `json:"deviceConfig"`
-	ChillingDifferential    float64       `json:"chillingDifferential"`
-	HeatingDifferential     float64       `json:"heatingDifferential"`
-	CurrentBatch            *batch.Detail `json:"currentBatch,omitempty"`
-	CurrentFermentationStep string        `json:"currentFermentationStep,omitempty"`
-	ModTime                 time.Time     `json:"modTime"`
-	Readings                *Readings     `json:"readings,omitempty"`
+	ID                      string           `json:"id,omitempty"`
+	Name                    string           `json:"name"`
+	DeviceConfig            DeviceConfig     `json:"deviceConfig"`
+	ControllerConfig        ControllerConfig `json:"controllerConfig"`
+	ChillingDifferential    float64          `json:"chillingDifferential"`
+	HeatingDifferential     float64          `json:"heatingDifferential"`
+	RampRate                float64          `json:"rampRate,omitempty"`
+	Streams                 []stream.Config  `json:"streams,omitempty"`
+	CurrentBatch            *batch.Detail    `json:"currentBatch,omitempty"`
+	CurrentFermentationStep string           `json:"currentFermentationStep,omitempty"`
+	CurrentStepStatus       *StepStatus      `json:"currentStepStatus,omitempty"`
+	Manual                  *ManualState     `json:"manual,omitempty"`
+	ModTime                 time.Time        `json:"modTime"`
+	Readings                *Readings        `json:"readings,omitempty"`
+	Chiller                 *ActuatorStatus  `json:"chiller,omitempty"`
+	Heater                  *ActuatorStatus  `json:"heater,omitempty"`
 	logger                  *logrus.Logger
+	clock                   clock.Clock
 	metrics                 metrics.Metrics
 	beerThermometer         device.Thermometer
 	auxiliaryThermometer    device.Thermometer
@@ -38,12 +49,19 @@ type Chamber struct {
 	hydrometer              device.Hydrometer
 	chiller                 device.Actuator
 	heater                  device.Actuator
-	temperatureController   device.TemperatureController
+	temperatureController   temperaturecontrol.TemperatureController
 	service                 brewfather.Service
+	streamers               []stream.Streamer
+	stateRepo               StateRepo
+	readingsRepo            ReadingsRepo
+	publisher               event.Publisher
 	cancelFunc              context.CancelFunc
+	runDone                 chan struct{}
+	autoTuneCancelFunc      context.CancelFunc
 	readingsUpdateInterval  time.Duration
 	runMutex                *sync.RWMutex
 	readingsMutex           *sync.Mutex
+	statusMutex             *sync.Mutex
 }
 
 type DeviceConfig struct {
@@ -64,22 +82,54 @@ type Readings struct {
 	AuxiliaryTemperature *float64 `json:"auxiliaryTemperature,omitempty"`
 	ExternalTemperature  *float64 `json:"externalTemperature,omitempty"`
 	HydrometerGravity    *float64 `json:"hydrometerGravity,omitempty"`
+	SetPoint             *float64 `json:"setPoint,omitempty"`
+}
+
+type OptionsFunc func(*Chamber)
+
+// SetClock sets the clock used to time fermentation steps.
+func SetClock(clock clock.Clock) OptionsFunc {
+	return func(c *Chamber) {
+		c.clock = clock
+	}
+}
+
+func setStateRepo(stateRepo StateRepo) OptionsFunc {
+	return func(c *Chamber) {
+		c.stateRepo = stateRepo
+	}
 }
 
 func (c *Chamber) Configure(configurator Configurator, service brewfather.Service,
-	logger *logrus.Logger, metrics metrics.Metrics, readingsUpdateInterval time.Duration,
+	logger *logrus.Logger, metrics metrics.Metrics, readingsUpdateInterval time.Duration, options ...OptionsFunc,
 ) error {
 	c.service = service
 	c.logger = logger
 	c.metrics = metrics
 	c.readingsUpdateInterval = readingsUpdateInterval
+	c.clock = clock.NewRealClock()
+
+	for _, option := range options {
+		option(c)
+	}
 
 	errs := c.configureDevices(configurator, c.DeviceConfig)
 
-	c.temperatureController = hysteresis.NewController(c.beerThermometer, c.chiller, c.heater, c.ChillingDifferential,
-		c.HeatingDifferential, logger)
+	temperatureController, err := c.createTemperatureController(logger)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "could not configure temperature controller"))
+	}
+
+	c.temperatureController = temperatureController
+
+	errs = append(errs, c.configureStreamers(service)...)
 
 	c.runMutex = &sync.RWMutex{}
+	c.statusMutex = &sync.Mutex{}
+
+	if c.readingsMutex == nil {
+		c.readingsMutex = &sync.Mutex{}
+	}
 
 	if errs != nil {
 		return &InvalidConfigurationError{configErrors: errs}
@@ -88,6 +138,39 @@ func (c *Chamber) Configure(configurator Configurator, service brewfather.Servic
 	return nil
 }
 
+// configureStreamers closes the chamber's streamers and creates its Brewfather streamer along with a streamer for
+// each of its streams.
+func (c *Chamber) configureStreamers(service brewfather.Service) []error {
+	c.closeStreamers()
+
+	var errs []error
+
+	c.streamers = []stream.Streamer{stream.NewBrewfather(service)}
+
+	for i, config := range c.Streams {
+		s, err := stream.New(config, stream.SetClock(c.clock))
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "could not configure stream %d", i))
+
+			continue
+		}
+
+		c.streamers = append(c.streamers, s)
+	}
+
+	return errs
+}
+
+func (c *Chamber) closeStreamers() {
+	for _, s := range c.streamers {
+		if err := s.Close(); err != nil {
+			c.logger.WithError(err).Errorf("Unable to close %s stream", s.Name())
+		}
+	}
+
+	c.streamers = nil
+}
+
 func (c *Chamber) configureDevices(configurator Configurator, config DeviceConfig) []error {
 	var errs []error
 
@@ -120,14 +203,14 @@ func (c *Chamber) configureActuators(configurator Configurator, config DeviceCon
 		errs = append(errs, errors.Wrapf(err, "could not create new GPIO %s for chiller", config.ChillerGPIO))
 	}
 
-	c.chiller = a
+	c.chiller = trackActuator(a, c.clock, c.actuatorChanged(ChillerActuator))
 
 	a, err = configurator.CreateGPIOActuator(config.HeaterGPIO)
 	if err != nil {
 		errs = append(errs, errors.Wrapf(err, "could not create new GPIO %s for heater", config.HeaterGPIO))
 	}
 
-	c.heater = a
+	c.heater = trackActuator(a, c.clock, c.actuatorChanged(HeaterActuator))
 
 	if len(errs) == 0 {
 		return nil
@@ -211,7 +294,8 @@ func getHydrometer(configurator Configurator, hydrometerType, id string) (device
 	}
 }
 
-// StartFermentation signals the chamber to start the given fermentation step.
+// StartFermentation signals the chamber to start the given fermentation step. Once the step's duration has elapsed
+// the chamber moves on to the next step of the current batch's fermentation schedule.
 func (c *Chamber) StartFermentation(ctx context.Context, stepID string) error {
 	c.runMutex.Lock()
 	defer c.runMutex.Unlock()
@@ -220,41 +304,122 @@ func (c *Chamber) StartFermentation(ctx context.Context, stepID string) error {
 		return ErrNoCurrentBatch
 	}
 
-	step := c.getStep(stepID)
-	if step == nil {
+	index := c.getStepIndex(stepID)
+	if index < 0 {
+		return ErrInvalidStep
+	}
+
+	if err := c.startSchedule(ctx, index, c.clock.Now(),
+		c.CurrentBatch.Recipe.Fermentation.Steps[index].Temperature, false); err != nil {
+		return err
+	}
+
+	update := brewfather.BatchUpdate{Status: brewfather.FermentingStatus}
+
+	// the gravity is only the original gravity if fermentation starts at the beginning of the schedule
+	if index == 0 {
+		update.MeasuredOG = c.getMeasuredGravity()
+	}
+
+	go c.updateBatch(ctx, c.CurrentBatch, update)
+
+	return nil
+}
+
+// resumeFermentation restarts the fermentation described by the given state. Any steps whose duration has elapsed
+// since the state was saved are skipped.
+func (c *Chamber) resumeFermentation(ctx context.Context, state *FermentationState) error {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	if state.Manual != nil {
+		return c.resumeManual(ctx, state.Manual)
+	}
+
+	if c.CurrentBatch == nil {
+		return ErrNoCurrentBatch
+	}
+
+	index := c.getStateStepIndex(state)
+	if index < 0 {
 		return ErrInvalidStep
 	}
 
-	if c.cancelFunc != nil {
-		c.cancelFunc()
+	steps := c.CurrentBatch.Recipe.Fermentation.Steps
+	stepStartTime := state.StepStartTime
+	setPoint := state.SetPoint
+
+	for index < len(steps)-1 {
+		duration := getStepDuration(steps[index])
+		if duration <= 0 || c.clock.Since(stepStartTime) < duration {
+			break
+		}
+
+		stepStartTime = stepStartTime.Add(duration)
+		setPoint = steps[index].Temperature
+		index++
+	}
+
+	return c.startSchedule(ctx, index, stepStartTime, setPoint, state.Completed)
+}
+
+// startSchedule runs the fermentation schedule beginning with the step at the given index. The step is treated as
+// having started at stepStartTime with the given set point. completed is set if the step is the last one and its
+// completion has already been handled. The caller must hold runMutex.
+func (c *Chamber) startSchedule(ctx context.Context, index int, stepStartTime time.Time, setPoint float64,
+	completed bool,
+) error {
+	steps := c.CurrentBatch.Recipe.Fermentation.Steps
+
+	return c.start(ctx, func(ctx context.Context, errCh chan<- error) {
+		c.runSchedule(ctx, steps, index, stepStartTime, setPoint, completed, errCh)
+	})
+}
+
+// start calls run in a new go routine and periodically refreshes, saves and sends the chamber's readings until the
+// fermentation is stopped. run must send an error to errCh if the temperature controller fails. The caller must hold
+// runMutex.
+func (c *Chamber) start(ctx context.Context, run func(ctx context.Context, errCh chan<- error)) error {
+	if c.temperatureController == nil {
+		return ErrNoTemperatureController
+	}
+
+	if c.autoTuneCancelFunc != nil {
+		return ErrAutoTuning
 	}
 
-	temp := step.Temperature
+	c.stopAndWait()
+
 	ctx, cancelFunc := context.WithCancel(ctx)
 	c.cancelFunc = cancelFunc
 
-	var err error
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+	c.runDone = done
 
 	go func() {
-		err = c.temperatureController.Run(ctx, temp)
-		if err != nil {
-			c.cancelFunc = nil
-			c.logger.WithError(err).Errorf("could not run temperature controller for chamber %s", c.Name)
-		}
+		defer close(done)
+		run(ctx, errCh)
 	}()
 
-	<-time.After(1 * time.Second)
-
-	if err != nil {
+	select {
+	case err := <-errCh:
 		cancelFunc() // stop updateReadings go routine
 
 		c.cancelFunc = nil
 
 		return errors.Wrapf(err, "could not run temperature controller for chamber %s", c.Name)
+	case <-time.After(1 * time.Second):
+	}
+
+	var batchID string
+	if c.CurrentBatch != nil {
+		batchID = c.CurrentBatch.ID
 	}
 
 	go func() {
 		c.RefreshReadings()
+		c.saveReading(batchID)
 		c.sendData(ctx)
 
 		for {
@@ -264,6 +429,8 @@ func (c *Chamber) StartFermentation(ctx context.Context, stepID string) error {
 			select {
 			case <-timer.C:
 				c.RefreshReadings()
+				c.refreshStepStatus()
+				c.saveReading(batchID)
 				c.sendData(ctx)
 			case <-ctx.Done():
 				return
@@ -282,9 +449,9 @@ func (c *Chamber) StopFermentation() error {
 		return ErrNotFermenting
 	}
 
-	c.cancelFunc()
-
-	c.cancelFunc = nil
+	c.stopAndWait()
+	c.clearStepStatus()
+	c.deleteState()
 
 	return nil
 }
@@ -340,6 +507,60 @@ func (c *Chamber) RefreshReadings() {
 	}
 
 	c.Readings.HydrometerGravity = v
+
+	c.Readings.SetPoint = c.getSetPoint()
+
+	readings := *c.Readings
+	c.publish(event.ReadingsType, &readings)
+}
+
+// MarshalJSON encodes a snapshot of the chamber, which allows it to be encoded while its readings and step status
+// are being updated.
+func (c *Chamber) MarshalJSON() ([]byte, error) {
+	type chamber Chamber // prevents MarshalJSON from being called recursively
+
+	if c.readingsMutex != nil {
+		c.readingsMutex.Lock()
+	}
+
+	if c.statusMutex != nil {
+		c.statusMutex.Lock()
+	}
+
+	snapshot := chamber(*c)
+
+	if c.Readings != nil {
+		readings := *c.Readings
+		snapshot.Readings = &readings
+	}
+
+	if c.CurrentStepStatus != nil {
+		status := *c.CurrentStepStatus
+		snapshot.CurrentStepStatus = &status
+	}
+
+	if c.Manual != nil {
+		manual := *c.Manual
+		snapshot.Manual = &manual
+	}
+
+	if c.statusMutex != nil {
+		c.statusMutex.Unlock()
+	}
+
+	if c.readingsMutex != nil {
+		c.readingsMutex.Unlock()
+	}
+
+	snapshot.Chiller = getActuatorStatus(c.chiller)
+	snapshot.Heater = getActuatorStatus(c.heater)
+
+	data, err := json.Marshal(&snapshot)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal chamber")
+	}
+
+	return data, nil
 }
 
 func (c *Chamber) getBeerTemperature() (*float64, error) {
@@ -394,18 +615,38 @@ func (c *Chamber) getHydrometerGravity() (*float64, error) {
 	return &t, nil
 }
 
-func (c *Chamber) getStep(name string) *batch.FermentationStep {
-	var step *batch.FermentationStep
+// getSetPoint returns the effective set point of the temperature controller or nil if the chamber is not fermenting.
+func (c *Chamber) getSetPoint() *float64 {
+	if c.temperatureController == nil || !c.IsFermenting() {
+		return nil
+	}
 
+	t := c.temperatureController.GetSetPoint()
+
+	return &t
+}
+
+// getStateStepIndex returns the index of the step of the given state. The saved index is used as long as it still
+// refers to a step with the saved name, since several steps may have the same name. Otherwise, such as for states saved
+// before the index was, the first step with the name is used.
+func (c *Chamber) getStateStepIndex(state *FermentationState) int {
+	steps := c.CurrentBatch.Recipe.Fermentation.Steps
+
+	if state.StepIndex >= 0 && state.StepIndex < len(steps) && steps[state.StepIndex].Name == state.Step {
+		return state.StepIndex
+	}
+
+	return c.getStepIndex(state.Step)
+}
+
+func (c *Chamber) getStepIndex(name string) int {
 	for i := range c.CurrentBatch.Recipe.Fermentation.Steps {
 		if c.CurrentBatch.Recipe.Fermentation.Steps[i].Name == name {
-			step = &c.CurrentBatch.Recipe.Fermentation.Steps[i]
-
-			break
+			return i
 		}
 	}
 
-	return step
+	return -1
 }
 
 func (c *Chamber) sendData(ctx context.Context) {
@@ -413,8 +654,12 @@ func (c *Chamber) sendData(ctx context.Context) {
 		c.logger.WithError(err).Error("Unable to emit metrics.")
 	}
 
-	if err := c.sendToBrewFather(ctx); err != nil {
-		c.logger.WithError(err).Error("Unable to send readings to Brewfather")
+	reading := c.getStreamReading()
+
+	for _, s := range c.streamers {
+		if err := s.Stream(ctx, reading); err != nil {
+			c.logger.WithError(err).Errorf("Unable to send readings to %s", s.Name())
+		}
 	}
 }
 
@@ -426,61 +671,125 @@ func (c *Chamber) emitMetrics() error {
 	c.readingsMutex.Lock()
 	defer c.readingsMutex.Unlock()
 
-	name := strings.ReplaceAll(c.Name, " ", "")
+	chamberTag := metrics.Tag{Key: "chamber", Value: c.Name}
 
 	if c.Readings.BeerTemperature != nil {
-		c.metrics.Gauge(fmt.Sprintf("zymurgauge.%s.beer_temperature,sensor_id=%s", name,
-			c.beerThermometer.GetID()), *c.Readings.BeerTemperature)
+		c.metrics.Gauge(metrics.Bucket("zymurgauge.beer_temperature", chamberTag, sensorTag(c.beerThermometer)),
+			*c.Readings.BeerTemperature)
 	}
 
 	if c.Readings.AuxiliaryTemperature != nil {
-		c.metrics.Gauge(fmt.Sprintf("zymurgauge.%s.auxiliary_temperature,sensor_id=%s", name,
-			c.auxiliaryThermometer.GetID()), *c.Readings.AuxiliaryTemperature)
+		c.metrics.Gauge(metrics.Bucket("zymurgauge.auxiliary_temperature", chamberTag,
+			sensorTag(c.auxiliaryThermometer)), *c.Readings.AuxiliaryTemperature)
 	}
 
 	if c.Readings.ExternalTemperature != nil {
-		c.metrics.Gauge(fmt.Sprintf("zymurgauge.%s.external_temperature,sensor_id=%s", name,
-			c.externalThermometer.GetID()), *c.Readings.ExternalTemperature)
+		c.metrics.Gauge(metrics.Bucket("zymurgauge.external_temperature", chamberTag,
+			sensorTag(c.externalThermometer)), *c.Readings.ExternalTemperature)
 	}
 
 	if c.Readings.HydrometerGravity != nil {
-		c.metrics.Gauge(fmt.Sprintf("zymurgauge.%s.hydrometer_gravity,sensor_id=%s", name,
-			c.hydrometer.GetID()), *c.Readings.HydrometerGravity)
+		c.metrics.Gauge(metrics.Bucket("zymurgauge.hydrometer_gravity", chamberTag, sensorTag(c.hydrometer)),
+			*c.Readings.HydrometerGravity)
+	}
+
+	if c.Readings.SetPoint != nil {
+		c.metrics.Gauge(metrics.Bucket("zymurgauge.set_point", chamberTag), *c.Readings.SetPoint)
 	}
 
+	c.emitActuatorMetrics(chamberTag, ChillerActuator, getActuatorStatus(c.chiller))
+	c.emitActuatorMetrics(chamberTag, HeaterActuator, getActuatorStatus(c.heater))
+
 	return nil
 }
 
-func (c *Chamber) sendToBrewFather(ctx context.Context) error {
+// actuatorComment summarizes the state of the actuators, such as "Chiller: on, 2h13m0s today (45%), 12 cycles".
+func (c *Chamber) actuatorComment() string {
+	var parts []string
+
+	if status := getActuatorStatus(c.chiller); status != nil {
+		parts = append(parts, "Chiller: "+status.String())
+	}
+
+	if status := getActuatorStatus(c.heater); status != nil {
+		parts = append(parts, "Heater: "+status.String())
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func (c *Chamber) emitActuatorMetrics(chamberTag metrics.Tag, actuator string, status *ActuatorStatus) {
+	if status == nil {
+		return
+	}
+
+	isOn := 0
+	if status.IsOn {
+		isOn = 1
+	}
+
+	actuatorTag := metrics.Tag{Key: "actuator", Value: actuator}
+
+	c.metrics.Gauge(metrics.Bucket("zymurgauge.actuator_on", chamberTag, actuatorTag), isOn)
+	c.metrics.Gauge(metrics.Bucket("zymurgauge.actuator_duty_percent", chamberTag, actuatorTag), status.DutyPercent)
+	c.metrics.Gauge(metrics.Bucket("zymurgauge.actuator_cycles", chamberTag, actuatorTag), status.Cycles)
+}
+
+func sensorTag(s device.Sensor) metrics.Tag {
+	return metrics.Tag{Key: "sensor_id", Value: s.GetID()}
+}
+
+func (c *Chamber) getStreamReading() *stream.Reading {
+	c.statusMutex.Lock()
+	step := c.CurrentFermentationStep
+	c.statusMutex.Unlock()
+
 	c.readingsMutex.Lock()
 	defer c.readingsMutex.Unlock()
 
-	l := brewfather.LogEntry{
-		DeviceName:      c.Name,
-		Beer:            c.CurrentBatch.Recipe.Name,
-		TemperatureUnit: "C",
-		GravityUnit:     "G",
+	r := &stream.Reading{
+		Time:                 c.clock.Now(),
+		ChamberID:            c.ID,
+		ChamberName:          c.Name,
+		BeerTemperature:      c.Readings.BeerTemperature,
+		AuxiliaryTemperature: c.Readings.AuxiliaryTemperature,
+		ExternalTemperature:  c.Readings.ExternalTemperature,
+		Gravity:              c.Readings.HydrometerGravity,
+		SetPoint:             c.Readings.SetPoint,
+		Step:                 step,
+		Comment:              c.actuatorComment(),
 	}
 
-	if c.Readings.BeerTemperature != nil {
-		l.BeerTemperature = fmt.Sprintf("%f", *c.Readings.BeerTemperature)
+	if c.CurrentBatch != nil {
+		r.Beer = c.CurrentBatch.Recipe.Name
 	}
 
-	if c.Readings.AuxiliaryTemperature != nil {
-		l.AuxiliaryTemperature = fmt.Sprintf("%f", *c.Readings.AuxiliaryTemperature)
-	}
+	return r
+}
 
-	if c.Readings.ExternalTemperature != nil {
-		l.ExternalTemperature = fmt.Sprintf("%f", *c.Readings.ExternalTemperature)
+// updateBatch writes the given update to the batch in Brewfather. Batches that are not from Brewfather are not updated.
+func (c *Chamber) updateBatch(ctx context.Context, b *batch.Detail, update brewfather.BatchUpdate) {
+	if b == nil || !b.IsFromBrewfather() {
+		return
 	}
 
-	if c.Readings.HydrometerGravity != nil {
-		l.Gravity = fmt.Sprintf("%f", *c.Readings.HydrometerGravity)
+	if err := c.service.UpdateBatch(ctx, b.ID, update); err != nil {
+		if !errors.Is(err, brewfather.ErrUpdatesDisabled) {
+			c.logger.WithError(err).Errorf("Unable to update batch %s in Brewfather", b.ID)
+		}
 	}
+}
 
-	if err := c.service.Log(ctx, l); err != nil {
-		return errors.Wrap(err, "could not log to Brewfather")
+// getMeasuredGravity returns the hydrometer's gravity or nil if it can not be read.
+func (c *Chamber) getMeasuredGravity() *float64 {
+	gravity, err := c.getHydrometerGravity()
+	if err != nil {
+		if !errors.Is(err, ErrDeviceIsNil) {
+			c.logger.WithError(err).Error("could not get reading for hydrometer gravity")
+		}
+
+		return nil
 	}
 
-	return nil
+	return gravity
 }
@@ -2,11 +2,16 @@ package chamber_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
 	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/stream"
 	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
 	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
 	"github.com/pkg/errors"
@@ -29,6 +34,9 @@ func TestConfigure(t *testing.T) {
 	t.Run("configureDs18b20Error", configureDs18b20Error)
 	t.Run("configureTiltError", configureTiltError)
 	t.Run("configureGPIOError", configureGPIOError)
+	t.Run("configurePIDController", configurePIDController)
+	t.Run("configureInvalidControllerConfig", configureInvalidControllerConfig)
+	t.Run("configureInvalidStream", configureInvalidStream)
 }
 
 const (
@@ -135,12 +143,137 @@ func configureGPIOError(t *testing.T) {
 	assert.Contains(t, cfgErr.Problems()[0].Error(), fmt.Sprintf(gpioErrMsg, gpio2))
 }
 
+func configurePIDController(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	c := createTestChambers()
+	c[0].ControllerConfig = chamber.ControllerConfig{
+		Type:              chamber.PIDControllerType,
+		KP:                10,
+		KI:                0.01,
+		CyclePeriod:       60,
+		ChillerMinOffTime: 300,
+	}
+
+	err := c[0].Configure(configuratorMock, serviceMock, l, m, readingUpdateInterval)
+	assert.NoError(t, err)
+
+	err = c[0].StartFermentation(context.Background(), "Primary")
+	assert.NoError(t, err)
+	assert.True(t, c[0].IsFermenting())
+
+	err = c[0].StopFermentation()
+	assert.NoError(t, err)
+}
+
+func configureInvalidControllerConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config chamber.ControllerConfig
+		errMsg string
+	}{
+		{
+			name:   "invalidType",
+			config: chamber.ControllerConfig{Type: "bangbang"},
+			errMsg: "invalid temperature controller type 'bangbang'",
+		},
+		{
+			name:   "negativeCyclePeriod",
+			config: chamber.ControllerConfig{CyclePeriod: -1},
+			errMsg: "invalid cycle period -1",
+		},
+		{
+			name:   "negativeChillerMinOffTime",
+			config: chamber.ControllerConfig{Type: chamber.PIDControllerType, KP: 1, ChillerMinOffTime: -1},
+			errMsg: "invalid chiller minimum off time -1",
+		},
+		{
+			name:   "negativeGain",
+			config: chamber.ControllerConfig{Type: chamber.PIDControllerType, KP: 1, KD: -1},
+			errMsg: "PID gains cannot be negative",
+		},
+		{
+			name:   "noGains",
+			config: chamber.ControllerConfig{Type: chamber.PIDControllerType},
+			errMsg: "at least one PID gain must be set",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, _ := logtest.NewNullLogger()
+			m := &mocks.Metrics{}
+
+			configuratorMock := &mocks.Configurator{}
+			configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+			configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+			configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+			c := createTestChambers()
+			c[0].ControllerConfig = tc.config
+
+			err := c[0].Configure(configuratorMock, &mocks.Service{}, l, m, readingUpdateInterval)
+
+			var cfgErr *chamber.InvalidConfigurationError
+
+			assert.ErrorAs(t, err, &cfgErr)
+			assert.Contains(t, cfgErr.Problems()[0].Error(), tc.errMsg)
+
+			err = c[0].StartFermentation(context.Background(), "Primary")
+			assert.ErrorIs(t, err, chamber.ErrNoTemperatureController)
+		})
+	}
+}
+
+func configureInvalidStream(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	c := createTestChambers()
+	c[0].Streams = []stream.Config{{Type: stream.HTTPType, URL: "http://localhost"}, {Type: "beersmith"}}
+
+	err := c[0].Configure(configuratorMock, &mocks.Service{}, l, m, readingUpdateInterval)
+
+	var cfgErr *chamber.InvalidConfigurationError
+
+	assert.ErrorAs(t, err, &cfgErr)
+	assert.Len(t, cfgErr.Problems(), 1)
+	assert.ErrorIs(t, cfgErr.Problems()[0], stream.ErrUnknownType)
+	assert.Contains(t, cfgErr.Problems()[0].Error(), "could not configure stream 1")
+}
+
 //nolint:paralleltest // False positives with r.Run not in a loop
 func TestLogging(t *testing.T) {
 	t.Parallel()
 	t.Run("log", log)
 	t.Run("logServiceErrors", logServiceErrors)
 	t.Run("logDeviceErrors", logDeviceErrors)
+	t.Run("logToStreams", logToStreams)
 }
 
 func log(t *testing.T) {
@@ -171,7 +304,12 @@ func log(t *testing.T) {
 	serviceMock := &mocks.Service{}
 	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil).Run(
 		func(args mock.Arguments) {
-			assert.Equal(t, expected, args[1])
+			entry, _ := args[1].(brewfather.LogEntry)
+			assert.Contains(t, entry.Comment, "Chiller: on, ")
+			assert.Contains(t, entry.Comment, "Heater: off, 0s today (0%), 0 cycles")
+
+			entry.Comment = "" // depends on how long the chiller has been on
+			assert.Equal(t, expected, entry)
 			doneCh <- struct{}{}
 		})
 
@@ -246,6 +384,7 @@ func logDeviceErrors(t *testing.T) {
 		DeviceName:      "ChamberWithCompleteConfigWithBatch",
 		TemperatureUnit: "C",
 		GravityUnit:     "G",
+		Comment:         "Chiller: off, 0s today (0%), 0 cycles; Heater: off, 0s today (0%), 0 cycles",
 	}
 
 	serviceMock := &mocks.Service{}
@@ -267,3 +406,63 @@ func logDeviceErrors(t *testing.T) {
 
 	<-doneCh
 }
+
+func logToStreams(t *testing.T) {
+	t.Parallel()
+
+	l, hook := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	readingCh := make(chan stream.Reading, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reading stream.Reading
+		_ = json.NewDecoder(r.Body).Decode(&reading)
+
+		select {
+		case readingCh <- reading:
+		default:
+		}
+	}))
+	defer server.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	c := createTestChambers()
+	c[0].Streams = []stream.Config{
+		{Type: stream.HTTPType, URL: failing.URL},
+		{Type: stream.HTTPType, URL: server.URL},
+	}
+
+	err := c[0].Configure(configuratorMock, serviceMock, l, m, readingUpdateInterval)
+	assert.NoError(t, err)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	err = c[0].StartFermentation(ctx, "Primary")
+	assert.NoError(t, err)
+
+	reading := <-readingCh
+	assert.Equal(t, c[0].ID, reading.ChamberID)
+	assert.Equal(t, "Pale Ale", reading.Beer)
+	assert.Equal(t, "Primary", reading.Step)
+	assert.Equal(t, 22.0, *reading.SetPoint)
+
+	assert.Eventually(t, func() bool {
+		return logContains(hook.AllEntries(), logrus.ErrorLevel, "Unable to send readings to HTTP "+
+			failing.Listener.Addr().String())
+	}, time.Second, 10*time.Millisecond)
+}
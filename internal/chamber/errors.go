@@ -1,13 +1,17 @@
 package chamber
 
 const (
-	ErrNotFound       = Error("chamber not found")
-	ErrNoCurrentBatch = Error("chamber does not have a current batch")
-	ErrInvalidStep    = Error("invalid step")
-	ErrNotFermenting  = Error("fermentation has not started")
-	ErrFermenting     = Error("fermentation has started")
-	ErrDeviceIsNil    = Error("device is nil")
-	ErrMetricsIsNil   = Error("metrics provider nil")
+	ErrNotFound                = Error("chamber not found")
+	ErrNoCurrentBatch          = Error("chamber does not have a current batch")
+	ErrInvalidStep             = Error("invalid step")
+	ErrNotFermenting           = Error("fermentation has not started")
+	ErrFermenting              = Error("fermentation has started")
+	ErrDeviceIsNil             = Error("device is nil")
+	ErrMetricsIsNil            = Error("metrics provider nil")
+	ErrControllerStopped       = Error("temperature controller stopped unexpectedly")
+	ErrNoTemperatureController = Error("temperature controller is not configured")
+	ErrAutoTuning              = Error("auto-tune is in progress")
+	ErrNotAutoTuning           = Error("auto-tune has not started")
 )
 
 type Error string
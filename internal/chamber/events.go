@@ -0,0 +1,50 @@
+package chamber
+
+import (
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+)
+
+const (
+	ChillerActuator = "chiller"
+	HeaterActuator  = "heater"
+)
+
+// ActuatorEvent is the data of an event that is published when an actuator is turned on or off.
+type ActuatorEvent struct {
+	Actuator string `json:"actuator"`
+	IsOn     bool   `json:"isOn"`
+}
+
+// StepEvent is the data of an event that is published when a fermentation step or manual set point starts or the
+// fermentation stops, in which case Step and Manual are empty.
+type StepEvent struct {
+	Step   string       `json:"step,omitempty"`
+	Manual *ManualState `json:"manual,omitempty"`
+	Status *StepStatus  `json:"status,omitempty"`
+}
+
+// SetPublisher sets the publisher that readings, actuator changes and step transitions are published to.
+func SetPublisher(publisher event.Publisher) OptionsFunc {
+	return func(c *Chamber) {
+		c.publisher = publisher
+	}
+}
+
+func (c *Chamber) publish(eventType event.Type, data interface{}) {
+	if c.publisher == nil {
+		return
+	}
+
+	c.publisher.Publish(event.Event{
+		Type:      eventType,
+		ChamberID: c.ID,
+		Time:      c.clock.Now(),
+		Data:      data,
+	})
+}
+
+func (c *Chamber) actuatorChanged(actuator string) func(isOn bool) {
+	return func(isOn bool) {
+		c.publish(event.ActuatorType, &ActuatorEvent{Actuator: actuator, IsOn: isOn})
+	}
+}
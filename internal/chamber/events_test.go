@@ -0,0 +1,119 @@
+package chamber_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestEvents(t *testing.T) {
+	t.Parallel()
+	t.Run("publishEventsWhileFermenting", publishEventsWhileFermenting)
+	t.Run("refreshReadingsWhenNotFermenting", refreshReadingsWhenNotFermenting)
+}
+
+func publishEventsWhileFermenting(t *testing.T) {
+	t.Parallel()
+
+	bus := event.NewBus(event.BufferSize(100))
+	events, unsubscribe := bus.Subscribe()
+
+	defer unsubscribe()
+
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	readingsRepoMock.On("SaveReading", chamberID1, mock.Anything).Return(nil)
+
+	manager := setupReadingsTest(t, readingsRepoMock, chamber.SetPublisher(bus))
+
+	err := manager.StartFermentation(chamberID1, "Primary")
+	assert.NoError(t, err)
+
+	var readingsPublished, chillerOnPublished bool
+
+	received := waitForEvents(t, events, func(e event.Event) bool {
+		if a, ok := e.Data.(*chamber.ActuatorEvent); ok && a.Actuator == chamber.ChillerActuator && a.IsOn {
+			chillerOnPublished = true
+		}
+
+		readingsPublished = readingsPublished || e.Type == event.ReadingsType
+
+		return readingsPublished && chillerOnPublished
+	})
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+
+	received = append(received, waitForEvents(t, events, func(e event.Event) bool {
+		s, ok := e.Data.(*chamber.StepEvent)
+
+		return ok && s.Step == ""
+	})...)
+
+	for _, e := range received {
+		assert.Equal(t, chamberID1, e.ChamberID)
+	}
+
+	step, ok := received[0].Data.(*chamber.StepEvent)
+	assert.True(t, ok)
+	assert.Equal(t, "Primary", step.Step)
+}
+
+func refreshReadingsWhenNotFermenting(t *testing.T) {
+	t.Parallel()
+
+	bus := event.NewBus(event.BufferSize(100))
+	events, unsubscribe := bus.Subscribe()
+
+	defer unsubscribe()
+
+	manager := setupReadingsTest(t, &mocks.ReadingsRepo{}, chamber.SetPublisher(bus))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go manager.RefreshReadings(ctx)
+
+	waitForEvents(t, events, func(e event.Event) bool {
+		return e.Type == event.ReadingsType && e.ChamberID == chamberID2
+	})
+
+	c, err := manager.Get(chamberID2)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+
+	var result chamber.Chamber
+
+	err = json.Unmarshal(data, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, *result.Readings.BeerTemperature)
+}
+
+// waitForEvents receives events until one matches, and returns all of the events that were received.
+func waitForEvents(t *testing.T, events <-chan event.Event, match func(e event.Event) bool) []event.Event {
+	t.Helper()
+
+	var received []event.Event
+
+	for {
+		select {
+		case e := <-events:
+			received = append(received, e)
+
+			if match(e) {
+				return received
+			}
+		case <-time.After(1 * time.Second):
+			assert.FailNow(t, "expected event was not published")
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package chamber
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const percent = 100
+
+// Reading is a point in a chamber's history of readings.
+type Reading struct {
+	Time                 time.Time `json:"time"`
+	BatchID              string    `json:"batchId,omitempty"`
+	Step                 string    `json:"step,omitempty"`
+	BeerTemperature      *float64  `json:"beerTemperature,omitempty"`
+	AuxiliaryTemperature *float64  `json:"auxiliaryTemperature,omitempty"`
+	ExternalTemperature  *float64  `json:"externalTemperature,omitempty"`
+	HydrometerGravity    *float64  `json:"hydrometerGravity,omitempty"`
+	SetPoint             *float64  `json:"setPoint,omitempty"`
+	// ChillerDuty is the percentage of time the chiller was on. It is either 0 or 100 for a single reading.
+	ChillerDuty float64 `json:"chillerDuty"`
+	// HeaterDuty is the percentage of time the heater was on. It is either 0 or 100 for a single reading.
+	HeaterDuty float64 `json:"heaterDuty"`
+}
+
+// SetReadingsRepo sets the repository that the chamber's readings are recorded to while fermenting.
+func SetReadingsRepo(readingsRepo ReadingsRepo) OptionsFunc {
+	return func(c *Chamber) {
+		c.readingsRepo = readingsRepo
+	}
+}
+
+// GetReadings returns the readings recorded from, inclusive, to, exclusive, downsampled to the given resolution.
+func (c *Chamber) GetReadings(from, to time.Time, resolution time.Duration) ([]*Reading, error) {
+	if c.readingsRepo == nil {
+		return []*Reading{}, nil
+	}
+
+	readings, err := c.readingsRepo.GetReadings(c.ID, from, to)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get readings for chamber %s", c.Name)
+	}
+
+	return Downsample(readings, resolution), nil
+}
+
+func (c *Chamber) saveReading(batchID string) {
+	if c.readingsRepo == nil {
+		return
+	}
+
+	step, _ := c.GetCurrentStep()
+
+	c.readingsMutex.Lock()
+
+	reading := &Reading{
+		Time:                 c.clock.Now(),
+		BatchID:              batchID,
+		Step:                 step,
+		BeerTemperature:      c.Readings.BeerTemperature,
+		AuxiliaryTemperature: c.Readings.AuxiliaryTemperature,
+		ExternalTemperature:  c.Readings.ExternalTemperature,
+		HydrometerGravity:    c.Readings.HydrometerGravity,
+		SetPoint:             c.Readings.SetPoint,
+	}
+
+	c.readingsMutex.Unlock()
+
+	if isActuatorOn(c.chiller) {
+		reading.ChillerDuty = percent
+	}
+
+	if isActuatorOn(c.heater) {
+		reading.HeaterDuty = percent
+	}
+
+	if err := c.readingsRepo.SaveReading(c.ID, reading); err != nil {
+		c.logger.WithError(err).Errorf("could not save readings for chamber %s", c.Name)
+	}
+}
+
+func (c *Chamber) deleteReadings() {
+	if c.readingsRepo == nil {
+		return
+	}
+
+	if err := c.readingsRepo.DeleteReadings(c.ID); err != nil {
+		c.logger.WithError(err).Errorf("could not delete readings for chamber %s", c.Name)
+	}
+}
+
+// Downsample averages the given readings, which must be in chronological order, into one reading per resolution. The
+// time of each downsampled reading is the start of the period it covers and its batch and step are those of the last
+// reading in the period. If resolution is not greater than zero the readings are returned unchanged.
+func Downsample(readings []*Reading, resolution time.Duration) []*Reading {
+	if resolution <= 0 || len(readings) == 0 {
+		return readings
+	}
+
+	result := []*Reading{}
+
+	var current *readingAverage
+
+	for _, r := range readings {
+		start := r.Time.Truncate(resolution)
+
+		if current == nil || !current.start.Equal(start) {
+			if current != nil {
+				result = append(result, current.reading())
+			}
+
+			current = &readingAverage{start: start}
+		}
+
+		current.add(r)
+	}
+
+	return append(result, current.reading())
+}
+
+type average struct {
+	sum   float64
+	count int
+}
+
+func (a *average) add(v *float64) {
+	if v != nil {
+		a.sum += *v
+		a.count++
+	}
+}
+
+func (a *average) value() *float64 {
+	if a.count == 0 {
+		return nil
+	}
+
+	v := a.sum / float64(a.count)
+
+	return &v
+}
+
+type readingAverage struct {
+	start                time.Time
+	batchID              string
+	step                 string
+	count                int
+	beerTemperature      average
+	auxiliaryTemperature average
+	externalTemperature  average
+	hydrometerGravity    average
+	setPoint             average
+	chillerDuty          float64
+	heaterDuty           float64
+}
+
+func (a *readingAverage) add(r *Reading) {
+	a.count++
+	a.batchID = r.BatchID
+	a.step = r.Step
+	a.beerTemperature.add(r.BeerTemperature)
+	a.auxiliaryTemperature.add(r.AuxiliaryTemperature)
+	a.externalTemperature.add(r.ExternalTemperature)
+	a.hydrometerGravity.add(r.HydrometerGravity)
+	a.setPoint.add(r.SetPoint)
+	a.chillerDuty += r.ChillerDuty
+	a.heaterDuty += r.HeaterDuty
+}
+
+func (a *readingAverage) reading() *Reading {
+	return &Reading{
+		Time:                 a.start,
+		BatchID:              a.batchID,
+		Step:                 a.step,
+		BeerTemperature:      a.beerTemperature.value(),
+		AuxiliaryTemperature: a.auxiliaryTemperature.value(),
+		ExternalTemperature:  a.externalTemperature.value(),
+		HydrometerGravity:    a.hydrometerGravity.value(),
+		SetPoint:             a.setPoint.value(),
+		ChillerDuty:          a.chillerDuty / float64(a.count),
+		HeaterDuty:           a.heaterDuty / float64(a.count),
+	}
+}
@@ -0,0 +1,186 @@
+package chamber_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestReadings(t *testing.T) {
+	t.Parallel()
+	t.Run("recordReadingsWhileFermenting", recordReadingsWhileFermenting)
+	t.Run("getReadingsDownsampled", getReadingsDownsampled)
+	t.Run("getReadingsRepoError", getReadingsRepoError)
+	t.Run("getReadingsNoRepo", getReadingsNoRepo)
+	t.Run("deleteChamberDeletesReadings", deleteChamberDeletesReadings)
+}
+
+func recordReadingsWhileFermenting(t *testing.T) {
+	t.Parallel()
+
+	savedCh := make(chan *chamber.Reading, 10)
+
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	readingsRepoMock.On("SaveReading", chamberID1, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		select {
+		case savedCh <- args.Get(1).(*chamber.Reading): //nolint:forcetypeassert // Always a reading
+		default:
+		}
+	})
+
+	manager := setupReadingsTest(t, readingsRepoMock)
+
+	err := manager.StartFermentation(chamberID1, "Primary")
+	assert.NoError(t, err)
+
+	var readings []*chamber.Reading
+
+	// the initial reading and at least one update
+	for len(readings) < 2 {
+		select {
+		case r := <-savedCh:
+			readings = append(readings, r)
+		case <-time.After(1 * time.Second):
+			assert.FailNow(t, "readings should have been saved by now")
+		}
+	}
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+
+	assert.False(t, readings[0].Time.IsZero())
+	assert.False(t, readings[1].Time.Before(readings[0].Time))
+	assert.NotNil(t, readings[0].BeerTemperature)
+	assert.Equal(t, "Primary", readings[0].Step)
+	assert.Equal(t, 22.0, *readings[0].SetPoint)
+}
+
+func getReadingsDownsampled(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	temperatures := []float64{18, 20, 21, 23}
+
+	readings := make([]*chamber.Reading, len(temperatures))
+	for i := range temperatures {
+		readings[i] = &chamber.Reading{
+			Time:            start.Add(time.Duration(i*30) * time.Second),
+			BeerTemperature: &temperatures[i],
+		}
+	}
+
+	readings[1].ChillerDuty = 100
+	readings[1].Step = "Primary"
+	readings[3].HeaterDuty = 100
+	readings[3].Step = "Secondary"
+
+	from, to := start, start.Add(time.Hour)
+
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	readingsRepoMock.On("GetReadings", chamberID1, from, to).Return(readings, nil)
+
+	manager := setupReadingsTest(t, readingsRepoMock)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+
+	result, err := c.GetReadings(from, to, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, start, result[0].Time)
+	assert.Equal(t, 19.0, *result[0].BeerTemperature)
+	assert.Equal(t, 50.0, result[0].ChillerDuty)
+	assert.Equal(t, 0.0, result[0].HeaterDuty)
+	assert.Nil(t, result[0].HydrometerGravity)
+	assert.Equal(t, "Primary", result[0].Step)
+	assert.Equal(t, start.Add(time.Minute), result[1].Time)
+	assert.Equal(t, 22.0, *result[1].BeerTemperature)
+	assert.Equal(t, 50.0, result[1].HeaterDuty)
+	assert.Equal(t, "Secondary", result[1].Step)
+
+	result, err = c.GetReadings(from, to, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, readings, result)
+}
+
+func getReadingsRepoError(t *testing.T) {
+	t.Parallel()
+
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	readingsRepoMock.On("GetReadings", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("readingsRepoMock error"))
+
+	manager := setupReadingsTest(t, readingsRepoMock)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+
+	_, err = c.GetReadings(time.Now().Add(-time.Hour), time.Now(), 0)
+	assert.Contains(t, err.Error(), "could not get readings for chamber")
+}
+
+func getReadingsNoRepo(t *testing.T) {
+	t.Parallel()
+
+	c := &chamber.Chamber{}
+
+	readings, err := c.GetReadings(time.Now().Add(-time.Hour), time.Now(), time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, readings)
+}
+
+func deleteChamberDeletesReadings(t *testing.T) {
+	t.Parallel()
+
+	readingsRepoMock := &mocks.ReadingsRepo{}
+	readingsRepoMock.On("DeleteReadings", chamberID2).Return(nil)
+
+	manager := setupReadingsTest(t, readingsRepoMock)
+
+	err := manager.Delete(chamberID2)
+	assert.NoError(t, err)
+
+	readingsRepoMock.AssertCalled(t, "DeleteReadings", chamberID2)
+}
+
+func setupReadingsTest(t *testing.T, readingsRepo chamber.ReadingsRepo,
+	options ...chamber.OptionsFunc,
+) *chamber.Manager {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	repoMock := &mocks.ChamberRepo{}
+	repoMock.On("GetAll").Return(createTestChambers(), nil)
+	repoMock.On("Delete", mock.Anything).Return(nil)
+
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	manager, err := chamber.NewManager(context.Background(), repoMock, stateRepoMock, configuratorMock, serviceMock,
+		l, m, readingUpdateInterval, append([]chamber.OptionsFunc{chamber.SetReadingsRepo(readingsRepo)}, options...)...)
+	assert.NoError(t, err)
+
+	return manager
+}
@@ -1,14 +1,22 @@
 package chamber
 
 import (
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/device"
 	"github.com/benjaminbartels/zymurgauge/internal/device/tilt"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
 )
 
 type Controller interface {
 	Repo
+	SetBatch(chamberID string, b *batch.Detail) error
 	StartFermentation(chamberID string, step string) error
 	StopFermentation(chamberID string) error
+	StartManual(chamberID string, setPoint float64, duration time.Duration, revert bool) error
+	StartAutoTune(chamberID string, setPoint float64, rule pid.TuningRule) error
+	StopAutoTune(chamberID string) error
 }
 
 type Configurator interface {
@@ -23,3 +31,18 @@ type Repo interface {
 	Save(c *Chamber) error
 	Delete(id string) error
 }
+
+// StateRepo persists the state of running fermentations.
+type StateRepo interface {
+	GetState(chamberID string) (*FermentationState, error)
+	SaveState(chamberID string, state *FermentationState) error
+	DeleteState(chamberID string) error
+}
+
+// ReadingsRepo stores the history of chambers' readings.
+type ReadingsRepo interface {
+	SaveReading(chamberID string, reading *Reading) error
+	// GetReadings returns the readings recorded from, inclusive, to, exclusive, in chronological order.
+	GetReadings(chamberID string, from, to time.Time) ([]*Reading, error)
+	DeleteReadings(chamberID string) error
+}
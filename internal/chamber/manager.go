@@ -6,8 +6,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -19,27 +21,36 @@ type Manager struct {
 	//nolint:containedctx // TODO: fix
 	ctx                    context.Context
 	repo                   Repo
+	stateRepo              StateRepo
 	chambers               map[string]*Chamber
 	configurator           Configurator
 	service                brewfather.Service
 	logger                 *logrus.Logger
 	metrics                metrics.Metrics
 	readingsUpdateInterval time.Duration
+	options                []OptionsFunc
 	mutex                  sync.RWMutex
 }
 
-func NewManager(ctx context.Context, repo Repo, configurator Configurator, service brewfather.Service,
-	logger *logrus.Logger, metrics metrics.Metrics, readingsUpdateInterval time.Duration,
+// NewManager creates a Manager for all chambers in the repository. Fermentations that were running when the program
+// last stopped are resumed.
+func NewManager(ctx context.Context, repo Repo, stateRepo StateRepo, configurator Configurator,
+	service brewfather.Service, logger *logrus.Logger, metrics metrics.Metrics, readingsUpdateInterval time.Duration,
+	options ...OptionsFunc,
 ) (*Manager, error) {
+	options = append([]OptionsFunc{setStateRepo(stateRepo)}, options...)
+
 	m := &Manager{
 		ctx:                    ctx,
 		repo:                   repo,
+		stateRepo:              stateRepo,
 		chambers:               make(map[string]*Chamber),
 		configurator:           configurator,
 		service:                service,
 		logger:                 logger,
 		metrics:                metrics,
 		readingsUpdateInterval: readingsUpdateInterval,
+		options:                options,
 	}
 
 	chambers, err := m.repo.GetAll()
@@ -52,15 +63,22 @@ func NewManager(ctx context.Context, repo Repo, configurator Configurator, servi
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	configured := []*Chamber{}
+
 	for i := range chambers {
-		if err := chambers[i].Configure(configurator, service, logger, metrics, readingsUpdateInterval); err != nil {
+		if err := chambers[i].Configure(configurator, service, logger, metrics, readingsUpdateInterval,
+			options...); err != nil {
 			errs = multierror.Append(errs,
 				errors.Wrapf(err, "could not configure temperature controller for chamber %s", chambers[i].Name))
+		} else {
+			configured = append(configured, chambers[i])
 		}
 
 		m.chambers[chambers[i].ID] = chambers[i]
 	}
 
+	m.resumeFermentations(configured)
+
 	if errs != nil {
 		return m, errors.Wrap(errs, "could not configure temperature controllers")
 	}
@@ -68,6 +86,47 @@ func NewManager(ctx context.Context, repo Repo, configurator Configurator, servi
 	return m, nil
 }
 
+// resumeFermentations resumes the fermentation of each of the given chambers that has a saved fermentation state.
+func (m *Manager) resumeFermentations(chambers []*Chamber) {
+	for _, chamber := range chambers {
+		state, err := m.stateRepo.GetState(chamber.ID)
+		if err != nil {
+			m.logger.WithError(err).Errorf("could not get fermentation state for chamber %s", chamber.Name)
+
+			continue
+		}
+
+		if state == nil {
+			continue
+		}
+
+		if err := chamber.resumeFermentation(m.ctx, state); err != nil {
+			m.logger.WithError(err).Errorf("could not resume fermentation step %s for chamber %s", state.Step,
+				chamber.Name)
+
+			// the batch has changed so the state can never be resumed
+			if errors.Is(err, ErrInvalidStep) || errors.Is(err, ErrNoCurrentBatch) {
+				chamber.deleteState()
+			}
+
+			continue
+		}
+
+		if !chamber.IsFermenting() {
+			continue
+		}
+
+		step, _ := chamber.GetCurrentStep()
+		if step == "" {
+			m.logger.Infof("Resumed manual set point for chamber %s", chamber.Name)
+
+			continue
+		}
+
+		m.logger.Infof("Resumed fermentation step %s for chamber %s", step, chamber.Name)
+	}
+}
+
 func (m *Manager) GetAll() ([]*Chamber, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
@@ -101,16 +160,27 @@ func (m *Manager) Save(chamber *Chamber) error {
 
 	if c, ok := m.chambers[chamber.ID]; ok && c.IsFermenting() {
 		return ErrFermenting
+	} else if ok && c.IsAutoTuning() {
+		return ErrAutoTuning
 	}
 
-	if err := chamber.Configure(m.configurator, m.service, m.logger, m.metrics, m.readingsUpdateInterval); err != nil {
+	if err := chamber.Configure(m.configurator, m.service, m.logger, m.metrics, m.readingsUpdateInterval,
+		m.options...); err != nil {
+		chamber.closeStreamers()
+
 		return errors.Wrap(err, "could not configure chamber")
 	}
 
 	if err := m.repo.Save(chamber); err != nil {
+		chamber.closeStreamers()
+
 		return errors.Wrap(err, "could not save chamber to repository")
 	}
 
+	if c, ok := m.chambers[chamber.ID]; ok && c != chamber {
+		c.closeStreamers()
+	}
+
 	m.chambers[chamber.ID] = chamber
 
 	return nil
@@ -122,17 +192,53 @@ func (m *Manager) Delete(id string) error {
 
 	if c, ok := m.chambers[id]; ok && c.IsFermenting() {
 		return ErrFermenting
+	} else if ok && c.IsAutoTuning() {
+		return ErrAutoTuning
 	}
 
 	if err := m.repo.Delete(id); err != nil {
 		return errors.Wrapf(err, "could not delete chamber %s from repository", id)
 	}
 
+	if err := m.stateRepo.DeleteState(id); err != nil {
+		m.logger.WithError(err).Errorf("could not delete fermentation state for chamber %s", id)
+	}
+
+	if c, ok := m.chambers[id]; ok {
+		c.deleteReadings()
+		c.closeStreamers()
+	}
+
 	delete(m.chambers, id)
 
 	return nil
 }
 
+// SetBatch sets the current batch of the given chamber and saves the chamber.
+func (m *Manager) SetBatch(chamberID string, b *batch.Detail) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	chamber, ok := m.chambers[chamberID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if chamber.IsFermenting() {
+		return ErrFermenting
+	} else if chamber.IsAutoTuning() {
+		return ErrAutoTuning
+	}
+
+	chamber.CurrentBatch = b
+
+	if err := m.repo.Save(chamber); err != nil {
+		return errors.Wrap(err, "could not save chamber to repository")
+	}
+
+	return nil
+}
+
 // StartFermentation signals the given chamber to start the given fermentation step.
 func (m *Manager) StartFermentation(chamberID string, step string) error {
 	m.mutex.Lock()
@@ -152,14 +258,49 @@ func (m *Manager) StartFermentation(chamberID string, step string) error {
 		return errors.Wrap(err, "could not start fermentation")
 	}
 
-	chamber.CurrentFermentationStep = step
+	return nil
+}
 
-	m.chambers[chamber.ID] = chamber
+// StopFermentation stops the fermentation of the given chamber. The manager is not locked while waiting for it to
+// stop.
+func (m *Manager) StopFermentation(chamberID string) error {
+	m.mutex.RLock()
+	chamber, ok := m.chambers[chamberID]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	err := chamber.StopFermentation()
+	if err != nil {
+		return errors.Wrap(err, "could not stop fermentation")
+	}
 
 	return nil
 }
 
-func (m *Manager) StopFermentation(chamberID string) error {
+// StartManual signals the given chamber to hold the given set point. A fermentation that is running is replaced. The
+// manager is not locked while waiting for it to stop.
+func (m *Manager) StartManual(chamberID string, setPoint float64, duration time.Duration, revert bool) error {
+	m.mutex.RLock()
+	chamber, ok := m.chambers[chamberID]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	if err := chamber.StartManual(m.ctx, setPoint, duration, revert); err != nil {
+		return errors.Wrap(err, "could not start manual set point")
+	}
+
+	return nil
+}
+
+// StartAutoTune starts tuning the PID gains of the given chamber. Once the tune has completed, the chamber is saved
+// with the new gains.
+func (m *Manager) StartAutoTune(chamberID string, setPoint float64, rule pid.TuningRule) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -168,14 +309,76 @@ func (m *Manager) StopFermentation(chamberID string) error {
 		return ErrNotFound
 	}
 
-	err := chamber.StopFermentation()
+	err := chamber.StartAutoTune(m.ctx, setPoint, rule, func(result *pid.TuningResult, err error) {
+		if err != nil {
+			m.logger.Warnf("Auto-tune for chamber %s did not complete, its controller configuration is unchanged",
+				chamber.Name)
+
+			return
+		}
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		// the chamber may have been replaced or deleted once the auto-tune finished
+		if m.chambers[chamberID] != chamber {
+			return
+		}
+
+		// a copy is saved as the repository clears the status of the chamber, which is still being updated
+		if err := m.repo.Save(chamber.configCopy()); err != nil {
+			m.logger.WithError(err).Errorf("could not save auto-tune result for chamber %s", chamber.Name)
+		}
+	})
 	if err != nil {
-		return errors.Wrap(err, "could not stop fermentation")
+		return errors.Wrap(err, "could not start auto-tune")
 	}
 
-	chamber.CurrentFermentationStep = ""
+	return nil
+}
 
-	m.chambers[chamber.ID] = chamber
+func (m *Manager) StopAutoTune(chamberID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	chamber, ok := m.chambers[chamberID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if err := chamber.StopAutoTune(); err != nil {
+		return errors.Wrap(err, "could not stop auto-tune")
+	}
 
 	return nil
 }
+
+// RefreshReadings refreshes the readings of every chamber that is not fermenting, and then again every readings update
+// interval until the context is canceled. Fermenting chambers refresh their own readings. This keeps the readings
+// returned with each chamber up to date without reading the devices on every request.
+func (m *Manager) RefreshReadings(ctx context.Context) {
+	ticker := time.NewTicker(m.readingsUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		m.mutex.RLock()
+		chambers := make([]*Chamber, 0, len(m.chambers))
+
+		for _, chamber := range m.chambers {
+			chambers = append(chambers, chamber)
+		}
+		m.mutex.RUnlock()
+
+		for _, chamber := range chambers {
+			if chamber.runMutex == nil || !chamber.IsFermenting() {
+				chamber.RefreshReadings()
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
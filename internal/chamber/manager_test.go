@@ -11,6 +11,7 @@ import (
 
 	"github.com/benjaminbartels/zymurgauge/internal/batch"
 	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
 	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
 	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
 	"github.com/sirupsen/logrus"
@@ -23,6 +24,7 @@ const (
 	chamberID1            = "96f58a65-03c0-49f3-83ca-ab751bbf3768"
 	chamberID2            = "dd2610fe-95fc-45f3-8dd8-3051fb1bd4c1"
 	chamberID3            = "82d328bf-a9a2-4bf9-adce-b87e0bd92141"
+	batchID               = "KBTM3F9soO5TtbAx0A5mBZTAUsNZyg"
 	repoErrMsg            = "could not %s repository"
 	readingUpdateInterval = 100 * time.Millisecond
 )
@@ -32,6 +34,7 @@ func createTestChambers() []*chamber.Chamber {
 		ID:   chamberID1,
 		Name: "ChamberWithCompleteConfigWithBatch",
 		CurrentBatch: &batch.Detail{
+			Source: batch.LocalSource,
 			Recipe: batch.Recipe{
 				Name: "Pale Ale",
 				Fermentation: batch.Fermentation{
@@ -81,6 +84,7 @@ func createTestChambers() []*chamber.Chamber {
 			BeerThermometerID:   "28-0000071cbc72",
 		},
 		CurrentBatch: &batch.Detail{
+			Source: batch.LocalSource,
 			Recipe: batch.Recipe{
 				Name: "Stout",
 				Fermentation: batch.Fermentation{
@@ -108,6 +112,12 @@ func TestNewManager(t *testing.T) {
 
 	t.Run("newManagerGetAllError", newManagerGetAllError)
 	t.Run("newManagerConfigureErrors", newManagerConfigureErrors)
+	t.Run("newManagerResumeFermentation", newManagerResumeFermentation)
+	t.Run("newManagerResumeSkipsElapsedSteps", newManagerResumeSkipsElapsedSteps)
+	t.Run("newManagerResumeDuplicateStepName", newManagerResumeDuplicateStepName)
+	t.Run("newManagerResumeElapsedLastStep", newManagerResumeElapsedLastStep)
+	t.Run("newManagerResumeCompletedLastStep", newManagerResumeCompletedLastStep)
+	t.Run("newManagerResumeInvalidStep", newManagerResumeInvalidStep)
 }
 
 func newManagerGetAllError(t *testing.T) {
@@ -120,6 +130,11 @@ func newManagerGetAllError(t *testing.T) {
 	repoMock := &mocks.ChamberRepo{}
 	repoMock.On("GetAll").Return(nil, errors.New("repoMock error"))
 
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
 	configuratorMock := &mocks.Configurator{}
 	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
 	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
@@ -128,8 +143,8 @@ func newManagerGetAllError(t *testing.T) {
 	serviceMock := &mocks.Service{}
 	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
 
-	manager, err := chamber.NewManager(context.Background(), repoMock, configuratorMock, serviceMock, l, m,
-		readingUpdateInterval)
+	manager, err := chamber.NewManager(context.Background(), repoMock, stateRepoMock, configuratorMock, serviceMock,
+		l, m, readingUpdateInterval)
 	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "get all chambers from"))
 	assert.Nil(t, manager)
 }
@@ -151,6 +166,11 @@ func newManagerConfigureErrors(t *testing.T) {
 	repoMock := &mocks.ChamberRepo{}
 	repoMock.On("GetAll").Return(testChambers, nil)
 
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
 	configuratorMock := &mocks.Configurator{}
 	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
 	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
@@ -159,12 +179,185 @@ func newManagerConfigureErrors(t *testing.T) {
 	serviceMock := &mocks.Service{}
 	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
 
-	manager, err := chamber.NewManager(context.Background(), repoMock, configuratorMock, serviceMock, l, m,
-		readingUpdateInterval)
+	manager, err := chamber.NewManager(context.Background(), repoMock, stateRepoMock, configuratorMock, serviceMock,
+		l, m, readingUpdateInterval)
 	assert.Contains(t, err.Error(), "could not configure temperature controllers")
 	assert.NotNil(t, manager)
 }
 
+func newManagerResumeFermentation(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	stepStartTime := time.Now().Add(-1 * time.Hour)
+	state := &chamber.FermentationState{Step: "Secondary", StepStartTime: stepStartTime, SetPoint: 20}
+
+	manager, stateRepoMock := setupResumeTest(t, testChambers, state)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+	assert.True(t, c.IsFermenting())
+
+	step, status := c.GetCurrentStep()
+	assert.Equal(t, "Secondary", step)
+	assert.True(t, stepStartTime.Equal(status.StartTime))
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+	stateRepoMock.AssertCalled(t, "DeleteState", chamberID1)
+}
+
+func newManagerResumeSkipsElapsedSteps(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	testChambers[0].CurrentBatch.Recipe.Fermentation.Steps[0].Duration = 1
+	stepStartTime := time.Now().Add(-36 * time.Hour)
+	state := &chamber.FermentationState{Step: "Primary", StepStartTime: stepStartTime, SetPoint: 22}
+
+	manager, stateRepoMock := setupResumeTest(t, testChambers, state)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+
+	step, status := c.GetCurrentStep()
+	assert.Equal(t, "Secondary", step)
+	assert.True(t, stepStartTime.Add(24*time.Hour).Equal(status.StartTime))
+	stateRepoMock.AssertCalled(t, "SaveState", chamberID1, mock.MatchedBy(func(s *chamber.FermentationState) bool {
+		return s.Step == "Secondary"
+	}))
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+}
+
+func newManagerResumeDuplicateStepName(t *testing.T) {
+	t.Parallel()
+
+	// Brewfather names steps after their type, so several steps may have the same name
+	testChambers := createTestChambers()
+	testChambers[0].CurrentBatch.Recipe.Fermentation.Steps[1].Name = "Primary"
+	state := &chamber.FermentationState{
+		Step: "Primary", StepIndex: 1, StepStartTime: time.Now().Add(-1 * time.Hour), SetPoint: 20,
+	}
+
+	manager, stateRepoMock := setupResumeTest(t, testChambers, state)
+
+	stateRepoMock.AssertCalled(t, "SaveState", chamberID1, mock.MatchedBy(func(s *chamber.FermentationState) bool {
+		return s.Step == "Primary" && s.StepIndex == 1
+	}))
+	stateRepoMock.AssertNotCalled(t, "SaveState", chamberID1, mock.MatchedBy(func(s *chamber.FermentationState) bool {
+		return s.StepIndex == 0
+	}))
+
+	err := manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+}
+
+func newManagerResumeElapsedLastStep(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	testChambers[0].CurrentBatch.Recipe.Fermentation.Steps[1].Duration = 1
+	state := &chamber.FermentationState{Step: "Secondary", StepStartTime: time.Now().Add(-36 * time.Hour), SetPoint: 20}
+
+	manager, stateRepoMock := setupResumeTest(t, testChambers, state)
+
+	// the step completed while the program was stopped so its completion is handled once resumed
+	assert.Eventually(t, func() bool {
+		return countSavedStates(stateRepoMock, func(s *chamber.FermentationState) bool { return s.Completed }) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	err := manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+}
+
+func newManagerResumeCompletedLastStep(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	testChambers[0].CurrentBatch.Recipe.Fermentation.Steps[1].Duration = 1
+	state := &chamber.FermentationState{
+		Step: "Secondary", StepStartTime: time.Now().Add(-36 * time.Hour), SetPoint: 20, Completed: true,
+	}
+
+	manager, stateRepoMock := setupResumeTest(t, testChambers, state)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+	assert.True(t, c.IsFermenting())
+
+	<-time.After(200 * time.Millisecond)
+
+	// only the state saved when resuming, the completion is not handled again
+	assert.Equal(t, 1, countSavedStates(stateRepoMock, func(s *chamber.FermentationState) bool { return true }))
+	assert.Equal(t, 1, countSavedStates(stateRepoMock, func(s *chamber.FermentationState) bool { return s.Completed }))
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+}
+
+// countSavedStates returns the number of saved fermentation states that match.
+func countSavedStates(stateRepoMock *mocks.StateRepo, match func(s *chamber.FermentationState) bool) int {
+	count := 0
+
+	for _, call := range stateRepoMock.Calls {
+		if call.Method == "SaveState" && match(call.Arguments.Get(1).(*chamber.FermentationState)) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func newManagerResumeInvalidStep(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	state := &chamber.FermentationState{Step: "BadStep", StepStartTime: time.Now(), SetPoint: 22}
+
+	manager, stateRepoMock := setupResumeTest(t, testChambers, state)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+	assert.False(t, c.IsFermenting())
+	stateRepoMock.AssertCalled(t, "DeleteState", chamberID1)
+}
+
+func setupResumeTest(t *testing.T, chambers []*chamber.Chamber,
+	state *chamber.FermentationState,
+) (*chamber.Manager, *mocks.StateRepo) {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	repoMock := &mocks.ChamberRepo{}
+	repoMock.On("GetAll").Return(chambers, nil)
+	repoMock.On("Delete", mock.Anything).Return(nil)
+
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", chamberID1).Return(state, nil)
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	manager, err := chamber.NewManager(context.Background(), repoMock, stateRepoMock, configuratorMock, serviceMock,
+		l, m, readingUpdateInterval)
+	assert.NoError(t, err)
+
+	return manager, stateRepoMock
+}
+
 //nolint:paralleltest // False positives with r.Run not in a loop
 func TestGetAllChambers(t *testing.T) {
 	t.Parallel()
@@ -310,6 +503,7 @@ func saveChamberConfigureError(t *testing.T) {
 func TestDeleteChamber(t *testing.T) {
 	t.Parallel()
 	t.Run("deleteChamber", deleteChamber)
+	t.Run("deleteChamberDeletesState", deleteChamberDeletesState)
 	t.Run("deleteChamberFermentingError", deleteChamberFermentingError)
 	t.Run("deleteChamberRepoError", deleteChamberRepoError)
 }
@@ -330,6 +524,16 @@ func deleteChamber(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+func deleteChamberDeletesState(t *testing.T) {
+	t.Parallel()
+
+	manager, stateRepoMock := setupResumeTest(t, createTestChambers(), nil)
+
+	err := manager.Delete(chamberID2)
+	assert.NoError(t, err)
+	stateRepoMock.AssertCalled(t, "DeleteState", chamberID2)
+}
+
 func deleteChamberFermentingError(t *testing.T) {
 	t.Parallel()
 
@@ -357,6 +561,68 @@ func deleteChamberRepoError(t *testing.T) {
 	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "delete chamber "+chamberID1+" from"))
 }
 
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSetBatch(t *testing.T) {
+	t.Parallel()
+	t.Run("setBatch", setBatch)
+	t.Run("setBatchNotFoundError", setBatchNotFoundError)
+	t.Run("setBatchFermentingError", setBatchFermentingError)
+	t.Run("setBatchRepoError", setBatchRepoError)
+}
+
+func setBatch(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	b := &batch.Detail{Recipe: batch.Recipe{Name: "Imported"}}
+
+	manager, repoMock, _ := setupManagerTest(t, testChambers)
+	repoMock.On("Save", testChambers[1]).Return(nil)
+
+	err := manager.SetBatch(chamberID2, b)
+	assert.NoError(t, err)
+
+	result, err := manager.Get(chamberID2)
+	assert.NoError(t, err)
+	assert.Equal(t, b, result.CurrentBatch)
+	repoMock.AssertExpectations(t)
+}
+
+func setBatchNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	manager, _, _ := setupManagerTest(t, createTestChambers())
+
+	err := manager.SetBatch("badID", &batch.Detail{})
+	assert.ErrorIs(t, err, chamber.ErrNotFound)
+}
+
+func setBatchFermentingError(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+
+	manager, _, _ := setupManagerTest(t, testChambers)
+
+	err := manager.StartFermentation(chamberID1, "Primary")
+	assert.NoError(t, err)
+
+	err = manager.SetBatch(chamberID1, &batch.Detail{})
+	assert.ErrorIs(t, err, chamber.ErrFermenting)
+}
+
+func setBatchRepoError(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+
+	manager, repoMock, _ := setupManagerTest(t, testChambers)
+	repoMock.On("Save", testChambers[1]).Return(errors.New("repoMock error"))
+
+	err := manager.SetBatch(chamberID2, &batch.Detail{})
+	assert.Contains(t, err.Error(), fmt.Sprintf(repoErrMsg, "save chamber to"))
+}
+
 //nolint:paralleltest // False positives with r.Run not in a loop
 func TestStartFermentation(t *testing.T) {
 	t.Parallel()
@@ -378,13 +644,31 @@ func startFermentation(t *testing.T) {
 	doneCh := make(chan struct{}, 1)
 	manager, _, metricsMock := setupManagerTest(t, testChambers)
 	metricsMock.ExpectedCalls = nil // Erase previous On() functions
-	metricsMock.On("Gauge", fmt.Sprintf("zymurgauge.%s.auxiliary_temperature,sensor_id=", testChambers[0].Name),
+	chamberTag := metrics.Tag{Key: "chamber", Value: testChambers[0].Name}
+	sensorTag := metrics.Tag{Key: "sensor_id"}
+
+	metricsMock.On("Gauge", metrics.Bucket("zymurgauge.auxiliary_temperature", chamberTag, sensorTag),
 		mock.Anything).Return()
-	metricsMock.On("Gauge", fmt.Sprintf("zymurgauge.%s.external_temperature,sensor_id=", testChambers[0].Name),
+	metricsMock.On("Gauge", metrics.Bucket("zymurgauge.external_temperature", chamberTag, sensorTag),
 		mock.Anything).Return()
-	metricsMock.On("Gauge", fmt.Sprintf("zymurgauge.%s.hydrometer_gravity,sensor_id=", testChambers[0].Name),
+	metricsMock.On("Gauge", metrics.Bucket("zymurgauge.hydrometer_gravity", chamberTag, sensorTag),
 		mock.Anything).Return()
-	metricsMock.On("Gauge", fmt.Sprintf("zymurgauge.%s.beer_temperature,sensor_id=", testChambers[0].Name),
+	metricsMock.On("Gauge", metrics.Bucket("zymurgauge.set_point", chamberTag), 22.0).Return()
+	metricsMock.On("Gauge", metrics.Bucket("zymurgauge.actuator_on", chamberTag,
+		metrics.Tag{Key: "actuator", Value: chamber.ChillerActuator}), 1).Return()
+	metricsMock.On("Gauge", metrics.Bucket("zymurgauge.actuator_on", chamberTag,
+		metrics.Tag{Key: "actuator", Value: chamber.HeaterActuator}), 0).Return()
+
+	for _, actuator := range []string{chamber.ChillerActuator, chamber.HeaterActuator} {
+		actuatorTag := metrics.Tag{Key: "actuator", Value: actuator}
+
+		metricsMock.On("Gauge", metrics.Bucket("zymurgauge.actuator_duty_percent", chamberTag, actuatorTag),
+			mock.Anything).Return()
+		metricsMock.On("Gauge", metrics.Bucket("zymurgauge.actuator_cycles", chamberTag, actuatorTag),
+			mock.Anything).Return()
+	}
+
+	metricsMock.On("Gauge", metrics.Bucket("zymurgauge.beer_temperature", chamberTag, sensorTag),
 		mock.Anything).Return().Run(
 		func(args mock.Arguments) {
 			counter++
@@ -448,6 +732,11 @@ func startFermentationTemperatureControllerLogError(t *testing.T) {
 	repoMock := &mocks.ChamberRepo{}
 	repoMock.On("GetAll").Return(testChambers, nil)
 
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
 	doneCh := make(chan struct{}, 1)
 
 	thermometerMock := &mocks.ThermometerAndHydrometer{}
@@ -466,8 +755,8 @@ func startFermentationTemperatureControllerLogError(t *testing.T) {
 	serviceMock := &mocks.Service{}
 	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
 
-	manager, err := chamber.NewManager(context.Background(), repoMock, configuratorMock, serviceMock, l, m,
-		readingUpdateInterval)
+	manager, err := chamber.NewManager(context.Background(), repoMock, stateRepoMock, configuratorMock, serviceMock,
+		l, m, readingUpdateInterval)
 	assert.NoError(t, err)
 
 	go func() {
@@ -507,6 +796,11 @@ func startFermentationOtherDevicesAreNil(t *testing.T) {
 	repoMock := &mocks.ChamberRepo{}
 	repoMock.On("GetAll").Return(testChambers, nil)
 
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
 	thermometerMock := &mocks.Thermometer{}
 	thermometerMock.On("GetID").Return("")
 	thermometerMock.On("GetTemperature").Return(25.0, nil)
@@ -518,8 +812,8 @@ func startFermentationOtherDevicesAreNil(t *testing.T) {
 
 	serviceMock := &mocks.Service{}
 	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
-	manager, err := chamber.NewManager(context.Background(), repoMock, configuratorMock, serviceMock, l, m,
-		readingUpdateInterval)
+	manager, err := chamber.NewManager(context.Background(), repoMock, stateRepoMock, configuratorMock, serviceMock,
+		l, m, readingUpdateInterval)
 	assert.NoError(t, err)
 
 	err = manager.StartFermentation(chamberID3, "Primary")
@@ -614,6 +908,11 @@ func setupManagerTest(t *testing.T,
 	repoMock := &mocks.ChamberRepo{}
 	repoMock.On("GetAll").Return(chambers, nil)
 
+	stateRepoMock := &mocks.StateRepo{}
+	stateRepoMock.On("GetState", mock.Anything).Return(nil, nil)
+	stateRepoMock.On("SaveState", mock.Anything, mock.Anything).Return(nil)
+	stateRepoMock.On("DeleteState", mock.Anything).Return(nil)
+
 	configuratorMock := &mocks.Configurator{}
 	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
 	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
@@ -622,8 +921,8 @@ func setupManagerTest(t *testing.T,
 	serviceMock := &mocks.Service{}
 	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
 
-	manager, err := chamber.NewManager(context.Background(), repoMock, configuratorMock, serviceMock, l, metricsMock,
-		readingUpdateInterval)
+	manager, err := chamber.NewManager(context.Background(), repoMock, stateRepoMock, configuratorMock, serviceMock,
+		l, metricsMock, readingUpdateInterval)
 	assert.NoError(t, err)
 
 	return manager, repoMock, metricsMock
@@ -0,0 +1,175 @@
+package chamber
+
+import (
+	"context"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
+)
+
+// ManualState describes a chamber that is holding a set point chosen by the user instead of following the
+// fermentation schedule of a batch.
+type ManualState struct {
+	SetPoint  float64    `json:"setPoint"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	// RevertStep is the fermentation step that is started once EndTime is reached. If it is empty the chamber stops.
+	RevertStep string `json:"revertStep,omitempty"`
+}
+
+// StartManual holds the chamber at the given set point, which does not require a batch. If duration is positive the
+// set point is only held for that long, after which the chamber stops or, if revert is true, returns to the
+// fermentation step that was running when StartManual was called. Any fermentation that is running is replaced.
+func (c *Chamber) StartManual(ctx context.Context, setPoint float64, duration time.Duration, revert bool) error {
+	state := &ManualState{SetPoint: setPoint, StartTime: c.clock.Now()}
+
+	if duration > 0 {
+		endTime := state.StartTime.Add(duration)
+		state.EndTime = &endTime
+
+		if revert {
+			state.RevertStep = c.getRevertStep()
+		}
+	}
+
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	return c.startManual(ctx, state)
+}
+
+// resumeManual restarts the manual set point described by the given state. If its end time passed while the program
+// was stopped, the revert step is started instead or, if there is none, the state is deleted. The caller must hold
+// runMutex.
+func (c *Chamber) resumeManual(ctx context.Context, state *ManualState) error {
+	if state.EndTime == nil || c.clock.Now().Before(*state.EndTime) {
+		return c.startManual(ctx, state)
+	}
+
+	if index := c.getRevertIndex(state); index >= 0 {
+		return c.startSchedule(ctx, index, c.clock.Now(), state.SetPoint, false)
+	}
+
+	c.deleteState()
+
+	return nil
+}
+
+// startManual runs the temperature controller at the set point of the given state. The caller must hold runMutex.
+func (c *Chamber) startManual(ctx context.Context, state *ManualState) error {
+	return c.start(ctx, func(ctx context.Context, errCh chan<- error) {
+		c.runManual(ctx, state, errCh)
+	})
+}
+
+// runManual runs the temperature controller at the manual set point until the context is canceled or the end time of
+// the state is reached, at which point the revert step is started or the chamber is stopped. If the temperature
+// controller fails the error is sent to errCh and the chamber is stopped.
+func (c *Chamber) runManual(ctx context.Context, state *ManualState, errCh chan<- error) {
+	c.saveState(&FermentationState{StepStartTime: state.StartTime, SetPoint: state.SetPoint, Manual: state})
+	c.setManualStatus(state)
+
+	c.logger.Debugf("Holding chamber %s at %.2f°C", c.Name, state.SetPoint)
+
+	runCtx, stopRun := context.WithCancel(ctx)
+	runErrCh := make(chan error, 1)
+
+	go func() {
+		runErrCh <- c.temperatureController.RunRamp(runCtx, temperaturecontrol.NewSetPoint(state.SetPoint))
+	}()
+
+	var (
+		timer   *time.Timer
+		timerCh <-chan time.Time
+	)
+
+	if state.EndTime != nil {
+		timer = c.clock.NewTimer(state.EndTime.Sub(c.clock.Now()))
+		timerCh = timer.C
+	}
+
+	select {
+	case err := <-runErrCh:
+		stopTimer(timer)
+		stopRun()
+		c.controllerReturned(ctx, err, errCh)
+
+		return
+	case <-ctx.Done():
+		stopTimer(timer)
+		stopRun()
+		c.waitForController(runErrCh)
+
+		return
+	case <-timerCh:
+	}
+
+	stopRun()
+	c.waitForController(runErrCh)
+
+	if index := c.getRevertIndex(state); index >= 0 {
+		c.logger.Infof("Manual set point for chamber %s has ended, reverting to fermentation step %s", c.Name,
+			state.RevertStep)
+		c.runSchedule(ctx, c.CurrentBatch.Recipe.Fermentation.Steps, index, c.clock.Now(), state.SetPoint, false,
+			errCh)
+
+		return
+	}
+
+	c.logger.Infof("Manual set point for chamber %s has ended", c.Name)
+	c.finish(ctx)
+}
+
+// stopAndWait stops the fermentation, if one is running, and waits for its temperature controller to stop. The
+// caller must hold runMutex, which is released while waiting so that the run can finish.
+func (c *Chamber) stopAndWait() {
+	for c.cancelFunc != nil {
+		done := c.runDone
+
+		c.cancelFunc()
+		c.cancelFunc = nil
+
+		c.runMutex.Unlock()
+		<-done
+		c.runMutex.Lock()
+	}
+}
+
+// getRevertStep returns the fermentation step that is running, or the revert step if a manual set point is being
+// held.
+func (c *Chamber) getRevertStep() string {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	if c.Manual != nil {
+		return c.Manual.RevertStep
+	}
+
+	return c.CurrentFermentationStep
+}
+
+// getRevertIndex returns the index of the revert step of the given state or -1 if there is no step to revert to.
+func (c *Chamber) getRevertIndex(state *ManualState) int {
+	if state.RevertStep == "" || c.CurrentBatch == nil {
+		return -1
+	}
+
+	return c.getStepIndex(state.RevertStep)
+}
+
+func (c *Chamber) setManualStatus(state *ManualState) {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	manual := *state
+
+	c.Manual = &manual
+	c.CurrentFermentationStep = ""
+	c.CurrentStepStatus = &StepStatus{StartTime: state.StartTime, EndTime: state.EndTime}
+
+	c.updateTimeRemaining()
+
+	statusCopy := *c.CurrentStepStatus
+	c.publish(event.StepType, &StepEvent{Manual: &manual, Status: &statusCopy})
+}
@@ -0,0 +1,178 @@
+package chamber_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestManual(t *testing.T) {
+	t.Parallel()
+	t.Run("startManualWithoutBatch", startManualWithoutBatch)
+	t.Run("startManualStopsAfterDuration", startManualStopsAfterDuration)
+	t.Run("startManualRevertsToStep", startManualRevertsToStep)
+	t.Run("startManualNotFoundError", startManualNotFoundError)
+	t.Run("resumeManual", resumeManual)
+	t.Run("resumeManualEnded", resumeManualEnded)
+	t.Run("resumeManualEndedRevertsToStep", resumeManualEndedRevertsToStep)
+}
+
+func startManualWithoutBatch(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	manager, _, _ := setupManagerTest(t, testChambers)
+
+	err := manager.StartManual(chamberID2, 4, 0, false)
+	assert.NoError(t, err)
+
+	c, err := manager.Get(chamberID2)
+	assert.NoError(t, err)
+	assert.True(t, c.IsFermenting())
+
+	status := getChamberStatus(t, c)
+	assert.Equal(t, 4.0, status.Manual.SetPoint)
+	assert.Nil(t, status.Manual.EndTime)
+	assert.Empty(t, status.CurrentFermentationStep)
+	assert.NotNil(t, status.CurrentStepStatus)
+
+	err = manager.StopFermentation(chamberID2)
+	assert.NoError(t, err)
+	assert.Nil(t, getChamberStatus(t, c).Manual)
+}
+
+func startManualStopsAfterDuration(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	manager, stateRepoMock := setupResumeTest(t, testChambers, nil)
+
+	err := manager.StartManual(chamberID1, 4, 100*time.Millisecond, false)
+	assert.NoError(t, err)
+
+	stateRepoMock.AssertCalled(t, "SaveState", chamberID1, mock.MatchedBy(func(s *chamber.FermentationState) bool {
+		return s.Manual != nil && s.Manual.SetPoint == 4 && s.Manual.EndTime != nil
+	}))
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return !c.IsFermenting() }, time.Second, 10*time.Millisecond)
+	assert.Nil(t, getChamberStatus(t, c).Manual)
+	stateRepoMock.AssertCalled(t, "DeleteState", chamberID1)
+}
+
+func startManualRevertsToStep(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	manager, _, _ := setupManagerTest(t, testChambers)
+
+	err := manager.StartFermentation(chamberID1, "Secondary")
+	assert.NoError(t, err)
+
+	err = manager.StartManual(chamberID1, 4, 100*time.Millisecond, true)
+	assert.NoError(t, err)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		step, _ := c.GetCurrentStep()
+
+		return step == "Secondary"
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, c.IsFermenting())
+	assert.Nil(t, getChamberStatus(t, c).Manual)
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+}
+
+func startManualNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	manager, _, _ := setupManagerTest(t, createTestChambers())
+
+	err := manager.StartManual("", 4, 0, false)
+	assert.ErrorIs(t, err, chamber.ErrNotFound)
+}
+
+func resumeManual(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	testChambers[0].CurrentBatch = nil
+	startTime := time.Now().Add(-1 * time.Hour)
+	endTime := time.Now().Add(1 * time.Hour)
+	state := &chamber.FermentationState{
+		StepStartTime: startTime,
+		SetPoint:      4,
+		Manual:        &chamber.ManualState{SetPoint: 4, StartTime: startTime, EndTime: &endTime},
+	}
+
+	manager, _ := setupResumeTest(t, testChambers, state)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+	assert.True(t, c.IsFermenting())
+
+	status := getChamberStatus(t, c)
+	assert.Equal(t, 4.0, status.Manual.SetPoint)
+	assert.True(t, startTime.Equal(status.CurrentStepStatus.StartTime))
+	assert.True(t, endTime.Equal(*status.CurrentStepStatus.EndTime))
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+}
+
+func resumeManualEnded(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	startTime := time.Now().Add(-2 * time.Hour)
+	endTime := time.Now().Add(-1 * time.Hour)
+	state := &chamber.FermentationState{
+		StepStartTime: startTime,
+		SetPoint:      4,
+		Manual:        &chamber.ManualState{SetPoint: 4, StartTime: startTime, EndTime: &endTime},
+	}
+
+	manager, stateRepoMock := setupResumeTest(t, testChambers, state)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+	assert.False(t, c.IsFermenting())
+	stateRepoMock.AssertCalled(t, "DeleteState", chamberID1)
+}
+
+func resumeManualEndedRevertsToStep(t *testing.T) {
+	t.Parallel()
+
+	testChambers := createTestChambers()
+	startTime := time.Now().Add(-2 * time.Hour)
+	endTime := time.Now().Add(-1 * time.Hour)
+	state := &chamber.FermentationState{
+		StepStartTime: startTime,
+		SetPoint:      4,
+		Manual: &chamber.ManualState{
+			SetPoint: 4, StartTime: startTime, EndTime: &endTime, RevertStep: "Primary",
+		},
+	}
+
+	manager, _ := setupResumeTest(t, testChambers, state)
+
+	c, err := manager.Get(chamberID1)
+	assert.NoError(t, err)
+	assert.True(t, c.IsFermenting())
+
+	step, _ := c.GetCurrentStep()
+	assert.Equal(t, "Primary", step)
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,290 @@
+package chamber
+
+import (
+	"context"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
+)
+
+// stepDurationUnit is the unit of batch.FermentationStep.Duration. Brewfather expresses step times in days.
+const stepDurationUnit = 24 * time.Hour
+
+// StepStatus represents the progress of the fermentation step that is currently running in a chamber.
+type StepStatus struct {
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	// TimeRemaining is the number of seconds left in the step. It is only set if the step has a duration.
+	TimeRemaining *int64 `json:"timeRemaining,omitempty"`
+}
+
+// FermentationState is the persisted state of a running fermentation. It is used to resume the fermentation after
+// a restart.
+type FermentationState struct {
+	Step string `json:"step"`
+	// StepIndex is the index of Step in the fermentation schedule, since several steps may have the same name.
+	StepIndex     int       `json:"stepIndex"`
+	StepStartTime time.Time `json:"stepStartTime"`
+	// SetPoint is the set point at the start of the step, from which the set point is ramped to the step's temperature.
+	SetPoint float64 `json:"setPoint"`
+	// Manual is set if the chamber is holding a manual set point, in which case Step is empty.
+	Manual *ManualState `json:"manual,omitempty"`
+	// Completed is set once the duration of the last step has elapsed and the batch has been updated, so that the
+	// update is not sent again when the fermentation is resumed.
+	Completed bool `json:"completed,omitempty"`
+}
+
+// runSchedule runs the temperature controller for each fermentation step, starting with the step at the given index,
+// and advances to the next step when the step's duration has elapsed. A step without a duration, as well as the last
+// step, is held until the context is canceled. When advancing, the set point is ramped from the previous step's set
+// point to the next step's temperature. If completed is set the completion of the step at the given index, which must
+// be the last step, has already been handled. If the temperature controller fails the error is sent to errCh and the
+// schedule is stopped.
+func (c *Chamber) runSchedule(ctx context.Context, steps []batch.FermentationStep, index int,
+	stepStartTime time.Time, setPoint float64, completed bool, errCh chan<- error,
+) {
+	for i := index; i < len(steps); i++ {
+		step := steps[i]
+		duration := getStepDuration(step)
+		isLast := i == len(steps)-1
+
+		if i > index {
+			stepStartTime = c.clock.Now()
+			setPoint = c.temperatureController.GetSetPoint()
+			completed = false
+		}
+
+		state := &FermentationState{
+			Step: step.Name, StepIndex: i, StepStartTime: stepStartTime, SetPoint: setPoint, Completed: completed,
+		}
+		c.saveState(state)
+		c.setStepStatus(step.Name, stepStartTime, duration)
+
+		// the step may have started before now if the fermentation was resumed
+		elapsed := c.clock.Since(stepStartTime)
+		ramp := temperaturecontrol.Ramp{From: setPoint, To: step.Temperature, Rate: c.getRampRate(step)}
+		ramp.From = ramp.SetPoint(elapsed)
+
+		c.logger.Debugf("Starting fermentation step %s for chamber %s at %.2f°C", step.Name, c.Name, step.Temperature)
+
+		stepCtx, stopStep := context.WithCancel(ctx)
+		runErrCh := make(chan error, 1)
+
+		go func() {
+			runErrCh <- c.temperatureController.RunRamp(stepCtx, ramp)
+		}()
+
+		var (
+			timer   *time.Timer
+			timerCh <-chan time.Time
+		)
+
+		if duration > 0 && !completed {
+			timer = c.clock.NewTimer(duration - elapsed)
+			timerCh = timer.C
+		}
+
+		for done := false; !done; {
+			select {
+			case err := <-runErrCh:
+				stopTimer(timer)
+				stopStep()
+				c.controllerReturned(ctx, err, errCh)
+
+				return
+			case <-timerCh:
+				timerCh = nil
+
+				if isLast {
+					c.logger.Infof("Fermentation schedule for chamber %s is complete, holding step %s", c.Name,
+						step.Name)
+
+					go c.updateBatch(ctx, c.CurrentBatch, brewfather.BatchUpdate{
+						Status:     brewfather.ConditioningStatus,
+						MeasuredFG: c.getMeasuredGravity(),
+					})
+
+					completedState := *state
+					completedState.Completed = true
+					c.saveState(&completedState)
+
+					continue
+				}
+
+				c.logger.Infof("Fermentation step %s for chamber %s is complete", step.Name, c.Name)
+
+				done = true
+			case <-ctx.Done():
+				stopTimer(timer)
+				stopStep()
+				c.waitForController(runErrCh)
+
+				return
+			}
+		}
+
+		stopStep()
+		c.waitForController(runErrCh)
+	}
+}
+
+// getRampRate returns the ramp rate of the given step, falling back to the chamber's ramp rate.
+func (c *Chamber) getRampRate(step batch.FermentationStep) float64 {
+	if step.RampRate > 0 {
+		return step.RampRate
+	}
+
+	return c.RampRate
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+func (c *Chamber) waitForController(runErrCh <-chan error) {
+	if err := <-runErrCh; err != nil {
+		c.logger.WithError(err).Errorf("error occurred while stopping temperature controller for chamber %s", c.Name)
+	}
+}
+
+// controllerReturned handles the temperature controller returning before the run was done with it. The controller
+// also returns when the fermentation is stopped, which may be selected before ctx.Done(), in which case it is not a
+// failure. Otherwise a nil error means the controller stopped unexpectedly.
+func (c *Chamber) controllerReturned(ctx context.Context, err error, errCh chan<- error) {
+	if ctx.Err() != nil {
+		if err != nil {
+			c.logger.WithError(err).Errorf("error occurred while stopping temperature controller for chamber %s", c.Name)
+		}
+
+		return
+	}
+
+	if err == nil {
+		err = ErrControllerStopped
+	}
+
+	c.fail(ctx, err, errCh)
+}
+
+// fail reports the temperature controller error and stops the chamber.
+func (c *Chamber) fail(ctx context.Context, err error, errCh chan<- error) {
+	c.logger.WithError(err).Errorf("could not run temperature controller for chamber %s", c.Name)
+
+	errCh <- err
+
+	c.finish(ctx)
+}
+
+// finish marks the chamber as no longer fermenting if the run with the given context is still the chamber's active
+// run.
+func (c *Chamber) finish(ctx context.Context) {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	if ctx.Err() == nil && c.cancelFunc != nil {
+		c.cancelFunc()
+		c.cancelFunc = nil
+		c.clearStepStatus()
+		c.deleteState()
+	}
+}
+
+func getStepDuration(step batch.FermentationStep) time.Duration {
+	return time.Duration(step.Duration) * stepDurationUnit
+}
+
+func (c *Chamber) saveState(state *FermentationState) {
+	if c.stateRepo == nil {
+		return
+	}
+
+	if err := c.stateRepo.SaveState(c.ID, state); err != nil {
+		c.logger.WithError(err).Errorf("could not save fermentation state for chamber %s", c.Name)
+	}
+}
+
+func (c *Chamber) deleteState() {
+	if c.stateRepo == nil {
+		return
+	}
+
+	if err := c.stateRepo.DeleteState(c.ID); err != nil {
+		c.logger.WithError(err).Errorf("could not delete fermentation state for chamber %s", c.Name)
+	}
+}
+
+func (c *Chamber) setStepStatus(name string, startTime time.Time, duration time.Duration) {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	status := &StepStatus{
+		StartTime: startTime,
+	}
+
+	if duration > 0 {
+		endTime := status.StartTime.Add(duration)
+		status.EndTime = &endTime
+	}
+
+	c.CurrentFermentationStep = name
+	c.CurrentStepStatus = status
+	c.Manual = nil
+
+	c.updateTimeRemaining()
+
+	statusCopy := *status
+	c.publish(event.StepType, &StepEvent{Step: name, Status: &statusCopy})
+}
+
+// GetCurrentStep returns the name and status of the fermentation step that is currently running. The returned status
+// is nil if the chamber is not fermenting.
+func (c *Chamber) GetCurrentStep() (string, *StepStatus) {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	if c.CurrentStepStatus == nil {
+		return c.CurrentFermentationStep, nil
+	}
+
+	status := *c.CurrentStepStatus
+
+	return c.CurrentFermentationStep, &status
+}
+
+func (c *Chamber) refreshStepStatus() {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	c.updateTimeRemaining()
+}
+
+func (c *Chamber) clearStepStatus() {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	c.CurrentFermentationStep = ""
+	c.CurrentStepStatus = nil
+	c.Manual = nil
+
+	c.publish(event.StepType, &StepEvent{})
+}
+
+// updateTimeRemaining must be called while holding statusMutex.
+func (c *Chamber) updateTimeRemaining() {
+	if c.CurrentStepStatus == nil || c.CurrentStepStatus.EndTime == nil {
+		return
+	}
+
+	remaining := c.CurrentStepStatus.EndTime.Sub(c.clock.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	seconds := int64(remaining / time.Second)
+	c.CurrentStepStatus.TimeRemaining = &seconds
+}
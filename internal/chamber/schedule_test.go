@@ -0,0 +1,249 @@
+package chamber_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/test/fakes"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/benjaminbartels/zymurgauge/internal/test/stubs"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// one day of dilated time passes in roughly 200ms.
+const scheduleMultiplier = 432000
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSchedule(t *testing.T) {
+	t.Parallel()
+	t.Run("scheduleAdvancesToNextStep", scheduleAdvancesToNextStep)
+	t.Run("scheduleHoldsLastStep", scheduleHoldsLastStep)
+	t.Run("scheduleStepWithoutDuration", scheduleStepWithoutDuration)
+	t.Run("scheduleStop", scheduleStop)
+	t.Run("scheduleStopWaitsForRun", scheduleStopWaitsForRun)
+	t.Run("scheduleUpdatesBrewfatherBatch", scheduleUpdatesBrewfatherBatch)
+	t.Run("scheduleUpdatesBatchWithoutSource", scheduleUpdatesBatchWithoutSource)
+	t.Run("scheduleSkipsLocalBatchUpdate", scheduleSkipsLocalBatchUpdate)
+}
+
+func scheduleAdvancesToNextStep(t *testing.T) {
+	t.Parallel()
+
+	c := createScheduleTestChamber(t, []batch.FermentationStep{
+		{Name: "Primary", Temperature: 20, Duration: 1},
+		{Name: "Diacetyl Rest", Temperature: 22, Duration: 1},
+		{Name: "Cold Crash", Temperature: 2, Duration: 3},
+	})
+
+	err := c.StartFermentation(context.Background(), "Primary")
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		step, _ := c.GetCurrentStep()
+
+		return step == "Cold Crash"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	step, status := c.GetCurrentStep()
+	assert.Equal(t, "Cold Crash", step)
+	assert.NotNil(t, status.EndTime)
+	assert.Equal(t, 3*24*time.Hour, status.EndTime.Sub(status.StartTime))
+	assert.NotNil(t, status.TimeRemaining)
+
+	err = c.StopFermentation()
+	assert.NoError(t, err)
+}
+
+func scheduleHoldsLastStep(t *testing.T) {
+	t.Parallel()
+
+	c := createScheduleTestChamber(t, []batch.FermentationStep{
+		{Name: "Primary", Temperature: 20, Duration: 1},
+	})
+
+	err := c.StartFermentation(context.Background(), "Primary")
+	assert.NoError(t, err)
+
+	// wait for well over the step duration
+	<-time.After(500 * time.Millisecond)
+
+	assert.True(t, c.IsFermenting())
+
+	step, status := c.GetCurrentStep()
+	assert.Equal(t, "Primary", step)
+	assert.Equal(t, int64(0), *status.TimeRemaining)
+
+	err = c.StopFermentation()
+	assert.NoError(t, err)
+}
+
+func scheduleStepWithoutDuration(t *testing.T) {
+	t.Parallel()
+
+	c := createScheduleTestChamber(t, []batch.FermentationStep{
+		{Name: "Primary", Temperature: 20},
+		{Name: "Secondary", Temperature: 18, Duration: 1},
+	})
+
+	err := c.StartFermentation(context.Background(), "Primary")
+	assert.NoError(t, err)
+
+	<-time.After(500 * time.Millisecond)
+
+	step, status := c.GetCurrentStep()
+	assert.Equal(t, "Primary", step)
+	assert.Nil(t, status.EndTime)
+	assert.Nil(t, status.TimeRemaining)
+
+	err = c.StopFermentation()
+	assert.NoError(t, err)
+}
+
+func scheduleStop(t *testing.T) {
+	t.Parallel()
+
+	c := createScheduleTestChamber(t, []batch.FermentationStep{
+		{Name: "Primary", Temperature: 20, Duration: 1},
+		{Name: "Secondary", Temperature: 18, Duration: 1},
+	})
+
+	err := c.StartFermentation(context.Background(), "Secondary")
+	assert.NoError(t, err)
+
+	step, _ := c.GetCurrentStep()
+	assert.Equal(t, "Secondary", step)
+
+	err = c.StopFermentation()
+	assert.NoError(t, err)
+
+	step, status := c.GetCurrentStep()
+	assert.Empty(t, step)
+	assert.Nil(t, status)
+}
+
+func scheduleStopWaitsForRun(t *testing.T) {
+	t.Parallel()
+
+	manager, stateRepoMock := setupResumeTest(t, createTestChambers(), nil)
+
+	err := manager.StartFermentation(chamberID1, "Primary")
+	assert.NoError(t, err)
+
+	// replacing the fermentation must not let the previous run save its state after the new one
+	err = manager.StartManual(chamberID1, 4, 0, false)
+	assert.NoError(t, err)
+
+	err = manager.StopFermentation(chamberID1)
+	assert.NoError(t, err)
+
+	<-time.After(100 * time.Millisecond)
+
+	calls := stateRepoMock.Calls
+	assert.Equal(t, "DeleteState", calls[len(calls)-1].Method)
+	assert.Equal(t, "SaveState", calls[len(calls)-2].Method)
+	assert.NotNil(t, calls[len(calls)-2].Arguments.Get(1).(*chamber.FermentationState).Manual)
+}
+
+func scheduleUpdatesBrewfatherBatch(t *testing.T) {
+	t.Parallel()
+	testScheduleUpdatesBatch(t, batch.BrewfatherSource)
+}
+
+// batches attached before sources were recorded came from Brewfather.
+func scheduleUpdatesBatchWithoutSource(t *testing.T) {
+	t.Parallel()
+	testScheduleUpdatesBatch(t, "")
+}
+
+func testScheduleUpdatesBatch(t *testing.T, source string) {
+	t.Helper()
+
+	gravity := 0.950
+	updates := make(chan brewfather.BatchUpdate, 2)
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+	serviceMock.On("UpdateBatch", mock.Anything, batchID, mock.Anything).Return(nil).Run(
+		func(args mock.Arguments) {
+			updates <- args.Get(2).(brewfather.BatchUpdate)
+		})
+
+	c := createScheduleTestChamberWithService(t, []batch.FermentationStep{
+		{Name: "Primary", Temperature: 20, Duration: 1},
+	}, serviceMock)
+	c.CurrentBatch.ID = batchID
+	c.CurrentBatch.Source = source
+
+	err := c.StartFermentation(context.Background(), "Primary")
+	assert.NoError(t, err)
+
+	// the updates are sent concurrently and the step is short enough for them to arrive in either order
+	assert.ElementsMatch(t, []brewfather.BatchUpdate{
+		{Status: brewfather.FermentingStatus, MeasuredOG: &gravity},
+		{Status: brewfather.ConditioningStatus, MeasuredFG: &gravity},
+	}, []brewfather.BatchUpdate{<-updates, <-updates})
+
+	err = c.StopFermentation()
+	assert.NoError(t, err)
+}
+
+func scheduleSkipsLocalBatchUpdate(t *testing.T) {
+	t.Parallel()
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	c := createScheduleTestChamberWithService(t, []batch.FermentationStep{
+		{Name: "Primary", Temperature: 20, Duration: 1},
+	}, serviceMock)
+	c.CurrentBatch.Source = batch.LocalSource
+
+	err := c.StartFermentation(context.Background(), "Primary")
+	assert.NoError(t, err)
+
+	// wait for well over the step duration
+	<-time.After(500 * time.Millisecond)
+
+	err = c.StopFermentation()
+	assert.NoError(t, err)
+	serviceMock.AssertNotCalled(t, "UpdateBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func createScheduleTestChamber(t *testing.T, steps []batch.FermentationStep) *chamber.Chamber {
+	t.Helper()
+
+	serviceMock := &mocks.Service{}
+	serviceMock.On("Log", mock.Anything, mock.Anything).Return(nil)
+
+	return createScheduleTestChamberWithService(t, steps, serviceMock)
+}
+
+func createScheduleTestChamberWithService(t *testing.T, steps []batch.FermentationStep,
+	serviceMock *mocks.Service,
+) *chamber.Chamber {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+	m := &mocks.Metrics{}
+	m.On("Gauge", mock.Anything, mock.Anything).Return()
+
+	configuratorMock := &mocks.Configurator{}
+	configuratorMock.On("CreateDs18b20", mock.Anything).Return(&stubs.Thermometer{}, nil)
+	configuratorMock.On("CreateTilt", mock.Anything).Return(&stubs.Tilt{}, nil)
+	configuratorMock.On("CreateGPIOActuator", mock.Anything).Return(&stubs.Actuator{}, nil)
+
+	c := createTestChambers()[0]
+	c.CurrentBatch.Recipe.Fermentation.Steps = steps
+
+	err := c.Configure(configuratorMock, serviceMock, l, m, readingUpdateInterval,
+		chamber.SetClock(fakes.NewDilatedClock(scheduleMultiplier)))
+	assert.NoError(t, err)
+
+	return c
+}
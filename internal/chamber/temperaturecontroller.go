@@ -0,0 +1,86 @@
+package chamber
+
+import (
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/hysteresis"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HysteresisControllerType = "hysteresis"
+	PIDControllerType        = "pid"
+)
+
+// ControllerConfig configures the algorithm used to control the temperature of a chamber. If Type is empty, a
+// hysteresis controller using the chamber's chilling and heating differentials is used.
+type ControllerConfig struct {
+	Type string  `json:"type,omitempty"`
+	KP   float64 `json:"kP,omitempty"`
+	KI   float64 `json:"kI,omitempty"`
+	KD   float64 `json:"kD,omitempty"`
+	// CyclePeriod is the number of seconds between each temperature adjustment. Zero uses the controller's default.
+	CyclePeriod int `json:"cyclePeriod,omitempty"`
+	// ChillerMinOffTime is the number of seconds the chiller must be off before it can be turned on again. Zero uses
+	// the controller's default.
+	ChillerMinOffTime int `json:"chillerMinOffTime,omitempty"`
+}
+
+func (c *Chamber) createTemperatureController(logger *logrus.Logger) (temperaturecontrol.TemperatureController,
+	error,
+) {
+	config := c.ControllerConfig
+
+	if config.CyclePeriod < 0 {
+		return nil, errors.Errorf("invalid cycle period %d", config.CyclePeriod)
+	}
+
+	if config.ChillerMinOffTime < 0 {
+		return nil, errors.Errorf("invalid chiller minimum off time %d", config.ChillerMinOffTime)
+	}
+
+	cyclePeriod := time.Duration(config.CyclePeriod) * time.Second
+	chillerMinOffTime := time.Duration(config.ChillerMinOffTime) * time.Second
+
+	switch config.Type {
+	case "", HysteresisControllerType:
+		options := []hysteresis.OptionsFunc{hysteresis.SetClock(c.clock)}
+
+		if cyclePeriod > 0 {
+			options = append(options, hysteresis.CyclePeriod(cyclePeriod))
+		}
+
+		if chillerMinOffTime > 0 {
+			options = append(options, hysteresis.ChillerCooldown(chillerMinOffTime))
+		}
+
+		return hysteresis.NewController(c.beerThermometer, c.chiller, c.heater, c.ChillingDifferential,
+			c.HeatingDifferential, logger, options...), nil
+	case PIDControllerType:
+		if config.KP < 0 || config.KI < 0 || config.KD < 0 {
+			return nil, errors.New("PID gains cannot be negative")
+		}
+
+		if config.KP == 0 && config.KI == 0 && config.KD == 0 {
+			return nil, errors.New("at least one PID gain must be set")
+		}
+
+		options := []pid.DualOptionsFunc{pid.SetDualClock(c.clock)}
+
+		if cyclePeriod > 0 {
+			options = append(options, pid.DualCyclePeriod(cyclePeriod))
+		}
+
+		if chillerMinOffTime > 0 {
+			options = append(options, pid.ChillerMinOffTime(chillerMinOffTime))
+		}
+
+		return pid.NewDualController(c.beerThermometer, c.chiller, c.heater, config.KP, config.KI, config.KD, logger,
+			options...), nil
+	default:
+		return nil, errors.Errorf("invalid temperature controller type '%s'", config.Type)
+	}
+}
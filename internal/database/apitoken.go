@@ -0,0 +1,147 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const apiTokenBucket = "APITokens"
+
+var _ auth.APITokenRepo = (*APITokenRepo)(nil)
+
+// APITokenRepo represents a bbolt repository for managing APITokens.
+type APITokenRepo struct {
+	db *bbolt.DB
+}
+
+// NewAPITokenRepo returns a new APIToken repository using the given bbolt database. It also creates the APITokens
+// bucket if it is not yet created on disk.
+func NewAPITokenRepo(db *bbolt.DB) (*APITokenRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(apiTokenBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create APIToken bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &APITokenRepo{
+		db: db,
+	}, nil
+}
+
+// GetAll returns all APITokens.
+func (r *APITokenRepo) GetAll() ([]*auth.APIToken, error) {
+	tokens := []*auth.APIToken{}
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		err := tx.Bucket([]byte(apiTokenBucket)).ForEach(func(k, v []byte) error {
+			var t auth.APIToken
+			if err := json.Unmarshal(v, &t); err != nil {
+				return errors.Wrap(err, "could not unmarshal APIToken")
+			}
+			tokens = append(tokens, &t)
+
+			return nil
+		})
+
+		return errors.Wrap(err, "could not iterate over APITokens")
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return tokens, nil
+}
+
+// Get returns an APIToken by its ID.
+func (r *APITokenRepo) Get(id string) (*auth.APIToken, error) {
+	var t *auth.APIToken
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket([]byte(apiTokenBucket)).Get([]byte(id)); v != nil {
+			if err := json.Unmarshal(v, &t); err != nil {
+				return errors.Wrapf(err, "could not unmarshal APIToken %s", id)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return t, nil
+}
+
+// Save creates or updates an APIToken.
+func (r *APITokenRepo) Save(t *auth.APIToken) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		return putAPIToken(tx.Bucket([]byte(apiTokenBucket)), t)
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// Delete permanently removes an APIToken.
+func (r *APITokenRepo) Delete(id string) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(apiTokenBucket))
+		if err := bu.Delete([]byte(id)); err != nil {
+			return errors.Wrapf(err, "could not delete APIToken %s", id)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// SetLastUsedTime sets the time an APIToken was last used. Nothing is done if the APIToken has been deleted.
+func (r *APITokenRepo) SetLastUsedTime(id string, lastUsedTime time.Time) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(apiTokenBucket))
+
+		v := bu.Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+
+		var t auth.APIToken
+		if err := json.Unmarshal(v, &t); err != nil {
+			return errors.Wrapf(err, "could not unmarshal APIToken %s", id)
+		}
+
+		lastUsedTime = lastUsedTime.UTC()
+		t.LastUsedTime = &lastUsedTime
+
+		return putAPIToken(bu, &t)
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+func putAPIToken(bu *bbolt.Bucket, t *auth.APIToken) error {
+	if v, err := json.Marshal(t); err != nil {
+		return errors.Wrapf(err, "could not marshal APIToken %s", t.ID)
+	} else if err := bu.Put([]byte(t.ID), v); err != nil {
+		return errors.Wrapf(err, "could not put APIToken %s", t.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestAPITokens(t *testing.T) {
+	t.Parallel()
+	t.Run("saveAndGetAllAPITokens", saveAndGetAllAPITokens)
+	t.Run("setAPITokenLastUsedTime", setAPITokenLastUsedTime)
+	t.Run("setDeletedAPITokenLastUsedTime", setDeletedAPITokenLastUsedTime)
+}
+
+func saveAndGetAllAPITokens(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	createdTime := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	t1 := &auth.APIToken{ID: "59679696-1263-4340-a256-6c46876b4a13", Name: "grafana", Scope: auth.ReadOnlyScope,
+		Hash: "hash1", CreatedBy: "admin", CreatedTime: createdTime}
+	t2 := &auth.APIToken{ID: "d9d075b4-6b45-44cc-945b-c5b9ce13e442", Name: "cron", Scope: auth.ControlScope,
+		Hash: "hash2", CreatedBy: "admin", CreatedTime: createdTime}
+
+	err := testDB.apiTokenRepo.Save(t1)
+	assert.NoError(t, err)
+
+	err = testDB.apiTokenRepo.Save(t2)
+	assert.NoError(t, err)
+
+	result, err := testDB.apiTokenRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []*auth.APIToken{t1, t2}, result)
+
+	err = testDB.apiTokenRepo.Delete(t1.ID)
+	assert.NoError(t, err)
+
+	result, err = testDB.apiTokenRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []*auth.APIToken{t2}, result)
+}
+
+func setAPITokenLastUsedTime(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	apiToken, _, err := auth.NewAPIToken("grafana", auth.ReadOnlyScope, "admin")
+	assert.NoError(t, err)
+
+	err = testDB.apiTokenRepo.Save(apiToken)
+	assert.NoError(t, err)
+
+	lastUsedTime := time.Date(2022, 8, 2, 12, 0, 0, 0, time.UTC)
+
+	err = testDB.apiTokenRepo.SetLastUsedTime(apiToken.ID, lastUsedTime)
+	assert.NoError(t, err)
+
+	result, err := testDB.apiTokenRepo.Get(apiToken.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &lastUsedTime, result.LastUsedTime)
+	assert.Equal(t, apiToken.Hash, result.Hash)
+}
+
+func setDeletedAPITokenLastUsedTime(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	err := testDB.apiTokenRepo.SetLastUsedTime("59679696-1263-4340-a256-6c46876b4a13", time.Now())
+	assert.NoError(t, err)
+
+	result, err := testDB.apiTokenRepo.Get("59679696-1263-4340-a256-6c46876b4a13")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
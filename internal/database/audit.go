@@ -0,0 +1,157 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const auditBucket = "Audit"
+
+var _ audit.Repo = (*AuditRepo)(nil)
+
+// AuditRepo represents a bbolt repository for the audit log. Entries are keyed by their time, followed by a sequence
+// number to keep entries recorded at the same time apart, so that they can be scanned in order. Entries older than the
+// retention period, and the oldest entries once there are more than the maximum, are removed when new ones are saved.
+type AuditRepo struct {
+	db         *bbolt.DB
+	retention  time.Duration
+	maxEntries int
+	count      int
+	mutex      sync.Mutex
+}
+
+// NewAuditRepo returns a new Audit repository using the given bbolt database. It also creates the Audit bucket if it
+// is not yet created on disk. Entries are kept for the given retention period and up to the given number of entries,
+// either of which is unlimited if it is 0.
+func NewAuditRepo(db *bbolt.DB, retention time.Duration, maxEntries int) (*AuditRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	bu, err := tx.CreateBucketIfNotExists([]byte(auditBucket))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create Audit bucket")
+	}
+
+	count := bu.Stats().KeyN
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &AuditRepo{
+		db:         db,
+		retention:  retention,
+		maxEntries: maxEntries,
+		count:      count,
+	}, nil
+}
+
+// GetAll returns the entries recorded from, inclusive, to, exclusive, in chronological order.
+func (r *AuditRepo) GetAll(from, to time.Time) ([]*audit.Entry, error) {
+	entries := []*audit.Entry{}
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(auditBucket)).Cursor()
+		end := readingKey(to)
+
+		for k, v := c.Seek(readingKey(from)); k != nil && bytes.Compare(k, end) < 0; k, v = c.Next() {
+			var e audit.Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return errors.Wrap(err, "could not unmarshal audit Entry")
+			}
+
+			entries = append(entries, &e)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return entries, nil
+}
+
+// Save adds an entry to the audit log and removes the entries that are older than the retention period or exceed the
+// maximum number of entries.
+func (r *AuditRepo) Save(e *audit.Entry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := r.count
+
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(auditBucket))
+
+		removed, err := r.removeOldEntries(bu, e.Time, count)
+		if err != nil {
+			return errors.Wrap(err, "could not remove old audit Entries")
+		}
+
+		count -= removed
+
+		seq, err := bu.NextSequence()
+		if err != nil {
+			return errors.Wrap(err, "could not get next sequence of Audit bucket")
+		}
+
+		if v, err := json.Marshal(e); err != nil {
+			return errors.Wrap(err, "could not marshal audit Entry")
+		} else if err := bu.Put(auditKey(e.Time, seq), v); err != nil {
+			return errors.Wrap(err, "could not put audit Entry")
+		}
+
+		count++
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	r.count = count
+
+	return nil
+}
+
+// removeOldEntries removes the entries that are older than the retention period at the given time, and the oldest
+// entries until there is room for another one, from a bucket holding count entries. It returns the number of entries
+// removed.
+func (r *AuditRepo) removeOldEntries(bu *bbolt.Bucket, now time.Time, count int) (int, error) {
+	c := bu.Cursor()
+	end := readingKey(now.Add(-r.retention))
+	removed := 0
+
+	for k, _ := c.First(); k != nil; k, _ = c.First() {
+		tooOld := r.retention > 0 && bytes.Compare(k, end) < 0
+		tooMany := r.maxEntries > 0 && count-removed >= r.maxEntries
+
+		if !tooOld && !tooMany {
+			break
+		}
+
+		if err := c.Delete(); err != nil {
+			return removed, errors.Wrap(err, "could not delete entry")
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+// auditKey returns a key that sorts in chronological order, and in the order entries were saved for the same time.
+func auditKey(t time.Time, seq uint64) []byte {
+	key := readingKey(t)
+
+	return binary.BigEndian.AppendUint64(key, seq)
+}
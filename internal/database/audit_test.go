@@ -0,0 +1,96 @@
+package database_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestAudit(t *testing.T) {
+	t.Parallel()
+	t.Run("saveAndGetAuditEntries", saveAndGetAuditEntries)
+	t.Run("saveAuditEntryRemovesOld", saveAuditEntryRemovesOld)
+	t.Run("saveAuditEntryRemovesOldest", saveAuditEntryRemovesOldest)
+}
+
+func saveAndGetAuditEntries(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []*audit.Entry{
+		{Time: start, Action: audit.LoginAction, Username: "admin", SourceIP: "10.0.0.1"},
+		{Time: start.Add(time.Minute), Action: audit.ChamberSavedAction, Username: "admin", Target: "chamber"},
+		// recorded at the same time as the previous entry to check that both are kept in order
+		{Time: start.Add(time.Minute), Action: audit.FermentationStartedAction, Username: "admin", Target: "chamber"},
+		{Time: start.Add(2 * time.Minute), Action: audit.LogoutAction, Username: "admin"},
+	}
+
+	for _, e := range entries {
+		err := testDB.auditRepo.Save(e)
+		assert.NoError(t, err)
+	}
+
+	result, err := testDB.auditRepo.GetAll(start.Add(time.Minute), start.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, audit.ChamberSavedAction, result[0].Action)
+	assert.Equal(t, audit.FermentationStartedAction, result[1].Action)
+	assert.Equal(t, "chamber", result[1].Target)
+}
+
+func saveAuditEntryRemovesOld(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	now := time.Now().UTC()
+
+	err := testDB.auditRepo.Save(&audit.Entry{Time: now.Add(-100 * 24 * time.Hour), Action: audit.LoginAction})
+	assert.NoError(t, err)
+
+	err = testDB.auditRepo.Save(&audit.Entry{Time: now.Add(-10 * 24 * time.Hour), Action: audit.LoginAction})
+	assert.NoError(t, err)
+
+	err = testDB.auditRepo.Save(&audit.Entry{Time: now, Action: audit.LogoutAction})
+	assert.NoError(t, err)
+
+	result, err := testDB.auditRepo.GetAll(time.Time{}, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, audit.LoginAction, result[0].Action)
+	assert.Equal(t, audit.LogoutAction, result[1].Action)
+}
+
+func saveAuditEntryRemovesOldest(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	now := time.Now().UTC()
+
+	for i := 0; i < auditMaxEntries+2; i++ {
+		err := testDB.auditRepo.Save(&audit.Entry{
+			Time: now.Add(time.Duration(i) * time.Second), Action: audit.LoginFailedAction, Detail: fmt.Sprint(i),
+		})
+		assert.NoError(t, err)
+	}
+
+	result, err := testDB.auditRepo.GetAll(time.Time{}, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, result, auditMaxEntries)
+	assert.Equal(t, "2", result[0].Detail)
+	assert.Equal(t, fmt.Sprint(auditMaxEntries+1), result[auditMaxEntries-1].Detail)
+}
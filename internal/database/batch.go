@@ -0,0 +1,122 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const batchBucket = "Batches"
+
+var _ batch.Repo = (*BatchRepo)(nil)
+
+// BatchRepo represents a bbolt repository for managing Batches.
+type BatchRepo struct {
+	db *bbolt.DB
+}
+
+// NewBatchRepo returns a new Batch repository using the given bbolt database. It also creates the Batches
+// bucket if it is not yet created on disk.
+func NewBatchRepo(db *bbolt.DB) (*BatchRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(batchBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create Batch bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &BatchRepo{
+		db: db,
+	}, nil
+}
+
+// GetAll returns all Batches.
+func (r *BatchRepo) GetAll() ([]*batch.Detail, error) {
+	batches := []*batch.Detail{}
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		err := tx.Bucket([]byte(batchBucket)).ForEach(func(k, v []byte) error {
+			var d batch.Detail
+			if err := json.Unmarshal(v, &d); err != nil {
+				return errors.Wrap(err, "could not unmarshal Batch")
+			}
+			batches = append(batches, &d)
+
+			return nil
+		})
+
+		return errors.Wrap(err, "could not iterate over Batches")
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return batches, nil
+}
+
+// Get returns a Batch by its ID.
+func (r *BatchRepo) Get(id string) (*batch.Detail, error) {
+	var d *batch.Detail
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket([]byte(batchBucket)).Get([]byte(id)); v != nil {
+			if err := json.Unmarshal(v, &d); err != nil {
+				return errors.Wrapf(err, "could not unmarshal Batch %s", id)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return d, nil
+}
+
+// Save creates or updates a Batch.
+func (r *BatchRepo) Save(d *batch.Detail) error {
+	if d.ID == "" {
+		d.ID = uuid.NewString()
+	}
+
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(batchBucket))
+		if v, err := json.Marshal(d); err != nil {
+			return errors.Wrapf(err, "could not marshal Batch %s", d.ID)
+		} else if err := bu.Put([]byte(d.ID), v); err != nil {
+			return errors.Wrapf(err, "could not put Batch %s", d.ID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// Delete permanently removes a Batch.
+func (r *BatchRepo) Delete(id string) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(batchBucket))
+		if err := bu.Delete([]byte(id)); err != nil {
+			return errors.Wrapf(err, "could not delete Batch %s", id)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
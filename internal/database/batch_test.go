@@ -0,0 +1,91 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestBatches(t *testing.T) {
+	t.Parallel()
+	t.Run("saveNewBatch", saveNewBatch)
+	t.Run("saveAndGetAllBatches", saveAndGetAllBatches)
+	t.Run("deleteBatch", deleteBatch)
+	t.Run("saveBatchPutError", saveBatchPutError)
+}
+
+func saveNewBatch(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	d := batch.Detail{Recipe: batch.Recipe{Name: "My Recipe"}}
+
+	err := testDB.batchRepo.Save(&d)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, d.ID)
+
+	result, err := testDB.batchRepo.Get(d.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &d, result)
+}
+
+func saveAndGetAllBatches(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	d1 := &batch.Detail{ID: "59679696-1263-4340-a256-6c46876b4a13", Recipe: batch.Recipe{Name: "My Recipe 1"}}
+	d2 := &batch.Detail{ID: "d9d075b4-6b45-44cc-945b-c5b9ce13e442", Recipe: batch.Recipe{Name: "My Recipe 2"}}
+
+	err := testDB.batchRepo.Save(d1)
+	assert.NoError(t, err)
+
+	err = testDB.batchRepo.Save(d2)
+	assert.NoError(t, err)
+
+	result, err := testDB.batchRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []*batch.Detail{d1, d2}, result)
+}
+
+func deleteBatch(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	d := &batch.Detail{Recipe: batch.Recipe{Name: "My Recipe"}}
+
+	err := testDB.batchRepo.Save(d)
+	assert.NoError(t, err)
+
+	err = testDB.batchRepo.Delete(d.ID)
+	assert.NoError(t, err)
+
+	result, err := testDB.batchRepo.Get(d.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func saveBatchPutError(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	d := batch.Detail{ID: generateRandomString(bbolt.MaxKeySize + 1)}
+
+	err := testDB.batchRepo.Save(&d)
+	// TODO: Waiting on PR for ErrorContains(): https://github.com/stretchr/testify/pull/1022
+	assert.Contains(t, err.Error(), "could not execute update transaction: could not put Batch")
+}
@@ -8,11 +8,26 @@ import (
 	"go.etcd.io/bbolt"
 )
 
+const (
+	readingsRetention = 30 * 24 * time.Hour
+	auditRetention    = 90 * 24 * time.Hour
+	auditMaxEntries   = 5
+)
+
 // TestClient is a wrapper around the bbolt.Client.
 type testDB struct {
-	db           *bbolt.DB
-	chamberRepo  *database.ChamberRepo
-	settingsRepo *database.SettingsRepo
+	db                    *bbolt.DB
+	batchRepo             *database.BatchRepo
+	brewfatherCacheRepo   *database.BrewfatherCacheRepo
+	brewfatherOutboxRepo  *database.BrewfatherOutboxRepo
+	chamberRepo           *database.ChamberRepo
+	fermentationStateRepo *database.FermentationStateRepo
+	readingsRepo          *database.ReadingsRepo
+	settingsRepo          *database.SettingsRepo
+	userRepo              *database.UserRepo
+	apiTokenRepo          *database.APITokenRepo
+	sessionRepo           *database.SessionRepo
+	auditRepo             *database.AuditRepo
 }
 
 func createTestDB() *testDB {
@@ -33,20 +48,74 @@ func createTestDB() *testDB {
 		panic(err)
 	}
 
+	batchRepo, err := database.NewBatchRepo(db)
+	if err != nil {
+		panic(err)
+	}
+
+	brewfatherCacheRepo, err := database.NewBrewfatherCacheRepo(db)
+	if err != nil {
+		panic(err)
+	}
+
+	brewfatherOutboxRepo, err := database.NewBrewfatherOutboxRepo(db)
+	if err != nil {
+		panic(err)
+	}
+
 	chamberRepo, err := database.NewChamberRepo(db)
 	if err != nil {
 		panic(err)
 	}
 
+	fermentationStateRepo, err := database.NewFermentationStateRepo(db)
+	if err != nil {
+		panic(err)
+	}
+
+	readingsRepo, err := database.NewReadingsRepo(db, readingsRetention)
+	if err != nil {
+		panic(err)
+	}
+
 	settingsRepo, err := database.NewSettingsRepo(db)
 	if err != nil {
 		panic(err)
 	}
 
+	userRepo, err := database.NewUserRepo(db)
+	if err != nil {
+		panic(err)
+	}
+
+	apiTokenRepo, err := database.NewAPITokenRepo(db)
+	if err != nil {
+		panic(err)
+	}
+
+	sessionRepo, err := database.NewSessionRepo(db)
+	if err != nil {
+		panic(err)
+	}
+
+	auditRepo, err := database.NewAuditRepo(db, auditRetention, auditMaxEntries)
+	if err != nil {
+		panic(err)
+	}
+
 	t := &testDB{
-		db:           db,
-		chamberRepo:  chamberRepo,
-		settingsRepo: settingsRepo,
+		db:                    db,
+		batchRepo:             batchRepo,
+		brewfatherCacheRepo:   brewfatherCacheRepo,
+		brewfatherOutboxRepo:  brewfatherOutboxRepo,
+		chamberRepo:           chamberRepo,
+		fermentationStateRepo: fermentationStateRepo,
+		readingsRepo:          readingsRepo,
+		settingsRepo:          settingsRepo,
+		userRepo:              userRepo,
+		apiTokenRepo:          apiTokenRepo,
+		sessionRepo:           sessionRepo,
+		auditRepo:             auditRepo,
 	}
 
 	return t
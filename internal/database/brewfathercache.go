@@ -0,0 +1,96 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const brewfatherCacheBucket = "BrewfatherCache"
+
+var _ brewfather.CacheRepo = (*BrewfatherCacheRepo)(nil)
+
+// BrewfatherCacheRepo represents a bbolt repository for persisting Brewfather cache entries.
+type BrewfatherCacheRepo struct {
+	db *bbolt.DB
+}
+
+// NewBrewfatherCacheRepo returns a new Brewfather cache repository using the given bbolt database. It also creates
+// the BrewfatherCache bucket if it is not yet created on disk.
+func NewBrewfatherCacheRepo(db *bbolt.DB) (*BrewfatherCacheRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(brewfatherCacheBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create BrewfatherCache bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &BrewfatherCacheRepo{
+		db: db,
+	}, nil
+}
+
+// GetEntry returns the cache entry stored under the given key.
+func (r *BrewfatherCacheRepo) GetEntry(key string) (*brewfather.CacheEntry, error) {
+	var e *brewfather.CacheEntry
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket([]byte(brewfatherCacheBucket)).Get([]byte(key)); v != nil {
+			if err := json.Unmarshal(v, &e); err != nil {
+				return errors.Wrap(err, "could not unmarshal cache entry")
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return e, nil
+}
+
+// SaveEntry creates or updates the cache entry stored under the given key.
+func (r *BrewfatherCacheRepo) SaveEntry(key string, e *brewfather.CacheEntry) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		if v, err := json.Marshal(e); err != nil {
+			return errors.Wrap(err, "could not marshal cache entry")
+		} else if err := tx.Bucket([]byte(brewfatherCacheBucket)).Put([]byte(key), v); err != nil {
+			return errors.Wrap(err, "could not put cache entry")
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// DeleteAll permanently removes all cache entries.
+func (r *BrewfatherCacheRepo) DeleteAll() error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(brewfatherCacheBucket)); err != nil {
+			return errors.Wrap(err, "could not delete BrewfatherCache bucket")
+		}
+
+		if _, err := tx.CreateBucket([]byte(brewfatherCacheBucket)); err != nil {
+			return errors.Wrap(err, "could not create BrewfatherCache bucket")
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package database_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrewfatherCache(t *testing.T) {
+	t.Parallel()
+	t.Run("saveAndGetEntry", saveAndGetEntry)
+	t.Run("getMissingEntry", getMissingEntry)
+	t.Run("deleteAllEntries", deleteAllEntries)
+}
+
+func saveAndGetEntry(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	e := &brewfather.CacheEntry{
+		ETag:      `"abc"`,
+		FetchTime: time.Now().UTC().Truncate(time.Second),
+		Data:      json.RawMessage(`[{"_id":"1"}]`),
+	}
+
+	err := testDB.brewfatherCacheRepo.SaveEntry("batches", e)
+	assert.NoError(t, err)
+
+	result, err := testDB.brewfatherCacheRepo.GetEntry("batches")
+	assert.NoError(t, err)
+	assert.Equal(t, e, result)
+}
+
+func getMissingEntry(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	result, err := testDB.brewfatherCacheRepo.GetEntry("batches")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func deleteAllEntries(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	err := testDB.brewfatherCacheRepo.SaveEntry("batches", &brewfather.CacheEntry{Data: json.RawMessage(`[]`)})
+	assert.NoError(t, err)
+
+	err = testDB.brewfatherCacheRepo.DeleteAll()
+	assert.NoError(t, err)
+
+	result, err := testDB.brewfatherCacheRepo.GetEntry("batches")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
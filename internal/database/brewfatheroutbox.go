@@ -0,0 +1,81 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const brewfatherOutboxBucket = "BrewfatherOutbox"
+
+var _ brewfather.OutboxRepo = (*BrewfatherOutboxRepo)(nil)
+
+// BrewfatherOutboxRepo represents a bbolt repository for persisting the Brewfather outbox.
+type BrewfatherOutboxRepo struct {
+	db *bbolt.DB
+}
+
+// NewBrewfatherOutboxRepo returns a new Brewfather outbox repository using the given bbolt database. It also creates
+// the BrewfatherOutbox bucket if it is not yet created on disk.
+func NewBrewfatherOutboxRepo(db *bbolt.DB) (*BrewfatherOutboxRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(brewfatherOutboxBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create BrewfatherOutbox bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &BrewfatherOutboxRepo{
+		db: db,
+	}, nil
+}
+
+// GetAll returns all outbox items.
+func (r *BrewfatherOutboxRepo) GetAll() ([]*brewfather.OutboxItem, error) {
+	items := []*brewfather.OutboxItem{}
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		err := tx.Bucket([]byte(brewfatherOutboxBucket)).ForEach(func(k, v []byte) error {
+			var i brewfather.OutboxItem
+			if err := json.Unmarshal(v, &i); err != nil {
+				return errors.Wrap(err, "could not unmarshal outbox item")
+			}
+			items = append(items, &i)
+
+			return nil
+		})
+
+		return errors.Wrap(err, "could not iterate over outbox items")
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return items, nil
+}
+
+// Save creates or updates the outbox item of a device.
+func (r *BrewfatherOutboxRepo) Save(i *brewfather.OutboxItem) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		if v, err := json.Marshal(i); err != nil {
+			return errors.Wrap(err, "could not marshal outbox item")
+		} else if err := tx.Bucket([]byte(brewfatherOutboxBucket)).Put([]byte(i.DeviceName), v); err != nil {
+			return errors.Wrap(err, "could not put outbox item")
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrewfatherOutbox(t *testing.T) {
+	t.Parallel()
+	t.Run("saveAndGetAllOutboxItems", saveAndGetAllOutboxItems)
+	t.Run("getAllOutboxItemsEmpty", getAllOutboxItemsEmpty)
+}
+
+func saveAndGetAllOutboxItems(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	item := &brewfather.OutboxItem{
+		DeviceName: "Chamber 1",
+		Entry:      &brewfather.LogEntry{DeviceName: "Chamber 1", BeerTemperature: "20.5"},
+		Sums:       map[string]float64{"temp": 41},
+		Counts:     map[string]int{"temp": 2},
+		Readings:   2,
+		LastSent:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	err := testDB.brewfatherOutboxRepo.Save(item)
+	assert.NoError(t, err)
+
+	// saving again replaces the item of the device
+	item.Readings = 3
+	err = testDB.brewfatherOutboxRepo.Save(item)
+	assert.NoError(t, err)
+
+	items, err := testDB.brewfatherOutboxRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []*brewfather.OutboxItem{item}, items)
+}
+
+func getAllOutboxItemsEmpty(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	items, err := testDB.brewfatherOutboxRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
@@ -92,6 +92,7 @@ func (r *ChamberRepo) Save(c *chamber.Chamber) error {
 
 	c.Readings = nil
 	c.CurrentFermentationStep = ""
+	c.CurrentStepStatus = nil
 	c.ModTime = time.Now()
 
 	if err := r.db.Update(func(tx *bbolt.Tx) error {
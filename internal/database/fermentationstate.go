@@ -0,0 +1,94 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const fermentationStateBucket = "FermentationStates"
+
+var _ chamber.StateRepo = (*FermentationStateRepo)(nil)
+
+// FermentationStateRepo represents a bbolt repository for managing the state of running fermentations.
+type FermentationStateRepo struct {
+	db *bbolt.DB
+}
+
+// NewFermentationStateRepo returns a new FermentationState repository using the given bbolt database. It also
+// creates the FermentationStates bucket if it is not yet created on disk.
+func NewFermentationStateRepo(db *bbolt.DB) (*FermentationStateRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(fermentationStateBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create FermentationState bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &FermentationStateRepo{
+		db: db,
+	}, nil
+}
+
+// GetState returns the FermentationState of a Chamber by the Chamber's ID.
+func (r *FermentationStateRepo) GetState(chamberID string) (*chamber.FermentationState, error) {
+	var s *chamber.FermentationState
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket([]byte(fermentationStateBucket)).Get([]byte(chamberID)); v != nil {
+			if err := json.Unmarshal(v, &s); err != nil {
+				return errors.Wrapf(err, "could not unmarshal FermentationState %s", chamberID)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return s, nil
+}
+
+// SaveState creates or updates the FermentationState of a Chamber.
+func (r *FermentationStateRepo) SaveState(chamberID string, s *chamber.FermentationState) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(fermentationStateBucket))
+		if v, err := json.Marshal(s); err != nil {
+			return errors.Wrapf(err, "could not marshal FermentationState %s", chamberID)
+		} else if err := bu.Put([]byte(chamberID), v); err != nil {
+			return errors.Wrapf(err, "could not put FermentationState %s", chamberID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// DeleteState permanently removes the FermentationState of a Chamber.
+func (r *FermentationStateRepo) DeleteState(chamberID string) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(fermentationStateBucket))
+		if err := bu.Delete([]byte(chamberID)); err != nil {
+			return errors.Wrapf(err, "could not delete FermentationState %s", chamberID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
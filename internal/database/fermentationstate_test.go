@@ -0,0 +1,85 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+)
+
+const stateChamberID = "59679696-1263-4340-a256-6c46876b4a13"
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestFermentationState(t *testing.T) {
+	t.Parallel()
+	t.Run("saveAndGetState", saveAndGetState)
+	t.Run("getStateNotFound", getStateNotFound)
+	t.Run("deleteState", deleteState)
+	t.Run("saveStatePutError", saveStatePutError)
+}
+
+func saveAndGetState(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	s := &chamber.FermentationState{
+		Step:          "Primary",
+		StepIndex:     1,
+		StepStartTime: time.Now().UTC().Truncate(time.Second),
+		SetPoint:      19.5,
+	}
+
+	err := testDB.fermentationStateRepo.SaveState(stateChamberID, s)
+	assert.NoError(t, err)
+
+	result, err := testDB.fermentationStateRepo.GetState(stateChamberID)
+	assert.NoError(t, err)
+	assert.Equal(t, s, result)
+}
+
+func getStateNotFound(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	result, err := testDB.fermentationStateRepo.GetState(stateChamberID)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func deleteState(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	err := testDB.fermentationStateRepo.SaveState(stateChamberID, &chamber.FermentationState{Step: "Primary"})
+	assert.NoError(t, err)
+
+	err = testDB.fermentationStateRepo.DeleteState(stateChamberID)
+	assert.NoError(t, err)
+
+	result, err := testDB.fermentationStateRepo.GetState(stateChamberID)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func saveStatePutError(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	err := testDB.fermentationStateRepo.SaveState(generateRandomString(bbolt.MaxKeySize+1),
+		&chamber.FermentationState{Step: "Primary"})
+	assert.Contains(t, err.Error(), "could not execute update transaction: could not put FermentationState")
+}
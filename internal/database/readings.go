@@ -0,0 +1,158 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const readingsBucket = "Readings"
+
+var _ chamber.ReadingsRepo = (*ReadingsRepo)(nil)
+
+// ReadingsRepo represents a bbolt repository for storing the history of Chambers' readings. Each Chamber's readings
+// are stored in their own nested bucket, keyed by the time of the reading so that they can be scanned in order.
+// Readings older than the retention period are removed when new ones are saved.
+type ReadingsRepo struct {
+	db        *bbolt.DB
+	retention time.Duration
+}
+
+// NewReadingsRepo returns a new Readings repository using the given bbolt database. It also creates the Readings
+// bucket if it is not yet created on disk. Readings are kept for the given retention period, or forever if it is 0.
+func NewReadingsRepo(db *bbolt.DB, retention time.Duration) (*ReadingsRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(readingsBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create Readings bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &ReadingsRepo{
+		db:        db,
+		retention: retention,
+	}, nil
+}
+
+// GetReadings returns the readings of a Chamber recorded from, inclusive, to, exclusive, in chronological order.
+func (r *ReadingsRepo) GetReadings(chamberID string, from, to time.Time) ([]*chamber.Reading, error) {
+	readings := []*chamber.Reading{}
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(readingsBucket)).Bucket([]byte(chamberID))
+		if bu == nil {
+			return nil
+		}
+
+		c := bu.Cursor()
+		end := readingKey(to)
+
+		for k, v := c.Seek(readingKey(from)); k != nil && bytes.Compare(k, end) < 0; k, v = c.Next() {
+			var reading chamber.Reading
+			if err := json.Unmarshal(v, &reading); err != nil {
+				return errors.Wrapf(err, "could not unmarshal Reading for Chamber %s", chamberID)
+			}
+
+			readings = append(readings, &reading)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return readings, nil
+}
+
+// SaveReading adds a reading to the history of a Chamber and removes the Chamber's readings that are older than the
+// retention period.
+func (r *ReadingsRepo) SaveReading(chamberID string, reading *chamber.Reading) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu, err := tx.Bucket([]byte(readingsBucket)).CreateBucketIfNotExists([]byte(chamberID))
+		if err != nil {
+			return errors.Wrapf(err, "could not create Readings bucket for Chamber %s", chamberID)
+		}
+
+		if r.retention > 0 {
+			if err := removeOldEntries(bu, reading.Time.Add(-r.retention)); err != nil {
+				return errors.Wrapf(err, "could not remove old Readings for Chamber %s", chamberID)
+			}
+		}
+
+		if v, err := json.Marshal(reading); err != nil {
+			return errors.Wrapf(err, "could not marshal Reading for Chamber %s", chamberID)
+		} else if err := bu.Put(readingKey(reading.Time), v); err != nil {
+			return errors.Wrapf(err, "could not put Reading for Chamber %s", chamberID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// DeleteReadings permanently removes all readings of a Chamber.
+func (r *ReadingsRepo) DeleteReadings(chamberID string) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(readingsBucket))
+		if err := bu.DeleteBucket([]byte(chamberID)); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
+			return errors.Wrapf(err, "could not delete Readings for Chamber %s", chamberID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// removeOldEntries removes the entries of a bucket keyed by readingKey that were recorded before the given time.
+func removeOldEntries(bu *bbolt.Bucket, before time.Time) error {
+	c := bu.Cursor()
+	end := readingKey(before)
+
+	for k, _ := c.First(); k != nil && bytes.Compare(k, end) < 0; k, _ = c.First() {
+		if err := c.Delete(); err != nil {
+			return errors.Wrap(err, "could not delete entry")
+		}
+	}
+
+	return nil
+}
+
+// readingKey returns a key that sorts in chronological order. Times before the Unix epoch, such as the zero time, are
+// clamped to it and times that can not be represented in nanoseconds are clamped to the last one, so that they sort
+// before and after every reading respectively.
+func readingKey(t time.Time) []byte {
+	var n int64
+
+	switch {
+	case t.Before(time.Unix(0, 0)):
+	case t.After(time.Unix(0, math.MaxInt64)):
+		n = math.MaxInt64
+	default:
+		n = t.UnixNano()
+	}
+
+	key := make([]byte, 8) //nolint:gomnd // size of uint64
+	binary.BigEndian.PutUint64(key, uint64(n))
+
+	return key
+}
@@ -0,0 +1,150 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/stretchr/testify/assert"
+)
+
+const otherChamberID = "a5a2cd3b-2d3c-4e3f-9f1e-0c2f8f8ef1e2"
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestReadings(t *testing.T) {
+	t.Parallel()
+	t.Run("saveAndGetReadings", saveAndGetReadings)
+	t.Run("getReadingsFromZeroTime", getReadingsFromZeroTime)
+	t.Run("getReadingsNoChamber", getReadingsNoChamber)
+	t.Run("saveReadingRemovesOld", saveReadingRemovesOld)
+	t.Run("deleteReadings", deleteReadings)
+	t.Run("saveReadingPutError", saveReadingPutError)
+}
+
+func saveAndGetReadings(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	temperature := 19.5
+
+	// saved out of order to check that readings are returned chronologically
+	for _, i := range []int{3, 0, 2, 1, 4} {
+		r := &chamber.Reading{Time: start.Add(time.Duration(i) * time.Minute), BeerTemperature: &temperature}
+		err := testDB.readingsRepo.SaveReading(stateChamberID, r)
+		assert.NoError(t, err)
+	}
+
+	err := testDB.readingsRepo.SaveReading(otherChamberID, &chamber.Reading{Time: start.Add(2 * time.Minute)})
+	assert.NoError(t, err)
+
+	readings, err := testDB.readingsRepo.GetReadings(stateChamberID, start.Add(1*time.Minute),
+		start.Add(4*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 3)
+
+	for i, r := range readings {
+		assert.True(t, start.Add(time.Duration(i+1)*time.Minute).Equal(r.Time))
+		assert.Equal(t, temperature, *r.BeerTemperature)
+	}
+}
+
+func getReadingsFromZeroTime(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	now := time.Now()
+
+	err := testDB.readingsRepo.SaveReading(stateChamberID, &chamber.Reading{Time: now})
+	assert.NoError(t, err)
+
+	readings, err := testDB.readingsRepo.GetReadings(stateChamberID, time.Time{}, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+
+	// times past what can be represented in nanoseconds sort after every reading
+	readings, err = testDB.readingsRepo.GetReadings(stateChamberID, now.Add(-time.Hour),
+		time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+}
+
+func saveReadingRemovesOld(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	now := time.Now()
+
+	for _, age := range []time.Duration{readingsRetention + time.Hour, readingsRetention - time.Hour, 0} {
+		err := testDB.readingsRepo.SaveReading(stateChamberID, &chamber.Reading{Time: now.Add(-age)})
+		assert.NoError(t, err)
+	}
+
+	// the readings of other chambers are kept
+	err := testDB.readingsRepo.SaveReading(otherChamberID, &chamber.Reading{Time: now.Add(-2 * readingsRetention)})
+	assert.NoError(t, err)
+
+	readings, err := testDB.readingsRepo.GetReadings(stateChamberID, time.Time{}, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 2)
+
+	readings, err = testDB.readingsRepo.GetReadings(otherChamberID, time.Time{}, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+}
+
+func getReadingsNoChamber(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	readings, err := testDB.readingsRepo.GetReadings(stateChamberID, time.Now().Add(-time.Hour), time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, readings)
+}
+
+func deleteReadings(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	now := time.Now()
+
+	err := testDB.readingsRepo.SaveReading(stateChamberID, &chamber.Reading{Time: now})
+	assert.NoError(t, err)
+
+	err = testDB.readingsRepo.DeleteReadings(stateChamberID)
+	assert.NoError(t, err)
+
+	readings, err := testDB.readingsRepo.GetReadings(stateChamberID, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, readings)
+
+	// deleting readings that do not exist is not an error
+	err = testDB.readingsRepo.DeleteReadings(stateChamberID)
+	assert.NoError(t, err)
+}
+
+func saveReadingPutError(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	err := testDB.readingsRepo.SaveReading("", &chamber.Reading{})
+	assert.Contains(t, err.Error(), "could not execute update transaction: could not create Readings bucket")
+}
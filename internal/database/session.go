@@ -0,0 +1,235 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	sessionBucket      = "Sessions"
+	revokedTokenBucket = "RevokedTokens"
+)
+
+var _ auth.SessionRepo = (*SessionRepo)(nil)
+
+// SessionRepo represents a bbolt repository for managing Sessions and revoked tokens.
+type SessionRepo struct {
+	db *bbolt.DB
+}
+
+// NewSessionRepo returns a new Session repository using the given bbolt database. It also creates the Sessions and
+// RevokedTokens buckets if they are not yet created on disk.
+func NewSessionRepo(db *bbolt.DB) (*SessionRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(sessionBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create Session bucket")
+	}
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(revokedTokenBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create RevokedToken bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &SessionRepo{
+		db: db,
+	}, nil
+}
+
+// Get returns a Session by its ID.
+func (r *SessionRepo) Get(id string) (*auth.Session, error) {
+	var s *auth.Session
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket([]byte(sessionBucket)).Get([]byte(id)); v != nil {
+			if err := json.Unmarshal(v, &s); err != nil {
+				return errors.Wrapf(err, "could not unmarshal Session %s", id)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return s, nil
+}
+
+// Save creates or updates a Session.
+func (r *SessionRepo) Save(s *auth.Session) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(sessionBucket))
+		if v, err := json.Marshal(s); err != nil {
+			return errors.Wrapf(err, "could not marshal Session %s", s.ID)
+		} else if err := bu.Put([]byte(s.ID), v); err != nil {
+			return errors.Wrapf(err, "could not put Session %s", s.ID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// Delete permanently removes a Session.
+func (r *SessionRepo) Delete(id string) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(sessionBucket))
+		if err := bu.Delete([]byte(id)); err != nil {
+			return errors.Wrapf(err, "could not delete Session %s", id)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// DeleteByUser permanently removes all Sessions of a user and revokes the access tokens that were last issued for them.
+func (r *SessionRepo) DeleteByUser(userID string) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(sessionBucket))
+		rb := tx.Bucket([]byte(revokedTokenBucket))
+
+		var ids [][]byte
+
+		if err := bu.ForEach(func(k, v []byte) error {
+			var s auth.Session
+			if err := json.Unmarshal(v, &s); err != nil {
+				return errors.Wrap(err, "could not unmarshal Session")
+			}
+
+			if s.UserID != userID {
+				return nil
+			}
+
+			ids = append(ids, k)
+
+			if s.AccessTokenID == "" {
+				return nil
+			}
+
+			return putRevokedToken(rb, s.AccessTokenID, s.AccessExpiresTime)
+		}); err != nil {
+			return errors.Wrap(err, "could not iterate over Sessions")
+		}
+
+		for _, id := range ids {
+			if err := bu.Delete(id); err != nil {
+				return errors.Wrapf(err, "could not delete Session %s", id)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// DeleteAll permanently removes all Sessions.
+func (r *SessionRepo) DeleteAll() error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(sessionBucket)); err != nil {
+			return errors.Wrap(err, "could not delete Session bucket")
+		}
+
+		if _, err := tx.CreateBucket([]byte(sessionBucket)); err != nil {
+			return errors.Wrap(err, "could not create Session bucket")
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// Revoke adds the ID of a token to the denylist until the token expires. Tokens that have expired are removed from
+// the denylist.
+func (r *SessionRepo) Revoke(tokenID string, expiresTime time.Time) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		rb := tx.Bucket([]byte(revokedTokenBucket))
+
+		if err := removeExpiredTokens(rb); err != nil {
+			return err
+		}
+
+		return putRevokedToken(rb, tokenID, expiresTime)
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether the token with the given ID is in the denylist.
+func (r *SessionRepo) IsRevoked(tokenID string) (bool, error) {
+	var revoked bool
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		revoked = tx.Bucket([]byte(revokedTokenBucket)).Get([]byte(tokenID)) != nil
+
+		return nil
+	}); err != nil {
+		return false, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return revoked, nil
+}
+
+func putRevokedToken(rb *bbolt.Bucket, tokenID string, expiresTime time.Time) error {
+	v, err := expiresTime.UTC().MarshalText()
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal expiry time of revoked token %s", tokenID)
+	}
+
+	if err := rb.Put([]byte(tokenID), v); err != nil {
+		return errors.Wrapf(err, "could not put revoked token %s", tokenID)
+	}
+
+	return nil
+}
+
+func removeExpiredTokens(rb *bbolt.Bucket) error {
+	now := time.Now()
+
+	var expired [][]byte
+
+	if err := rb.ForEach(func(k, v []byte) error {
+		var expiresTime time.Time
+		if err := expiresTime.UnmarshalText(v); err != nil || expiresTime.Before(now) {
+			expired = append(expired, k)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not iterate over revoked tokens")
+	}
+
+	for _, k := range expired {
+		if err := rb.Delete(k); err != nil {
+			return errors.Wrapf(err, "could not delete revoked token %s", k)
+		}
+	}
+
+	return nil
+}
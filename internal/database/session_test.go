@@ -0,0 +1,157 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSessions(t *testing.T) {
+	t.Parallel()
+	t.Run("saveAndGetSession", saveAndGetSession)
+	t.Run("deleteSessionsByUser", deleteSessionsByUser)
+	t.Run("deleteAllSessions", deleteAllSessions)
+	t.Run("revokeToken", revokeToken)
+	t.Run("revokeTokenRemovesExpired", revokeTokenRemovesExpired)
+}
+
+func createTestSession(t *testing.T, userID, accessTokenID string) *auth.Session {
+	t.Helper()
+
+	s := auth.NewSession(userID)
+	_, err := s.Rotate(time.Hour)
+	assert.NoError(t, err)
+
+	s.SetAccessToken(&auth.Claims{StandardClaims: jwt.StandardClaims{
+		Id:        accessTokenID,
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}})
+
+	return s
+}
+
+func saveAndGetSession(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	s := createTestSession(t, "59679696-1263-4340-a256-6c46876b4a13", "access1")
+
+	err := testDB.sessionRepo.Save(s)
+	assert.NoError(t, err)
+
+	result, err := testDB.sessionRepo.Get(s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, s.ID, result.ID)
+	assert.Equal(t, s.Hash, result.Hash)
+	assert.Equal(t, s.AccessTokenID, result.AccessTokenID)
+	assert.True(t, s.ExpiresTime.Equal(result.ExpiresTime))
+
+	err = testDB.sessionRepo.Delete(s.ID)
+	assert.NoError(t, err)
+
+	result, err = testDB.sessionRepo.Get(s.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func deleteSessionsByUser(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	s1 := createTestSession(t, "59679696-1263-4340-a256-6c46876b4a13", "access1")
+	s2 := createTestSession(t, "59679696-1263-4340-a256-6c46876b4a13", "access2")
+	s3 := createTestSession(t, "d9d075b4-6b45-44cc-945b-c5b9ce13e442", "access3")
+
+	for _, s := range []*auth.Session{s1, s2, s3} {
+		err := testDB.sessionRepo.Save(s)
+		assert.NoError(t, err)
+	}
+
+	err := testDB.sessionRepo.DeleteByUser("59679696-1263-4340-a256-6c46876b4a13")
+	assert.NoError(t, err)
+
+	for _, s := range []*auth.Session{s1, s2} {
+		result, err := testDB.sessionRepo.Get(s.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+
+		revoked, err := testDB.sessionRepo.IsRevoked(s.AccessTokenID)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	}
+
+	result, err := testDB.sessionRepo.Get(s3.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+
+	revoked, err := testDB.sessionRepo.IsRevoked(s3.AccessTokenID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func deleteAllSessions(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	s := createTestSession(t, "59679696-1263-4340-a256-6c46876b4a13", "access1")
+
+	err := testDB.sessionRepo.Save(s)
+	assert.NoError(t, err)
+
+	err = testDB.sessionRepo.DeleteAll()
+	assert.NoError(t, err)
+
+	result, err := testDB.sessionRepo.Get(s.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func revokeToken(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	err := testDB.sessionRepo.Revoke("access1", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	revoked, err := testDB.sessionRepo.IsRevoked("access1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = testDB.sessionRepo.IsRevoked("access2")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func revokeTokenRemovesExpired(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	err := testDB.sessionRepo.Revoke("access1", time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+
+	err = testDB.sessionRepo.Revoke("access2", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	revoked, err := testDB.sessionRepo.IsRevoked("access1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const userBucket = "Users"
+
+var _ auth.UserRepo = (*UserRepo)(nil)
+
+// UserRepo represents a bbolt repository for managing Users.
+type UserRepo struct {
+	db *bbolt.DB
+}
+
+// NewUserRepo returns a new User repository using the given bbolt database. It also creates the Users bucket if it is
+// not yet created on disk.
+func NewUserRepo(db *bbolt.DB) (*UserRepo, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer rollback(tx, &err)
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(userBucket)); err != nil {
+		return nil, errors.Wrap(err, "could not create User bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit transaction")
+	}
+
+	return &UserRepo{
+		db: db,
+	}, nil
+}
+
+// GetAll returns all Users.
+func (r *UserRepo) GetAll() ([]*auth.User, error) {
+	users := []*auth.User{}
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		err := tx.Bucket([]byte(userBucket)).ForEach(func(k, v []byte) error {
+			var u auth.User
+			if err := json.Unmarshal(v, &u); err != nil {
+				return errors.Wrap(err, "could not unmarshal User")
+			}
+			users = append(users, &u)
+
+			return nil
+		})
+
+		return errors.Wrap(err, "could not iterate over Users")
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return users, nil
+}
+
+// Get returns a User by its ID.
+func (r *UserRepo) Get(id string) (*auth.User, error) {
+	var u *auth.User
+
+	if err := r.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket([]byte(userBucket)).Get([]byte(id)); v != nil {
+			if err := json.Unmarshal(v, &u); err != nil {
+				return errors.Wrapf(err, "could not unmarshal User %s", id)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not execute view transaction")
+	}
+
+	return u, nil
+}
+
+// GetByUsername returns a User by its username.
+func (r *UserRepo) GetByUsername(username string) (*auth.User, error) {
+	users, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Save creates or updates a User.
+func (r *UserRepo) Save(u *auth.User) error {
+	if u.ID == "" {
+		u.ID = uuid.NewString()
+	}
+
+	u.ModTime = time.Now().UTC()
+
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(userBucket))
+		if v, err := json.Marshal(u); err != nil {
+			return errors.Wrapf(err, "could not marshal User %s", u.ID)
+		} else if err := bu.Put([]byte(u.ID), v); err != nil {
+			return errors.Wrapf(err, "could not put User %s", u.ID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
+
+// Delete permanently removes a User.
+func (r *UserRepo) Delete(id string) error {
+	if err := r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket([]byte(userBucket))
+		if err := bu.Delete([]byte(id)); err != nil {
+			return errors.Wrapf(err, "could not delete User %s", id)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not execute update transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestUsers(t *testing.T) {
+	t.Parallel()
+	t.Run("saveNewUser", saveNewUser)
+	t.Run("saveAndGetAllUsers", saveAndGetAllUsers)
+	t.Run("getUserByUsername", getUserByUsername)
+	t.Run("deleteUser", deleteUser)
+}
+
+func saveNewUser(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	u := auth.User{Username: "brewer", Role: auth.BrewerRole}
+
+	err := testDB.userRepo.Save(&u)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, u.ID)
+	assert.False(t, u.ModTime.IsZero())
+
+	result, err := testDB.userRepo.Get(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &u, result)
+}
+
+func saveAndGetAllUsers(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	u1 := &auth.User{ID: "59679696-1263-4340-a256-6c46876b4a13", Username: "admin", Role: auth.AdminRole}
+	u2 := &auth.User{ID: "d9d075b4-6b45-44cc-945b-c5b9ce13e442", Username: "viewer", Role: auth.ViewerRole}
+
+	err := testDB.userRepo.Save(u1)
+	assert.NoError(t, err)
+
+	err = testDB.userRepo.Save(u2)
+	assert.NoError(t, err)
+
+	result, err := testDB.userRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []*auth.User{u1, u2}, result)
+}
+
+func getUserByUsername(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	u := &auth.User{Username: "brewer", Role: auth.BrewerRole}
+
+	err := testDB.userRepo.Save(u)
+	assert.NoError(t, err)
+
+	result, err := testDB.userRepo.GetByUsername("brewer")
+	assert.NoError(t, err)
+	assert.Equal(t, u, result)
+
+	result, err = testDB.userRepo.GetByUsername("someone")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func deleteUser(t *testing.T) {
+	t.Parallel()
+
+	testDB := createTestDB()
+
+	defer func() { testDB.Close() }()
+
+	u := &auth.User{Username: "brewer", Role: auth.BrewerRole}
+
+	err := testDB.userRepo.Save(u)
+	assert.NoError(t, err)
+
+	err = testDB.userRepo.Delete(u.ID)
+	assert.NoError(t, err)
+
+	result, err := testDB.userRepo.Get(u.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
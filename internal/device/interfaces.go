@@ -1,7 +1,5 @@
 package device
 
-import "context"
-
 type Sensor interface {
 	GetID() string
 }
@@ -29,7 +27,3 @@ type Actuator interface {
 	On() error
 	Off() error
 }
-
-type TemperatureController interface {
-	Run(ctx context.Context, setPoint float64) error
-}
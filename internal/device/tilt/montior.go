@@ -7,20 +7,26 @@ import (
 	"sync"
 	"time"
 
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"tinygo.org/x/bluetooth"
 )
 
 const (
-	iBeaconCompanyID = 76 // Apple's IBeacon company Id
-	tiltTLL          = 60 * time.Second
+	iBeaconCompanyID     = 76 // Apple's IBeacon company Id
+	tiltTLL              = 60 * time.Second
+	advertisementsMetric = "zymurgauge.tilt.advertisements"
+	rssiMetric           = "zymurgauge.tilt.rssi"
+	activeMetric         = "zymurgauge.tilt.active"
+	expiredMetric        = "zymurgauge.tilt.expired"
 )
 
 type Color string
 
 type Monitor struct {
 	logger    *logrus.Logger
+	metrics   metrics.Metrics
 	tilts     map[Color]*Tilt
 	colors    map[string]Color
 	isRunning bool
@@ -28,7 +34,7 @@ type Monitor struct {
 	tiltMutex sync.RWMutex
 }
 
-func NewMonitor(logger *logrus.Logger) *Monitor {
+func NewMonitor(logger *logrus.Logger, options ...OptionsFunc) *Monitor {
 	m := &Monitor{
 		logger: logger,
 		tilts:  make(map[Color]*Tilt),
@@ -44,9 +50,22 @@ func NewMonitor(logger *logrus.Logger) *Monitor {
 		},
 	}
 
+	for _, option := range options {
+		option(m)
+	}
+
 	return m
 }
 
+type OptionsFunc func(*Monitor)
+
+// SetMetrics sets the Metrics that advertisements, signal strengths and active Tilts are reported to.
+func SetMetrics(metrics metrics.Metrics) OptionsFunc {
+	return func(m *Monitor) {
+		m.metrics = metrics
+	}
+}
+
 func (m *Monitor) Run(ctx context.Context) error {
 	m.runMutex.Lock()
 
@@ -71,8 +90,16 @@ func (m *Monitor) removeExpiredTilts() {
 		if tilt.lastSeen.Before(time.Now().Add(-tiltTLL)) {
 			m.logger.Debugf("Removing expired Tilt: %s", tilt.color)
 			delete(m.tilts, tilt.color)
+
+			if m.metrics != nil {
+				m.metrics.Increment(metrics.Bucket(expiredMetric, colorTag(tilt.color)))
+			}
 		}
 	}
+
+	if m.metrics != nil {
+		m.metrics.Gauge(activeMetric, len(m.tilts))
+	}
 }
 
 func (m *Monitor) startCycle(ctx context.Context) error {
@@ -133,6 +160,8 @@ func (m *Monitor) scan(_ *bluetooth.Adapter, device bluetooth.ScanResult) {
 						lastSeen:    time.Now(),
 					}
 					m.tiltMutex.Unlock()
+
+					m.emitScanMetrics(color, device.RSSI)
 				}
 			}
 		}
@@ -150,3 +179,21 @@ func (m *Monitor) GetTilt(color Color) (*Tilt, error) {
 
 	return tilt, nil
 }
+
+func (m *Monitor) emitScanMetrics(color Color, rssi int16) {
+	if m.metrics == nil {
+		return
+	}
+
+	m.tiltMutex.RLock()
+	active := len(m.tilts)
+	m.tiltMutex.RUnlock()
+
+	m.metrics.Increment(metrics.Bucket(advertisementsMetric, colorTag(color)))
+	m.metrics.Gauge(metrics.Bucket(rssiMetric, colorTag(color)), rssi)
+	m.metrics.Gauge(activeMetric, active)
+}
+
+func colorTag(color Color) metrics.Tag {
+	return metrics.Tag{Key: "color", Value: string(color)}
+}
@@ -0,0 +1,107 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	ReadingsType Type = "readings"
+	ActuatorType Type = "actuator"
+	StepType     Type = "step"
+	AlertType    Type = "alert"
+
+	defaultBufferSize = 32
+)
+
+// Type is the kind of an event.
+type Type string
+
+// Event is something that happened to a chamber. ChamberID is empty for events that are not specific to a chamber.
+type Event struct {
+	Type      Type        `json:"type"`
+	ChamberID string      `json:"chamberId,omitempty"`
+	Time      time.Time   `json:"time"`
+	Data      interface{} `json:"data"`
+}
+
+// Publisher publishes events.
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Subscriber subscribes to events.
+type Subscriber interface {
+	Subscribe() (<-chan Event, func())
+}
+
+var (
+	_ Publisher  = (*Bus)(nil)
+	_ Subscriber = (*Bus)(nil)
+)
+
+// Bus fans events out to subscribers. Publishing never blocks, so a subscriber that does not keep up misses events.
+type Bus struct {
+	mutex       sync.Mutex
+	subscribers map[chan Event]struct{}
+	bufferSize  int
+}
+
+func NewBus(options ...OptionsFunc) *Bus {
+	b := &Bus{
+		subscribers: make(map[chan Event]struct{}),
+		bufferSize:  defaultBufferSize,
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+
+	return b
+}
+
+type OptionsFunc func(*Bus)
+
+// BufferSize sets the number of events that are buffered for each subscriber.
+func BufferSize(size int) OptionsFunc {
+	return func(b *Bus) {
+		b.bufferSize = size
+	}
+}
+
+// Publish sends the event to every subscriber that has room for it.
+func (b *Bus) Publish(e Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel that receives published events and a function that must be called to unsubscribe,
+// which closes the channel.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	ch := make(chan Event, b.bufferSize)
+	b.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+
+			delete(b.subscribers, ch)
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
@@ -0,0 +1,36 @@
+package event_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+	t.Parallel()
+
+	bus := event.NewBus(event.BufferSize(1))
+
+	ch1, unsubscribe1 := bus.Subscribe()
+	ch2, unsubscribe2 := bus.Subscribe()
+
+	defer unsubscribe2()
+
+	e := event.Event{Type: event.ReadingsType, ChamberID: "1", Time: time.Now()}
+	bus.Publish(e)
+	bus.Publish(event.Event{Type: event.StepType}) // dropped because the buffers are full
+
+	assert.Equal(t, e, <-ch1)
+	assert.Equal(t, e, <-ch2)
+
+	unsubscribe1()
+	unsubscribe1() // unsubscribing twice is safe
+
+	_, ok := <-ch1
+	assert.False(t, ok)
+
+	bus.Publish(e)
+	assert.Equal(t, e, <-ch2)
+}
@@ -0,0 +1,7 @@
+package export
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
@@ -0,0 +1,221 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/pkg/errors"
+)
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+
+	ReadingEvent    EventType = "reading"
+	StepEvent       EventType = "step"
+	ChillerOnEvent  EventType = "chiller_on"
+	ChillerOffEvent EventType = "chiller_off"
+	HeaterOnEvent   EventType = "heater_on"
+	HeaterOffEvent  EventType = "heater_off"
+
+	ErrInvalidFormat = Error("invalid export format")
+)
+
+// Format is the file format of an export.
+type Format string
+
+// ParseFormat returns the Format with the given name. An empty name is CSV.
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", CSV:
+		return CSV, nil
+	case NDJSON:
+		return NDJSON, nil
+	default:
+		return "", ErrInvalidFormat
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == NDJSON {
+		return "application/x-ndjson"
+	}
+
+	return "text/csv"
+}
+
+// EventType is the kind of entry in a fermentation log.
+type EventType string
+
+// Event is an entry in a fermentation log. Reading events carry the recorded readings, step events mark the start of a
+// fermentation step and the remaining events mark an actuator turning on or off.
+type Event struct {
+	Time                 time.Time `json:"time"`
+	Type                 EventType `json:"type"`
+	BatchID              string    `json:"batchId,omitempty"`
+	BatchNumber          int       `json:"batchNumber,omitempty"`
+	RecipeName           string    `json:"recipeName,omitempty"`
+	Step                 string    `json:"step,omitempty"`
+	BeerTemperature      *float64  `json:"beerTemperature,omitempty"`
+	AuxiliaryTemperature *float64  `json:"auxiliaryTemperature,omitempty"`
+	ExternalTemperature  *float64  `json:"externalTemperature,omitempty"`
+	HydrometerGravity    *float64  `json:"hydrometerGravity,omitempty"`
+	SetPoint             *float64  `json:"setPoint,omitempty"`
+	ChillerDuty          *float64  `json:"chillerDuty,omitempty"`
+	HeaterDuty           *float64  `json:"heaterDuty,omitempty"`
+}
+
+// GetBatch returns the batch of the given chamber to export. If batchID is empty the chamber's current batch is used.
+// Only the ID is known of a batch that is no longer the chamber's current batch. Nil is returned if there is no batch.
+func GetBatch(c *chamber.Chamber, batchID string) *batch.Detail {
+	if c.CurrentBatch != nil && (batchID == "" || batchID == c.CurrentBatch.ID) {
+		return c.CurrentBatch
+	}
+
+	if batchID == "" {
+		return nil
+	}
+
+	return &batch.Detail{ID: batchID}
+}
+
+// Events builds a fermentation log from readings, which must be in chronological order and not downsampled. If b is
+// not nil only the readings of that batch are included. Step and actuator events are inferred from changes between
+// consecutive readings, so their times are only as precise as the interval between readings.
+func Events(readings []*chamber.Reading, b *batch.Detail) []*Event {
+	events := []*Event{}
+
+	var previous *chamber.Reading
+
+	for _, r := range readings {
+		if b != nil && r.BatchID != b.ID {
+			continue
+		}
+
+		newEvent := func(eventType EventType) *Event {
+			e := &Event{Time: r.Time, Type: eventType, BatchID: r.BatchID, Step: r.Step}
+
+			if b != nil {
+				e.BatchNumber = b.Number
+				e.RecipeName = b.Recipe.Name
+			}
+
+			return e
+		}
+
+		if r.Step != "" && (previous == nil || previous.Step != r.Step) {
+			events = append(events, newEvent(StepEvent))
+		}
+
+		if e := actuatorEvent(previous, r, func(r *chamber.Reading) float64 { return r.ChillerDuty },
+			ChillerOnEvent, ChillerOffEvent); e != "" {
+			events = append(events, newEvent(e))
+		}
+
+		if e := actuatorEvent(previous, r, func(r *chamber.Reading) float64 { return r.HeaterDuty },
+			HeaterOnEvent, HeaterOffEvent); e != "" {
+			events = append(events, newEvent(e))
+		}
+
+		e := newEvent(ReadingEvent)
+		e.BeerTemperature = r.BeerTemperature
+		e.AuxiliaryTemperature = r.AuxiliaryTemperature
+		e.ExternalTemperature = r.ExternalTemperature
+		e.HydrometerGravity = r.HydrometerGravity
+		e.SetPoint = r.SetPoint
+		chillerDuty, heaterDuty := r.ChillerDuty, r.HeaterDuty
+		e.ChillerDuty = &chillerDuty
+		e.HeaterDuty = &heaterDuty
+
+		events = append(events, e)
+		previous = r
+	}
+
+	return events
+}
+
+func actuatorEvent(previous, current *chamber.Reading, duty func(*chamber.Reading) float64,
+	onEvent, offEvent EventType,
+) EventType {
+	isOn := duty(current) > 0
+	wasOn := previous != nil && duty(previous) > 0
+
+	switch {
+	case isOn && !wasOn:
+		return onEvent
+	case !isOn && wasOn:
+		return offEvent
+	default:
+		return ""
+	}
+}
+
+// Write writes the events to w in the given format.
+func Write(w io.Writer, format Format, events []*Event) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, events)
+	case NDJSON:
+		return writeNDJSON(w, events)
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+func writeCSV(w io.Writer, events []*Event) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{
+		"time", "type", "batch_id", "batch_number", "recipe_name", "step", "beer_temperature",
+		"auxiliary_temperature", "external_temperature", "hydrometer_gravity", "set_point", "chiller_duty",
+		"heater_duty",
+	}); err != nil {
+		return errors.Wrap(err, "could not write csv header")
+	}
+
+	for _, e := range events {
+		batchNumber := ""
+		if e.BatchNumber != 0 {
+			batchNumber = strconv.Itoa(e.BatchNumber)
+		}
+
+		if err := cw.Write([]string{
+			e.Time.Format(time.RFC3339), string(e.Type), e.BatchID, batchNumber, e.RecipeName, e.Step,
+			formatFloat(e.BeerTemperature), formatFloat(e.AuxiliaryTemperature), formatFloat(e.ExternalTemperature),
+			formatFloat(e.HydrometerGravity), formatFloat(e.SetPoint), formatFloat(e.ChillerDuty),
+			formatFloat(e.HeaterDuty),
+		}); err != nil {
+			return errors.Wrap(err, "could not write csv record")
+		}
+	}
+
+	cw.Flush()
+
+	return errors.Wrap(cw.Error(), "could not flush csv")
+}
+
+func writeNDJSON(w io.Writer, events []*Event) error {
+	encoder := json.NewEncoder(w)
+
+	for _, e := range events {
+		if err := encoder.Encode(e); err != nil {
+			return errors.Wrap(err, "could not encode event")
+		}
+	}
+
+	return nil
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
@@ -0,0 +1,173 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/export"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	batchID      = "tARQUANs3RmXUzYcH5hsOhAD2r6n0p"
+	otherBatchID = "kKgZdzCpWcJ35LiMYVQ8wq0pCQTY5a"
+)
+
+var start = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func createTestReadings() []*chamber.Reading {
+	temperatures := []float64{20, 19.5, 19, 18.5}
+
+	return []*chamber.Reading{
+		{Time: start.Add(-time.Minute), BatchID: otherBatchID, Step: "Secondary"},
+		{Time: start, BatchID: batchID, Step: "Primary", BeerTemperature: &temperatures[0], ChillerDuty: 100},
+		{Time: start.Add(1 * time.Minute), BatchID: batchID, Step: "Primary", BeerTemperature: &temperatures[1]},
+		{
+			Time: start.Add(2 * time.Minute), BatchID: batchID, Step: "Secondary", BeerTemperature: &temperatures[2],
+			HeaterDuty: 100,
+		},
+		{Time: start.Add(3 * time.Minute), BatchID: batchID, Step: "Secondary", BeerTemperature: &temperatures[3]},
+	}
+}
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	b := &batch.Detail{ID: batchID, Number: 12, Recipe: batch.Recipe{Name: "Pale Ale"}}
+
+	events := export.Events(createTestReadings(), b)
+
+	expected := []struct {
+		eventType export.EventType
+		time      time.Time
+		step      string
+	}{
+		{eventType: export.StepEvent, time: start, step: "Primary"},
+		{eventType: export.ChillerOnEvent, time: start, step: "Primary"},
+		{eventType: export.ReadingEvent, time: start, step: "Primary"},
+		{eventType: export.ChillerOffEvent, time: start.Add(1 * time.Minute), step: "Primary"},
+		{eventType: export.ReadingEvent, time: start.Add(1 * time.Minute), step: "Primary"},
+		{eventType: export.StepEvent, time: start.Add(2 * time.Minute), step: "Secondary"},
+		{eventType: export.HeaterOnEvent, time: start.Add(2 * time.Minute), step: "Secondary"},
+		{eventType: export.ReadingEvent, time: start.Add(2 * time.Minute), step: "Secondary"},
+		{eventType: export.HeaterOffEvent, time: start.Add(3 * time.Minute), step: "Secondary"},
+		{eventType: export.ReadingEvent, time: start.Add(3 * time.Minute), step: "Secondary"},
+	}
+
+	assert.Len(t, events, len(expected))
+
+	for i, e := range expected {
+		assert.Equal(t, e.eventType, events[i].Type)
+		assert.Equal(t, e.time, events[i].Time)
+		assert.Equal(t, e.step, events[i].Step)
+		assert.Equal(t, batchID, events[i].BatchID)
+		assert.Equal(t, 12, events[i].BatchNumber)
+		assert.Equal(t, "Pale Ale", events[i].RecipeName)
+	}
+
+	assert.Equal(t, 20.0, *events[2].BeerTemperature)
+	assert.Equal(t, 100.0, *events[2].ChillerDuty)
+	assert.Nil(t, events[1].BeerTemperature)
+
+	all := export.Events(createTestReadings(), nil)
+	assert.Len(t, all, len(expected)+2) // the other batch's step and reading
+	assert.Empty(t, all[0].RecipeName)
+}
+
+func TestGetBatch(t *testing.T) {
+	t.Parallel()
+
+	current := &batch.Detail{ID: batchID}
+
+	assert.Equal(t, current, export.GetBatch(&chamber.Chamber{CurrentBatch: current}, ""))
+	assert.Equal(t, current, export.GetBatch(&chamber.Chamber{CurrentBatch: current}, batchID))
+	assert.Equal(t, &batch.Detail{ID: otherBatchID}, export.GetBatch(&chamber.Chamber{CurrentBatch: current},
+		otherBatchID))
+	assert.Equal(t, &batch.Detail{ID: otherBatchID}, export.GetBatch(&chamber.Chamber{}, otherBatchID))
+	assert.Nil(t, export.GetBatch(&chamber.Chamber{}, ""))
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		expected export.Format
+		err      error
+	}{
+		{name: "", expected: export.CSV},
+		{name: "csv", expected: export.CSV},
+		{name: "ndjson", expected: export.NDJSON},
+		{name: "xml", err: export.ErrInvalidFormat},
+	}
+
+	for _, tc := range tests {
+		format, err := export.ParseFormat(tc.name)
+		assert.Equal(t, tc.expected, format)
+		assert.ErrorIs(t, err, tc.err)
+	}
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	t.Run("writeCSV", writeCSV)
+	t.Run("writeNDJSON", writeNDJSON)
+	t.Run("writeInvalidFormat", writeInvalidFormat)
+}
+
+func writeCSV(t *testing.T) {
+	t.Parallel()
+
+	b := &batch.Detail{ID: batchID, Number: 12, Recipe: batch.Recipe{Name: "Pale Ale"}}
+	events := export.Events(createTestReadings()[:2], b)
+
+	var buf bytes.Buffer
+
+	err := export.Write(&buf, export.CSV, events)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		"time,type,batch_id,batch_number,recipe_name,step,beer_temperature,auxiliary_temperature," +
+			"external_temperature,hydrometer_gravity,set_point,chiller_duty,heater_duty",
+		"2023-05-01T12:00:00Z,step," + batchID + ",12,Pale Ale,Primary,,,,,,,",
+		"2023-05-01T12:00:00Z,chiller_on," + batchID + ",12,Pale Ale,Primary,,,,,,,",
+		"2023-05-01T12:00:00Z,reading," + batchID + ",12,Pale Ale,Primary,20,,,,,100,0",
+	}, lines)
+}
+
+func writeNDJSON(t *testing.T) {
+	t.Parallel()
+
+	events := export.Events(createTestReadings(), nil)
+
+	var buf bytes.Buffer
+
+	err := export.Write(&buf, export.NDJSON, events)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, len(events))
+
+	for i, line := range lines {
+		var e export.Event
+
+		err := json.Unmarshal([]byte(line), &e)
+		assert.NoError(t, err)
+		assert.Equal(t, events[i].Type, e.Type)
+		assert.True(t, events[i].Time.Equal(e.Time))
+	}
+}
+
+func writeInvalidFormat(t *testing.T) {
+	t.Parallel()
+
+	err := export.Write(&bytes.Buffer{}, "xml", nil)
+	assert.ErrorIs(t, err, export.ErrInvalidFormat)
+}
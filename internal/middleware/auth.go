@@ -4,38 +4,66 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/benjaminbartels/zymurgauge/internal/auth"
 	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/benjaminbartels/zymurgauge/internal/settings"
 	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
 )
 
-const partsLength = 2
+const (
+	partsLength = 2
+	// lastUsedTimeResolution is how much the recorded last used time of an API token may lag behind, so that the
+	// database is not written to on every request.
+	lastUsedTimeResolution = time.Minute
+)
 
-func Authorize(secret string) web.Middleware {
-	m := func(handler web.Handler) web.Handler {
-		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, p httprouter.Params) error {
-			authHeader := r.Header["Authorization"]
+type authorizeOptions struct {
+	allowQueryToken bool
+}
 
-			if len(authHeader) != 1 {
-				return web.NewRequestError("authorization header invalid", http.StatusBadRequest)
-			}
+type AuthorizeOptionsFunc func(*authorizeOptions)
+
+// AllowQueryToken lets clients that cannot set headers, such as browser EventSources, pass the token in the
+// access_token query parameter instead of the Authorization header. It should only be used on the routes that need it,
+// as URLs end up in logs and browser history.
+func AllowQueryToken() AuthorizeOptionsFunc {
+	return func(o *authorizeOptions) {
+		o.allowQueryToken = true
+	}
+}
+
+// Authorize provides a Middleware that only allows requests with a valid JWT or API token whose role allows the given
+// role. JWTs are checked with the current auth secret from the settings, so that rotating it invalidates them at once,
+// and must not be in the denylist. The claims of the token are added to the context of the request.
+func Authorize(settingsRepo settings.Repo, apiTokenRepo auth.APITokenRepo, denylist auth.Denylist,
+	role auth.Role, options ...AuthorizeOptionsFunc,
+) web.Middleware {
+	opts := &authorizeOptions{}
 
-			parts := strings.Split(authHeader[0], "Bearer")
-			if len(parts) != partsLength {
-				return web.NewRequestError("authorization header invalid", http.StatusBadRequest)
+	for _, option := range options {
+		option(opts)
+	}
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, p httprouter.Params) error {
+			token, err := getToken(r, opts.allowQueryToken)
+			if err != nil {
+				return err
 			}
 
-			token := strings.TrimSpace(parts[1])
-			if len(token) < 1 {
-				return web.NewRequestError("authorization token invalid", http.StatusBadRequest)
+			claims, err := getClaims(settingsRepo, apiTokenRepo, denylist, token)
+			if err != nil {
+				return err
 			}
 
-			if _, err := auth.IsAuthorized(secret, token); err != nil {
-				return web.NewRequestError("access denied", http.StatusUnauthorized)
+			if !claims.GetRole().Allows(role) {
+				return web.NewRequestError("insufficient role", http.StatusForbidden)
 			}
 
-			return handler(ctx, w, r, p)
+			return handler(auth.NewContext(ctx, claims), w, r, p)
 		}
 
 		return h
@@ -43,3 +71,90 @@ func Authorize(secret string) web.Middleware {
 
 	return m
 }
+
+// getClaims returns the claims of the given JWT or API token.
+func getClaims(settingsRepo settings.Repo, apiTokenRepo auth.APITokenRepo, denylist auth.Denylist,
+	token string,
+) (*auth.Claims, error) {
+	if !auth.IsAPIToken(token) {
+		s, err := settingsRepo.Get()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get settings")
+		}
+
+		if s == nil {
+			return nil, errors.New("settings not found")
+		}
+
+		claims, err := auth.IsAuthorized(s.AuthSecret, token, denylist)
+		if err != nil {
+			return nil, web.NewRequestError("access denied", http.StatusUnauthorized)
+		}
+
+		return claims, nil
+	}
+
+	claims, err := checkAPIToken(apiTokenRepo, token)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		return nil, web.NewRequestError("access denied", http.StatusUnauthorized)
+	}
+
+	return claims, err
+}
+
+// checkAPIToken returns the claims of the given API token and records that it was used, unless that was already
+// recorded less than lastUsedTimeResolution ago.
+func checkAPIToken(apiTokenRepo auth.APITokenRepo, token string) (*auth.Claims, error) {
+	id, err := auth.ParseAPITokenID(token)
+	if err != nil {
+		return nil, err
+	}
+
+	apiToken, err := apiTokenRepo.Get(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get api token from repository")
+	}
+
+	if apiToken == nil || !apiToken.Check(token) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	now := time.Now()
+	if apiToken.LastUsedTime == nil || now.Sub(*apiToken.LastUsedTime) >= lastUsedTimeResolution {
+		if err := apiTokenRepo.SetLastUsedTime(id, now); err != nil {
+			return nil, errors.Wrap(err, "could not set last used time of api token")
+		}
+	}
+
+	return &auth.Claims{
+		Username:   apiToken.CreatedBy,
+		Role:       apiToken.Scope.Role(),
+		APITokenID: apiToken.ID,
+	}, nil
+}
+
+// getToken returns the bearer token from the Authorization header or, if allowQuery is true and there is no header,
+// from the access_token query parameter.
+func getToken(r *http.Request, allowQuery bool) (string, error) {
+	authHeader := r.Header["Authorization"]
+
+	if len(authHeader) == 0 && allowQuery && r.URL.Query().Has("access_token") {
+		authHeader = []string{"Bearer " + r.URL.Query().Get("access_token")}
+	}
+
+	if len(authHeader) != 1 {
+		return "", web.NewRequestError("authorization header invalid", http.StatusBadRequest)
+	}
+
+	parts := strings.Split(authHeader[0], "Bearer")
+	if len(parts) != partsLength {
+		return "", web.NewRequestError("authorization header invalid", http.StatusBadRequest)
+	}
+
+	token := strings.TrimSpace(parts[1])
+	if len(token) < 1 {
+		return "", web.NewRequestError("authorization token invalid", http.StatusBadRequest)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/middleware"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthorizeAPITokenLastUsedTime(t *testing.T) {
+	t.Parallel()
+
+	recently, longAgo := time.Now().Add(-10*time.Second), time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		lastUsedTime *time.Time
+		expected     bool
+	}{
+		{name: "neverUsed", expected: true},
+		{name: "usedLongAgo", lastUsedTime: &longAgo, expected: true},
+		{name: "usedRecently", lastUsedTime: &recently, expected: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			apiToken, token, err := auth.NewAPIToken("script", auth.ReadOnlyScope, "username")
+			assert.NoError(t, err)
+
+			apiToken.LastUsedTime = tc.lastUsedTime
+
+			apiTokenMock := &mocks.APITokenRepo{}
+			apiTokenMock.On("Get", apiToken.ID).Return(apiToken, nil)
+			apiTokenMock.On("SetLastUsedTime", apiToken.ID, mock.Anything).Return(nil)
+
+			handler := middleware.Authorize(nil, apiTokenMock, nil, auth.ViewerRole)(
+				func(context.Context, http.ResponseWriter, *http.Request, httprouter.Params) error {
+					return nil
+				})
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/chambers", nil)
+			r.Header.Add("Authorization", "Bearer "+token)
+
+			err = handler(context.Background(), httptest.NewRecorder(), r, httprouter.Params{})
+			assert.NoError(t, err)
+
+			if tc.expected {
+				apiTokenMock.AssertCalled(t, "SetLastUsedTime", apiToken.ID, mock.Anything)
+			} else {
+				apiTokenMock.AssertNotCalled(t, "SetLastUsedTime", apiToken.ID, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// Metrics provides a Middleware that reports the latency of requests in milliseconds by method, route and status.
+func Metrics(m metrics.Metrics) web.Middleware {
+	mw := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, p httprouter.Params) error {
+			v, err := web.GetContextValues(ctx)
+			if err != nil {
+				return errors.Wrap(web.NewShutdownError(err.Error()), "could not get context values")
+			}
+
+			err = handler(ctx, w, r, p)
+
+			m.Timing(metrics.Bucket("zymurgauge.http_request_duration",
+				metrics.Tag{Key: "method", Value: r.Method},
+				metrics.Tag{Key: "route", Value: v.Route},
+				metrics.Tag{Key: "status", Value: strconv.Itoa(v.StatusCode)}),
+				time.Since(v.Now).Milliseconds())
+
+			return err
+		}
+
+		return h
+	}
+
+	return mw
+}
@@ -0,0 +1,538 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	setPointCommand = "set_point"
+	modeCommand     = "mode"
+	startCommand    = "start"
+	stopCommand     = "stop"
+
+	onlinePayload  = "online"
+	offlinePayload = "offline"
+
+	qos                  = 1
+	defaultSyncInterval  = 1 * time.Minute
+	publishTimeout       = 10 * time.Second
+	connectRetryInterval = 10 * time.Second
+	disconnectWait       = 250
+	floatBitSize         = 64
+	auditDetail          = "via MQTT"
+)
+
+// Bridge publishes the state of chambers to an MQTT broker and routes the set point, mode, start and stop commands it
+// receives to the chambers. If enabled, Home Assistant discovery config messages are published so that chambers
+// appear as climate entities. Commands that are executed are recorded in the audit log.
+//
+// Topics are relative to the topic prefix:
+//
+//	status                      online or offline
+//	<chamber id>/state          State of the chamber as JSON
+//	<chamber id>/<command>/set  set_point, mode (off, auto or heat_cool), start (optional step) or stop
+type Bridge struct {
+	controller chamber.Controller
+	subscriber event.Subscriber
+	logger     *logrus.Logger
+	recorder   *audit.Recorder
+	clock      clock.Clock
+	interval   time.Duration
+	settings   Settings
+	client     paho.Client
+	discovered map[string]bool
+	// retained holds the chambers whose state is retained by the broker, so that it can be removed along with their
+	// discovery config messages when they are deleted.
+	retained map[string]bool
+	mutex    sync.Mutex
+}
+
+// NewBridge creates a new Bridge. It does not connect to a broker until it is configured.
+func NewBridge(controller chamber.Controller, subscriber event.Subscriber, logger *logrus.Logger,
+	options ...OptionsFunc,
+) *Bridge {
+	b := &Bridge{
+		controller: controller,
+		subscriber: subscriber,
+		logger:     logger,
+		clock:      clock.NewRealClock(),
+		interval:   defaultSyncInterval,
+		discovered: make(map[string]bool),
+		retained:   make(map[string]bool),
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+
+	return b
+}
+
+type OptionsFunc func(*Bridge)
+
+// SyncInterval sets how often the state of every chamber is published and deleted chambers are removed from the
+// broker.
+func SyncInterval(interval time.Duration) OptionsFunc {
+	return func(b *Bridge) {
+		b.interval = interval
+	}
+}
+
+// SetClock sets the clock used to time the syncs.
+func SetClock(clock clock.Clock) OptionsFunc {
+	return func(b *Bridge) {
+		b.clock = clock
+	}
+}
+
+// SetAuditRecorder sets the Recorder that executed commands are recorded with.
+func SetAuditRecorder(recorder *audit.Recorder) OptionsFunc {
+	return func(b *Bridge) {
+		b.recorder = recorder
+	}
+}
+
+// Configure connects to the broker of the given settings, replacing the previous connection if the settings have
+// changed. The connection is retried in the background until it succeeds.
+func (b *Bridge) Configure(s Settings) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if s == b.settings {
+		return
+	}
+
+	b.disconnect()
+
+	b.settings = s
+	b.discovered = make(map[string]bool)
+	b.retained = make(map[string]bool)
+
+	if s.BrokerURL == "" {
+		return
+	}
+
+	options := paho.NewClientOptions().
+		AddBroker(s.BrokerURL).
+		SetClientID(fmt.Sprintf("zymurgauge-%d", time.Now().UnixNano())).
+		SetUsername(s.Username).
+		SetPassword(s.Password).
+		SetWill(b.settings.availabilityTopic(), offlinePayload, qos, true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(connectRetryInterval).
+		SetOrderMatters(false).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			b.logger.WithError(err).Warnf("Lost connection to MQTT broker %s.", s.BrokerURL)
+		})
+
+	b.client = paho.NewClient(options)
+	b.client.Connect()
+}
+
+// Run publishes the state of chambers when their readings, actuators or steps change until the context is canceled,
+// at which point it disconnects from the broker.
+func (b *Bridge) Run(ctx context.Context) {
+	events, unsubscribe := b.subscriber.Subscribe()
+	defer unsubscribe()
+
+	timer := b.clock.NewTimer(b.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if e.ChamberID != "" && e.Type != event.AlertType {
+				b.publishChamber(e.ChamberID)
+			}
+		case <-timer.C:
+			b.sync()
+			timer.Reset(b.interval)
+		case <-ctx.Done():
+			b.Configure(Settings{})
+
+			return
+		}
+	}
+}
+
+func (b *Bridge) onConnect(client paho.Client) {
+	b.mutex.Lock()
+
+	if client != b.client {
+		b.mutex.Unlock()
+
+		return
+	}
+
+	b.discovered = make(map[string]bool)
+	settings := b.settings
+
+	b.mutex.Unlock()
+
+	b.logger.Infof("Connected to MQTT broker %s.", settings.BrokerURL)
+
+	if err := wait(client.Subscribe(settings.topicPrefix()+"/+/+/set", qos, b.handleCommand)); err != nil {
+		b.logger.WithError(err).Error("Could not subscribe to MQTT command topics.")
+	}
+
+	// the retained states of chambers that were deleted while the bridge was not connected are removed when they are
+	// received
+	if err := wait(client.Subscribe(settings.topicPrefix()+"/+/state", qos, b.handleState)); err != nil {
+		b.logger.WithError(err).Error("Could not subscribe to MQTT state topics.")
+	}
+
+	if settings.HomeAssistant {
+		// Home Assistant announces that it is online when it starts, after which discovery config messages must be
+		// published again
+		token := client.Subscribe(settings.discoveryPrefix()+"/status", qos, b.handleHomeAssistantStatus)
+		if err := wait(token); err != nil {
+			b.logger.WithError(err).Error("Could not subscribe to Home Assistant status topic.")
+		}
+	}
+
+	if err := wait(client.Publish(settings.availabilityTopic(), qos, true, onlinePayload)); err != nil {
+		b.logger.WithError(err).Error("Could not publish MQTT availability.")
+	}
+
+	b.sync()
+}
+
+func (b *Bridge) handleHomeAssistantStatus(_ paho.Client, msg paho.Message) {
+	if string(msg.Payload()) != onlinePayload {
+		return
+	}
+
+	b.mutex.Lock()
+	b.discovered = make(map[string]bool)
+	b.mutex.Unlock()
+
+	b.sync()
+}
+
+func (b *Bridge) handleState(_ paho.Client, msg paho.Message) {
+	if len(msg.Payload()) == 0 {
+		return
+	}
+
+	b.mutex.Lock()
+	prefix := b.settings.topicPrefix() + "/"
+	b.mutex.Unlock()
+
+	chamberID := strings.TrimSuffix(strings.TrimPrefix(msg.Topic(), prefix), "/state")
+
+	c, err := b.controller.Get(chamberID)
+	if err != nil {
+		b.logger.WithError(err).Errorf("Could not get chamber %s.", chamberID)
+
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected() {
+		return
+	}
+
+	if c != nil {
+		b.retained[chamberID] = true
+
+		return
+	}
+
+	b.remove(chamberID)
+}
+
+func (b *Bridge) handleCommand(_ paho.Client, msg paho.Message) {
+	b.mutex.Lock()
+	prefix := b.settings.topicPrefix() + "/"
+	b.mutex.Unlock()
+
+	parts := strings.Split(strings.TrimPrefix(msg.Topic(), prefix), "/")
+	if len(parts) != 3 { //nolint:gomnd // <chamber id>/<command>/set
+		return
+	}
+
+	chamberID, command := parts[0], parts[1]
+
+	if err := b.execute(chamberID, command, strings.TrimSpace(string(msg.Payload()))); err != nil {
+		b.logger.WithError(err).Errorf("Could not execute MQTT %s command for chamber %s.", command, chamberID)
+	}
+
+	b.publishChamber(chamberID)
+}
+
+func (b *Bridge) execute(chamberID, command, payload string) error {
+	switch command {
+	case setPointCommand:
+		setPoint, err := strconv.ParseFloat(payload, floatBitSize)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse set point %s", payload)
+		}
+
+		return b.startManual(chamberID, setPoint)
+	case modeCommand:
+		return b.setMode(chamberID, payload)
+	case startCommand:
+		return b.start(chamberID, payload)
+	case stopCommand:
+		return b.stop(chamberID)
+	default:
+		return ErrUnknownCommand
+	}
+}
+
+func (b *Bridge) setMode(chamberID, mode string) error {
+	switch mode {
+	case OffMode:
+		return b.stop(chamberID)
+	case AutoMode:
+		return b.start(chamberID, "")
+	case HeatCoolMode:
+		c, err := b.getChamber(chamberID)
+		if err != nil {
+			return err
+		}
+
+		state, err := getState(c)
+		if err != nil {
+			return err
+		}
+
+		if state.SetPoint == nil {
+			return ErrNoSetPoint
+		}
+
+		return b.startManual(chamberID, *state.SetPoint)
+	default:
+		return ErrUnknownMode
+	}
+}
+
+// start starts the given fermentation step or, if step is empty, the first step of the chamber's current batch.
+func (b *Bridge) start(chamberID, step string) error {
+	if step == "" {
+		c, err := b.getChamber(chamberID)
+		if err != nil {
+			return err
+		}
+
+		if c.CurrentBatch == nil {
+			return chamber.ErrNoCurrentBatch
+		}
+
+		steps := c.CurrentBatch.Recipe.Fermentation.Steps
+		if len(steps) == 0 {
+			return ErrNoSteps
+		}
+
+		step = steps[0].Name
+	}
+
+	if err := b.controller.StartFermentation(chamberID, step); err != nil {
+		return errors.Wrap(err, "could not start fermentation")
+	}
+
+	b.record(audit.FermentationStartedAction, chamberID, fmt.Sprintf("step %s", step))
+
+	return nil
+}
+
+func (b *Bridge) startManual(chamberID string, setPoint float64) error {
+	if err := b.controller.StartManual(chamberID, setPoint, 0, false); err != nil {
+		return errors.Wrap(err, "could not start manual")
+	}
+
+	b.record(audit.FermentationStartedAction, chamberID, fmt.Sprintf("set point %g", setPoint))
+
+	return nil
+}
+
+func (b *Bridge) stop(chamberID string) error {
+	if err := b.controller.StopFermentation(chamberID); err != nil {
+		return errors.Wrap(err, "could not stop fermentation")
+	}
+
+	b.record(audit.FermentationStoppedAction, chamberID, "")
+
+	return nil
+}
+
+// record adds an entry for an action taken on the given chamber through MQTT to the audit log. There is no user or
+// source IP as commands can be published by any client of the broker.
+func (b *Bridge) record(action audit.Action, chamberID, detail string) {
+	b.recorder.Record(&audit.Entry{
+		Action: action,
+		Target: chamberID,
+		Detail: strings.TrimSpace(detail + " " + auditDetail),
+	})
+}
+
+func (b *Bridge) getChamber(chamberID string) (*chamber.Chamber, error) {
+	c, err := b.controller.Get(chamberID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get chamber %s", chamberID)
+	}
+
+	if c == nil {
+		return nil, chamber.ErrNotFound
+	}
+
+	return c, nil
+}
+
+// sync publishes the state of every chamber and removes deleted chambers from the broker.
+func (b *Bridge) sync() {
+	chambers, err := b.controller.GetAll()
+	if err != nil {
+		b.logger.WithError(err).Error("Could not get chambers to publish to MQTT.")
+
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected() {
+		return
+	}
+
+	ids := make(map[string]bool)
+
+	for _, c := range chambers {
+		ids[c.ID] = true
+
+		b.publish(c)
+	}
+
+	for id := range b.retained {
+		if !ids[id] {
+			b.remove(id)
+		}
+	}
+}
+
+func (b *Bridge) publishChamber(chamberID string) {
+	c, err := b.controller.Get(chamberID)
+	if err != nil || c == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected() {
+		return
+	}
+
+	b.publish(c)
+}
+
+// publish publishes the state of the given chamber, preceded by its discovery config messages if Home Assistant has
+// not discovered it yet. The caller must hold mutex.
+func (b *Bridge) publish(c *chamber.Chamber) {
+	if b.settings.HomeAssistant && !b.discovered[c.ID] {
+		b.discovered[c.ID] = true
+
+		for topic, config := range b.discoveryConfigs(c) {
+			if err := b.publishJSON(topic, config); err != nil {
+				b.logger.WithError(err).Errorf("Could not publish Home Assistant discovery config %s.", topic)
+				b.discovered[c.ID] = false
+			}
+		}
+	}
+
+	state, err := getState(c)
+	if err != nil {
+		b.logger.WithError(err).Errorf("Could not get state of chamber %s.", c.ID)
+
+		return
+	}
+
+	if err := b.publishJSON(b.stateTopic(c.ID), state); err != nil {
+		b.logger.WithError(err).Errorf("Could not publish state of chamber %s to MQTT.", c.ID)
+
+		return
+	}
+
+	b.retained[c.ID] = true
+}
+
+// remove removes the retained state and discovery config messages of the given deleted chamber. The caller must hold
+// mutex.
+func (b *Bridge) remove(chamberID string) {
+	for _, topic := range append(b.discoveryTopics(chamberID), b.stateTopic(chamberID)) {
+		if err := wait(b.client.Publish(topic, qos, true, []byte{})); err != nil {
+			b.logger.WithError(err).Errorf("Could not remove %s from MQTT.", topic)
+		}
+	}
+
+	delete(b.discovered, chamberID)
+	delete(b.retained, chamberID)
+}
+
+func (b *Bridge) publishJSON(topic string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal %s", topic)
+	}
+
+	return wait(b.client.Publish(topic, qos, true, data))
+}
+
+// disconnect announces that the bridge is offline and disconnects from the broker. The caller must hold mutex.
+func (b *Bridge) disconnect() {
+	if b.client == nil {
+		return
+	}
+
+	if b.isConnected() {
+		if err := wait(b.client.Publish(b.settings.availabilityTopic(), qos, true, offlinePayload)); err != nil {
+			b.logger.WithError(err).Error("Could not publish MQTT availability.")
+		}
+	}
+
+	b.client.Disconnect(disconnectWait)
+	b.client = nil
+}
+
+// isConnected reports whether messages can be published. The caller must hold mutex.
+func (b *Bridge) isConnected() bool {
+	return b.client != nil && b.client.IsConnectionOpen()
+}
+
+func (b *Bridge) stateTopic(chamberID string) string {
+	return fmt.Sprintf("%s/%s/state", b.settings.topicPrefix(), chamberID)
+}
+
+func (b *Bridge) commandTopic(chamberID, command string) string {
+	return fmt.Sprintf("%s/%s/%s/set", b.settings.topicPrefix(), chamberID, command)
+}
+
+func wait(token paho.Token) error {
+	if !token.WaitTimeout(publishTimeout) {
+		return ErrTimeout
+	}
+
+	return errors.Wrap(token.Error(), "mqtt operation failed")
+}
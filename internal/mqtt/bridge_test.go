@@ -0,0 +1,344 @@
+package mqtt_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/audit"
+	"github.com/benjaminbartels/zymurgauge/internal/batch"
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/benjaminbartels/zymurgauge/internal/event"
+	"github.com/benjaminbartels/zymurgauge/internal/mqtt"
+	"github.com/benjaminbartels/zymurgauge/internal/test/fakes"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	chamberID    = "96f58a65-03c0-49f3-83ed-1ceb2e6e3b0b"
+	timeout      = 5 * time.Second
+	climateTopic = "homeassistant/climate/zymurgauge/" + chamberID + "/config"
+	stateTopic   = "zymurgauge/" + chamberID + "/state"
+)
+
+type message struct {
+	topic   string
+	payload []byte
+}
+
+type bridgeTest struct {
+	broker     *fakes.MQTTBroker
+	stop       context.CancelFunc
+	controller *mocks.Controller
+	bus        *event.Bus
+	subscriber paho.Client
+	messages   chan message
+}
+
+func createTestChamber() *chamber.Chamber {
+	beerTemperature, gravity, setPoint := 19.5, 1.048, 20.0
+
+	return &chamber.Chamber{
+		ID:   chamberID,
+		Name: "Chamber 1",
+		CurrentBatch: &batch.Detail{
+			Recipe: batch.Recipe{
+				Name: "Pale Ale",
+				Fermentation: batch.Fermentation{
+					Steps: []batch.FermentationStep{{Name: "Primary", Temperature: 20}},
+				},
+			},
+		},
+		CurrentFermentationStep: "Primary",
+		Readings: &chamber.Readings{
+			BeerTemperature:   &beerTemperature,
+			HydrometerGravity: &gravity,
+			SetPoint:          &setPoint,
+		},
+	}
+}
+
+func setupBridgeTest(t *testing.T, controller *mocks.Controller, options ...mqtt.OptionsFunc) *bridgeTest {
+	t.Helper()
+
+	broker, err := fakes.NewMQTTBroker()
+	assert.NoError(t, err)
+	t.Cleanup(broker.Close)
+
+	test := &bridgeTest{
+		broker:     broker,
+		controller: controller,
+		bus:        event.NewBus(),
+		messages:   make(chan message, 100),
+	}
+
+	test.subscriber = paho.NewClient(paho.NewClientOptions().AddBroker(broker.URL).SetClientID("subscriber"))
+	token := test.subscriber.Connect()
+	assert.True(t, token.WaitTimeout(timeout))
+	assert.NoError(t, token.Error())
+	t.Cleanup(func() { test.subscriber.Disconnect(0) })
+
+	token = test.subscriber.Subscribe("#", 1, func(_ paho.Client, m paho.Message) {
+		test.messages <- message{topic: m.Topic(), payload: m.Payload()}
+	})
+	assert.True(t, token.WaitTimeout(timeout))
+	assert.NoError(t, token.Error())
+
+	test.stop = test.startBridge(t, controller, mqtt.Settings{BrokerURL: broker.URL, HomeAssistant: true}, options...)
+
+	return test
+}
+
+// startBridge runs a bridge with the given settings until the returned function is called.
+func (b *bridgeTest) startBridge(t *testing.T, controller *mocks.Controller, settings mqtt.Settings,
+	options ...mqtt.OptionsFunc,
+) context.CancelFunc {
+	t.Helper()
+
+	l, _ := logtest.NewNullLogger()
+
+	bridge := mqtt.NewBridge(controller, b.bus, l, options...)
+	bridge.Configure(settings)
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	done := make(chan struct{})
+
+	go func() {
+		bridge.Run(ctx)
+		close(done)
+	}()
+
+	return func() {
+		stop()
+		<-done
+	}
+}
+
+// waitFor returns the payload of the next message published to the given topic.
+func (b *bridgeTest) waitFor(t *testing.T, topic string) []byte {
+	t.Helper()
+
+	for {
+		select {
+		case m := <-b.messages:
+			if m.topic == topic {
+				return m.payload
+			}
+		case <-time.After(timeout):
+			assert.FailNow(t, "no message published to "+topic)
+		}
+	}
+}
+
+// waitForAll returns the payloads of the next messages published to the given topics, in any order.
+func (b *bridgeTest) waitForAll(t *testing.T, topics ...string) map[string][]byte {
+	t.Helper()
+
+	payloads := make(map[string][]byte)
+
+	for len(payloads) < len(topics) {
+		select {
+		case m := <-b.messages:
+			for _, topic := range topics {
+				if m.topic == topic {
+					payloads[topic] = m.payload
+				}
+			}
+		case <-time.After(timeout):
+			assert.FailNow(t, "no message published to all of", "%v", topics)
+		}
+	}
+
+	return payloads
+}
+
+func (b *bridgeTest) publish(t *testing.T, topic, payload string) {
+	t.Helper()
+
+	token := b.subscriber.Publish(topic, 1, false, payload)
+	assert.True(t, token.WaitTimeout(timeout))
+	assert.NoError(t, token.Error())
+}
+
+func createControllerMock(c *chamber.Chamber) *mocks.Controller {
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("GetAll").Return([]*chamber.Chamber{c}, nil)
+	controllerMock.On("Get", chamberID).Return(c, nil)
+
+	return controllerMock
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestBridge(t *testing.T) {
+	t.Parallel()
+	t.Run("publishDiscovery", publishDiscovery)
+	t.Run("publishState", publishState)
+	t.Run("publishStateOnEvent", publishStateOnEvent)
+	t.Run("removeDeletedChamber", removeDeletedChamber)
+	t.Run("removeChamberDeletedWhileDisconnected", removeChamberDeletedWhileDisconnected)
+	t.Run("executeCommands", executeCommands)
+}
+
+func publishDiscovery(t *testing.T) {
+	t.Parallel()
+
+	test := setupBridgeTest(t, createControllerMock(createTestChamber()))
+
+	chillerTopic := "homeassistant/binary_sensor/zymurgauge/" + chamberID + "_chiller/config"
+	payloads := test.waitForAll(t, climateTopic, chillerTopic)
+
+	var config map[string]interface{}
+
+	assert.NoError(t, json.Unmarshal(payloads[climateTopic], &config))
+	assert.Equal(t, "zymurgauge_"+chamberID, config["unique_id"])
+	assert.Equal(t, "zymurgauge/status", config["availability_topic"])
+	assert.Equal(t, "zymurgauge/"+chamberID+"/set_point/set", config["temperature_command_topic"])
+	assert.Equal(t, "zymurgauge/"+chamberID+"/mode/set", config["mode_command_topic"])
+	assert.Equal(t, stateTopic, config["current_temperature_topic"])
+	assert.Equal(t, "Chamber 1", config["device"].(map[string]interface{})["name"])
+
+	assert.NoError(t, json.Unmarshal(payloads[chillerTopic], &config))
+	assert.Equal(t, "{{ value_json.chiller }}", config["value_template"])
+	assert.Equal(t, "running", config["device_class"])
+}
+
+func publishState(t *testing.T) {
+	t.Parallel()
+
+	test := setupBridgeTest(t, createControllerMock(createTestChamber()))
+
+	var state mqtt.State
+
+	assert.NoError(t, json.Unmarshal(test.waitFor(t, stateTopic), &state))
+	assert.Equal(t, mqtt.AutoMode, state.Mode)
+	assert.Equal(t, "idle", state.Action)
+	assert.Equal(t, "off", state.Chiller)
+	assert.Equal(t, "off", state.Heater)
+	assert.Equal(t, "Primary", state.Step)
+	assert.Equal(t, 19.5, *state.BeerTemperature)
+	assert.Equal(t, 1.048, *state.Gravity)
+	assert.Equal(t, 20.0, *state.SetPoint)
+	assert.Nil(t, state.ExternalTemperature)
+}
+
+func publishStateOnEvent(t *testing.T) {
+	t.Parallel()
+
+	test := setupBridgeTest(t, createControllerMock(createTestChamber()), mqtt.SyncInterval(time.Hour))
+
+	test.waitFor(t, stateTopic)
+
+	test.bus.Publish(event.Event{Type: event.ReadingsType, ChamberID: chamberID})
+
+	test.waitFor(t, stateTopic)
+}
+
+func removeDeletedChamber(t *testing.T) {
+	t.Parallel()
+
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("GetAll").Return([]*chamber.Chamber{createTestChamber()}, nil).Once()
+	controllerMock.On("GetAll").Return([]*chamber.Chamber{}, nil)
+	controllerMock.On("Get", chamberID).Return(nil, nil)
+
+	test := setupBridgeTest(t, controllerMock, mqtt.SyncInterval(time.Minute), mqtt.SetClock(fakes.NewDilatedClock(600)))
+
+	assert.NotEmpty(t, test.waitFor(t, climateTopic))
+	assert.Empty(t, test.waitFor(t, climateTopic))
+}
+
+func removeChamberDeletedWhileDisconnected(t *testing.T) {
+	t.Parallel()
+
+	test := setupBridgeTest(t, createControllerMock(createTestChamber()), mqtt.SyncInterval(time.Hour))
+
+	assert.NotEmpty(t, test.waitFor(t, stateTopic))
+
+	test.stop()
+
+	// the chamber is deleted while no bridge is connected and Home Assistant is disabled when it connects again
+	controllerMock := &mocks.Controller{}
+	controllerMock.On("GetAll").Return([]*chamber.Chamber{}, nil)
+	controllerMock.On("Get", chamberID).Return(nil, nil)
+
+	test.startBridge(t, controllerMock, mqtt.Settings{BrokerURL: test.broker.URL}, mqtt.SyncInterval(time.Hour))
+
+	payloads := test.waitForAll(t, stateTopic, climateTopic)
+	assert.Empty(t, payloads[stateTopic])
+	assert.Empty(t, payloads[climateTopic])
+}
+
+func executeCommands(t *testing.T) {
+	t.Parallel()
+
+	c := createTestChamber()
+	calls := make(chan string, 10)
+	record := func(name string) func(mock.Arguments) {
+		return func(mock.Arguments) { calls <- name }
+	}
+
+	controllerMock := createControllerMock(c)
+	controllerMock.On("StartManual", chamberID, 18.5, time.Duration(0), false).Return(nil).
+		Run(record("setPoint"))
+	controllerMock.On("StartManual", chamberID, 20.0, time.Duration(0), false).Return(nil).
+		Run(record("heatCool"))
+	controllerMock.On("StartFermentation", chamberID, "Primary").Return(nil).Run(record("start"))
+	controllerMock.On("StopFermentation", chamberID).Return(nil).Run(record("stop"))
+
+	entries := make(chan *audit.Entry, 10)
+
+	auditMock := &mocks.AuditRepo{}
+	auditMock.On("Save", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		entries <- args.Get(0).(*audit.Entry)
+	})
+
+	l, _ := logtest.NewNullLogger()
+
+	test := setupBridgeTest(t, controllerMock, mqtt.SetAuditRecorder(audit.NewRecorder(auditMock, l)))
+	test.waitFor(t, stateTopic)
+
+	started, stopped := audit.FermentationStartedAction, audit.FermentationStoppedAction
+
+	commands := []struct {
+		topic   string
+		payload string
+		call    string
+		action  audit.Action
+		detail  string
+	}{
+		{topic: "set_point", payload: "18.5", call: "setPoint", action: started, detail: "set point 18.5 via MQTT"},
+		{topic: "mode", payload: "heat_cool", call: "heatCool", action: started, detail: "set point 20 via MQTT"},
+		{topic: "mode", payload: "auto", call: "start", action: started, detail: "step Primary via MQTT"},
+		{topic: "mode", payload: "off", call: "stop", action: stopped, detail: "via MQTT"},
+		{topic: "start", payload: "", call: "start", action: started, detail: "step Primary via MQTT"},
+		{topic: "start", payload: "Primary", call: "start", action: started, detail: "step Primary via MQTT"},
+		{topic: "stop", payload: "", call: "stop", action: stopped, detail: "via MQTT"},
+	}
+
+	for _, command := range commands {
+		test.publish(t, "zymurgauge/"+chamberID+"/"+command.topic+"/set", command.payload)
+
+		select {
+		case call := <-calls:
+			assert.Equal(t, command.call, call, "%s %s", command.topic, command.payload)
+		case <-time.After(timeout):
+			assert.Fail(t, "command was not executed", "%s %s", command.topic, command.payload)
+		}
+
+		select {
+		case e := <-entries:
+			assert.Equal(t, command.action, e.Action, "%s %s", command.topic, command.payload)
+			assert.Equal(t, chamberID, e.Target)
+			assert.Equal(t, command.detail, e.Detail)
+		case <-time.After(timeout):
+			assert.Fail(t, "command was not recorded", "%s %s", command.topic, command.payload)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package mqtt
+
+import (
+	"fmt"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+)
+
+const (
+	nodeID           = "zymurgauge"
+	climateType      = "climate"
+	sensorType       = "sensor"
+	binarySensorType = "binary_sensor"
+	minTemperature   = -5
+	maxTemperature   = 40
+	precision        = 0.1
+)
+
+// entity is a Home Assistant entity, in addition to the climate entity, that is created for every chamber.
+type entity struct {
+	component   string
+	key         string
+	name        string
+	deviceClass string
+	unit        string
+}
+
+var entities = []entity{
+	{component: sensorType, key: "beerTemperature", name: "Beer Temperature", deviceClass: "temperature", unit: "°C"},
+	{
+		component: sensorType, key: "auxiliaryTemperature", name: "Auxiliary Temperature", deviceClass: "temperature",
+		unit: "°C",
+	},
+	{
+		component: sensorType, key: "externalTemperature", name: "External Temperature", deviceClass: "temperature",
+		unit: "°C",
+	},
+	{component: sensorType, key: "gravity", name: "Gravity"},
+	{component: sensorType, key: "step", name: "Fermentation Step"},
+	{component: binarySensorType, key: "chiller", name: "Chiller", deviceClass: "running"},
+	{component: binarySensorType, key: "heater", name: "Heater", deviceClass: "running"},
+}
+
+// discoveryConfigs returns the Home Assistant discovery config messages of the given chamber by topic.
+func (b *Bridge) discoveryConfigs(c *chamber.Chamber) map[string]interface{} {
+	stateTopic := b.stateTopic(c.ID)
+	device := map[string]interface{}{
+		"identifiers":  []string{nodeID + "_" + c.ID},
+		"name":         c.Name,
+		"manufacturer": "Zymurgauge",
+		"model":        "Fermentation Chamber",
+	}
+
+	configs := map[string]interface{}{
+		b.discoveryTopic(climateType, c.ID): map[string]interface{}{
+			"name":                         nil,
+			"unique_id":                    nodeID + "_" + c.ID,
+			"device":                       device,
+			"availability_topic":           b.settings.availabilityTopic(),
+			"modes":                        []string{OffMode, AutoMode, HeatCoolMode},
+			"mode_command_topic":           b.commandTopic(c.ID, modeCommand),
+			"mode_state_topic":             stateTopic,
+			"mode_state_template":          "{{ value_json.mode }}",
+			"action_topic":                 stateTopic,
+			"action_template":              "{{ value_json.action }}",
+			"current_temperature_topic":    stateTopic,
+			"current_temperature_template": "{{ value_json.beerTemperature }}",
+			"temperature_command_topic":    b.commandTopic(c.ID, setPointCommand),
+			"temperature_state_topic":      stateTopic,
+			"temperature_state_template":   "{{ value_json.setPoint }}",
+			"temperature_unit":             "C",
+			"precision":                    precision,
+			"min_temp":                     minTemperature,
+			"max_temp":                     maxTemperature,
+		},
+	}
+
+	for _, e := range entities {
+		config := map[string]interface{}{
+			"name":               e.name,
+			"unique_id":          fmt.Sprintf("%s_%s_%s", nodeID, c.ID, e.key),
+			"device":             device,
+			"availability_topic": b.settings.availabilityTopic(),
+			"state_topic":        stateTopic,
+			"value_template":     fmt.Sprintf("{{ value_json.%s }}", e.key),
+		}
+
+		if e.deviceClass != "" {
+			config["device_class"] = e.deviceClass
+		}
+
+		if e.unit != "" {
+			config["unit_of_measurement"] = e.unit
+		}
+
+		if e.component == binarySensorType {
+			config["payload_on"] = onPayload
+			config["payload_off"] = offPayload
+		}
+
+		configs[b.discoveryTopic(e.component, c.ID+"_"+e.key)] = config
+	}
+
+	return configs
+}
+
+// discoveryTopics returns the topics of the Home Assistant discovery config messages of the given chamber.
+func (b *Bridge) discoveryTopics(chamberID string) []string {
+	topics := []string{b.discoveryTopic(climateType, chamberID)}
+
+	for _, e := range entities {
+		topics = append(topics, b.discoveryTopic(e.component, chamberID+"_"+e.key))
+	}
+
+	return topics
+}
+
+func (b *Bridge) discoveryTopic(component, objectID string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/config", b.settings.discoveryPrefix(), component, nodeID, objectID)
+}
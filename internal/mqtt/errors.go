@@ -0,0 +1,15 @@
+package mqtt
+
+const (
+	ErrUnknownCommand = Error("unknown command")
+	ErrUnknownMode    = Error("unknown mode")
+	ErrNoSetPoint     = Error("chamber does not have a set point")
+	ErrNoSteps        = Error("current batch does not have fermentation steps")
+	ErrTimeout        = Error("timed out waiting for broker")
+)
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
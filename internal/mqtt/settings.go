@@ -0,0 +1,40 @@
+package mqtt
+
+const (
+	defaultTopicPrefix     = "zymurgauge"
+	defaultDiscoveryPrefix = "homeassistant"
+)
+
+// Settings configures the connection to an MQTT broker. The bridge is disabled when BrokerURL is empty.
+type Settings struct {
+	// BrokerURL is the address of the broker, such as tcp://localhost:1883.
+	BrokerURL string `json:"brokerUrl,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	// TopicPrefix is the prefix of the state and command topics. It defaults to zymurgauge.
+	TopicPrefix string `json:"topicPrefix,omitempty"`
+	// HomeAssistant enables Home Assistant MQTT discovery.
+	HomeAssistant bool `json:"homeAssistant,omitempty"`
+	// DiscoveryPrefix is the Home Assistant discovery prefix. It defaults to homeassistant.
+	DiscoveryPrefix string `json:"discoveryPrefix,omitempty"`
+}
+
+func (s Settings) topicPrefix() string {
+	if s.TopicPrefix == "" {
+		return defaultTopicPrefix
+	}
+
+	return s.TopicPrefix
+}
+
+func (s Settings) discoveryPrefix() string {
+	if s.DiscoveryPrefix == "" {
+		return defaultDiscoveryPrefix
+	}
+
+	return s.DiscoveryPrefix
+}
+
+func (s Settings) availabilityTopic() string {
+	return s.topicPrefix() + "/status"
+}
@@ -0,0 +1,98 @@
+package mqtt
+
+import (
+	"encoding/json"
+
+	"github.com/benjaminbartels/zymurgauge/internal/chamber"
+	"github.com/pkg/errors"
+)
+
+const (
+	OffMode      = "off"
+	AutoMode     = "auto"
+	HeatCoolMode = "heat_cool"
+
+	offAction     = "off"
+	idleAction    = "idle"
+	coolingAction = "cooling"
+	heatingAction = "heating"
+
+	onPayload  = "on"
+	offPayload = "off"
+)
+
+// State is the state of a chamber that is published to its state topic. The mode is auto while the chamber follows
+// the fermentation schedule of its batch and heat_cool while it holds a manual set point.
+type State struct {
+	Mode                 string   `json:"mode"`
+	Action               string   `json:"action"`
+	BeerTemperature      *float64 `json:"beerTemperature"`
+	AuxiliaryTemperature *float64 `json:"auxiliaryTemperature"`
+	ExternalTemperature  *float64 `json:"externalTemperature"`
+	Gravity              *float64 `json:"gravity"`
+	SetPoint             *float64 `json:"setPoint"`
+	Chiller              string   `json:"chiller"`
+	Heater               string   `json:"heater"`
+	Step                 string   `json:"step"`
+}
+
+// getState returns the state of the given chamber. The chamber is copied through JSON so that its readings and
+// status are read while holding its locks.
+func getState(c *chamber.Chamber) (*State, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal chamber")
+	}
+
+	var snapshot chamber.Chamber
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal chamber")
+	}
+
+	s := &State{
+		Mode:    OffMode,
+		Action:  offAction,
+		Chiller: actuatorPayload(snapshot.Chiller),
+		Heater:  actuatorPayload(snapshot.Heater),
+		Step:    snapshot.CurrentFermentationStep,
+	}
+
+	switch {
+	case snapshot.Manual != nil:
+		s.Mode = HeatCoolMode
+		s.SetPoint = &snapshot.Manual.SetPoint
+	case snapshot.CurrentFermentationStep != "":
+		s.Mode = AutoMode
+	}
+
+	if r := snapshot.Readings; r != nil {
+		s.BeerTemperature = r.BeerTemperature
+		s.AuxiliaryTemperature = r.AuxiliaryTemperature
+		s.ExternalTemperature = r.ExternalTemperature
+		s.Gravity = r.HydrometerGravity
+
+		if r.SetPoint != nil {
+			s.SetPoint = r.SetPoint
+		}
+	}
+
+	switch {
+	case s.Chiller == onPayload:
+		s.Action = coolingAction
+	case s.Heater == onPayload:
+		s.Action = heatingAction
+	case s.Mode != OffMode:
+		s.Action = idleAction
+	}
+
+	return s, nil
+}
+
+func actuatorPayload(status *chamber.ActuatorStatus) string {
+	if status != nil && status.IsOn {
+		return onPayload
+	}
+
+	return offPayload
+}
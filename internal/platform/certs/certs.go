@@ -0,0 +1,161 @@
+// Package certs provides the TLS configuration to serve HTTPS with, using certificate files, a self-signed certificate
+// or certificates obtained with ACME.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	// NoneMode serves plain HTTP.
+	NoneMode Mode = "none"
+	// FileMode serves HTTPS with the certificate and key in the given files.
+	FileMode Mode = "file"
+	// SelfSignedMode serves HTTPS with a self-signed certificate that is created when needed.
+	SelfSignedMode Mode = "self-signed"
+	// ACMEMode serves HTTPS with certificates obtained from an ACME server, such as Let's Encrypt.
+	ACMEMode Mode = "acme"
+
+	acmeCacheDir = "acme"
+)
+
+var ErrInvalidMode = errors.New("tls mode is invalid")
+
+// Mode determines where the certificate to serve HTTPS with comes from.
+type Mode string
+
+// Config configures how to serve HTTPS.
+type Config struct {
+	Mode Mode
+	// CertFile and KeyFile are the paths of the PEM encoded certificate and key in FileMode.
+	CertFile string
+	KeyFile  string
+	// Dir is the directory where the self-signed certificate and the certificates obtained with ACME are stored.
+	Dir string
+	// Hosts are the names the self-signed certificate is valid for and the domains certificates are obtained for with
+	// ACME. The self-signed certificate defaults to the hostname of the machine.
+	Hosts []string
+	// ACMEDirectoryURL is the URL of the directory of the ACME server.
+	ACMEDirectoryURL string
+	// ACMEEmail is the contact email of the ACME account. It is optional.
+	ACMEEmail string
+	// ACMECAFile is the path of a PEM encoded CA certificate to trust when connecting to the ACME server, such as the
+	// one of a local test server. The system's CA certificates are used if it is empty.
+	ACMECAFile string
+}
+
+// TLS holds the TLS configuration of the HTTPS server.
+type TLS struct {
+	Config  *tls.Config
+	manager *autocert.Manager
+}
+
+// New returns the TLS configuration for the given Config. It returns nil in NoneMode.
+func New(cfg Config) (*TLS, error) {
+	switch cfg.Mode {
+	case NoneMode, "":
+		return nil, nil //nolint:nilnil // plain HTTP is served without a TLS configuration
+	case FileMode:
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load certificate")
+		}
+
+		return &TLS{Config: newTLSConfig(cert)}, nil
+	case SelfSignedMode:
+		cert, err := loadOrCreateSelfSigned(cfg.Dir, cfg.Hosts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &TLS{Config: newTLSConfig(cert)}, nil
+	case ACMEMode:
+		return newACME(cfg)
+	default:
+		return nil, errors.Wrapf(ErrInvalidMode, "'%s'", cfg.Mode)
+	}
+}
+
+// HTTPHandler returns a handler for plain HTTP requests that redirects them to HTTPS on the given port. With ACME it
+// also answers the HTTP challenges of the ACME server.
+func (t *TLS) HTTPHandler(httpsPort string) http.Handler {
+	redirect := redirectHandler(httpsPort)
+
+	if t.manager != nil {
+		return t.manager.HTTPHandler(redirect)
+	}
+
+	return redirect
+}
+
+func newACME(cfg Config) (*TLS, error) {
+	if len(cfg.Hosts) == 0 {
+		return nil, errors.New("at least one host is required for acme")
+	}
+
+	client := &acme.Client{DirectoryURL: cfg.ACMEDirectoryURL}
+
+	if cfg.ACMECAFile != "" {
+		pem, err := os.ReadFile(cfg.ACMECAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read acme ca certificate")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("could not parse acme ca certificate")
+		}
+
+		client.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			},
+		}
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(filepath.Join(cfg.Dir, acmeCacheDir)),
+		HostPolicy: autocert.HostWhitelist(cfg.Hosts...),
+		Email:      cfg.ACMEEmail,
+		Client:     client,
+	}
+
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+
+	return &TLS{Config: tlsConfig, manager: manager}, nil
+}
+
+func newTLSConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// redirectHandler redirects requests to the same host and path over HTTPS on the given port.
+func redirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
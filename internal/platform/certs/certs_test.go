@@ -0,0 +1,173 @@
+package certs_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/certs"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestNew(t *testing.T) {
+	t.Parallel()
+	t.Run("newNone", newNone)
+	t.Run("newSelfSigned", newSelfSigned)
+	t.Run("newSelfSignedReused", newSelfSignedReused)
+	t.Run("newFile", newFile)
+	t.Run("newFileMissing", newFileMissing)
+	t.Run("newACME", newACME)
+	t.Run("newACMEWithoutHosts", newACMEWithoutHosts)
+	t.Run("newInvalidMode", newInvalidMode)
+}
+
+func newNone(t *testing.T) {
+	t.Parallel()
+
+	result, err := certs.New(certs.Config{Mode: certs.NoneMode})
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func newSelfSigned(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	result, err := certs.New(certs.Config{
+		Mode:  certs.SelfSignedMode,
+		Dir:   dir,
+		Hosts: []string{"zymurgauge.local", "192.168.1.10"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result.Config.Certificates, 1)
+	assert.Equal(t, uint16(tls.VersionTLS12), result.Config.MinVersion)
+
+	leaf, err := x509.ParseCertificate(result.Config.Certificates[0].Certificate[0])
+	assert.NoError(t, err)
+	assert.NoError(t, leaf.VerifyHostname("zymurgauge.local"))
+	assert.NoError(t, leaf.VerifyHostname("192.168.1.10"))
+
+	info, err := os.Stat(filepath.Join(dir, certs.KeyFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func newSelfSignedReused(t *testing.T) {
+	t.Parallel()
+
+	cfg := certs.Config{Mode: certs.SelfSignedMode, Dir: t.TempDir()}
+
+	first, err := certs.New(cfg)
+	assert.NoError(t, err)
+
+	second, err := certs.New(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Config.Certificates[0].Certificate, second.Config.Certificates[0].Certificate)
+}
+
+func newFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// a self-signed certificate is created to have files to load
+	_, err := certs.New(certs.Config{Mode: certs.SelfSignedMode, Dir: dir, Hosts: []string{"localhost"}})
+	assert.NoError(t, err)
+
+	result, err := certs.New(certs.Config{
+		Mode:     certs.FileMode,
+		CertFile: filepath.Join(dir, certs.CertFileName),
+		KeyFile:  filepath.Join(dir, certs.KeyFileName),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result.Config.Certificates, 1)
+}
+
+func newFileMissing(t *testing.T) {
+	t.Parallel()
+
+	_, err := certs.New(certs.Config{
+		Mode:     certs.FileMode,
+		CertFile: filepath.Join(t.TempDir(), "missing.pem"),
+		KeyFile:  filepath.Join(t.TempDir(), "missing.key"),
+	})
+	assert.ErrorContains(t, err, "could not load certificate")
+}
+
+func newACME(t *testing.T) {
+	t.Parallel()
+
+	result, err := certs.New(certs.Config{
+		Mode:             certs.ACMEMode,
+		Dir:              t.TempDir(),
+		Hosts:            []string{"zymurgauge.example.com"},
+		ACMEDirectoryURL: "https://localhost:14000/dir",
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Config.GetCertificate)
+	assert.Contains(t, result.Config.NextProtos, "acme-tls/1")
+
+	// challenges are answered by the acme manager rather than redirected
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://zymurgauge.example.com/.well-known/acme-challenge/token", nil)
+	result.HTTPHandler("443").ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func newACMEWithoutHosts(t *testing.T) {
+	t.Parallel()
+
+	_, err := certs.New(certs.Config{Mode: certs.ACMEMode, Dir: t.TempDir()})
+	assert.ErrorContains(t, err, "at least one host is required")
+}
+
+func newInvalidMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := certs.New(certs.Config{Mode: "bogus"})
+	assert.ErrorIs(t, err, certs.ErrInvalidMode)
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		port     string
+		target   string
+		expected string
+	}{
+		{
+			name: "otherPort", port: "8443", target: "http://zymurgauge.local:8080/chambers?id=1",
+			expected: "https://zymurgauge.local:8443/chambers?id=1",
+		},
+		{
+			name: "defaultPort", port: "443", target: "http://zymurgauge.local/login",
+			expected: "https://zymurgauge.local/login",
+		},
+	}
+
+	result, err := certs.New(certs.Config{Mode: certs.SelfSignedMode, Dir: t.TempDir(), Hosts: []string{"localhost"}})
+	assert.NoError(t, err)
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			result.HTTPHandler(tc.port).ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusMovedPermanently, w.Code)
+			assert.Equal(t, tc.expected, w.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CertFileName and KeyFileName are the names of the files the self-signed certificate and its key are stored in.
+	CertFileName = "cert.pem"
+	KeyFileName  = "key.pem"
+
+	selfSignedValidity = 2 * 365 * 24 * time.Hour
+	// selfSignedRenewBefore is how long before it expires the self-signed certificate is replaced.
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+	serialNumberBits      = 128
+	dirPermissions        = 0o700
+	filePermissions       = 0o600
+)
+
+// loadOrCreateSelfSigned returns the self-signed certificate stored in dir. A new one is created for the given hosts,
+// or the hostname of the machine if there are none, if it does not exist, can not be loaded or is about to expire.
+func loadOrCreateSelfSigned(dir string, hosts []string) (tls.Certificate, error) {
+	certPath := filepath.Join(dir, CertFileName)
+	keyPath := filepath.Join(dir, KeyFileName)
+
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil &&
+			time.Now().Add(selfSignedRenewBefore).Before(leaf.NotAfter) {
+			return cert, nil
+		}
+	}
+
+	if len(hosts) == 0 {
+		hosts = defaultHosts()
+	}
+
+	certPEM, keyPEM, err := createSelfSigned(hosts, time.Now())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return tls.Certificate{}, errors.Wrapf(err, "could not create directory %s", dir)
+	}
+
+	if err := os.WriteFile(certPath, certPEM, filePermissions); err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "could not write self-signed certificate")
+	}
+
+	if err := os.WriteFile(keyPath, keyPEM, filePermissions); err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "could not write self-signed certificate key")
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "could not load self-signed certificate")
+	}
+
+	return cert, nil
+}
+
+// createSelfSigned returns a PEM encoded self-signed certificate for the given hosts, which are names or IP addresses,
+// and its key.
+func createSelfSigned(hosts []string, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not generate key")
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialNumberBits))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not generate serial number")
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{Organization: []string{"Zymurgauge"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not create certificate")
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not marshal key")
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
+
+// defaultHosts returns the names the machine is likely reached at on the local network.
+func defaultHosts() []string {
+	hosts := []string{}
+
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		hosts = append(hosts, hostname, hostname+".local")
+	}
+
+	return append(hosts, "localhost", "127.0.0.1", "::1")
+}
@@ -6,7 +6,9 @@ import (
 	"net/http/pprof"
 )
 
-func Mux() *http.ServeMux {
+// Mux returns a ServeMux with the pprof and expvar handlers. The given metrics handler is served at /metrics if it is
+// not nil.
+func Mux(metricsHandler http.Handler) *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
 	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
@@ -15,5 +17,9 @@ func Mux() *http.ServeMux {
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	mux.Handle("/debug/vars", expvar.Handler())
 
+	if metricsHandler != nil {
+		mux.Handle("/metrics", metricsHandler)
+	}
+
 	return mux
 }
@@ -0,0 +1,383 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultInfluxDBBatchSize     = 500
+	defaultInfluxDBFlushInterval = 10 * time.Second
+	defaultInfluxDBMaxRetries    = 3
+	defaultInfluxDBRetryDelay    = time.Second
+	defaultInfluxDBMaxPoints     = 10000
+	influxDBRequestTimeout       = 10 * time.Second
+	influxDBDialTimeout          = 10 * time.Second
+	influxDBFieldName            = "value"
+	maxErrorBodyLength           = 512
+	floatBitSize                 = 64
+)
+
+var (
+	_ Metrics = (*InfluxDB)(nil)
+
+	ErrInfluxDBRejected = errors.New("points rejected by influxdb")
+
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// InfluxDB is a Metrics that writes points to the v2 write API of an InfluxDB server in the line protocol. The first
+// part of the name of a bucket becomes the measurement and the rest the field, so "zymurgauge.beer_temperature"
+// is written to the beer_temperature field of the zymurgauge measurement, as with Telegraf's StatsD template. Points
+// are buffered and written in batches by Run. Batches that can not be written are retried and kept until the buffer
+// is full, after which the oldest points are dropped.
+type InfluxDB struct {
+	url           string
+	org           string
+	bucket        string
+	token         string
+	client        *http.Client
+	logger        *logrus.Logger
+	clock         clock.Clock
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryDelay    time.Duration
+	maxPoints     int
+	points        []string
+	dropped       int
+	flushCh       chan struct{}
+	mutex         sync.Mutex
+	flushMutex    sync.Mutex
+}
+
+// NewInfluxDB creates a new InfluxDB that writes to the given org and bucket using the given write token.
+func NewInfluxDB(url, org, bucket, token string, logger *logrus.Logger, options ...InfluxDBOptionsFunc) *InfluxDB {
+	i := &InfluxDB{
+		url:    strings.TrimSuffix(url, "/"),
+		org:    org,
+		bucket: bucket,
+		token:  token,
+		client: &http.Client{
+			Timeout: influxDBRequestTimeout,
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout: influxDBDialTimeout,
+				}).DialContext,
+			},
+		},
+		logger:        logger,
+		clock:         clock.NewRealClock(),
+		batchSize:     defaultInfluxDBBatchSize,
+		flushInterval: defaultInfluxDBFlushInterval,
+		maxRetries:    defaultInfluxDBMaxRetries,
+		retryDelay:    defaultInfluxDBRetryDelay,
+		maxPoints:     defaultInfluxDBMaxPoints,
+		flushCh:       make(chan struct{}, 1),
+	}
+
+	for _, option := range options {
+		option(i)
+	}
+
+	return i
+}
+
+type InfluxDBOptionsFunc func(*InfluxDB)
+
+// BatchSize sets the maximum number of points written in one request. A batch is written as soon as it is full.
+func BatchSize(size int) InfluxDBOptionsFunc {
+	return func(i *InfluxDB) {
+		i.batchSize = size
+	}
+}
+
+// FlushInterval sets how often buffered points are written.
+func FlushInterval(interval time.Duration) InfluxDBOptionsFunc {
+	return func(i *InfluxDB) {
+		i.flushInterval = interval
+	}
+}
+
+// Retry sets how many times a batch is retried and the delay before the first retry. The delay doubles with each
+// retry.
+func Retry(maxRetries int, delay time.Duration) InfluxDBOptionsFunc {
+	return func(i *InfluxDB) {
+		i.maxRetries = maxRetries
+		i.retryDelay = delay
+	}
+}
+
+// MaxPoints sets how many points are buffered while InfluxDB can not be reached.
+func MaxPoints(max int) InfluxDBOptionsFunc {
+	return func(i *InfluxDB) {
+		i.maxPoints = max
+	}
+}
+
+func SetInfluxDBClock(clock clock.Clock) InfluxDBOptionsFunc {
+	return func(i *InfluxDB) {
+		i.clock = clock
+	}
+}
+
+func (i *InfluxDB) Count(bucket string, n interface{}) {
+	i.add(bucket, n)
+}
+
+func (i *InfluxDB) Increment(bucket string) {
+	i.add(bucket, 1)
+}
+
+func (i *InfluxDB) Gauge(bucket string, value interface{}) {
+	i.add(bucket, value)
+}
+
+func (i *InfluxDB) Timing(bucket string, value interface{}) {
+	i.add(bucket, value)
+}
+
+func (i *InfluxDB) Histogram(bucket string, value interface{}) {
+	i.add(bucket, value)
+}
+
+// Run writes the buffered points every flush interval, or as soon as a batch is full, until the context is canceled.
+// The remaining points are written before it returns.
+func (i *InfluxDB) Run(ctx context.Context) {
+	for {
+		timer := i.clock.NewTimer(i.flushInterval)
+
+		select {
+		case <-timer.C:
+		case <-i.flushCh:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+
+			flushCtx, cancel := context.WithTimeout(context.Background(), influxDBRequestTimeout)
+			//nolint: contextcheck // the points must be written after ctx is canceled
+			if err := i.Flush(flushCtx); err != nil {
+				i.logger.WithError(err).Warn("Could not write remaining points to InfluxDB.")
+			}
+
+			cancel()
+
+			return
+		}
+
+		if err := i.Flush(ctx); err != nil {
+			i.logger.WithError(err).Warn("Could not write points to InfluxDB.")
+		}
+	}
+}
+
+// Flush writes the buffered points in batches. Batches that InfluxDB rejects are dropped, while batches that could
+// not be written after all retries are put back in the buffer.
+func (i *InfluxDB) Flush(ctx context.Context) error {
+	i.flushMutex.Lock()
+	defer i.flushMutex.Unlock()
+
+	for {
+		batch := i.take()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := i.write(ctx, batch); err != nil {
+			if errors.Is(err, ErrInfluxDBRejected) {
+				return errors.Wrapf(err, "dropped %d points", len(batch))
+			}
+
+			i.requeue(batch)
+
+			return err
+		}
+	}
+}
+
+// Len returns the number of buffered points.
+func (i *InfluxDB) Len() int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	return len(i.points)
+}
+
+func (i *InfluxDB) add(bucket string, value interface{}) {
+	v, ok := toFloat(value)
+	if !ok {
+		i.logger.Debugf("Could not write %s, %v is not a number.", bucket, value)
+
+		return
+	}
+
+	point := i.line(bucket, v)
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.points = append(i.points, point)
+	i.trim()
+
+	if len(i.points) >= i.batchSize {
+		select {
+		case i.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// line returns the point of a bucket in the line protocol.
+func (i *InfluxDB) line(bucket string, v float64) string {
+	name, tags := ParseBucket(bucket)
+
+	measurement, field, ok := strings.Cut(name, ".")
+	if !ok {
+		field = influxDBFieldName
+	}
+
+	sort.SliceStable(tags, func(a, b int) bool {
+		return tags[a].Key < tags[b].Key
+	})
+
+	var sb strings.Builder
+
+	sb.WriteString(measurementEscaper.Replace(measurement))
+
+	for _, tag := range tags {
+		if tag.Value == "" {
+			continue
+		}
+
+		sb.WriteString(",")
+		sb.WriteString(keyEscaper.Replace(tag.Key))
+		sb.WriteString("=")
+		sb.WriteString(keyEscaper.Replace(tag.Value))
+	}
+
+	sb.WriteString(" ")
+	sb.WriteString(keyEscaper.Replace(strings.ReplaceAll(field, ".", "_")))
+	sb.WriteString("=")
+	sb.WriteString(strconv.FormatFloat(v, 'f', -1, floatBitSize))
+	sb.WriteString(" ")
+	sb.WriteString(strconv.FormatInt(i.clock.Now().UnixNano(), 10))
+
+	return sb.String()
+}
+
+// take removes and returns the oldest batch of points.
+func (i *InfluxDB) take() []string {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	n := i.batchSize
+	if n > len(i.points) {
+		n = len(i.points)
+	}
+
+	batch := i.points[:n:n]
+	i.points = i.points[n:]
+
+	return batch
+}
+
+// requeue puts a batch back in front of the buffered points.
+func (i *InfluxDB) requeue(batch []string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.points = append(batch, i.points...)
+	i.trim()
+}
+
+// trim drops the oldest points if the buffer is full.
+func (i *InfluxDB) trim() {
+	if len(i.points) <= i.maxPoints {
+		return
+	}
+
+	n := len(i.points) - i.maxPoints
+	i.points = i.points[n:]
+
+	if i.dropped == 0 {
+		i.logger.Warn("InfluxDB buffer is full, dropping the oldest points.")
+	}
+
+	i.dropped += n
+}
+
+// write writes a batch, retrying with exponential backoff if InfluxDB can not be reached or is unavailable.
+func (i *InfluxDB) write(ctx context.Context, batch []string) error {
+	body := []byte(strings.Join(batch, "\n"))
+	delay := i.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		err := i.post(ctx, body)
+		if err == nil || errors.Is(err, ErrInfluxDBRejected) || attempt >= i.maxRetries {
+			return err
+		}
+
+		i.logger.WithError(err).Debugf("Could not write %d points to InfluxDB, will retry in %s.", len(batch), delay)
+
+		timer := i.clock.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return errors.Wrap(ctx.Err(), "could not retry write")
+		}
+
+		delay *= 2
+	}
+}
+
+func (i *InfluxDB) post(ctx context.Context, body []byte) error {
+	query := url.Values{}
+	query.Set("org", i.org)
+	query.Set("bucket", i.bucket)
+	query.Set("precision", "ns")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.url+"/api/v2/write?"+query.Encode(),
+		bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "could not create request")
+	}
+
+	req.Header.Set("Authorization", "Token "+i.token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not send request")
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return errors.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return errors.Wrapf(ErrInfluxDBRejected, "status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
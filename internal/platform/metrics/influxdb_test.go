@@ -0,0 +1,280 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	influxDBToken  = "writeToken"
+	influxDBOrg    = "zymurgauge"
+	influxDBBucket = "zymurgauge"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Since(t time.Time) time.Duration {
+	return c.now.Sub(t)
+}
+
+func (c *testClock) NewTimer(d time.Duration) *time.Timer {
+	return time.NewTimer(d)
+}
+
+type influxDBServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	statuses []int
+	bodies   []string
+	requests []*http.Request
+}
+
+// newInfluxDBServer creates a server that responds with the given statuses in order and 204 after that.
+func newInfluxDBServer(statuses ...int) *influxDBServer {
+	s := &influxDBServer{statuses: statuses}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+
+		status := http.StatusNoContent
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	return s
+}
+
+func (s *influxDBServer) getBodies() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.bodies...)
+}
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestInfluxDB(t *testing.T) {
+	t.Parallel()
+	t.Run("writeLineProtocol", writeLineProtocol)
+	t.Run("writeInBatches", writeInBatches)
+	t.Run("retryUnavailable", retryUnavailable)
+	t.Run("requeueAfterRetries", requeueAfterRetries)
+	t.Run("dropRejected", dropRejected)
+	t.Run("dropOldestWhenFull", dropOldestWhenFull)
+	t.Run("flushWhenBatchIsFull", flushWhenBatchIsFull)
+	t.Run("flushOnCancel", flushOnCancel)
+}
+
+func createInfluxDB(url string, options ...metrics.InfluxDBOptionsFunc) *metrics.InfluxDB {
+	l, _ := logtest.NewNullLogger()
+	clock := &testClock{now: time.Unix(1660000000, 0)}
+
+	options = append([]metrics.InfluxDBOptionsFunc{
+		metrics.SetInfluxDBClock(clock),
+		metrics.Retry(1, time.Millisecond),
+	}, options...)
+
+	return metrics.NewInfluxDB(url, influxDBOrg, influxDBBucket, influxDBToken, l, options...)
+}
+
+func writeLineProtocol(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer()
+	defer s.Close()
+
+	i := createInfluxDB(s.URL + "/")
+
+	i.Gauge(metrics.Bucket("zymurgauge.beer_temperature",
+		metrics.Tag{Key: "sensor_id", Value: "28-0000071cbc72"},
+		metrics.Tag{Key: "chamber", Value: "Chamber 1"}), 20.5)
+	i.Increment(metrics.Bucket("zymurgauge.tilt.advertisements", metrics.Tag{Key: "color", Value: "red"}))
+	i.Timing("zymurgauge.http_request_duration", 12)
+	i.Gauge(metrics.Bucket("uptime", metrics.Tag{Key: "sensor_id"}), true)
+	i.Gauge("zymurgauge.invalid", "not a number")
+
+	assert.NoError(t, i.Flush(context.Background()))
+	assert.Equal(t, 0, i.Len())
+
+	assert.Len(t, s.requests, 1)
+	r := s.requests[0]
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "/api/v2/write", r.URL.Path)
+	assert.Equal(t, influxDBOrg, r.URL.Query().Get("org"))
+	assert.Equal(t, influxDBBucket, r.URL.Query().Get("bucket"))
+	assert.Equal(t, "ns", r.URL.Query().Get("precision"))
+	assert.Equal(t, "Token "+influxDBToken, r.Header.Get("Authorization"))
+
+	assert.Equal(t, strings.Join([]string{
+		"zymurgauge,chamber=Chamber_1,sensor_id=28-0000071cbc72 beer_temperature=20.5 1660000000000000000",
+		"zymurgauge,color=red tilt_advertisements=1 1660000000000000000",
+		"zymurgauge http_request_duration=12 1660000000000000000",
+		"uptime value=1 1660000000000000000",
+	}, "\n"), s.bodies[0])
+}
+
+func writeInBatches(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer()
+	defer s.Close()
+
+	i := createInfluxDB(s.URL, metrics.BatchSize(2))
+
+	for n := 0; n < 5; n++ {
+		i.Gauge("zymurgauge.set_point", n)
+	}
+
+	assert.NoError(t, i.Flush(context.Background()))
+
+	bodies := s.getBodies()
+	assert.Len(t, bodies, 3)
+	assert.Equal(t, 2, strings.Count(bodies[0], "\n")+1)
+	assert.Equal(t, 2, strings.Count(bodies[1], "\n")+1)
+	assert.Contains(t, bodies[2], "set_point=4 ")
+}
+
+func retryUnavailable(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer(http.StatusServiceUnavailable)
+	defer s.Close()
+
+	i := createInfluxDB(s.URL)
+	i.Gauge("zymurgauge.set_point", 18)
+
+	assert.NoError(t, i.Flush(context.Background()))
+
+	bodies := s.getBodies()
+	assert.Len(t, bodies, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, 0, i.Len())
+}
+
+func requeueAfterRetries(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer(http.StatusTooManyRequests, http.StatusInternalServerError)
+	defer s.Close()
+
+	i := createInfluxDB(s.URL)
+	i.Gauge("zymurgauge.set_point", 18)
+
+	assert.Error(t, i.Flush(context.Background()))
+	assert.Equal(t, 1, i.Len())
+
+	i.Gauge("zymurgauge.set_point", 19)
+
+	assert.NoError(t, i.Flush(context.Background()))
+	assert.Equal(t, 0, i.Len())
+
+	bodies := s.getBodies()
+	assert.Len(t, bodies, 3)
+	assert.Contains(t, bodies[2], "set_point=18 ")
+	assert.Contains(t, bodies[2], "set_point=19 ")
+}
+
+func dropRejected(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer(http.StatusBadRequest)
+	defer s.Close()
+
+	i := createInfluxDB(s.URL)
+	i.Gauge("zymurgauge.set_point", 18)
+
+	err := i.Flush(context.Background())
+	assert.ErrorIs(t, err, metrics.ErrInfluxDBRejected)
+	assert.Equal(t, 0, i.Len())
+	assert.Len(t, s.getBodies(), 1)
+}
+
+func dropOldestWhenFull(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer()
+	defer s.Close()
+
+	i := createInfluxDB(s.URL, metrics.MaxPoints(2))
+
+	i.Gauge("zymurgauge.set_point", 1)
+	i.Gauge("zymurgauge.set_point", 2)
+	i.Gauge("zymurgauge.set_point", 3)
+
+	assert.Equal(t, 2, i.Len())
+	assert.NoError(t, i.Flush(context.Background()))
+
+	bodies := s.getBodies()
+	assert.Len(t, bodies, 1)
+	assert.NotContains(t, bodies[0], "set_point=1 ")
+	assert.Contains(t, bodies[0], "set_point=3 ")
+}
+
+func flushWhenBatchIsFull(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer()
+	defer s.Close()
+
+	i := createInfluxDB(s.URL, metrics.BatchSize(2), metrics.FlushInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go i.Run(ctx)
+
+	i.Gauge("zymurgauge.set_point", 1)
+	i.Gauge("zymurgauge.set_point", 2)
+
+	assert.Eventually(t, func() bool {
+		return len(s.getBodies()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func flushOnCancel(t *testing.T) {
+	t.Parallel()
+
+	s := newInfluxDBServer()
+	defer s.Close()
+
+	i := createInfluxDB(s.URL, metrics.FlushInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		i.Run(ctx)
+		close(done)
+	}()
+
+	i.Gauge("zymurgauge.set_point", 1)
+	cancel()
+	<-done
+
+	assert.Len(t, s.getBodies(), 1)
+	assert.Equal(t, 0, i.Len())
+}
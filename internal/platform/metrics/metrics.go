@@ -1,5 +1,9 @@
 package metrics
 
+import (
+	"strings"
+)
+
 type Metrics interface {
 	Count(bucket string, n interface{})
 	Increment(bucket string)
@@ -7,3 +11,43 @@ type Metrics interface {
 	Timing(bucket string, value interface{})
 	Histogram(bucket string, value interface{})
 }
+
+// Tag is a dimension of a metric, such as the chamber it belongs to.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+var tagReplacer = strings.NewReplacer(" ", "_", ",", "_", "=", "_")
+
+// Bucket returns the name of a metric along with its tags in the format understood by Telegraf's StatsD input, such
+// as "zymurgauge.beer_temperature,chamber=Chamber_1,sensor_id=28-0000071cbc72". Spaces, commas and equal signs in tag
+// values are replaced with underscores.
+func Bucket(name string, tags ...Tag) string {
+	var sb strings.Builder
+
+	sb.WriteString(name)
+
+	for _, tag := range tags {
+		sb.WriteString(",")
+		sb.WriteString(tag.Key)
+		sb.WriteString("=")
+		sb.WriteString(tagReplacer.Replace(tag.Value))
+	}
+
+	return sb.String()
+}
+
+// ParseBucket returns the name and tags of a bucket returned by Bucket.
+func ParseBucket(bucket string) (string, []Tag) {
+	parts := strings.Split(bucket, ",")
+
+	var tags []Tag
+
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		tags = append(tags, Tag{Key: key, Value: value})
+	}
+
+	return parts[0], tags
+}
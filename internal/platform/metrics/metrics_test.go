@@ -0,0 +1,29 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket(t *testing.T) {
+	t.Parallel()
+
+	bucket := metrics.Bucket("zymurgauge.beer_temperature",
+		metrics.Tag{Key: "chamber", Value: "My Chamber"},
+		metrics.Tag{Key: "sensor_id", Value: "a,b=c"})
+
+	assert.Equal(t, "zymurgauge.beer_temperature,chamber=My_Chamber,sensor_id=a_b_c", bucket)
+
+	name, tags := metrics.ParseBucket(bucket)
+	assert.Equal(t, "zymurgauge.beer_temperature", name)
+	assert.Equal(t, []metrics.Tag{
+		{Key: "chamber", Value: "My_Chamber"},
+		{Key: "sensor_id", Value: "a_b_c"},
+	}, tags)
+
+	name, tags = metrics.ParseBucket("zymurgauge.uptime")
+	assert.Equal(t, "zymurgauge.uptime", name)
+	assert.Empty(t, tags)
+}
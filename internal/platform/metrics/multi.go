@@ -0,0 +1,36 @@
+package metrics
+
+var _ Metrics = (Multi)(nil)
+
+// Multi is a Metrics that sends metrics to each of its Metrics.
+type Multi []Metrics
+
+func (m Multi) Count(bucket string, n interface{}) {
+	for _, metrics := range m {
+		metrics.Count(bucket, n)
+	}
+}
+
+func (m Multi) Increment(bucket string) {
+	for _, metrics := range m {
+		metrics.Increment(bucket)
+	}
+}
+
+func (m Multi) Gauge(bucket string, value interface{}) {
+	for _, metrics := range m {
+		metrics.Gauge(bucket, value)
+	}
+}
+
+func (m Multi) Timing(bucket string, value interface{}) {
+	for _, metrics := range m {
+		metrics.Timing(bucket, value)
+	}
+}
+
+func (m Multi) Histogram(bucket string, value interface{}) {
+	for _, metrics := range m {
+		metrics.Histogram(bucket, value)
+	}
+}
@@ -0,0 +1,228 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const millisecondsPerSecond = 1000
+
+var (
+	_ Metrics = (*Prometheus)(nil)
+
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+)
+
+// Prometheus is a Metrics that exposes metrics in the Prometheus exposition format. The tags of a bucket become
+// labels, dots in its name become underscores and counters are suffixed with _total. Timings are in milliseconds, as
+// with StatsD, and are exposed as histograms in seconds. A metric must always be reported with the same tags.
+type Prometheus struct {
+	registry   *prometheus.Registry
+	logger     *logrus.Logger
+	collectors map[string]prometheus.Collector
+	labels     map[string][]string
+	mutex      sync.Mutex
+}
+
+// NewPrometheus creates a new Prometheus that also exposes Go runtime and process metrics.
+func NewPrometheus(logger *logrus.Logger) *Prometheus {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return &Prometheus{
+		registry:   registry,
+		logger:     logger,
+		collectors: make(map[string]prometheus.Collector),
+		labels:     make(map[string][]string),
+	}
+}
+
+// Handler returns the handler that serves the metrics.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+func (p *Prometheus) Count(bucket string, n interface{}) {
+	v, ok := toFloat(n)
+	if !ok {
+		p.logger.Debugf("Could not count %s, %v is not a number.", bucket, n)
+
+		return
+	}
+
+	if counter := p.counter(bucket); counter != nil {
+		counter.Add(v)
+	}
+}
+
+func (p *Prometheus) Increment(bucket string) {
+	if counter := p.counter(bucket); counter != nil {
+		counter.Inc()
+	}
+}
+
+func (p *Prometheus) Gauge(bucket string, value interface{}) {
+	v, ok := toFloat(value)
+	if !ok {
+		p.logger.Debugf("Could not gauge %s, %v is not a number.", bucket, value)
+
+		return
+	}
+
+	name, labels := parse(bucket)
+
+	vec, ok := p.collector(name, labels, func(labelNames []string) prometheus.Collector {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, labelNames)
+	}).(*prometheus.GaugeVec)
+	if ok {
+		vec.With(labels).Set(v)
+	}
+}
+
+func (p *Prometheus) Timing(bucket string, value interface{}) {
+	v, ok := toFloat(value)
+	if !ok {
+		p.logger.Debugf("Could not time %s, %v is not a number.", bucket, value)
+
+		return
+	}
+
+	name, labels := parse(bucket)
+	p.observe(name+"_seconds", labels, v/millisecondsPerSecond)
+}
+
+func (p *Prometheus) Histogram(bucket string, value interface{}) {
+	v, ok := toFloat(value)
+	if !ok {
+		p.logger.Debugf("Could not observe %s, %v is not a number.", bucket, value)
+
+		return
+	}
+
+	name, labels := parse(bucket)
+	p.observe(name, labels, v)
+}
+
+func (p *Prometheus) counter(bucket string) prometheus.Counter {
+	name, labels := parse(bucket)
+	if !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+
+	vec, ok := p.collector(name, labels, func(labelNames []string) prometheus.Collector {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name}, labelNames)
+	}).(*prometheus.CounterVec)
+	if !ok {
+		return nil
+	}
+
+	return vec.With(labels)
+}
+
+func (p *Prometheus) observe(name string, labels prometheus.Labels, v float64) {
+	vec, ok := p.collector(name, labels, func(labelNames []string) prometheus.Collector {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name}, labelNames)
+	}).(*prometheus.HistogramVec)
+	if ok {
+		vec.With(labels).Observe(v)
+	}
+}
+
+// collector returns the collector of the given metric, using create to create and register it if it does not exist.
+// Nil is returned if the metric was created with different labels or is of a different type.
+func (p *Prometheus) collector(name string, labels prometheus.Labels,
+	create func(labelNames []string) prometheus.Collector,
+) prometheus.Collector {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	labelNames := make([]string, 0, len(labels))
+	for k := range labels {
+		labelNames = append(labelNames, k)
+	}
+
+	sort.Strings(labelNames)
+
+	if c, ok := p.collectors[name]; ok {
+		if strings.Join(p.labels[name], ",") != strings.Join(labelNames, ",") {
+			p.logger.Debugf("Could not report %s with labels %v, it has labels %v.", name, labelNames, p.labels[name])
+
+			return nil
+		}
+
+		return c
+	}
+
+	c := create(labelNames)
+
+	if err := p.registry.Register(c); err != nil {
+		p.logger.WithError(err).Debugf("Could not register %s.", name)
+
+		return nil
+	}
+
+	p.collectors[name] = c
+	p.labels[name] = labelNames
+
+	return c
+}
+
+// parse returns the Prometheus name and labels of a bucket.
+func parse(bucket string) (string, prometheus.Labels) {
+	name, tags := ParseBucket(bucket)
+
+	labels := make(prometheus.Labels, len(tags))
+	for _, tag := range tags {
+		labels[invalidNameChars.ReplaceAllString(tag.Key, "_")] = tag.Value
+	}
+
+	return invalidNameChars.ReplaceAllString(name, "_"), labels
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case time.Duration:
+		return float64(v.Milliseconds()), true
+	case bool:
+		if v {
+			return 1, true
+		}
+
+		return 0, true
+	default:
+		return 0, false
+	}
+}
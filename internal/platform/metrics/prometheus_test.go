@@ -0,0 +1,80 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestPrometheus(t *testing.T) {
+	t.Parallel()
+	t.Run("exposeLabeledMetrics", exposeLabeledMetrics)
+	t.Run("ignoreMismatchedLabels", ignoreMismatchedLabels)
+}
+
+func exposeLabeledMetrics(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	p := metrics.NewPrometheus(l)
+
+	chamberTag := metrics.Tag{Key: "chamber", Value: "Chamber 1"}
+
+	p.Gauge(metrics.Bucket("zymurgauge.beer_temperature", chamberTag,
+		metrics.Tag{Key: "sensor_id", Value: "28-0000071cbc72"}), 20.5)
+	p.Increment(metrics.Bucket("zymurgauge.tilt.advertisements", metrics.Tag{Key: "color", Value: "red"}))
+	p.Count(metrics.Bucket("zymurgauge.tilt.advertisements", metrics.Tag{Key: "color", Value: "red"}), 2)
+	p.Timing(metrics.Bucket("zymurgauge.http_request_duration", metrics.Tag{Key: "route", Value: "/api/v1/chambers"}),
+		250)
+	p.Histogram("zymurgauge.cycle_length", 3)
+	p.Gauge("zymurgauge.invalid", "not a number")
+
+	body := scrape(t, p)
+
+	assert.Contains(t, body,
+		`zymurgauge_beer_temperature{chamber="Chamber_1",sensor_id="28-0000071cbc72"} 20.5`)
+	assert.Contains(t, body, `zymurgauge_tilt_advertisements_total{color="red"} 3`)
+	assert.Contains(t, body, `zymurgauge_http_request_duration_seconds_sum{route="/api/v1/chambers"} 0.25`)
+	assert.Contains(t, body, `zymurgauge_http_request_duration_seconds_count{route="/api/v1/chambers"} 1`)
+	assert.Contains(t, body, `zymurgauge_cycle_length_count 1`)
+	assert.Contains(t, body, `go_goroutines`)
+	assert.NotContains(t, body, `zymurgauge_invalid`)
+}
+
+func ignoreMismatchedLabels(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	p := metrics.NewPrometheus(l)
+
+	p.Gauge(metrics.Bucket("zymurgauge.set_point", metrics.Tag{Key: "chamber", Value: "Chamber_1"}), 18)
+	p.Gauge(metrics.Bucket("zymurgauge.set_point", metrics.Tag{Key: "sensor_id", Value: "1"}), 19)
+	p.Histogram(metrics.Bucket("zymurgauge.set_point", metrics.Tag{Key: "chamber", Value: "Chamber_1"}), 20)
+
+	body := scrape(t, p)
+
+	assert.Contains(t, body, `zymurgauge_set_point{chamber="Chamber_1"} 18`)
+	assert.NotContains(t, body, `sensor_id="1"`)
+	assert.NotContains(t, body, `zymurgauge_set_point_count`)
+}
+
+func scrape(t *testing.T, p *metrics.Prometheus) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	p.Handler().ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body, err := io.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	return string(body)
+}
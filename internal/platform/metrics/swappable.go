@@ -0,0 +1,60 @@
+package metrics
+
+import "sync"
+
+var _ Metrics = (*Swappable)(nil)
+
+// Swappable is a Metrics that sends metrics to a Metrics that can be replaced while it is in use, such as when the
+// metrics sink is changed in the settings. Metrics are discarded while it has none.
+type Swappable struct {
+	metrics Metrics
+	mutex   sync.RWMutex
+}
+
+// Swap replaces the Metrics that metrics are sent to and returns the one it replaced.
+func (s *Swappable) Swap(metrics Metrics) Metrics {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old := s.metrics
+	s.metrics = metrics
+
+	return old
+}
+
+func (s *Swappable) Count(bucket string, n interface{}) {
+	if m := s.get(); m != nil {
+		m.Count(bucket, n)
+	}
+}
+
+func (s *Swappable) Increment(bucket string) {
+	if m := s.get(); m != nil {
+		m.Increment(bucket)
+	}
+}
+
+func (s *Swappable) Gauge(bucket string, value interface{}) {
+	if m := s.get(); m != nil {
+		m.Gauge(bucket, value)
+	}
+}
+
+func (s *Swappable) Timing(bucket string, value interface{}) {
+	if m := s.get(); m != nil {
+		m.Timing(bucket, value)
+	}
+}
+
+func (s *Swappable) Histogram(bucket string, value interface{}) {
+	if m := s.get(); m != nil {
+		m.Histogram(bucket, value)
+	}
+}
+
+func (s *Swappable) get() Metrics {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.metrics
+}
@@ -0,0 +1,54 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/metrics"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:paralleltest // False positives with r.Run not in a loop
+func TestSwappable(t *testing.T) {
+	t.Parallel()
+	t.Run("swapMetrics", swapMetrics)
+	t.Run("discardWithoutMetrics", discardWithoutMetrics)
+}
+
+func swapMetrics(t *testing.T) {
+	t.Parallel()
+
+	first := &mocks.Metrics{}
+	first.On("Gauge", "zymurgauge.beer_temperature", 20.5).Return()
+
+	second := &mocks.Metrics{}
+	second.On("Increment", "zymurgauge.tilt.advertisements").Return()
+
+	s := &metrics.Swappable{}
+	assert.Nil(t, s.Swap(first))
+
+	s.Gauge("zymurgauge.beer_temperature", 20.5)
+
+	assert.Same(t, first, s.Swap(second))
+
+	s.Increment("zymurgauge.tilt.advertisements")
+
+	first.AssertExpectations(t)
+	first.AssertNumberOfCalls(t, "Increment", 0)
+	second.AssertExpectations(t)
+	second.AssertNumberOfCalls(t, "Gauge", 0)
+}
+
+func discardWithoutMetrics(t *testing.T) {
+	t.Parallel()
+
+	s := &metrics.Swappable{}
+
+	assert.NotPanics(t, func() {
+		s.Count("zymurgauge.tilt.advertisements", 2)
+		s.Increment("zymurgauge.tilt.advertisements")
+		s.Gauge("zymurgauge.beer_temperature", 20.5)
+		s.Timing("zymurgauge.http_request_duration", 250)
+		s.Histogram("zymurgauge.cycle_length", 3)
+	})
+}
@@ -42,10 +42,15 @@ func (a *API) Register(method, group, path string, handler Handler, middlewares
 	handler = wrap(middlewares, handler)
 	handler = wrap(a.middlewares, handler)
 
+	if group != "" {
+		path = "/api/" + group + path
+	}
+
 	h := func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		v := CtxValues{
-			Path: r.URL.Path,
-			Now:  time.Now(),
+			Path:  r.URL.Path,
+			Route: path,
+			Now:   time.Now(),
 		}
 
 		ctx := InitContextValues(r.Context(), &v)
@@ -57,10 +62,6 @@ func (a *API) Register(method, group, path string, handler Handler, middlewares
 		}
 	}
 
-	if group != "" {
-		path = "/api/" + group + path
-	}
-
 	a.router.Handle(method, path, h)
 }
 
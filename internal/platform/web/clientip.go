@@ -0,0 +1,49 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client that sent the request.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// RealIP wraps a handler so that the remote address of requests is taken from the X-Real-IP or X-Forwarded-For
+// headers set by a reverse proxy. It must only be used behind a proxy that sets them, since clients can send them too.
+// Only the last X-Forwarded-For entry is used since it is the one added by the proxy, the entries before it are sent
+// by the client.
+func RealIP(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := strings.TrimSpace(r.Header.Get("X-Real-IP"))
+
+		if ip == "" {
+			ip = lastForwardedFor(r.Header)
+		}
+
+		if net.ParseIP(ip) != nil {
+			r.RemoteAddr = net.JoinHostPort(ip, "0")
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// lastForwardedFor returns the last entry of the X-Forwarded-For headers.
+func lastForwardedFor(header http.Header) string {
+	values := header.Values("X-Forwarded-For")
+	if len(values) == 0 {
+		return ""
+	}
+
+	entries := strings.Split(values[len(values)-1], ",")
+
+	return strings.TrimSpace(entries[len(entries)-1])
+}
@@ -15,6 +15,7 @@ const key ctxKey = 1
 // CtxValues represent state for each request.
 type CtxValues struct {
 	Path       string
+	Route      string
 	Now        time.Time
 	StatusCode int
 }
@@ -156,3 +156,50 @@ func logContains(logs []*logrus.Entry, level logrus.Level, substr string) bool {
 
 	return found
 }
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		name     string
+		realIP   string
+		fwdFor   string
+		expected string
+	}
+
+	tests := []test{
+		{name: "noHeaders", expected: "192.0.2.1"},
+		{name: "realIP", realIP: "203.0.113.7", fwdFor: "198.51.100.2", expected: "203.0.113.7"},
+		{name: "forwardedFor", fwdFor: "198.51.100.2", expected: "198.51.100.2"},
+		{name: "spoofedForwardedFor", fwdFor: "203.0.113.9, 198.51.100.2", expected: "198.51.100.2"},
+		{name: "invalidHeader", realIP: "not-an-ip", expected: "192.0.2.1"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var ip string
+
+			handler := web.RealIP(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				ip = web.ClientIP(r)
+			}))
+
+			r := httptest.NewRequest("", "/", nil)
+
+			if tc.realIP != "" {
+				r.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			if tc.fwdFor != "" {
+				r.Header.Set("X-Forwarded-For", tc.fwdFor)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tc.expected, ip)
+		})
+	}
+}
@@ -3,22 +3,54 @@ package settings
 import (
 	"time"
 
+	"github.com/benjaminbartels/zymurgauge/internal/alert"
 	"github.com/benjaminbartels/zymurgauge/internal/auth"
+	"github.com/benjaminbartels/zymurgauge/internal/mqtt"
+)
+
+// Metrics sinks that readings and statistics can be written to.
+const (
+	StatsDMetricsSink   = "statsd"
+	InfluxDBMetricsSink = "influxdb"
+	NoMetricsSink       = "none"
 )
 
 type Settings struct {
 	AppSettings
+	// Credentials are the admin credentials from before there were users. They are only used to create the first
+	// admin user.
 	auth.Credentials
 	ModTime time.Time `json:"modTime"`
 }
 
 type AppSettings struct {
-	TemperatureUnits    string `json:"temperatureUnits"`
-	AuthSecret          string `json:"authSecret"`
-	BrewfatherAPIUserID string `json:"brewfatherApiUserId,omitempty"`
-	BrewfatherAPIKey    string `json:"brewfatherApiKey,omitempty"`
-	BrewfatherLogURL    string `json:"brewfatherLogUrl,omitempty"`
-	InfluxDBURL         string `json:"influxDbUrl,omitempty"`
-	InfluxDBReadToken   string `json:"influxDbReadToken,omitempty"`
-	StatsDAddress       string `json:"statsDAddress,omitempty"`
+	TemperatureUnits    string         `json:"temperatureUnits"`
+	AuthSecret          string         `json:"authSecret"`
+	BrewfatherAPIUserID string         `json:"brewfatherApiUserId,omitempty"`
+	BrewfatherAPIKey    string         `json:"brewfatherApiKey,omitempty"`
+	BrewfatherLogURL    string         `json:"brewfatherLogUrl,omitempty"`
+	BrewfatherUpdates   bool           `json:"brewfatherUpdates,omitempty"`
+	InfluxDBURL         string         `json:"influxDbUrl,omitempty"`
+	InfluxDBReadToken   string         `json:"influxDbReadToken,omitempty"`
+	InfluxDBWriteToken  string         `json:"influxDbWriteToken,omitempty"`
+	InfluxDBOrg         string         `json:"influxDbOrg,omitempty"`
+	InfluxDBBucket      string         `json:"influxDbBucket,omitempty"`
+	StatsDAddress       string         `json:"statsDAddress,omitempty"`
+	MetricsSink         string         `json:"metricsSink,omitempty"`
+	Alerts              alert.Settings `json:"alerts"`
+	MQTT                mqtt.Settings  `json:"mqtt"`
+}
+
+// GetMetricsSink returns the metrics sink. Settings saved before the sink could be selected use StatsD if a StatsD
+// address is set.
+func (s AppSettings) GetMetricsSink() string {
+	if s.MetricsSink != "" {
+		return s.MetricsSink
+	}
+
+	if s.StatsDAddress != "" {
+		return StatsDMetricsSink
+	}
+
+	return NoMetricsSink
 }
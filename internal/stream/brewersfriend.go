@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	brewersFriendURL      = "https://log.brewersfriend.com/stream/"
+	brewersFriendInterval = 15 * time.Minute
+)
+
+var _ Streamer = (*BrewersFriend)(nil)
+
+// BrewersFriend is a Streamer that posts readings to a Brewer's Friend stream. Brewer's Friend accepts at most one
+// reading every 15 minutes.
+type BrewersFriend struct {
+	client *http.Client
+	url    string
+}
+
+type brewersFriendEntry struct {
+	Name        string   `json:"name"`
+	Beer        string   `json:"beer,omitempty"`
+	Temperature *float64 `json:"temp,omitempty"`
+	Ambient     *float64 `json:"ambient,omitempty"`
+	TempUnit    string   `json:"temp_unit"`
+	Gravity     *float64 `json:"gravity,omitempty"`
+	GravityUnit string   `json:"gravity_unit"`
+	Comment     string   `json:"comment,omitempty"`
+}
+
+func newBrewersFriend(config Config) (*BrewersFriend, error) {
+	url := config.URL
+
+	if url == "" {
+		if config.APIKey == "" {
+			return nil, ErrAPIKeyRequired
+		}
+
+		url = brewersFriendURL + config.APIKey
+	}
+
+	return &BrewersFriend{client: createHTTPClient(), url: url}, nil
+}
+
+func (b *BrewersFriend) Name() string {
+	return "Brewer's Friend"
+}
+
+func (b *BrewersFriend) Stream(ctx context.Context, reading *Reading) error {
+	data, err := json.Marshal(brewersFriendEntry{
+		Name:        reading.ChamberName,
+		Beer:        reading.Beer,
+		Temperature: reading.BeerTemperature,
+		Ambient:     reading.ExternalTemperature,
+		TempUnit:    "C",
+		Gravity:     reading.Gravity,
+		GravityUnit: "G",
+		Comment:     reading.Comment,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not marshal Brewer's Friend stream entry")
+	}
+
+	return post(ctx, b.client, b.url, nil, data)
+}
+
+func (b *BrewersFriend) Close() error {
+	return nil
+}
+
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return errors.Wrap(err, "could not create POST request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "could not POST to %s", req.URL.Host)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("could not POST to %s: %s", req.URL.Host, resp.Status)
+	}
+
+	return nil
+}
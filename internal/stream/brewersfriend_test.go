@@ -0,0 +1,60 @@
+package stream_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrewersFriend(t *testing.T) {
+	t.Parallel()
+	t.Run("brewersFriendStream", brewersFriendStream)
+	t.Run("brewersFriendStreamError", brewersFriendStreamError)
+}
+
+func brewersFriendStream(t *testing.T) {
+	t.Parallel()
+
+	server, requests := createTestServer(t, http.StatusOK)
+
+	s, err := stream.New(stream.Config{Type: stream.BrewersFriendType, URL: server.URL + "/stream/key"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Brewer's Friend", s.Name())
+
+	err = s.Stream(context.Background(), createTestReading())
+	assert.NoError(t, err)
+
+	r := <-requests
+	assert.Equal(t, "/stream/key", r.path)
+	assert.Equal(t, "application/json", r.headers.Get("Content-Type"))
+	assert.Equal(t, map[string]interface{}{
+		"name":         chamberName,
+		"beer":         beerName,
+		"temp":         19.5,
+		"ambient":      4.25,
+		"temp_unit":    "C",
+		"gravity":      1.048,
+		"gravity_unit": "G",
+		"comment":      "Chiller: off",
+	}, decode(t, r.body))
+
+	// Brewer's Friend only accepts a reading every 15 minutes
+	err = s.Stream(context.Background(), createTestReading())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 0)
+}
+
+func brewersFriendStreamError(t *testing.T) {
+	t.Parallel()
+
+	server, _ := createTestServer(t, http.StatusUnauthorized)
+
+	s, err := stream.New(stream.Config{Type: stream.BrewersFriendType, URL: server.URL})
+	assert.NoError(t, err)
+
+	err = s.Stream(context.Background(), createTestReading())
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
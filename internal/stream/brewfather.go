@@ -0,0 +1,61 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjaminbartels/zymurgauge/internal/brewfather"
+	"github.com/pkg/errors"
+)
+
+var _ Streamer = (*Brewfather)(nil)
+
+// Brewfather is a Streamer that logs readings to the Brewfather custom stream.
+type Brewfather struct {
+	service brewfather.Service
+}
+
+// NewBrewfather creates a new Brewfather streamer that logs readings using the given service.
+func NewBrewfather(service brewfather.Service) *Brewfather {
+	return &Brewfather{service: service}
+}
+
+func (b *Brewfather) Name() string {
+	return "Brewfather"
+}
+
+func (b *Brewfather) Stream(ctx context.Context, reading *Reading) error {
+	l := brewfather.LogEntry{
+		DeviceName:      reading.ChamberName,
+		Beer:            reading.Beer,
+		TemperatureUnit: "C",
+		GravityUnit:     "G",
+		Comment:         reading.Comment,
+	}
+
+	if reading.BeerTemperature != nil {
+		l.BeerTemperature = fmt.Sprintf("%f", *reading.BeerTemperature)
+	}
+
+	if reading.AuxiliaryTemperature != nil {
+		l.AuxiliaryTemperature = fmt.Sprintf("%f", *reading.AuxiliaryTemperature)
+	}
+
+	if reading.ExternalTemperature != nil {
+		l.ExternalTemperature = fmt.Sprintf("%f", *reading.ExternalTemperature)
+	}
+
+	if reading.Gravity != nil {
+		l.Gravity = fmt.Sprintf("%f", *reading.Gravity)
+	}
+
+	if err := b.service.Log(ctx, l); err != nil {
+		return errors.Wrap(err, "could not log to Brewfather")
+	}
+
+	return nil
+}
+
+func (b *Brewfather) Close() error {
+	return nil
+}
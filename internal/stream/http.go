@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+var _ Streamer = (*HTTP)(nil)
+
+// templateFuncs are available to templates. json renders a value as JSON, which renders
+// readings that are not available as null.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+
+		return string(data), err
+	},
+}
+
+// HTTP is a Streamer that posts readings to any platform that accepts JSON, such as a Grainfather custom device. The
+// body is rendered from a template or, if there is no template, is the Reading itself.
+type HTTP struct {
+	client   *http.Client
+	url      string
+	host     string
+	headers  map[string]string
+	template *template.Template
+}
+
+func newHTTP(config Config) (*HTTP, error) {
+	if config.URL == "" {
+		return nil, ErrURLRequired
+	}
+
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse url")
+	}
+
+	h := &HTTP{
+		client:  createHTTPClient(),
+		url:     config.URL,
+		host:    u.Host,
+		headers: config.Headers,
+	}
+
+	if config.Template != "" {
+		if h.template, err = parseTemplate("body", config.Template); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+func (h *HTTP) Name() string {
+	return "HTTP " + h.host
+}
+
+func (h *HTTP) Stream(ctx context.Context, reading *Reading) error {
+	data, err := render(h.template, reading)
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, h.client, h.url, h.headers, data)
+}
+
+func (h *HTTP) Close() error {
+	return nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s template", name)
+	}
+
+	return t, nil
+}
+
+// render executes t with the reading or, if t is nil, marshals the reading.
+func render(t *template.Template, reading *Reading) ([]byte, error) {
+	if t == nil {
+		data, err := json.Marshal(reading)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal reading")
+		}
+
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, reading); err != nil {
+		return nil, errors.Wrap(err, "could not execute template")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,78 @@
+package stream_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/benjaminbartels/zymurgauge/internal/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP(t *testing.T) {
+	t.Parallel()
+	t.Run("httpStreamJSON", httpStreamJSON)
+	t.Run("httpStreamTemplate", httpStreamTemplate)
+	t.Run("httpStreamError", httpStreamError)
+}
+
+func httpStreamJSON(t *testing.T) {
+	t.Parallel()
+
+	server, requests := createTestServer(t, http.StatusOK)
+
+	s, err := stream.New(stream.Config{
+		Type:    stream.HTTPType,
+		URL:     server.URL + "/log",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+	assert.NoError(t, err)
+
+	reading := createTestReading()
+
+	err = s.Stream(context.Background(), reading)
+	assert.NoError(t, err)
+
+	r := <-requests
+	assert.Equal(t, "/log", r.path)
+	assert.Equal(t, "Bearer token", r.headers.Get("Authorization"))
+
+	var actual stream.Reading
+	assert.NoError(t, json.Unmarshal(r.body, &actual))
+	assert.Equal(t, reading.ChamberID, actual.ChamberID)
+	assert.Equal(t, *reading.Gravity, *actual.Gravity)
+	assert.Nil(t, actual.AuxiliaryTemperature)
+}
+
+func httpStreamTemplate(t *testing.T) {
+	t.Parallel()
+
+	server, requests := createTestServer(t, http.StatusOK)
+
+	s, err := stream.New(stream.Config{
+		Type: stream.HTTPType,
+		URL:  server.URL,
+		Template: `{"name":{{json .ChamberName}},"temp":{{json .BeerTemperature}},` +
+			`"aux_temp":{{json .AuxiliaryTemperature}},"gravity":{{.Gravity}}}`,
+	})
+	assert.NoError(t, err)
+
+	err = s.Stream(context.Background(), createTestReading())
+	assert.NoError(t, err)
+
+	r := <-requests
+	assert.JSONEq(t, `{"name":"Chamber 1","temp":19.5,"aux_temp":null,"gravity":1.048}`, string(r.body))
+}
+
+func httpStreamError(t *testing.T) {
+	t.Parallel()
+
+	server, _ := createTestServer(t, http.StatusInternalServerError)
+
+	s, err := stream.New(stream.Config{Type: stream.HTTPType, URL: server.URL})
+	assert.NoError(t, err)
+
+	err = s.Stream(context.Background(), createTestReading())
+	assert.ErrorContains(t, err, "500 Internal Server Error")
+}
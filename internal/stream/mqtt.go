@@ -0,0 +1,133 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTopic   = "zymurgauge/{{.ChamberID}}/readings"
+	mqttTimeout    = 10 * time.Second
+	mqttQOS        = 1
+	disconnectWait = 250
+)
+
+var _ Streamer = (*MQTT)(nil)
+
+// MQTT is a Streamer that publishes readings to an MQTT broker. It connects to the broker when the first reading is
+// published.
+type MQTT struct {
+	client   mqtt.Client
+	broker   string
+	topic    *template.Template
+	template *template.Template
+	mutex    sync.Mutex
+}
+
+func newMQTT(config Config) (*MQTT, error) {
+	if config.URL == "" {
+		return nil, ErrURLRequired
+	}
+
+	topic := config.Topic
+	if topic == "" {
+		topic = defaultTopic
+	}
+
+	m := &MQTT{broker: config.URL}
+
+	var err error
+
+	if m.topic, err = parseTemplate("topic", topic); err != nil {
+		return nil, err
+	}
+
+	if config.Template != "" {
+		if m.template, err = parseTemplate("payload", config.Template); err != nil {
+			return nil, err
+		}
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(config.URL).
+		SetClientID(fmt.Sprintf("zymurgauge-%d", time.Now().UnixNano())).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetConnectTimeout(mqttTimeout).
+		SetAutoReconnect(true)
+
+	m.client = mqtt.NewClient(options)
+
+	return m, nil
+}
+
+func (m *MQTT) Name() string {
+	return "MQTT " + m.broker
+}
+
+func (m *MQTT) Stream(ctx context.Context, reading *Reading) error {
+	if err := m.connect(); err != nil {
+		return err
+	}
+
+	topic, err := render(m.topic, reading)
+	if err != nil {
+		return err
+	}
+
+	payload, err := render(m.template, reading)
+	if err != nil {
+		return err
+	}
+
+	token := m.client.Publish(string(topic), mqttQOS, false, payload)
+
+	select {
+	case <-token.Done():
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "could not publish reading")
+	}
+
+	if err := token.Error(); err != nil {
+		return errors.Wrap(err, "could not publish reading")
+	}
+
+	return nil
+}
+
+func (m *MQTT) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.client.IsConnected() {
+		m.client.Disconnect(disconnectWait)
+	}
+
+	return nil
+}
+
+func (m *MQTT) connect() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.client.IsConnected() {
+		return nil
+	}
+
+	token := m.client.Connect()
+	if !token.WaitTimeout(mqttTimeout) {
+		return errors.Errorf("timed out connecting to %s", m.broker)
+	}
+
+	if err := token.Error(); err != nil {
+		return errors.Wrapf(err, "could not connect to %s", m.broker)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package stream_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/stream"
+	"github.com/benjaminbartels/zymurgauge/internal/test/fakes"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const mqttTimeout = 5 * time.Second
+
+func TestMQTT(t *testing.T) {
+	t.Parallel()
+	t.Run("mqttStream", mqttStream)
+	t.Run("mqttStreamTopicTemplate", mqttStreamTopicTemplate)
+	t.Run("mqttStreamConnectError", mqttStreamConnectError)
+}
+
+func setupMQTTTest(t *testing.T, topic string) (*fakes.MQTTBroker, chan mqtt.Message) {
+	t.Helper()
+
+	broker, err := fakes.NewMQTTBroker()
+	assert.NoError(t, err)
+	t.Cleanup(broker.Close)
+
+	client := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(broker.URL).SetClientID("subscriber"))
+	token := client.Connect()
+	assert.True(t, token.WaitTimeout(mqttTimeout))
+	assert.NoError(t, token.Error())
+	t.Cleanup(func() { client.Disconnect(0) })
+
+	messages := make(chan mqtt.Message, 1)
+
+	token = client.Subscribe(topic, 1, func(_ mqtt.Client, m mqtt.Message) { messages <- m })
+	assert.True(t, token.WaitTimeout(mqttTimeout))
+	assert.NoError(t, token.Error())
+
+	return broker, messages
+}
+
+func mqttStream(t *testing.T) {
+	t.Parallel()
+
+	broker, messages := setupMQTTTest(t, "zymurgauge/"+chamberID+"/readings")
+
+	s, err := stream.New(stream.Config{Type: stream.MQTTType, URL: broker.URL})
+	assert.NoError(t, err)
+
+	defer s.Close()
+
+	err = s.Stream(context.Background(), createTestReading())
+	assert.NoError(t, err)
+
+	select {
+	case m := <-messages:
+		v := decode(t, m.Payload())
+		assert.Equal(t, chamberName, v["chamberName"])
+		assert.Equal(t, 19.5, v["beerTemperature"])
+	case <-time.After(mqttTimeout):
+		assert.Fail(t, "no message received")
+	}
+}
+
+func mqttStreamTopicTemplate(t *testing.T) {
+	t.Parallel()
+
+	broker, messages := setupMQTTTest(t, "brewery/Pale Ale")
+
+	s, err := stream.New(stream.Config{
+		Type:     stream.MQTTType,
+		URL:      broker.URL,
+		Topic:    "brewery/{{.Beer}}",
+		Template: "{{.Gravity}}",
+	})
+	assert.NoError(t, err)
+
+	defer s.Close()
+
+	err = s.Stream(context.Background(), createTestReading())
+	assert.NoError(t, err)
+
+	select {
+	case m := <-messages:
+		assert.Equal(t, "1.048", string(m.Payload()))
+	case <-time.After(mqttTimeout):
+		assert.Fail(t, "no message received")
+	}
+}
+
+func mqttStreamConnectError(t *testing.T) {
+	t.Parallel()
+
+	broker, err := fakes.NewMQTTBroker()
+	assert.NoError(t, err)
+	broker.Close()
+
+	s, err := stream.New(stream.Config{Type: stream.MQTTType, URL: broker.URL})
+	assert.NoError(t, err)
+
+	err = s.Stream(context.Background(), createTestReading())
+	assert.ErrorContains(t, err, "could not connect to")
+}
@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+)
+
+const (
+	BrewersFriendType = "brewersfriend"
+	HTTPType          = "http"
+	MQTTType          = "mqtt"
+
+	requestTimeout      = 10 * time.Second
+	dialTimeout         = 10 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+const (
+	ErrUnknownType     = Error("unknown stream type")
+	ErrURLRequired     = Error("url is required")
+	ErrAPIKeyRequired  = Error("api key is required")
+	ErrInvalidInterval = Error("interval cannot be negative")
+)
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Streamer sends the readings of a chamber to a brewing platform.
+type Streamer interface {
+	Name() string
+	Stream(ctx context.Context, reading *Reading) error
+	Close() error
+}
+
+// Reading is a snapshot of a chamber's readings. Temperatures are in Celsius and gravity is specific gravity.
+type Reading struct {
+	Time                 time.Time `json:"time"`
+	ChamberID            string    `json:"chamberId"`
+	ChamberName          string    `json:"chamberName"`
+	Beer                 string    `json:"beer,omitempty"`
+	BeerTemperature      *float64  `json:"beerTemperature,omitempty"`
+	AuxiliaryTemperature *float64  `json:"auxiliaryTemperature,omitempty"`
+	ExternalTemperature  *float64  `json:"externalTemperature,omitempty"`
+	Gravity              *float64  `json:"gravity,omitempty"`
+	SetPoint             *float64  `json:"setPoint,omitempty"`
+	Step                 string    `json:"step,omitempty"`
+	Comment              string    `json:"comment,omitempty"`
+}
+
+// Config configures a stream of a chamber. Which fields are used depends on Type.
+type Config struct {
+	Type string `json:"type"`
+	// URL is the endpoint readings are posted to or, for MQTT, the address of the broker such as tcp://host:1883. It
+	// defaults to the Brewer's Friend stream endpoint for the Brewer's Friend API key.
+	URL     string            `json:"url,omitempty"`
+	APIKey  string            `json:"apiKey,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Template is a text/template that renders the HTTP body or MQTT payload from a Reading. If it is empty the
+	// Reading is sent as JSON.
+	Template string `json:"template,omitempty"`
+	// Topic is a text/template that renders the MQTT topic from a Reading.
+	Topic    string `json:"topic,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Interval is the minimum number of seconds between readings. Zero uses the platform's default.
+	Interval int `json:"interval,omitempty"`
+}
+
+type OptionsFunc func(*options)
+
+type options struct {
+	clock clock.Clock
+}
+
+func SetClock(clock clock.Clock) OptionsFunc {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// New creates the Streamer described by config.
+func New(config Config, opts ...OptionsFunc) (Streamer, error) {
+	o := &options{clock: clock.NewRealClock()}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if config.Interval < 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	var (
+		streamer Streamer
+		interval = time.Duration(config.Interval) * time.Second
+		err      error
+	)
+
+	switch config.Type {
+	case BrewersFriendType:
+		streamer, err = newBrewersFriend(config)
+
+		if interval == 0 {
+			interval = brewersFriendInterval
+		}
+	case HTTPType:
+		streamer, err = newHTTP(config)
+	case MQTTType:
+		streamer, err = newMQTT(config)
+	default:
+		return nil, ErrUnknownType
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		streamer = &throttled{Streamer: streamer, interval: interval, clock: o.clock}
+	}
+
+	return streamer, nil
+}
+
+// throttled is a Streamer that drops readings that arrive within interval of the last reading that was sent.
+type throttled struct {
+	Streamer
+	interval time.Duration
+	clock    clock.Clock
+	lastSent time.Time
+	mutex    sync.Mutex
+}
+
+func (t *throttled) Stream(ctx context.Context, reading *Reading) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.clock.Now()
+	if !t.lastSent.IsZero() && now.Sub(t.lastSent) < t.interval {
+		return nil
+	}
+
+	if err := t.Streamer.Stream(ctx, reading); err != nil {
+		return err
+	}
+
+	t.lastSent = now
+
+	return nil
+}
+
+func createHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: dialTimeout,
+			}).DialContext,
+			TLSHandshakeTimeout: tlsHandshakeTimeout,
+		},
+	}
+}
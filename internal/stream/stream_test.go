@@ -0,0 +1,162 @@
+package stream_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	chamberID   = "96f58a65-03c0-49f3-83ed-1ceb2e6e3b0b"
+	chamberName = "Chamber 1"
+	beerName    = "Pale Ale"
+)
+
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *testClock) NewTimer(d time.Duration) *time.Timer {
+	return time.NewTimer(d)
+}
+
+func (c *testClock) add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+type request struct {
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+func createTestServer(t *testing.T, status int) (*httptest.Server, chan request) {
+	t.Helper()
+
+	requests := make(chan request, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{path: r.URL.Path, headers: r.Header, body: body}
+
+		w.WriteHeader(status)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+func createTestReading() *stream.Reading {
+	beerTemperature, externalTemperature, gravity, setPoint := 19.5, 4.25, 1.048, 20.0
+
+	return &stream.Reading{
+		Time:                time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC),
+		ChamberID:           chamberID,
+		ChamberName:         chamberName,
+		Beer:                beerName,
+		BeerTemperature:     &beerTemperature,
+		ExternalTemperature: &externalTemperature,
+		Gravity:             &gravity,
+		SetPoint:            &setPoint,
+		Step:                "Primary",
+		Comment:             "Chiller: off",
+	}
+}
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+
+	var v map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &v))
+
+	return v
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	t.Run("newUnknownType", newUnknownType)
+	t.Run("newInvalidConfigs", newInvalidConfigs)
+	t.Run("newThrottled", newThrottled)
+}
+
+func newUnknownType(t *testing.T) {
+	t.Parallel()
+
+	_, err := stream.New(stream.Config{Type: "unknown"})
+	assert.ErrorIs(t, err, stream.ErrUnknownType)
+}
+
+func newInvalidConfigs(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		config stream.Config
+		err    error
+	}{
+		"brewersFriendNoKey": {config: stream.Config{Type: stream.BrewersFriendType}, err: stream.ErrAPIKeyRequired},
+		"httpNoURL":          {config: stream.Config{Type: stream.HTTPType}, err: stream.ErrURLRequired},
+		"mqttNoURL":          {config: stream.Config{Type: stream.MQTTType}, err: stream.ErrURLRequired},
+		"negativeInterval": {
+			config: stream.Config{Type: stream.HTTPType, URL: "http://localhost", Interval: -1},
+			err:    stream.ErrInvalidInterval,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := stream.New(tc.config)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	_, err := stream.New(stream.Config{Type: stream.HTTPType, URL: "http://localhost", Template: "{{.Beer"})
+	assert.ErrorContains(t, err, "could not parse body template")
+}
+
+func newThrottled(t *testing.T) {
+	t.Parallel()
+
+	server, requests := createTestServer(t, http.StatusOK)
+	clock := &testClock{now: time.Now()}
+
+	s, err := stream.New(stream.Config{Type: stream.HTTPType, URL: server.URL, Interval: 60},
+		stream.SetClock(clock))
+	assert.NoError(t, err)
+
+	reading := createTestReading()
+
+	assert.NoError(t, s.Stream(context.Background(), reading))
+	assert.NoError(t, s.Stream(context.Background(), reading))
+	clock.add(time.Minute)
+	assert.NoError(t, s.Stream(context.Background(), reading))
+
+	assert.Len(t, requests, 2)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/benjaminbartels/zymurgauge/internal/device"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
 	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
@@ -39,7 +40,9 @@ type Controller struct {
 	cyclePeriod          time.Duration
 	chillerCooldown      time.Duration
 	logger               *logrus.Logger
-	setPoint             float64
+	clock                clock.Clock
+	ramp                 temperaturecontrol.Ramp
+	rampStartTime        time.Time
 	isRunning            bool
 	chillerOnStartTime   time.Time
 	chillerOffStartTime  time.Time
@@ -59,6 +62,7 @@ func NewController(thermometer device.Thermometer, chiller, heater device.Actuat
 		cyclePeriod:          defaultCyclePeriod,
 		chillerCooldown:      defaultChillerCooldown,
 		logger:               logger,
+		clock:                clock.NewRealClock(),
 	}
 
 	for _, option := range options {
@@ -70,6 +74,12 @@ func NewController(thermometer device.Thermometer, chiller, heater device.Actuat
 
 type OptionsFunc func(*Controller)
 
+func SetClock(clock clock.Clock) OptionsFunc {
+	return func(t *Controller) {
+		t.clock = clock
+	}
+}
+
 func CyclePeriod(cyclePeriod time.Duration) OptionsFunc {
 	return func(t *Controller) {
 		t.cyclePeriod = cyclePeriod
@@ -82,7 +92,13 @@ func ChillerCooldown(chillerCooldown time.Duration) OptionsFunc {
 	}
 }
 
+// Run starts the controller and holds the given set point until the context is canceled.
 func (c *Controller) Run(ctx context.Context, setPoint float64) error {
+	return c.RunRamp(ctx, temperaturecontrol.NewSetPoint(setPoint))
+}
+
+// RunRamp starts the controller and moves the set point along the given ramp until the context is canceled.
+func (c *Controller) RunRamp(ctx context.Context, ramp temperaturecontrol.Ramp) error {
 	c.runMutex.Lock()
 	if c.isRunning {
 		defer c.runMutex.Unlock()
@@ -90,7 +106,11 @@ func (c *Controller) Run(ctx context.Context, setPoint float64) error {
 		return ErrAlreadyRunning
 	}
 
-	c.logger.Debugf("Running hysteresis controller with set point: %.2f", setPoint)
+	c.logger.Debugf("Running hysteresis controller with set point: %.2f", ramp.To)
+
+	if ramp.Rate > 0 {
+		c.logger.Debugf("Ramping set point from %.2f at %.2f degrees per hour", ramp.From, ramp.Rate)
+	}
 
 	if c.thermometer == nil {
 		defer c.runMutex.Unlock()
@@ -104,7 +124,8 @@ func (c *Controller) Run(ctx context.Context, setPoint float64) error {
 		return ErrActuatorIsNil
 	}
 
-	c.setPoint = setPoint
+	c.ramp = ramp
+	c.rampStartTime = c.clock.Now()
 
 	c.isRunning = true
 
@@ -124,13 +145,14 @@ func (c *Controller) startCycle(ctx context.Context) error {
 		temperature, err := c.thermometer.GetTemperature()
 		if err != nil {
 			c.logger.WithError(err).Error("could not read thermometer")
-			<-time.After(errorWaitPeriod)
+			<-c.clock.NewTimer(errorWaitPeriod).C
 
 			continue
 		}
 
-		upperBound := c.setPoint + c.chillingDifferential
-		lowerBound := c.setPoint - c.heatingDifferential
+		setPoint := c.GetSetPoint()
+		upperBound := setPoint + c.chillingDifferential
+		lowerBound := setPoint - c.heatingDifferential
 
 		if temperature >= upperBound {
 			c.logger.Debugf("Temperature %.2f is >= upperbound %.2f", temperature, upperBound)
@@ -142,13 +164,13 @@ func (c *Controller) startCycle(ctx context.Context) error {
 			c.heaterOn()
 		}
 
-		if temperature <= c.setPoint {
-			c.logger.Debugf("Temperature %.2f is <= setpoint %.2f", temperature, c.setPoint)
+		if temperature <= setPoint {
+			c.logger.Debugf("Temperature %.2f is <= setpoint %.2f", temperature, setPoint)
 			c.chillerOff()
 		}
 
-		if temperature > c.setPoint {
-			c.logger.Debugf("Temperature %.2f is > setpoint %.2f", temperature, c.setPoint)
+		if temperature > setPoint {
+			c.logger.Debugf("Temperature %.2f is > setpoint %.2f", temperature, setPoint)
 			c.heaterOff()
 		}
 
@@ -158,18 +180,27 @@ func (c *Controller) startCycle(ctx context.Context) error {
 	}
 }
 
+// GetSetPoint returns the current effective set point. While ramping, this is somewhere between the start and the
+// target of the ramp.
+func (c *Controller) GetSetPoint() float64 {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	return c.ramp.SetPoint(c.clock.Since(c.rampStartTime))
+}
+
 func (c *Controller) chillerOn() {
 	cooldownOverTime := c.chillerOffStartTime.Add(c.chillerCooldown)
 
-	if time.Now().After(cooldownOverTime) {
+	if c.clock.Now().After(cooldownOverTime) {
 		if err := c.chiller.On(); err != nil {
 			c.logger.WithError(err).Error("could not turn chiller actuator on")
 		} else {
 			c.logger.Debug("Chiller on")
-			c.chillerOnStartTime = time.Now()
+			c.chillerOnStartTime = c.clock.Now()
 		}
 	} else {
-		c.logger.Debugf("Cannot turn chiller on for another %s", time.Until(cooldownOverTime))
+		c.logger.Debugf("Cannot turn chiller on for another %s", cooldownOverTime.Sub(c.clock.Now()))
 	}
 }
 
@@ -178,7 +209,7 @@ func (c *Controller) chillerOff() {
 		c.logger.WithError(err).Error(err, "could not turn chiller actuator off")
 	} else if !c.chillerOnStartTime.IsZero() {
 		c.logger.Debug("Chiller off")
-		c.chillerOffStartTime = time.Now()
+		c.chillerOffStartTime = c.clock.Now()
 	}
 }
 
@@ -199,7 +230,7 @@ func (c *Controller) heaterOff() {
 }
 
 func (c *Controller) wait(ctx context.Context) bool {
-	timer := time.NewTimer(c.cyclePeriod)
+	timer := c.clock.NewTimer(c.cyclePeriod)
 	defer timer.Stop()
 
 	select {
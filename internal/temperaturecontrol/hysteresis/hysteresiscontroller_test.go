@@ -7,7 +7,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
 	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/hysteresis"
+	"github.com/benjaminbartels/zymurgauge/internal/test/fakes"
 	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
 	"github.com/sirupsen/logrus"
 	logtest "github.com/sirupsen/logrus/hooks/test"
@@ -258,6 +260,50 @@ func TestChillerCooldown(t *testing.T) {
 	assert.True(t, logContains(hook.AllEntries(), logrus.DebugLevel, cooldownLogMsg))
 }
 
+func TestChillerCooldownWithClock(t *testing.T) {
+	t.Parallel()
+
+	l, hook := logtest.NewNullLogger()
+	l.SetLevel(logrus.DebugLevel)
+
+	thermometerMock := &mocks.Thermometer{}
+	thermometerMock.On("GetTemperature").Once().Return(20.0, nil)
+	thermometerMock.On("GetTemperature").Once().Return(5.0, nil)
+	thermometerMock.On("GetTemperature").Return(20.0, nil)
+
+	onCh := make(chan struct{}, 2)
+
+	chillerMock := &mocks.Actuator{}
+	chillerMock.Mock.On("On").Return(nil).Run(func(args mock.Arguments) {
+		onCh <- struct{}{}
+	})
+	chillerMock.Mock.On("Off").Return(nil)
+
+	heaterMock := &mocks.Actuator{}
+	heaterMock.Mock.On("On").Return(nil)
+	heaterMock.Mock.On("Off").Return(nil)
+
+	// 10 minutes of cooldown pass in 600ms
+	ctlr := hysteresis.NewController(thermometerMock, chillerMock, heaterMock, chillingDifferential, heatingDifferential,
+		l, hysteresis.CyclePeriod(time.Minute), hysteresis.ChillerCooldown(10*time.Minute),
+		hysteresis.SetClock(fakes.NewDilatedClock(1000)))
+
+	ctx, stop := context.WithCancel(context.Background())
+
+	go func() {
+		<-onCh
+		start := time.Now()
+		<-onCh
+		assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+		stop()
+	}()
+
+	err := ctlr.Run(ctx, 10.0)
+	assert.NoError(t, err)
+
+	assert.True(t, logContains(hook.AllEntries(), logrus.DebugLevel, cooldownLogMsg))
+}
+
 func TestRunAlreadyRunningError(t *testing.T) {
 	t.Parallel()
 
@@ -294,6 +340,50 @@ func TestRunAlreadyRunningError(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRunRamp(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	ctx, stop := context.WithCancel(context.Background())
+
+	thermometerMock := &mocks.Thermometer{}
+	thermometerMock.On("GetTemperature").Return(25.0, nil)
+
+	chillerCh := make(chan float64, 1)
+
+	var ctrl *hysteresis.Controller
+
+	chillerMock := &mocks.Actuator{}
+	chillerMock.Mock.On("On").Return(nil).Run(func(args mock.Arguments) {
+		select {
+		case chillerCh <- ctrl.GetSetPoint():
+		default:
+		}
+	})
+	chillerMock.Mock.On("Off").Return(nil)
+
+	heaterMock := &mocks.Actuator{}
+	heaterMock.Mock.On("Off").Return(nil)
+
+	ctrl = hysteresis.NewController(thermometerMock, chillerMock, heaterMock, chillingDifferential,
+		heatingDifferential, l, hysteresis.CyclePeriod(10*time.Millisecond))
+
+	// 10 degrees per second
+	ramp := temperaturecontrol.Ramp{From: 25, To: 15, Rate: 36000}
+
+	go func() {
+		// the chiller should only turn on once the set point has ramped down past the chilling differential
+		setPoint := <-chillerCh
+		assert.LessOrEqual(t, setPoint, 25-chillingDifferential)
+
+		assert.Eventually(t, func() bool { return ctrl.GetSetPoint() == 15 }, 5*time.Second, 10*time.Millisecond)
+		stop()
+	}()
+
+	err := ctrl.RunRamp(ctx, ramp)
+	assert.NoError(t, err)
+}
+
 func TestThermometerIsNilError(t *testing.T) {
 	t.Parallel()
 
@@ -4,4 +4,6 @@ import "context"
 
 type TemperatureController interface {
 	Run(ctx context.Context, setPoint float64) error
+	RunRamp(ctx context.Context, ramp Ramp) error
+	GetSetPoint() float64
 }
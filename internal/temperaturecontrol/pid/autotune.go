@@ -0,0 +1,327 @@
+package pid
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/device"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ZieglerNichols TuningRule = "ziegler-nichols"
+	TyreusLuyben   TuningRule = "tyreus-luyben"
+
+	// relayAmplitude is half of the relay's peak to peak output, expressed in the same units as the DualController's
+	// output, where 100 is full heating and -100 is full chilling.
+	relayAmplitude             float64       = 100
+	defaultTuneSampleInterval  time.Duration = 1 * time.Minute
+	defaultTuneNoiseBand       float64       = 0.1
+	defaultTuneCycles                        = 3
+	defaultTuneTimeout         time.Duration = 24 * time.Hour
+	zieglerNicholsKP                         = 0.6
+	zieglerNicholsTI                         = 0.5
+	zieglerNicholsTD                         = 0.125
+	tyreusLuybenKP                           = 1 / 2.2
+	tyreusLuybenTI                           = 2.2
+	tyreusLuybenTD                           = 1 / 6.3
+	describingFunctionConstant               = 4 / math.Pi
+
+	ErrInvalidTuningRule = Error("invalid tuning rule")
+	ErrTuningTimedOut    = Error("auto-tune did not produce a stable oscillation in time")
+)
+
+// TuningRule is the set of formulas used to derive PID gains from the ultimate gain and period.
+type TuningRule string
+
+// TuningResult contains the measurements of a relay auto-tune and the PID gains derived from them.
+type TuningResult struct {
+	UltimateGain   float64
+	UltimatePeriod time.Duration
+	Amplitude      float64
+	KP             float64
+	KI             float64
+	KD             float64
+}
+
+// AutoTuner finds PID gains using relay feedback. It drives the chiller and heater as a relay, fully chilling when the
+// temperature rises above the set point and fully heating when it falls below it, which causes the temperature to
+// oscillate around the set point. The period and amplitude of the oscillation are used to compute the ultimate gain
+// and period of the chamber, from which the gains are derived.
+type AutoTuner struct {
+	thermometer       device.Thermometer
+	chiller           device.Actuator
+	heater            device.Actuator
+	sampleInterval    time.Duration
+	noiseBand         float64
+	cycles            int
+	timeout           time.Duration
+	chillerMinOffTime time.Duration
+	clock             clock.Clock
+	logger            *logrus.Logger
+}
+
+func NewAutoTuner(thermometer device.Thermometer, chiller, heater device.Actuator, logger *logrus.Logger,
+	options ...AutoTunerOptionsFunc,
+) *AutoTuner {
+	a := &AutoTuner{
+		thermometer:       thermometer,
+		chiller:           chiller,
+		heater:            heater,
+		sampleInterval:    defaultTuneSampleInterval,
+		noiseBand:         defaultTuneNoiseBand,
+		cycles:            defaultTuneCycles,
+		timeout:           defaultTuneTimeout,
+		chillerMinOffTime: defaultChillerMinOffTime,
+		clock:             clock.NewRealClock(),
+		logger:            logger,
+	}
+
+	for _, option := range options {
+		option(a)
+	}
+
+	if a.cycles < 1 {
+		a.cycles = 1
+	}
+
+	return a
+}
+
+type AutoTunerOptionsFunc func(*AutoTuner)
+
+func SetAutoTunerClock(clock clock.Clock) AutoTunerOptionsFunc {
+	return func(a *AutoTuner) {
+		a.clock = clock
+	}
+}
+
+// SampleInterval sets how often the temperature is read.
+func SampleInterval(interval time.Duration) AutoTunerOptionsFunc {
+	return func(a *AutoTuner) {
+		a.sampleInterval = interval
+	}
+}
+
+// NoiseBand sets how far, in degrees, the temperature must move past the set point before the relay switches.
+func NoiseBand(noiseBand float64) AutoTunerOptionsFunc {
+	return func(a *AutoTuner) {
+		a.noiseBand = noiseBand
+	}
+}
+
+// Cycles sets the number of oscillations that are measured, which is at least one. An extra, first oscillation is
+// always discarded.
+func Cycles(cycles int) AutoTunerOptionsFunc {
+	return func(a *AutoTuner) {
+		a.cycles = cycles
+	}
+}
+
+// Timeout sets the maximum amount of time the auto-tune can run for.
+func Timeout(timeout time.Duration) AutoTunerOptionsFunc {
+	return func(a *AutoTuner) {
+		a.timeout = timeout
+	}
+}
+
+// TunerChillerMinOffTime sets the minimum amount of time the chiller must be off before it can be turned on again.
+func TunerChillerMinOffTime(minOffTime time.Duration) AutoTunerOptionsFunc {
+	return func(a *AutoTuner) {
+		a.chillerMinOffTime = minOffTime
+	}
+}
+
+type oscillation struct {
+	period    time.Duration
+	amplitude float64
+}
+
+// Tune oscillates the temperature around the given set point until enough oscillations have been measured and returns
+// the gains computed with the given rule. The chiller and heater are turned off when Tune returns.
+func (a *AutoTuner) Tune(ctx context.Context, setPoint float64, rule TuningRule) (*TuningResult, error) {
+	if rule != ZieglerNichols && rule != TyreusLuyben {
+		return nil, ErrInvalidTuningRule
+	}
+
+	if a.thermometer == nil {
+		return nil, ErrThermometerIsNil
+	}
+
+	if a.chiller == nil || a.heater == nil {
+		return nil, ErrActuatorIsNil
+	}
+
+	a.logger.Debugf("Auto-tuning around set point %.2f using %s", setPoint, rule)
+
+	oscillations, err := a.oscillate(ctx, setPoint)
+
+	if offErr := a.allOff(); offErr != nil {
+		err = multierror.Append(err, offErr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return computeGains(oscillations, a.noiseBand, rule), nil
+}
+
+//nolint:cyclop // Breaking up the relay loop would make it harder to follow
+func (a *AutoTuner) oscillate(ctx context.Context, setPoint float64) ([]oscillation, error) {
+	var (
+		oscillations   []oscillation
+		isChilling     bool
+		cycleStartTime time.Time
+		chillerOffTime time.Time
+		minTemperature = math.Inf(1)
+		maxTemperature = math.Inf(-1)
+		startTime      = a.clock.Now()
+	)
+
+	for len(oscillations) <= a.cycles {
+		if a.clock.Since(startTime) > a.timeout {
+			return nil, ErrTuningTimedOut
+		}
+
+		temperature, err := a.thermometer.GetTemperature()
+		if err != nil {
+			a.logger.WithError(err).Error("could not read thermometer")
+		} else {
+			minTemperature = math.Min(minTemperature, temperature)
+			maxTemperature = math.Max(maxTemperature, temperature)
+
+			switch {
+			case !isChilling && temperature > setPoint+a.noiseBand:
+				isChilling = true
+
+				// each switch to chilling marks the start of a new oscillation
+				if !cycleStartTime.IsZero() {
+					o := oscillation{
+						period:    a.clock.Since(cycleStartTime),
+						amplitude: (maxTemperature - minTemperature) / 2, //nolint:gomnd // half of peak to peak
+					}
+					oscillations = append(oscillations, o)
+
+					a.logger.Debugf("Auto-tune oscillation %d has period %s and amplitude %.4f", len(oscillations),
+						o.period, o.amplitude)
+				}
+
+				cycleStartTime = a.clock.Now()
+				minTemperature, maxTemperature = temperature, temperature
+			case isChilling && temperature < setPoint-a.noiseBand:
+				isChilling = false
+				chillerOffTime = a.clock.Now()
+			}
+
+			if err := a.setRelay(isChilling, chillerOffTime); err != nil {
+				a.logger.WithError(err).Error("could not set auto-tune relay")
+			}
+		}
+
+		if didComplete := a.wait(ctx); !didComplete {
+			return nil, errors.Wrap(ctx.Err(), "auto-tune was canceled")
+		}
+	}
+
+	// the first oscillation is discarded because it includes the time taken to reach the set point
+	return oscillations[1:], nil
+}
+
+func (a *AutoTuner) setRelay(isChilling bool, chillerOffTime time.Time) error {
+	if !isChilling {
+		if err := a.chiller.Off(); err != nil {
+			return errors.Wrap(err, "could not turn chiller off")
+		}
+
+		return errors.Wrap(a.heater.On(), "could not turn heater on")
+	}
+
+	if err := a.heater.Off(); err != nil {
+		return errors.Wrap(err, "could not turn heater off")
+	}
+
+	if !chillerOffTime.IsZero() && a.clock.Since(chillerOffTime) < a.chillerMinOffTime {
+		return nil
+	}
+
+	return errors.Wrap(a.chiller.On(), "could not turn chiller on")
+}
+
+func (a *AutoTuner) allOff() error {
+	var result error
+
+	if err := a.chiller.Off(); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "could not turn chiller off"))
+	}
+
+	if err := a.heater.Off(); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "could not turn heater off"))
+	}
+
+	return result
+}
+
+func (a *AutoTuner) wait(ctx context.Context) bool {
+	timer := a.clock.NewTimer(a.sampleInterval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func computeGains(oscillations []oscillation, noiseBand float64, rule TuningRule) *TuningResult {
+	var (
+		period    time.Duration
+		amplitude float64
+	)
+
+	for _, o := range oscillations {
+		period += o.period
+		amplitude += o.amplitude
+	}
+
+	period /= time.Duration(len(oscillations))
+	amplitude /= float64(len(oscillations))
+
+	// account for the relay's noise band, which delays each switch
+	effectiveAmplitude := amplitude
+	if amplitude > noiseBand {
+		effectiveAmplitude = math.Sqrt(amplitude*amplitude - noiseBand*noiseBand)
+	}
+
+	ultimateGain := describingFunctionConstant * relayAmplitude / effectiveAmplitude
+	ultimatePeriod := period.Seconds()
+
+	result := &TuningResult{
+		UltimateGain:   ultimateGain,
+		UltimatePeriod: period,
+		Amplitude:      amplitude,
+	}
+
+	var integralTime, derivativeTime float64
+
+	switch rule {
+	case ZieglerNichols:
+		result.KP = zieglerNicholsKP * ultimateGain
+		integralTime = zieglerNicholsTI * ultimatePeriod
+		derivativeTime = zieglerNicholsTD * ultimatePeriod
+	case TyreusLuyben:
+		result.KP = tyreusLuybenKP * ultimateGain
+		integralTime = tyreusLuybenTI * ultimatePeriod
+		derivativeTime = tyreusLuybenTD * ultimatePeriod
+	}
+
+	result.KI = result.KP / integralTime
+	result.KD = result.KP * derivativeTime
+
+	return result
+}
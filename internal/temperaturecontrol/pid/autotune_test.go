@@ -0,0 +1,128 @@
+package pid_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/cmd/zymsim/simulator"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
+	"github.com/benjaminbartels/zymurgauge/internal/test/fakes"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// one hour of dilated time passes in 100ms.
+	tuneMultiplier  = 36000
+	tuneStartTemp   = 20.0
+	tuneSetPoint    = 18.0
+	tuneTestTimeout = 10 * time.Second
+)
+
+func TestAutoTune(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		rule              pid.TuningRule
+		integralFactor    float64
+		derivativeFactor  float64
+		proportionalRatio float64
+	}{
+		{name: "zieglerNichols", rule: pid.ZieglerNichols, integralFactor: 0.5, derivativeFactor: 0.125,
+			proportionalRatio: 0.6},
+		{name: "tyreusLuyben", rule: pid.TyreusLuyben, integralFactor: 2.2, derivativeFactor: 1 / 6.3,
+			proportionalRatio: 1 / 2.2},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, _ := logtest.NewNullLogger()
+			clock := fakes.NewDilatedClock(tuneMultiplier)
+			sim := simulator.New(tuneStartTemp)
+
+			ctx, stop := context.WithTimeout(context.Background(), tuneTestTimeout)
+			defer stop()
+
+			go sim.Run(ctx, clock)
+
+			tuner := pid.NewAutoTuner(sim.Thermometer, sim.Chiller, sim.Heater, l, pid.SetAutoTunerClock(clock))
+
+			result, err := tuner.Tune(ctx, tuneSetPoint, tc.rule)
+			assert.NoError(t, err)
+
+			// the simulated chamber oscillates roughly every 30 minutes by about 0.2°C
+			assert.InDelta(t, 30*time.Minute, result.UltimatePeriod, float64(10*time.Minute))
+			assert.InDelta(t, 0.2, result.Amplitude, 0.1)
+
+			period := result.UltimatePeriod.Seconds()
+			assert.InDelta(t, tc.proportionalRatio*result.UltimateGain, result.KP, 0.0001)
+			assert.InDelta(t, result.KP/(tc.integralFactor*period), result.KI, 0.0001)
+			assert.InDelta(t, result.KP*tc.derivativeFactor*period, result.KD, 0.0001)
+
+			assert.False(t, sim.Chiller.IsOn())
+			assert.False(t, sim.Heater.IsOn())
+		})
+	}
+}
+
+func TestAutoTuneTimeout(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	clock := fakes.NewDilatedClock(tuneMultiplier)
+	sim := simulator.New(tuneStartTemp)
+
+	ctx, stop := context.WithTimeout(context.Background(), tuneTestTimeout)
+	defer stop()
+
+	go sim.Run(ctx, clock)
+
+	tuner := pid.NewAutoTuner(sim.Thermometer, sim.Chiller, sim.Heater, l, pid.SetAutoTunerClock(clock),
+		pid.Timeout(1*time.Hour))
+
+	_, err := tuner.Tune(ctx, tuneSetPoint, pid.ZieglerNichols)
+	assert.ErrorIs(t, err, pid.ErrTuningTimedOut)
+}
+
+func TestAutoTuneCanceled(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	sim := simulator.New(tuneStartTemp)
+
+	ctx, stop := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer stop()
+
+	tuner := pid.NewAutoTuner(sim.Thermometer, sim.Chiller, sim.Heater, l)
+
+	_, err := tuner.Tune(ctx, tuneSetPoint, pid.TyreusLuyben)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, sim.Heater.IsOn())
+}
+
+func TestAutoTuneErrors(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	thermometerMock := &mocks.Thermometer{}
+	actuatorMock := &mocks.Actuator{}
+
+	tuner := pid.NewAutoTuner(thermometerMock, actuatorMock, actuatorMock, l)
+	_, err := tuner.Tune(context.Background(), tuneSetPoint, "guess")
+	assert.ErrorIs(t, err, pid.ErrInvalidTuningRule)
+
+	tuner = pid.NewAutoTuner(nil, actuatorMock, actuatorMock, l)
+	_, err = tuner.Tune(context.Background(), tuneSetPoint, pid.ZieglerNichols)
+	assert.ErrorIs(t, err, pid.ErrThermometerIsNil)
+
+	tuner = pid.NewAutoTuner(thermometerMock, nil, actuatorMock, l)
+	_, err = tuner.Tune(context.Background(), tuneSetPoint, pid.ZieglerNichols)
+	assert.ErrorIs(t, err, pid.ErrActuatorIsNil)
+}
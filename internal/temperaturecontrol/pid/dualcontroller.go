@@ -0,0 +1,318 @@
+package pid
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/device"
+	"github.com/benjaminbartels/zymurgauge/internal/platform/clock"
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
+	"github.com/felixge/pidctrl"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var _ temperaturecontrol.TemperatureController = (*DualController)(nil)
+
+const (
+	dualPIDMin               float64       = -100
+	dualPIDMax               float64       = 100
+	defaultDualCyclePeriod   time.Duration = 10 * time.Minute
+	defaultChillerMinOffTime time.Duration = 5 * time.Minute
+)
+
+// DualController is a PID controller that drives both a chiller and a heater. A positive PID output is used as the
+// heater's duty cycle and a negative output as the chiller's duty cycle. The chiller is never turned back on until it
+// has been off for at least the chiller minimum off time, protecting the compressor from short cycling.
+type DualController struct {
+	thermometer       device.Thermometer
+	chiller           device.Actuator
+	heater            device.Actuator
+	pid               *pidctrl.PIDController
+	cyclePeriod       time.Duration
+	chillerMinOffTime time.Duration
+	chillerIsOn       bool
+	chillerOffTime    time.Time
+	clock             clock.Clock
+	logger            *logrus.Logger
+	ramp              temperaturecontrol.Ramp
+	rampStartTime     time.Time
+	isRunning         bool
+	runMutex          sync.Mutex
+}
+
+func NewDualController(thermometer device.Thermometer, chiller, heater device.Actuator, kP, kI, kD float64,
+	logger *logrus.Logger, options ...DualOptionsFunc,
+) *DualController {
+	c := &DualController{
+		thermometer:       thermometer,
+		chiller:           chiller,
+		heater:            heater,
+		cyclePeriod:       defaultDualCyclePeriod,
+		chillerMinOffTime: defaultChillerMinOffTime,
+		clock:             clock.NewRealClock(),
+		logger:            logger,
+		pid:               newDualPID(kP, kI, kD),
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+type DualOptionsFunc func(*DualController)
+
+func SetDualClock(clock clock.Clock) DualOptionsFunc {
+	return func(c *DualController) {
+		c.clock = clock
+	}
+}
+
+// DualCyclePeriod sets the duration of the chiller's and heater's PWM cycle.
+func DualCyclePeriod(period time.Duration) DualOptionsFunc {
+	return func(c *DualController) {
+		c.cyclePeriod = period
+	}
+}
+
+// ChillerMinOffTime sets the minimum amount of time the chiller must be off before it can be turned on again.
+func ChillerMinOffTime(minOffTime time.Duration) DualOptionsFunc {
+	return func(c *DualController) {
+		c.chillerMinOffTime = minOffTime
+	}
+}
+
+// Run starts the controller and holds the given set point until the context is canceled.
+func (c *DualController) Run(ctx context.Context, setPoint float64) error {
+	return c.RunRamp(ctx, temperaturecontrol.NewSetPoint(setPoint))
+}
+
+// RunRamp starts the controller and moves the set point along the given ramp until the context is canceled.
+func (c *DualController) RunRamp(ctx context.Context, ramp temperaturecontrol.Ramp) error {
+	c.runMutex.Lock()
+	if c.isRunning {
+		defer c.runMutex.Unlock()
+
+		return ErrAlreadyRunning
+	}
+
+	if c.thermometer == nil {
+		defer c.runMutex.Unlock()
+
+		return ErrThermometerIsNil
+	}
+
+	if c.chiller == nil || c.heater == nil {
+		defer c.runMutex.Unlock()
+
+		return ErrActuatorIsNil
+	}
+
+	c.logger.Debugf("Running dual PID controller with set point: %.2f", ramp.To)
+
+	c.ramp = ramp
+	c.rampStartTime = c.clock.Now()
+
+	// the integral accumulated during a previous run does not apply to the new set point
+	c.pid = newDualPID(c.pid.PID())
+	c.pid.Set(ramp.SetPoint(0))
+
+	c.isRunning = true
+
+	c.runMutex.Unlock()
+
+	return c.startCycle(ctx)
+}
+
+func (c *DualController) startCycle(ctx context.Context) error {
+	lastUpdateTime := c.clock.Now()
+
+	for {
+		temperature, err := c.thermometer.GetTemperature()
+		if err != nil {
+			c.logger.WithError(err).Error("could not read thermometer")
+
+			if didComplete := c.wait(ctx, errorWaitPeriod); !didComplete {
+				return c.quit()
+			}
+
+			continue
+		}
+
+		c.pid.Set(c.GetSetPoint())
+
+		since := c.clock.Since(lastUpdateTime)
+		output := c.pid.UpdateDuration(temperature, since)
+		lastUpdateTime = c.clock.Now()
+
+		c.logger.Debugf("Current temperature is %.4f°C, set point is %.4f°C, output is %.2f%%", temperature,
+			c.pid.Get(), output)
+
+		var didComplete bool
+
+		if output < 0 {
+			c.heaterOff()
+			didComplete = c.chillerCycle(ctx, -output)
+		} else {
+			if err := c.chillerOff(); err != nil {
+				c.logger.Error(err)
+			}
+
+			didComplete = c.heaterCycle(ctx, output)
+		}
+
+		if !didComplete {
+			return c.quit()
+		}
+	}
+}
+
+// chillerCycle runs a single PWM cycle of the chiller. It returns false if the context was canceled.
+func (c *DualController) chillerCycle(ctx context.Context, dutyCycle float64) bool {
+	if remaining := c.chillerCooldownRemaining(); !c.chillerIsOn && remaining > 0 {
+		c.logger.Debugf("Cannot turn chiller on for another %s", remaining)
+
+		dutyCycle = 0
+	}
+
+	dutyTime, waitTime := c.getDutyTimes("Chiller", dutyCycle)
+
+	if dutyTime > 0 {
+		if err := c.chiller.On(); err != nil {
+			c.logger.WithError(err).Error("could not turn chiller on")
+
+			return c.wait(ctx, errorWaitPeriod)
+		}
+
+		c.chillerIsOn = true
+
+		if didComplete := c.wait(ctx, dutyTime); !didComplete {
+			return false
+		}
+	}
+
+	if waitTime > 0 {
+		if err := c.chillerOff(); err != nil {
+			c.logger.Error(err)
+		}
+
+		return c.wait(ctx, waitTime)
+	}
+
+	return true
+}
+
+// heaterCycle runs a single PWM cycle of the heater. It returns false if the context was canceled.
+func (c *DualController) heaterCycle(ctx context.Context, dutyCycle float64) bool {
+	dutyTime, waitTime := c.getDutyTimes("Heater", dutyCycle)
+
+	if dutyTime > 0 {
+		if err := c.heater.On(); err != nil {
+			c.logger.WithError(err).Error("could not turn heater on")
+
+			return c.wait(ctx, errorWaitPeriod)
+		}
+
+		if didComplete := c.wait(ctx, dutyTime); !didComplete {
+			return false
+		}
+	}
+
+	if waitTime > 0 {
+		c.heaterOff()
+
+		return c.wait(ctx, waitTime)
+	}
+
+	return true
+}
+
+func (c *DualController) getDutyTimes(name string, dutyCycle float64) (time.Duration, time.Duration) {
+	dutyTime := time.Duration(float64(c.cyclePeriod.Nanoseconds()) * dutyCycle / dutyTimeDivisor)
+	waitTime := c.cyclePeriod - dutyTime
+
+	c.logger.Debugf("%s dutyCycle is %.2f%%, dutyTime is %s, waitTime is %s", name, dutyCycle, dutyTime, waitTime)
+
+	return dutyTime, waitTime
+}
+
+// chillerOff turns the chiller off and, if it was on, records when so that the chiller minimum off time is kept, even
+// across runs.
+func (c *DualController) chillerOff() error {
+	if err := c.chiller.Off(); err != nil {
+		return errors.Wrap(err, "could not turn chiller off")
+	}
+
+	if c.chillerIsOn {
+		c.chillerIsOn = false
+		c.chillerOffTime = c.clock.Now()
+	}
+
+	return nil
+}
+
+func (c *DualController) heaterOff() {
+	if err := c.heater.Off(); err != nil {
+		c.logger.WithError(err).Error("could not turn heater off")
+	}
+}
+
+func (c *DualController) chillerCooldownRemaining() time.Duration {
+	if c.chillerOffTime.IsZero() {
+		return 0
+	}
+
+	return c.chillerMinOffTime - c.clock.Since(c.chillerOffTime)
+}
+
+// GetSetPoint returns the current effective set point. While ramping, this is somewhere between the start and the
+// target of the ramp.
+func (c *DualController) GetSetPoint() float64 {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	return c.ramp.SetPoint(c.clock.Since(c.rampStartTime))
+}
+
+func (c *DualController) wait(ctx context.Context, waitTime time.Duration) bool {
+	timer := c.clock.NewTimer(waitTime)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		c.runMutex.Lock()
+		defer c.runMutex.Unlock()
+		c.isRunning = false
+
+		return false
+	}
+}
+
+func (c *DualController) quit() error {
+	c.logger.Debug("Dual PID controller quiting")
+
+	var result error
+
+	if err := c.chillerOff(); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "could not turn chiller off while quiting"))
+	}
+
+	if err := c.heater.Off(); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "could not turn heater off while quiting"))
+	}
+
+	return result
+}
+
+func newDualPID(kP, kI, kD float64) *pidctrl.PIDController {
+	pid := pidctrl.NewPIDController(kP, kI, kD)
+	pid.SetOutputLimits(dualPIDMin, dualPIDMax)
+
+	return pid
+}
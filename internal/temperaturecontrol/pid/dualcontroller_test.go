@@ -0,0 +1,218 @@
+package pid_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
+	"github.com/benjaminbartels/zymurgauge/internal/test/mocks"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDualRunActuatorsOn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		temperature float64
+		setPoint    float64
+		chillerOn   bool
+		heaterOn    bool
+	}{
+		{name: "below", temperature: 10, setPoint: 15, heaterOn: true},
+		{name: "same", temperature: 15, setPoint: 15},
+		{name: "above", temperature: 20, setPoint: 15, chillerOn: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			l, _ := logtest.NewNullLogger()
+
+			thermometerMock := &mocks.Thermometer{}
+			thermometerMock.On("GetTemperature").Return(tc.temperature, nil)
+
+			chillerMock := &mocks.Actuator{}
+			chillerMock.Mock.On("On").Return(nil)
+			chillerMock.Mock.On("Off").Return(nil)
+
+			heaterMock := &mocks.Actuator{}
+			heaterMock.Mock.On("On").Return(nil)
+			heaterMock.Mock.On("Off").Return(nil)
+
+			ctrl := pid.NewDualController(thermometerMock, chillerMock, heaterMock, kP, kI, kD, l,
+				pid.DualCyclePeriod(100*time.Millisecond))
+
+			ctx, stop := context.WithTimeout(context.Background(), 250*time.Millisecond)
+			defer stop()
+
+			err := ctrl.Run(ctx, tc.setPoint)
+			assert.NoError(t, err)
+
+			assertCalled(t, chillerMock, "On", tc.chillerOn)
+			assertCalled(t, heaterMock, "On", tc.heaterOn)
+			chillerMock.AssertCalled(t, "Off")
+			heaterMock.AssertCalled(t, "Off")
+		})
+	}
+}
+
+func TestDualChillerMinOffTime(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	// 50% duty so that the chiller is turned off during every cycle
+	thermometerMock := &mocks.Thermometer{}
+	thermometerMock.On("GetTemperature").Return(20.0, nil)
+
+	chillerMock := &mocks.Actuator{}
+	chillerMock.Mock.On("On").Return(nil)
+	chillerMock.Mock.On("Off").Return(nil)
+
+	heaterMock := &mocks.Actuator{}
+	heaterMock.Mock.On("Off").Return(nil)
+
+	ctrl := pid.NewDualController(thermometerMock, chillerMock, heaterMock, kP, kI, kD, l,
+		pid.DualCyclePeriod(50*time.Millisecond), pid.ChillerMinOffTime(1*time.Hour))
+
+	ctx, stop := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer stop()
+
+	err := ctrl.Run(ctx, 15)
+	assert.NoError(t, err)
+
+	chillerMock.AssertNumberOfCalls(t, "On", 1)
+	heaterMock.AssertNotCalled(t, "On")
+}
+
+func TestDualChillerMinOffTimeAcrossRuns(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	thermometerMock := &mocks.Thermometer{}
+	thermometerMock.On("GetTemperature").Return(20.0, nil)
+
+	chillerMock := &mocks.Actuator{}
+	chillerMock.Mock.On("On").Return(nil)
+	chillerMock.Mock.On("Off").Return(nil)
+
+	heaterMock := &mocks.Actuator{}
+	heaterMock.Mock.On("Off").Return(nil)
+
+	ctrl := pid.NewDualController(thermometerMock, chillerMock, heaterMock, kP, kI, kD, l,
+		pid.DualCyclePeriod(100*time.Millisecond), pid.ChillerMinOffTime(1*time.Hour))
+
+	// canceled while the chiller is on
+	ctx, stop := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer stop()
+
+	err := ctrl.Run(ctx, 15)
+	assert.NoError(t, err)
+	chillerMock.AssertNumberOfCalls(t, "On", 1)
+
+	ctx, stop = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer stop()
+
+	err = ctrl.Run(ctx, 15)
+	assert.NoError(t, err)
+	chillerMock.AssertNumberOfCalls(t, "On", 1)
+}
+
+func TestDualRunAlreadyRunningError(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+	ctx, stop := context.WithCancel(context.Background())
+
+	thermometerMock := &mocks.Thermometer{}
+	thermometerMock.On("GetTemperature").Return(20.0, nil)
+
+	doneCh := make(chan struct{}, 1)
+	chillerMock := &mocks.Actuator{}
+	chillerMock.Mock.On("Off").Return(nil).Run(func(args mock.Arguments) {
+		select {
+		case doneCh <- struct{}{}:
+		default:
+		}
+	})
+
+	heaterMock := &mocks.Actuator{}
+	heaterMock.Mock.On("Off").Return(nil)
+
+	ctrl := pid.NewDualController(thermometerMock, chillerMock, heaterMock, kP, kI, kD, l)
+
+	go func() {
+		// wait until first ctrl.Run is called
+		<-doneCh
+
+		err := ctrl.Run(ctx, 66)
+		assert.ErrorIs(t, err, pid.ErrAlreadyRunning)
+		stop()
+	}()
+
+	err := ctrl.Run(ctx, 20)
+	assert.NoError(t, err)
+}
+
+func TestDualDeviceIsNilErrors(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	thermometerMock := &mocks.Thermometer{}
+	actuatorMock := &mocks.Actuator{}
+
+	ctrl := pid.NewDualController(nil, actuatorMock, actuatorMock, kP, kI, kD, l)
+	err := ctrl.Run(context.Background(), 20)
+	assert.ErrorIs(t, err, pid.ErrThermometerIsNil)
+
+	ctrl = pid.NewDualController(thermometerMock, nil, actuatorMock, kP, kI, kD, l)
+	err = ctrl.Run(context.Background(), 20)
+	assert.ErrorIs(t, err, pid.ErrActuatorIsNil)
+
+	ctrl = pid.NewDualController(thermometerMock, actuatorMock, nil, kP, kI, kD, l)
+	err = ctrl.Run(context.Background(), 20)
+	assert.ErrorIs(t, err, pid.ErrActuatorIsNil)
+}
+
+func TestDualActuatorOffErrorOnQuit(t *testing.T) {
+	t.Parallel()
+
+	l, _ := logtest.NewNullLogger()
+
+	thermometerMock := &mocks.Thermometer{}
+	thermometerMock.On("GetTemperature").Return(10.0, nil)
+
+	chillerMock := &mocks.Actuator{}
+	chillerMock.Mock.On("Off").Return(errDeadActuator)
+
+	heaterMock := &mocks.Actuator{}
+	heaterMock.Mock.On("On").Return(nil)
+	heaterMock.Mock.On("Off").Return(errDeadActuator)
+
+	ctrl := pid.NewDualController(thermometerMock, chillerMock, heaterMock, kP, kI, kD, l)
+
+	ctx, stop := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer stop()
+
+	err := ctrl.Run(ctx, 25)
+	assert.Contains(t, err.Error(), "could not turn chiller off while quiting")
+	assert.Contains(t, err.Error(), "could not turn heater off while quiting")
+}
+
+func assertCalled(t *testing.T, m *mocks.Actuator, method string, called bool) {
+	t.Helper()
+
+	if called {
+		m.AssertCalled(t, method)
+	} else {
+		m.AssertNotCalled(t, method)
+	}
+}
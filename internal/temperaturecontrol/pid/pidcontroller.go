@@ -34,14 +34,16 @@ func (e Error) Error() string {
 }
 
 type Controller struct {
-	thermometer device.Thermometer
-	actuator    device.Actuator
-	pid         *pidctrl.PIDController
-	cyclePeriod time.Duration
-	clock       clock.Clock
-	logger      *logrus.Logger
-	isRunning   bool
-	runMutex    sync.Mutex
+	thermometer   device.Thermometer
+	actuator      device.Actuator
+	pid           *pidctrl.PIDController
+	cyclePeriod   time.Duration
+	clock         clock.Clock
+	logger        *logrus.Logger
+	ramp          temperaturecontrol.Ramp
+	rampStartTime time.Time
+	isRunning     bool
+	runMutex      sync.Mutex
 }
 
 type Status struct {
@@ -87,7 +89,13 @@ func CyclePeriod(period time.Duration) OptionsFunc {
 	}
 }
 
+// Run starts the controller and holds the given set point until the context is canceled.
 func (c *Controller) Run(ctx context.Context, setPoint float64) error {
+	return c.RunRamp(ctx, temperaturecontrol.NewSetPoint(setPoint))
+}
+
+// RunRamp starts the controller and moves the set point along the given ramp until the context is canceled.
+func (c *Controller) RunRamp(ctx context.Context, ramp temperaturecontrol.Ramp) error {
 	c.runMutex.Lock()
 	if c.isRunning {
 		defer c.runMutex.Unlock()
@@ -103,7 +111,9 @@ func (c *Controller) Run(ctx context.Context, setPoint float64) error {
 		return ErrActuatorIsNil
 	}
 
-	c.pid.Set(setPoint)
+	c.ramp = ramp
+	c.rampStartTime = c.clock.Now()
+	c.pid.Set(ramp.SetPoint(0))
 
 	c.isRunning = true
 
@@ -124,6 +134,8 @@ func (c *Controller) startCycle(ctx context.Context) error {
 			continue
 		}
 
+		c.pid.Set(c.GetSetPoint())
+
 		since := c.clock.Since(lastUpdateTime)
 		dutyCycle := c.pid.UpdateDuration(temperature, since)
 		lastUpdateTime = c.clock.Now()
@@ -170,6 +182,15 @@ func (c *Controller) startCycle(ctx context.Context) error {
 	}
 }
 
+// GetSetPoint returns the current effective set point. While ramping, this is somewhere between the start and the
+// target of the ramp.
+func (c *Controller) GetSetPoint() float64 {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	return c.ramp.SetPoint(c.clock.Since(c.rampStartTime))
+}
+
 func (c *Controller) wait(ctx context.Context, waitTime time.Duration) bool {
 	timer := c.clock.NewTimer(waitTime)
 	defer timer.Stop()
@@ -0,0 +1,50 @@
+package temperaturecontrol
+
+import (
+	"math"
+	"time"
+)
+
+// Ramp describes a set point that moves linearly from one temperature to another at a fixed rate.
+type Ramp struct {
+	From float64
+	To   float64
+	// Rate is the number of degrees per hour the set point moves. A Rate of zero or less jumps straight to To.
+	Rate float64
+}
+
+// NewSetPoint returns a Ramp that holds the given set point.
+func NewSetPoint(setPoint float64) Ramp {
+	return Ramp{From: setPoint, To: setPoint}
+}
+
+// SetPoint returns the effective set point after the given time has elapsed since the start of the ramp.
+func (r Ramp) SetPoint(elapsed time.Duration) float64 {
+	if r.Rate <= 0 {
+		return r.To
+	}
+
+	if elapsed <= 0 {
+		return r.From
+	}
+
+	delta := r.Rate * elapsed.Hours()
+	if delta >= math.Abs(r.To-r.From) {
+		return r.To
+	}
+
+	if r.To < r.From {
+		return r.From - delta
+	}
+
+	return r.From + delta
+}
+
+// Duration returns how long the ramp takes to reach its target.
+func (r Ramp) Duration() time.Duration {
+	if r.Rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(math.Abs(r.To-r.From) / r.Rate * float64(time.Hour))
+}
@@ -0,0 +1,43 @@
+package temperaturecontrol_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRampSetPoint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		ramp     temperaturecontrol.Ramp
+		elapsed  time.Duration
+		expected float64
+	}{
+		{name: "noRamp", ramp: temperaturecontrol.Ramp{From: 20, To: 2}, elapsed: 0, expected: 2},
+		{name: "start", ramp: temperaturecontrol.Ramp{From: 20, To: 2, Rate: 1}, elapsed: 0, expected: 20},
+		{name: "down", ramp: temperaturecontrol.Ramp{From: 20, To: 2, Rate: 1}, elapsed: 3 * time.Hour, expected: 17},
+		{name: "up", ramp: temperaturecontrol.Ramp{From: 18, To: 22, Rate: 2}, elapsed: 90 * time.Minute, expected: 21},
+		{name: "reached", ramp: temperaturecontrol.Ramp{From: 18, To: 22, Rate: 2}, elapsed: 3 * time.Hour, expected: 22},
+		{name: "hold", ramp: temperaturecontrol.NewSetPoint(19), elapsed: time.Hour, expected: 19},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.InDelta(t, tc.expected, tc.ramp.SetPoint(tc.elapsed), 0.0001)
+		})
+	}
+}
+
+func TestRampDuration(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 18*time.Hour, temperaturecontrol.Ramp{From: 20, To: 2, Rate: 1}.Duration())
+	assert.Equal(t, time.Duration(0), temperaturecontrol.Ramp{From: 20, To: 2}.Duration())
+}
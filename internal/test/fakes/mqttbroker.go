@@ -0,0 +1,59 @@
+package fakes
+
+import (
+	"net"
+
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// MQTTBroker is an in-process MQTT broker that accepts all clients.
+type MQTTBroker struct {
+	server *mqtt.Server
+	URL    string
+}
+
+// NewMQTTBroker starts a broker on a free local port.
+func NewMQTTBroker() (*MQTTBroker, error) {
+	address, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := zerolog.Nop()
+	// the default capabilities are shared and modified by every new server
+	capabilities := *mqtt.DefaultServerCapabilities
+	server := mqtt.New(&mqtt.Options{Logger: &logger, Capabilities: &capabilities})
+
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		return nil, errors.Wrap(err, "could not add auth hook")
+	}
+
+	if err := server.AddListener(listeners.NewTCP("tcp", address, nil)); err != nil {
+		return nil, errors.Wrap(err, "could not add listener")
+	}
+
+	if err := server.Serve(); err != nil {
+		return nil, errors.Wrap(err, "could not serve")
+	}
+
+	return &MQTTBroker{server: server, URL: "tcp://" + address}, nil
+}
+
+func (b *MQTTBroker) Close() {
+	_ = b.server.Close()
+}
+
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.Wrap(err, "could not listen")
+	}
+
+	defer l.Close()
+
+	return l.Addr().String(), nil
+}
@@ -3,8 +3,13 @@
 package mocks
 
 import (
+	batch "github.com/benjaminbartels/zymurgauge/internal/batch"
 	chamber "github.com/benjaminbartels/zymurgauge/internal/chamber"
 	mock "github.com/stretchr/testify/mock"
+
+	pid "github.com/benjaminbartels/zymurgauge/internal/temperaturecontrol/pid"
+
+	time "time"
 )
 
 // Controller is an autogenerated mock type for the Controller type
@@ -86,6 +91,34 @@ func (_m *Controller) Save(c *chamber.Chamber) error {
 	return r0
 }
 
+// SetBatch provides a mock function with given fields: chamberID, b
+func (_m *Controller) SetBatch(chamberID string, b *batch.Detail) error {
+	ret := _m.Called(chamberID, b)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *batch.Detail) error); ok {
+		r0 = rf(chamberID, b)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartAutoTune provides a mock function with given fields: chamberID, setPoint, rule
+func (_m *Controller) StartAutoTune(chamberID string, setPoint float64, rule pid.TuningRule) error {
+	ret := _m.Called(chamberID, setPoint, rule)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, float64, pid.TuningRule) error); ok {
+		r0 = rf(chamberID, setPoint, rule)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // StartFermentation provides a mock function with given fields: chamberID, step
 func (_m *Controller) StartFermentation(chamberID string, step string) error {
 	ret := _m.Called(chamberID, step)
@@ -100,6 +133,34 @@ func (_m *Controller) StartFermentation(chamberID string, step string) error {
 	return r0
 }
 
+// StartManual provides a mock function with given fields: chamberID, setPoint, duration, revert
+func (_m *Controller) StartManual(chamberID string, setPoint float64, duration time.Duration, revert bool) error {
+	ret := _m.Called(chamberID, setPoint, duration, revert)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, float64, time.Duration, bool) error); ok {
+		r0 = rf(chamberID, setPoint, duration, revert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StopAutoTune provides a mock function with given fields: chamberID
+func (_m *Controller) StopAutoTune(chamberID string) error {
+	ret := _m.Called(chamberID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(chamberID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // StopFermentation provides a mock function with given fields: chamberID
 func (_m *Controller) StopFermentation(chamberID string) error {
 	ret := _m.Called(chamberID)
@@ -74,3 +74,17 @@ func (_m *Service) Log(ctx context.Context, log brewfather.LogEntry) error {
 
 	return r0
 }
+
+// UpdateBatch provides a mock function with given fields: ctx, id, update
+func (_m *Service) UpdateBatch(ctx context.Context, id string, update brewfather.BatchUpdate) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, brewfather.BatchUpdate) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
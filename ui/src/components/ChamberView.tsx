@@ -66,11 +66,15 @@ export default function ChamberView() {
   useEffect(() => {
     var influxDbUrl: string;
     var influxDbReadToken: string;
+    var influxDbOrg: string;
+    var influxDbBucket: string;
 
     SettingsService.get()
       .then((response: any) => {
         influxDbUrl = response.data.influxDbUrl;
         influxDbReadToken = response.data.influxDbReadToken;
+        influxDbOrg = response.data.influxDbOrg || "zymurgauge";
+        influxDbBucket = response.data.influxDbBucket || "telegraf";
       })
       .catch((e: Error) => {
         setErrorMessage("Could not get Settings: " + e);
@@ -95,12 +99,14 @@ export default function ChamberView() {
             const externalTemperatureData: { x: any; y: any }[] = [];
             const hydrometerGravityData: { x: any; y: any }[] = [];
 
-            const chamberName = response.data.name.replace(" ", "");
+            const chamberName = response.data.name.replace(/[ ,=]/g, "_");
 
             let query =
-              `from(bucket: "telegraf")
+              `from(bucket: "` +
+              influxDbBucket +
+              `")
               |> range(start: -12h)
-              |> filter(fn: (r) => r._measurement == "zymurgauge_` +
+              |> filter(fn: (r) => r._measurement == "zymurgauge" and r.chamber == "` +
               chamberName +
               `")
               |> sample(n:2, pos: 0)`;
@@ -111,7 +117,7 @@ export default function ChamberView() {
             };
 
             const queryApi = await new InfluxDB(clientOptions).getQueryApi(
-              "zymurgauge"
+              influxDbOrg
             );
 
             await queryApi.queryRows(query, {
@@ -14,6 +14,11 @@ const PrivateElement = ({ children }: any) => {
 function isAuthorized() {
   const token = AuthService.getToken();
 
+  // An expired access token is refreshed by the next request as long as there is a refresh token.
+  if (token && AuthService.getRefreshToken()) {
+    return true;
+  }
+
   try {
     if (token) {
       const decodedJwt = JSON.parse(atob(token.split(".")[1]));
@@ -34,7 +34,8 @@ export default function SettingsFormView() {
   }, []);
 
   const onSubmit = (data: any) => {
-    let settings: AppSettings = {
+    let updated: AppSettings = {
+      ...(settings as AppSettings),
       temperatureUnits: data.temperatureUnits,
       authSecret: data.authSecret,
       brewfatherApiUserId: data.brewfatherApiUserId,
@@ -45,7 +46,7 @@ export default function SettingsFormView() {
       statsDAddress: data.statsDAddress,
     };
 
-    SettingsService.save(settings)
+    SettingsService.save(updated)
       .then((response: any) => {
         console.debug("Settings saved: ", response.data);
       })
@@ -3,6 +3,8 @@ import { Credentials, LoginResponse } from "../types/Auth";
 import { authHeader, getUrl } from "./common";
 
 class AuthService {
+  private refreshing: Promise<string> | null = null;
+
   async login(username: string, password: string) {
     const credentials: Credentials = {
       username: username,
@@ -15,7 +17,7 @@ class AuthService {
         credentials
       );
       console.debug("Login success: ", response.data.token);
-      localStorage.setItem("token", response.data.token);
+      this.setTokens(response.data);
       localStorage.setItem("username", username);
     } catch (e: any) {
       console.error("Login Error:", e);
@@ -24,18 +26,91 @@ class AuthService {
   }
 
   logout() {
+    if (this.getToken()) {
+      // The session is ended on a best effort basis, the tokens are removed either way.
+      axios
+        .post(getUrl("auth/logout"), null, { headers: authHeader() })
+        .catch((e: any) => console.debug("Logout Error:", e));
+    }
+
     localStorage.removeItem("token");
+    localStorage.removeItem("refreshToken");
   }
 
   getToken() {
     return localStorage.getItem("token");
   }
 
+  getRefreshToken() {
+    return localStorage.getItem("refreshToken");
+  }
+
+  // refresh gets a new access token with the refresh token. Concurrent calls share the same request, since each
+  // refresh token can only be used once.
+  refresh() {
+    if (!this.refreshing) {
+      this.refreshing = axios
+        .post<LoginResponse>(getUrl("auth/refresh"), {
+          refreshToken: this.getRefreshToken(),
+        })
+        .then((response) => {
+          this.setTokens(response.data);
+          return response.data.token;
+        })
+        .catch((e: any) => {
+          localStorage.removeItem("token");
+          localStorage.removeItem("refreshToken");
+          throw e;
+        })
+        .finally(() => {
+          this.refreshing = null;
+        });
+    }
+
+    return this.refreshing;
+  }
+
   save(credentials: Credentials) {
-    return axios.post<Credentials>(getUrl("auth/update"), credentials, {
+    return axios.post<LoginResponse>(getUrl("auth/update"), credentials, {
       headers: authHeader(),
     });
   }
+
+  private setTokens(response: LoginResponse) {
+    localStorage.setItem("token", response.token);
+    localStorage.setItem("refreshToken", response.refreshToken);
+  }
 }
 
-export default new AuthService();
+const authService = new AuthService();
+
+// Requests that fail because the access token has expired are retried once with a refreshed access token. If the
+// session can not be refreshed the user has to log in again.
+axios.interceptors.response.use(undefined, async (error: any) => {
+  const config = error.config;
+
+  if (
+    error.response?.status !== 401 ||
+    !config ||
+    config.retried ||
+    config.url?.includes("/auth/") ||
+    !authService.getRefreshToken()
+  ) {
+    throw error;
+  }
+
+  config.retried = true;
+
+  try {
+    const token = await authService.refresh();
+    config.headers = { ...config.headers, Authorization: "Bearer " + token };
+  } catch (e: any) {
+    console.error("Refresh Error:", e);
+    window.location.assign("/login");
+    throw error;
+  }
+
+  return axios(config);
+});
+
+export default authService;
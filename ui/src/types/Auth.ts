@@ -5,4 +5,6 @@ export interface Credentials {
 
 export interface LoginResponse {
   token: string;
+  refreshToken: string;
+  role: string;
 }
@@ -11,5 +11,9 @@ export interface AppSettings {
   brewfatherLogUrl: string;
   influxDbUrl: string;
   influxDbReadToken: string;
+  influxDbWriteToken?: string;
+  influxDbOrg?: string;
+  influxDbBucket?: string;
   statsDAddress: string;
+  metricsSink?: string;
 }